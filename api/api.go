@@ -0,0 +1,326 @@
+// Package api describes the public API of compiled classes as sorted lines of text, one per
+// class, field and method, so that a snapshot of it can be committed and compared with the
+// API of later builds, similar to the api/*.txt files of Go.
+//
+// Every line is a declaration in Java syntax with qualified names, prefixed with the name of
+// the class, or the name of the class and the member, such as
+//
+//	com.example.Api public abstract class com.example.Api<T> implements java.io.Closeable
+//	com.example.Api#VERSION public static final int VERSION = 2
+//	com.example.Api#send @java.lang.Deprecated public void send(T) throws java.io.IOException
+//
+// so that the lines of a class sort right before the lines of its members.
+package api
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/tsatke/jt"
+	"github.com/tsatke/jt/class"
+	"github.com/tsatke/jt/classpath"
+)
+
+// documented is the meta-annotation of annotation types that are part of the API of the
+// elements they annotate.
+const documented = "java/lang/annotation/Documented"
+
+// Dump returns the lines that describe the API of the classes in the entries of the
+// classpath that are not skipped, sorted. The API are the public classes and their public
+// and protected fields and methods, without synthetic ones, and the annotations on them
+// whose types are annotated with @Documented. Annotation types are looked up on the whole
+// classpath, and annotations whose types are missing are left out.
+func Dump(cp *classpath.Classpath, skip func(*classpath.Entry) bool) ([]string, error) {
+	cache, err := classpath.NewCache(100)
+	if err != nil {
+		return nil, fmt.Errorf("create archive cache: %w", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	d := &dumper{
+		cp:         cp,
+		cache:      cache,
+		documented: make(map[string]bool),
+		seen:       make(map[string]bool),
+	}
+	cp.WalkArchives(skip, func(entry *classpath.Entry, archive classpath.Archive) {
+		if err != nil {
+			return
+		}
+		for _, name := range archive.ListClasses() {
+			// the first class with a name on the classpath is the one that is loaded
+			if d.seen[name] || strings.HasSuffix(name, "module-info") || strings.HasSuffix(name, "package-info") {
+				continue
+			}
+			d.seen[name] = true
+			c, openErr := archive.OpenClass(name)
+			if openErr != nil {
+				err = fmt.Errorf("open class %s: %w", name, openErr)
+				return
+			}
+			d.class(c)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(d.lines)
+	return d.lines, nil
+}
+
+// DumpProject returns the lines that describe the API of the output folders of the project, see
+// Dump. The classpath is built in the compile scope, so that the test output folders, whose
+// classes are public with JUnit 4, are not part of the API. A release other than 0 overrides
+// the release of the classpath.
+func DumpProject(project jt.Project, release int) ([]string, error) {
+	cp, err := project.Classpath(classpath.ScopeCompile)
+	if err != nil {
+		return nil, fmt.Errorf("get classpath: %w", err)
+	}
+	if release > 0 {
+		cp.Release = release
+	}
+	return Dump(cp, func(entry *classpath.Entry) bool {
+		return entry.Type != classpath.EntryTypeOutput
+	})
+}
+
+type dumper struct {
+	cp         *classpath.Classpath
+	cache      *classpath.Cache
+	documented map[string]bool
+	seen       map[string]bool
+	lines      []string
+}
+
+func (d *dumper) class(c *class.Class) {
+	// nested classes that are protected are public in their class files
+	if !c.IsPublic() || c.AccessFlags()&class.AccSynthetic != 0 {
+		return
+	}
+	name := javaName(c.Name())
+	d.lines = append(d.lines, name+" "+d.annotations(c.Annotations())+ClassDeclaration(c))
+	for _, f := range c.Fields() {
+		if isAPIMember(f.AccessFlags(), f.Name()) {
+			d.lines = append(d.lines, name+"#"+f.Name()+" "+d.annotations(f.Annotations())+FieldDeclaration(f))
+		}
+	}
+	for _, m := range c.Methods() {
+		if isAPIMember(m.AccessFlags(), m.Name()) {
+			d.lines = append(d.lines, name+"#"+m.Name()+" "+d.annotations(m.Annotations())+MethodDeclaration(c, m))
+		}
+	}
+}
+
+// annotations renders the annotations whose types are documented, sorted and followed by a
+// space, or returns an empty string if there are none.
+func (d *dumper) annotations(annotations []class.Annotation) string {
+	var types []string
+	for _, a := range annotations {
+		if d.isDocumented(a.Type) {
+			types = append(types, "@"+javaName(a.Type)+" ")
+		}
+	}
+	sort.Strings(types)
+	return strings.Join(types, "")
+}
+
+func (d *dumper) isDocumented(annotationType string) bool {
+	if documented, ok := d.documented[annotationType]; ok {
+		return documented
+	}
+	d.documented[annotationType] = false
+	if c, err := d.cp.OpenClassWithCache(annotationType, d.cache); err == nil && c != nil {
+		for _, a := range c.Annotations() {
+			if a.Type == documented {
+				d.documented[annotationType] = true
+			}
+		}
+	}
+	return d.documented[annotationType]
+}
+
+func isAPIMember(flags class.AccessFlags, name string) bool {
+	return flags&(class.AccPublic|class.AccProtected) != 0 && flags&class.AccSynthetic == 0 && name != "<clinit>"
+}
+
+// javaName converts an internal class name like com/example/Outer$Inner to com.example.Outer$Inner.
+func javaName(name string) string {
+	return strings.ReplaceAll(name, "/", ".")
+}
+
+// ClassDeclaration renders the declaration of a class in Java syntax with qualified names and
+// without annotations, such as public abstract class com.example.Api<T> implements java.io.Closeable.
+func ClassDeclaration(c *class.Class) string {
+	var b strings.Builder
+	b.WriteString(modifiers(c.AccessFlags(), 0))
+	switch {
+	case c.IsAnnotation():
+		b.WriteString("@interface ")
+	case c.IsInterface():
+		b.WriteString("interface ")
+	case c.AccessFlags()&class.AccEnum != 0:
+		b.WriteString("enum ")
+	default:
+		if c.IsAbstract() {
+			b.WriteString("abstract ")
+		}
+		if c.IsFinal() {
+			b.WriteString("final ")
+		}
+		b.WriteString("class ")
+	}
+	b.WriteString(javaName(c.Name()))
+
+	superclass := ""
+	if c.SuperclassName() != "" {
+		superclass = javaName(c.SuperclassName())
+	}
+	var interfaces []string
+	for _, i := range c.Interfaces() {
+		interfaces = append(interfaces, javaName(i))
+	}
+	if sig, err := class.ParseClassSignature(c.Signature()); err == nil {
+		b.WriteString(sig.TypeParameters)
+		superclass, interfaces = sig.Superclass, sig.Interfaces
+	}
+
+	if c.IsInterface() {
+		if c.IsAnnotation() {
+			// annotation types implicitly extend java.lang.annotation.Annotation
+			return b.String()
+		}
+		if len(interfaces) > 0 {
+			b.WriteString(" extends " + strings.Join(interfaces, ", "))
+		}
+		return b.String()
+	}
+	if superclass != "" && superclass != "java.lang.Object" {
+		b.WriteString(" extends " + superclass)
+	}
+	if len(interfaces) > 0 {
+		b.WriteString(" implements " + strings.Join(interfaces, ", "))
+	}
+	return b.String()
+}
+
+// FieldDeclaration renders the declaration of a field like ClassDeclaration, followed by its
+// constant value, such as public static final int VERSION = 2.
+func FieldDeclaration(f class.Field) string {
+	var b strings.Builder
+	b.WriteString(modifiers(f.AccessFlags(), class.AccStatic|class.AccFinal))
+	typ, err := class.ParseFieldSignature(f.Signature())
+	if err != nil {
+		typ, _ = class.ParseFieldSignature(f.Descriptor())
+	}
+	b.WriteString(typ + " " + f.Name())
+	if value, ok := f.ConstantValue(); ok {
+		b.WriteString(" = " + value)
+	}
+	return b.String()
+}
+
+// MethodDeclaration renders the declaration of a method of the class like ClassDeclaration,
+// such as public <T> void send(T, java.lang.String...) throws java.io.IOException.
+// Constructors are named after the class.
+func MethodDeclaration(c *class.Class, m class.Method) string {
+	var b strings.Builder
+	flags := m.AccessFlags()
+	if c.IsInterface() && flags&(class.AccAbstract|class.AccStatic|class.AccPrivate) == 0 {
+		b.WriteString(modifiers(flags, 0) + "default ")
+	} else {
+		b.WriteString(modifiers(flags, class.AccStatic|class.AccFinal|class.AccAbstract))
+	}
+
+	descriptor, err := class.ParseMethodSignature(m.Descriptor())
+	if err != nil {
+		descriptor = &class.MethodSignature{Result: "void"}
+	}
+	sig, err := class.ParseMethodSignature(m.Signature())
+	// signatures of constructors of inner classes and enums may leave out the synthetic
+	// parameters of their descriptors, so that the descriptor is used if they differ
+	if err != nil || len(sig.Parameters) != len(descriptor.Parameters) {
+		sig = descriptor
+	}
+	exceptions := sig.Exceptions
+	if len(exceptions) == 0 {
+		for _, e := range m.Exceptions() {
+			exceptions = append(exceptions, javaName(e))
+		}
+	}
+
+	if sig.TypeParameters != "" {
+		b.WriteString(sig.TypeParameters + " ")
+	}
+	if m.Name() == "<init>" {
+		name := c.Name()
+		b.WriteString(name[strings.LastIndexAny(name, "/$")+1:])
+	} else {
+		b.WriteString(sig.Result + " " + m.Name())
+	}
+	parameters := append([]string(nil), sig.Parameters...)
+	if flags&class.AccVarargs != 0 && len(parameters) > 0 {
+		last := parameters[len(parameters)-1]
+		parameters[len(parameters)-1] = strings.TrimSuffix(last, "[]") + "..."
+	}
+	b.WriteString("(" + strings.Join(parameters, ", ") + ")")
+	if len(exceptions) > 0 {
+		b.WriteString(" throws " + strings.Join(exceptions, ", "))
+	}
+	return b.String()
+}
+
+// modifiers renders the visibility and those of the given modifiers that are set, followed
+// by a space.
+func modifiers(flags, shown class.AccessFlags) string {
+	var text string
+	switch {
+	case flags&class.AccPublic != 0:
+		text = "public "
+	case flags&class.AccProtected != 0:
+		text = "protected "
+	case flags&class.AccPrivate != 0:
+		text = "private "
+	}
+	for _, modifier := range []struct {
+		flag class.AccessFlags
+		name string
+	}{
+		{class.AccAbstract, "abstract "},
+		{class.AccStatic, "static "},
+		{class.AccFinal, "final "},
+	} {
+		if flags&shown&modifier.flag != 0 {
+			text += modifier.name
+		}
+	}
+	return text
+}
+
+// Difference is a line that was removed from or added to the API.
+type Difference struct {
+	Line  string
+	Added bool
+}
+
+// Diff returns the lines that were removed from the old API and added to the new one. Both
+// must be sorted, and so are the differences.
+func Diff(old, new []string) []Difference {
+	var differences []Difference
+	i, j := 0, 0
+	for i < len(old) || j < len(new) {
+		switch {
+		case j == len(new) || (i < len(old) && old[i] < new[j]):
+			differences = append(differences, Difference{Line: old[i]})
+			i++
+		case i == len(old) || new[j] < old[i]:
+			differences = append(differences, Difference{Line: new[j], Added: true})
+			j++
+		default:
+			i++
+			j++
+		}
+	}
+	return differences
+}
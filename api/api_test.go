@@ -0,0 +1,112 @@
+package api
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"github.com/tsatke/jt"
+	"github.com/tsatke/jt/classpath"
+	"github.com/tsatke/jt/internal/classbuild"
+)
+
+func TestAPISuite(t *testing.T) {
+	suite.Run(t, new(APISuite))
+}
+
+type APISuite struct {
+	suite.Suite
+}
+
+func (suite *APISuite) TestDump() {
+	app, lib := suite.T().TempDir(), suite.T().TempDir()
+	write := func(dir string, c *classbuild.Class) { suite.Require().NoError(c.WriteFile(dir)) }
+
+	stable := classbuild.New("com/lib/Stable", "java/lang/Object")
+	stable.AccessFlags = classbuild.AccPublic | classbuild.AccInterface | classbuild.AccAbstract | classbuild.AccAnnotation
+	stable.Attributes = append(stable.Attributes, stable.Annotations(true, stable.Annotation("java/lang/annotation/Documented")))
+	write(lib, stable)
+
+	b := classbuild.New("com/example/Api", "java/lang/Object")
+	b.AccessFlags |= classbuild.AccAbstract
+	b.Interfaces = []string{"java/util/function/Supplier"}
+	b.Attributes = append(b.Attributes,
+		b.Signature("<T:Ljava/lang/Number;:Ljava/lang/Comparable<TT;>;>Ljava/lang/Object;Ljava/util/function/Supplier<Ljava/util/List<+TT;>;>;"),
+		b.Annotations(true, b.Annotation("com/lib/Stable"), b.Annotation("com/lib/Undocumented")),
+	)
+	b.Field(classbuild.AccPublic|classbuild.AccStatic|classbuild.AccFinal, "VERSION", "I", b.ConstantValue(b.Integer(2)))
+	b.Field(classbuild.AccProtected, "items", "[Ljava/lang/Object;", b.Signature("[TT;"))
+	b.Field(classbuild.AccPrivate, "secret", "I")
+	b.Method(classbuild.AccPublic, "<init>", "(Ljava/lang/String;)V")
+	b.Method(classbuild.AccPublic|classbuild.AccAbstract|classbuild.AccVarargs, "send", "(Ljava/util/Map;[Ljava/lang/String;)V",
+		b.Signature("<K:Ljava/lang/Object;>(Ljava/util/Map<TK;-TT;>;[Ljava/lang/String;)V"),
+		b.Exceptions("java/io/IOException"),
+		b.Annotations(true, b.Annotation("com/lib/Stable")),
+	)
+	b.Method(classbuild.AccPublic|classbuild.AccSynthetic|classbuild.AccBridge, "get", "()Ljava/lang/Object;")
+	b.Method(classbuild.AccStatic, "<clinit>", "()V")
+	write(app, b)
+
+	listener := classbuild.New("com/example/Api$Listener", "java/lang/Object")
+	listener.AccessFlags = classbuild.AccPublic | classbuild.AccInterface | classbuild.AccAbstract
+	listener.Interfaces = []string{"java/util/EventListener"}
+	listener.Method(classbuild.AccPublic|classbuild.AccAbstract, "onEvent", "(I)Z")
+	listener.Method(classbuild.AccPublic, "onClose", "()V")
+	write(app, listener)
+
+	hidden := classbuild.New("com/example/Hidden", "java/lang/Object")
+	hidden.AccessFlags = classbuild.AccSuper
+	hidden.Method(classbuild.AccPublic, "run", "()V")
+	write(app, hidden)
+
+	cp := classpath.NewClasspath()
+	cp.AddEntry(classpath.EntryTypeOutput, app)
+	cp.AddEntry(classpath.EntryTypeOutput, lib)
+
+	lines, err := Dump(cp, func(entry *classpath.Entry) bool { return entry.Path == lib })
+	suite.Require().NoError(err)
+	suite.Equal([]string{
+		"com.example.Api @com.lib.Stable public abstract class com.example.Api<T extends java.lang.Number & java.lang.Comparable<T>> implements java.util.function.Supplier<java.util.List<? extends T>>",
+		"com.example.Api#<init> public Api(java.lang.String)",
+		"com.example.Api#VERSION public static final int VERSION = 2",
+		"com.example.Api#items protected T[] items",
+		"com.example.Api#send @com.lib.Stable public abstract <K> void send(java.util.Map<K, ? super T>, java.lang.String...) throws java.io.IOException",
+		"com.example.Api$Listener public interface com.example.Api$Listener extends java.util.EventListener",
+		"com.example.Api$Listener#onClose public default void onClose()",
+		"com.example.Api$Listener#onEvent public abstract boolean onEvent(int)",
+	}, lines)
+}
+
+func (suite *APISuite) TestDumpProject() {
+	dir := suite.T().TempDir()
+	suite.Require().NoError(os.WriteFile(filepath.Join(dir, ".project"), []byte(`<projectDescription><name>example</name></projectDescription>`), 0644))
+	suite.Require().NoError(os.WriteFile(filepath.Join(dir, ".classpath"), []byte(`<classpath>
+<classpathentry kind="src" path="src/main/java" output="target/classes"/>
+<classpathentry kind="src" path="src/test/java" output="target/test-classes">
+	<attributes>
+		<attribute name="test" value="true"/>
+	</attributes>
+</classpathentry>
+<classpathentry kind="output" path="target/classes"/>
+</classpath>`), 0644))
+	suite.Require().NoError(classbuild.New("com/example/Api", "java/lang/Object").WriteFile(filepath.Join(dir, "target", "classes")))
+	// test classes are public with JUnit 4
+	suite.Require().NoError(classbuild.New("com/example/ApiTest", "java/lang/Object").WriteFile(filepath.Join(dir, "target", "test-classes")))
+
+	project, err := jt.LoadProject(dir)
+	suite.Require().NoError(err)
+	lines, err := DumpProject(project, 0)
+	suite.Require().NoError(err)
+	suite.Equal([]string{"com.example.Api public class com.example.Api"}, lines)
+}
+
+func (suite *APISuite) TestDiff() {
+	suite.Equal([]Difference{
+		{Line: "a#remove"},
+		{Line: "b#add", Added: true},
+		{Line: "c"},
+		{Line: "d", Added: true},
+	}, Diff([]string{"a", "a#remove", "b", "c"}, []string{"a", "b", "b#add", "d"}))
+	suite.Empty(Diff([]string{"a"}, []string{"a"}))
+}
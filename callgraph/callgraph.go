@@ -0,0 +1,417 @@
+// Package callgraph builds the method-level call graph of the classes on a classpath from the
+// invoke instructions of their methods. Virtual and interface calls are resolved with class
+// hierarchy analysis (CHA): a call can reach the implementation of the method in every class
+// that is a subtype of the class that the call references.
+package callgraph
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/tsatke/jt/class"
+	"github.com/tsatke/jt/classfile"
+	"github.com/tsatke/jt/classpath"
+)
+
+// Method identifies a method.
+type Method struct {
+	Class      string
+	Name       string
+	Descriptor string
+}
+
+// String returns the method like com/example/App#run(Ljava/lang/String;)V.
+func (m Method) String() string {
+	return m.Class + "#" + m.Name + m.Descriptor
+}
+
+// Call is a call of a method by another method.
+type Call struct {
+	Caller Method
+	Callee Method
+	// Opcode is the instruction of the call. Methods that lambdas and method references call
+	// are called by invokedynamic.
+	Opcode classfile.Opcode
+	// Line is the source line of the call in the caller, or 0 if it is unknown.
+	Line int
+}
+
+// Graph is the call graph of the classes on a classpath. Its questions are answered lazily,
+// so it is not safe for concurrent use.
+type Graph struct {
+	classes  map[string]*classInfo
+	subtypes map[string][]string
+	sites    []site
+	// byCaller and byName hold the indices of the call sites by their caller, and by the name
+	// and descriptor of the method they reference
+	byCaller map[Method][]int
+	byName   map[string][]int
+	// targets caches the methods that a call can reach by the call
+	targets map[targetKey][]Method
+	strings map[string]string
+}
+
+// classInfo is the part of a class that is needed to resolve calls.
+type classInfo struct {
+	name       string
+	super      string
+	interfaces []string
+	flags      class.AccessFlags
+	methods    map[string]class.AccessFlags
+	entry      *classpath.Entry
+}
+
+// site is an instruction that calls a method.
+type site struct {
+	caller Method
+	ref    class.Reference
+	opcode classfile.Opcode
+	line   int
+}
+
+type targetKey struct {
+	ref     class.Reference
+	virtual bool
+}
+
+// Build reads the classes of all entries of the classpath and builds their call graph.
+// Like the JVM, the first class on the classpath with a name is used.
+func Build(cp *classpath.Classpath) *Graph {
+	start := time.Now()
+	g := &Graph{
+		classes:  make(map[string]*classInfo),
+		subtypes: make(map[string][]string),
+		byCaller: make(map[Method][]int),
+		byName:   make(map[string][]int),
+		targets:  make(map[targetKey][]Method),
+		strings:  make(map[string]string),
+	}
+	cp.WalkArchives(nil, func(entry *classpath.Entry, archive classpath.Archive) {
+		var names []string
+		for _, name := range archive.ListClasses() {
+			if _, shadowed := g.classes[name]; !shadowed {
+				names = append(names, name)
+			}
+		}
+		for parsed := range readClasses(entry, archive, names) {
+			g.add(entry, parsed)
+		}
+	})
+	for name, c := range g.classes {
+		for _, super := range append([]string{c.super}, c.interfaces...) {
+			if super != "" {
+				g.subtypes[super] = append(g.subtypes[super], name)
+			}
+		}
+	}
+	log.Debug().
+		Stringer("took", time.Since(start)).
+		Int("classes", len(g.classes)).
+		Int("calls", len(g.sites)).
+		Msg("build call graph")
+	return g
+}
+
+// parsedClass is what is extracted from a class to build the graph.
+type parsedClass struct {
+	info  classInfo
+	sites []site
+}
+
+// readClasses parses the classes with the given names concurrently.
+func readClasses(entry *classpath.Entry, archive classpath.Archive, names []string) <-chan *parsedClass {
+	work := make(chan string)
+	results := make(chan *parsedClass)
+	go func() {
+		for _, name := range names {
+			work <- name
+		}
+		close(work)
+	}()
+
+	wg := &sync.WaitGroup{}
+	for i := 0; i < runtime.NumCPU(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for name := range work {
+				c, err := archive.OpenClass(name)
+				if err != nil {
+					log.Error().
+						Err(err).
+						Str("class", name).
+						Str("entry", entry.Path).
+						Msg("open class")
+					continue
+				}
+				results <- parse(c)
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+	return results
+}
+
+func parse(c *class.Class) *parsedClass {
+	p := &parsedClass{
+		info: classInfo{
+			name:       c.Name(),
+			super:      c.SuperclassName(),
+			interfaces: c.Interfaces(),
+			flags:      c.AccessFlags(),
+			methods:    make(map[string]class.AccessFlags),
+		},
+	}
+	for _, m := range c.Methods() {
+		p.info.methods[m.Name()+m.Descriptor()] = m.AccessFlags()
+
+		references, err := m.CodeReferences()
+		if err != nil {
+			log.Debug().
+				Err(err).
+				Str("class", c.Name()).
+				Msg("decode method")
+			continue
+		}
+		caller := Method{Class: c.Name(), Name: m.Name(), Descriptor: m.Descriptor()}
+		bootstrap := -1
+		for _, ref := range references {
+			if ref.Opcode == classfile.OpInvokedynamic && ref.Offset != bootstrap {
+				// the first reference of invokedynamic is its bootstrap method, which the JVM calls
+				// to link it; the method handles that follow are the methods of lambdas and method
+				// references
+				bootstrap = ref.Offset
+				continue
+			}
+			if (ref.Opcode.IsInvoke() || ref.Opcode == classfile.OpInvokedynamic) &&
+				(ref.Kind == class.RefMethod || ref.Kind == class.RefInterfaceMethod) {
+				p.sites = append(p.sites, site{caller: caller, ref: ref.Reference, opcode: ref.Opcode, line: ref.Line})
+			}
+		}
+	}
+	return p
+}
+
+// add adds a parsed class to the graph, sharing the strings of all classes.
+func (g *Graph) add(entry *classpath.Entry, p *parsedClass) {
+	info := p.info
+	info.name = g.intern(info.name)
+	info.super = g.intern(info.super)
+	for i, iface := range info.interfaces {
+		info.interfaces[i] = g.intern(iface)
+	}
+	info.entry = entry
+	g.classes[info.name] = &info
+
+	for _, s := range p.sites {
+		s.caller = g.method(s.caller.Class, s.caller.Name, s.caller.Descriptor)
+		s.ref.Class = g.intern(s.ref.Class)
+		s.ref.Name = g.intern(s.ref.Name)
+		s.ref.Descriptor = g.intern(s.ref.Descriptor)
+		index := len(g.sites)
+		g.sites = append(g.sites, s)
+		g.byCaller[s.caller] = append(g.byCaller[s.caller], index)
+		key := s.ref.Name + s.ref.Descriptor
+		g.byName[key] = append(g.byName[key], index)
+	}
+}
+
+func (g *Graph) intern(s string) string {
+	if interned, ok := g.strings[s]; ok {
+		return interned
+	}
+	g.strings[s] = s
+	return s
+}
+
+func (g *Graph) method(className, name, descriptor string) Method {
+	return Method{Class: g.intern(className), Name: g.intern(name), Descriptor: g.intern(descriptor)}
+}
+
+// Entry returns the classpath entry of the class with the given name, or nil if the class
+// is not on the classpath.
+func (g *Graph) Entry(className string) *classpath.Entry {
+	if c, ok := g.classes[className]; ok {
+		return c.entry
+	}
+	return nil
+}
+
+// Methods returns the methods that the class with the given name declares with the name,
+// and the descriptor unless it is empty. It returns a *classpath.ClassNotFoundError if the
+// class is not on the classpath.
+func (g *Graph) Methods(className, name, descriptor string) ([]Method, error) {
+	c, ok := g.classes[className]
+	if !ok {
+		return nil, &classpath.ClassNotFoundError{Name: className}
+	}
+	var methods []Method
+	for key := range c.methods {
+		if strings.HasPrefix(key, name+"(") && (descriptor == "" || key == name+descriptor) {
+			methods = append(methods, Method{Class: c.name, Name: name, Descriptor: key[len(name):]})
+		}
+	}
+	if len(methods) == 0 {
+		return nil, fmt.Errorf("class %s declares no method %s%s", className, name, descriptor)
+	}
+	sort.Slice(methods, func(i, j int) bool {
+		return methods[i].Descriptor < methods[j].Descriptor
+	})
+	return methods, nil
+}
+
+// Callees returns the calls of the given method, in the order of its code. A virtual or
+// interface call results in a call of every method it can reach.
+func (g *Graph) Callees(m Method) []Call {
+	var calls []Call
+	for _, index := range g.byCaller[m] {
+		s := &g.sites[index]
+		for _, target := range g.reachable(s) {
+			calls = append(calls, Call{Caller: s.caller, Callee: target, Opcode: s.opcode, Line: s.line})
+		}
+	}
+	return calls
+}
+
+// Callers returns the calls that can reach the given method, including calls of an abstract
+// method that it implements, sorted by caller.
+func (g *Graph) Callers(m Method) []Call {
+	var calls []Call
+	for _, index := range g.byName[m.Name+m.Descriptor] {
+		s := &g.sites[index]
+		reaches := false
+		for _, target := range g.reachable(s) {
+			reaches = reaches || target == m
+		}
+		if !reaches {
+			if resolved := g.resolve(s.ref); resolved == nil || *resolved != m {
+				continue
+			}
+		}
+		calls = append(calls, Call{Caller: s.caller, Callee: m, Opcode: s.opcode, Line: s.line})
+	}
+	sort.SliceStable(calls, func(i, j int) bool {
+		return calls[i].Caller.String() < calls[j].Caller.String()
+	})
+	return calls
+}
+
+// reachable returns the methods that the call site can reach. Calls of methods that can't
+// be resolved, since a class is missing, reach the referenced method.
+func (g *Graph) reachable(s *site) []Method {
+	resolved := g.resolve(s.ref)
+	if resolved == nil {
+		return []Method{g.method(s.ref.Class, s.ref.Name, s.ref.Descriptor)}
+	}
+	flags := g.classes[resolved.Class].methods[resolved.Name+resolved.Descriptor]
+	virtual := (s.opcode == classfile.OpInvokevirtual || s.opcode == classfile.OpInvokeinterface ||
+		s.opcode == classfile.OpInvokedynamic) &&
+		flags&(class.AccStatic|class.AccPrivate) == 0 && resolved.Name != "<init>"
+
+	key := targetKey{ref: s.ref, virtual: virtual}
+	if targets, ok := g.targets[key]; ok {
+		return targets
+	}
+	targets := []Method{*resolved}
+	if virtual {
+		targets = g.implementations(s.ref)
+		if len(targets) == 0 {
+			targets = []Method{*resolved}
+		}
+	}
+	g.targets[key] = targets
+	return targets
+}
+
+// implementations returns the methods that a virtual call of the referenced method can select
+// in the referenced class and all of its subtypes that are not abstract.
+func (g *Graph) implementations(ref class.Reference) []Method {
+	seen := make(map[string]bool)
+	found := make(map[Method]bool)
+	var methods []Method
+	queue := []string{ref.Class}
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		queue = append(queue, g.subtypes[name]...)
+
+		c, ok := g.classes[name]
+		if !ok || c.flags&(class.AccAbstract|class.AccInterface) != 0 {
+			continue
+		}
+		if m := g.selectMethod(c, ref.Name+ref.Descriptor); m != nil && !found[*m] {
+			found[*m] = true
+			methods = append(methods, *m)
+		}
+	}
+	return methods
+}
+
+// selectMethod returns the method that a virtual call selects for an instance of the class:
+// the first implementation in the class and its superclasses, or else a default method of
+// its superinterfaces.
+func (g *Graph) selectMethod(c *classInfo, key string) *Method {
+	var interfaces []string
+	for current := c; current != nil; current = g.classes[current.super] {
+		if flags, ok := current.methods[key]; ok && flags&(class.AccAbstract|class.AccStatic) == 0 {
+			return g.methodOf(current, key)
+		}
+		interfaces = append(interfaces, current.interfaces...)
+	}
+	return g.searchInterfaces(interfaces, key, true)
+}
+
+// resolve returns the method that a reference resolves to, like the JVM resolves it: in the
+// class, its superclasses and then its superinterfaces. It returns nil if a class is missing.
+func (g *Graph) resolve(ref class.Reference) *Method {
+	owner := ref.Class
+	if strings.HasPrefix(owner, "[") {
+		owner = "java/lang/Object"
+	}
+	key := ref.Name + ref.Descriptor
+	var interfaces []string
+	for current := g.classes[owner]; current != nil; current = g.classes[current.super] {
+		if _, ok := current.methods[key]; ok {
+			return g.methodOf(current, key)
+		}
+		interfaces = append(interfaces, current.interfaces...)
+	}
+	return g.searchInterfaces(interfaces, key, false)
+}
+
+// searchInterfaces searches the given interfaces and their superinterfaces for a method,
+// which must not be abstract if concrete is set.
+func (g *Graph) searchInterfaces(interfaces []string, key string, concrete bool) *Method {
+	seen := make(map[string]bool)
+	for len(interfaces) > 0 {
+		iface, ok := g.classes[interfaces[0]]
+		interfaces = interfaces[1:]
+		if !ok || seen[iface.name] {
+			continue
+		}
+		seen[iface.name] = true
+		if flags, ok := iface.methods[key]; ok && flags&class.AccStatic == 0 && (!concrete || flags&class.AccAbstract == 0) {
+			return g.methodOf(iface, key)
+		}
+		interfaces = append(interfaces, iface.interfaces...)
+	}
+	return nil
+}
+
+func (g *Graph) methodOf(c *classInfo, key string) *Method {
+	i := strings.Index(key, "(")
+	m := g.method(c.name, key[:i], key[i:])
+	return &m
+}
@@ -0,0 +1,158 @@
+package callgraph
+
+import (
+	"errors"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"github.com/tsatke/jt/classfile"
+	"github.com/tsatke/jt/classpath"
+	"github.com/tsatke/jt/internal/classbuild"
+)
+
+func TestCallGraphSuite(t *testing.T) {
+	suite.Run(t, new(CallGraphSuite))
+}
+
+type CallGraphSuite struct {
+	suite.Suite
+
+	graph *Graph
+}
+
+func (suite *CallGraphSuite) SetupTest() {
+	dir := suite.T().TempDir()
+	write := func(c *classbuild.Class) { suite.Require().NoError(c.WriteFile(dir)) }
+
+	object := classbuild.New("java/lang/Object", "")
+	object.Method(classbuild.AccPublic, "<init>", "()V")
+	write(object)
+
+	api := classbuild.New("com/example/Api", "java/lang/Object")
+	api.AccessFlags = classbuild.AccPublic | classbuild.AccInterface | classbuild.AccAbstract
+	api.Method(classbuild.AccPublic|classbuild.AccAbstract, "send", "(Ljava/lang/String;)V")
+	write(api)
+
+	impl := classbuild.New("com/example/Impl", "java/lang/Object")
+	impl.Interfaces = []string{"com/example/Api"}
+	impl.Method(classbuild.AccPublic, "<init>", "()V")
+	impl.Method(classbuild.AccPublic, "send", "(Ljava/lang/String;)V")
+	write(impl)
+
+	// Derived implements Api with the method that it inherits from Base
+	base := classbuild.New("com/example/Base", "java/lang/Object")
+	base.Method(classbuild.AccPublic, "send", "(Ljava/lang/String;)V")
+	write(base)
+	derived := classbuild.New("com/example/Derived", "com/example/Base")
+	derived.Interfaces = []string{"com/example/Api"}
+	write(derived)
+
+	caller := classbuild.New("com/example/Caller", "java/lang/Object")
+	lambda := caller.MethodHandle(7, caller.Methodref("com/example/Caller", "lambda$run$0", "(Ljava/lang/String;)V"))
+	metafactory := caller.MethodHandle(6, caller.Methodref("java/lang/invoke/LambdaMetafactory", "metafactory",
+		"(Ljava/lang/invoke/MethodHandles$Lookup;Ljava/lang/String;Ljava/lang/invoke/MethodType;Ljava/lang/invoke/MethodType;Ljava/lang/invoke/MethodHandle;Ljava/lang/invoke/MethodType;)Ljava/lang/invoke/CallSite;"))
+	caller.Method(classbuild.AccPublic, "run", "(Lcom/example/Api;)V", caller.Code(classbuild.Data(
+		byte(0x2b),                                                                                                  // 0: aload_1
+		byte(0x01),                                                                                                  // 1: aconst_null
+		byte(0xb9), caller.InterfaceMethodref("com/example/Api", "send", "(Ljava/lang/String;)V"), byte(2), byte(0), // 2: invokeinterface
+		byte(0xba), caller.InvokeDynamic(0, "accept", "()Ljava/util/function/Consumer;"), byte(0), byte(0), // 7: invokedynamic
+		byte(0x57),                                                         // 12: pop
+		byte(0xb8), caller.Methodref("com/example/Missing", "call", "()V"), // 13: invokestatic
+		byte(0xb1), // 16: return
+	),
+		classbuild.LineNumber{Pc: 0, Line: 10},
+		classbuild.LineNumber{Pc: 7, Line: 11},
+		classbuild.LineNumber{Pc: 13, Line: 12},
+	))
+	caller.Method(classbuild.AccPrivate|classbuild.AccStatic, "lambda$run$0", "(Ljava/lang/String;)V", caller.Code(classbuild.Data(
+		byte(0xbb), caller.Class("com/example/Impl"), // 0: new
+		byte(0x59),                                                        // 3: dup
+		byte(0xb7), caller.Methodref("com/example/Impl", "<init>", "()V"), // 4: invokespecial
+		byte(0x2a),                                                                        // 7: aload_0
+		byte(0xb6), caller.Methodref("com/example/Impl", "send", "(Ljava/lang/String;)V"), // 8: invokevirtual
+		byte(0xb1), // 11: return
+	)))
+	caller.Attributes = append(caller.Attributes, caller.BootstrapMethods(classbuild.BootstrapMethod{
+		MethodRef: metafactory,
+		Arguments: []uint16{caller.MethodType("(Ljava/lang/Object;)V"), lambda, caller.MethodType("(Ljava/lang/String;)V")},
+	}))
+	write(caller)
+
+	cp := classpath.NewClasspath()
+	cp.AddEntry(classpath.EntryTypeOutput, dir)
+	suite.graph = Build(cp)
+}
+
+func method(className, name, descriptor string) Method {
+	return Method{Class: className, Name: name, Descriptor: descriptor}
+}
+
+func (suite *CallGraphSuite) TestMethods() {
+	methods, err := suite.graph.Methods("com/example/Caller", "run", "")
+	suite.NoError(err)
+	suite.Equal([]Method{method("com/example/Caller", "run", "(Lcom/example/Api;)V")}, methods)
+	suite.Equal("com/example/Caller#run(Lcom/example/Api;)V", methods[0].String())
+
+	_, err = suite.graph.Methods("com/example/Caller", "run", "()V")
+	suite.Error(err)
+
+	_, err = suite.graph.Methods("com/example/Missing", "call", "")
+	var notFound *classpath.ClassNotFoundError
+	suite.True(errors.As(err, &notFound))
+}
+
+func (suite *CallGraphSuite) TestCallees() {
+	run := method("com/example/Caller", "run", "(Lcom/example/Api;)V")
+	suite.Equal([]Call{
+		{Caller: run, Callee: method("com/example/Impl", "send", "(Ljava/lang/String;)V"), Opcode: classfile.OpInvokeinterface, Line: 10},
+		{Caller: run, Callee: method("com/example/Base", "send", "(Ljava/lang/String;)V"), Opcode: classfile.OpInvokeinterface, Line: 10},
+		{Caller: run, Callee: method("com/example/Caller", "lambda$run$0", "(Ljava/lang/String;)V"), Opcode: classfile.OpInvokedynamic, Line: 11},
+		{Caller: run, Callee: method("com/example/Missing", "call", "()V"), Opcode: classfile.OpInvokestatic, Line: 12},
+	}, sortedTargets(suite.graph.Callees(run)))
+
+	lambda := method("com/example/Caller", "lambda$run$0", "(Ljava/lang/String;)V")
+	suite.Equal([]Call{
+		{Caller: lambda, Callee: method("com/example/Impl", "<init>", "()V"), Opcode: classfile.OpInvokespecial},
+		{Caller: lambda, Callee: method("com/example/Impl", "send", "(Ljava/lang/String;)V"), Opcode: classfile.OpInvokevirtual},
+	}, suite.graph.Callees(lambda))
+}
+
+func (suite *CallGraphSuite) TestCallers() {
+	run := method("com/example/Caller", "run", "(Lcom/example/Api;)V")
+	lambda := method("com/example/Caller", "lambda$run$0", "(Ljava/lang/String;)V")
+
+	send := method("com/example/Impl", "send", "(Ljava/lang/String;)V")
+	suite.Equal([]Call{
+		{Caller: lambda, Callee: send, Opcode: classfile.OpInvokevirtual},
+		{Caller: run, Callee: send, Opcode: classfile.OpInvokeinterface, Line: 10},
+	}, suite.graph.Callers(send))
+
+	// reached through Derived, which doesn't override it
+	send = method("com/example/Base", "send", "(Ljava/lang/String;)V")
+	suite.Equal([]Call{
+		{Caller: run, Callee: send, Opcode: classfile.OpInvokeinterface, Line: 10},
+	}, suite.graph.Callers(send))
+
+	// calls of the interface method itself
+	send = method("com/example/Api", "send", "(Ljava/lang/String;)V")
+	suite.Equal([]Call{
+		{Caller: run, Callee: send, Opcode: classfile.OpInvokeinterface, Line: 10},
+	}, suite.graph.Callers(send))
+
+	suite.Equal([]Call{
+		{Caller: run, Callee: lambda, Opcode: classfile.OpInvokedynamic, Line: 11},
+	}, suite.graph.Callers(lambda))
+}
+
+// sortedTargets returns the calls with the targets of the same call sorted by class,
+// since the subtypes of a class are not ordered.
+func sortedTargets(calls []Call) []Call {
+	sort.SliceStable(calls, func(i, j int) bool {
+		if calls[i].Line != calls[j].Line {
+			return calls[i].Line < calls[j].Line
+		}
+		return calls[i].Callee.Class > calls[j].Callee.Class
+	})
+	return calls
+}
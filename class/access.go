@@ -0,0 +1,27 @@
+package class
+
+// AccessFlags are the access flags of a class or a member.
+// Some flags have different meanings for classes, fields and methods.
+type AccessFlags uint16
+
+const (
+	AccPublic       AccessFlags = 0x0001
+	AccPrivate      AccessFlags = 0x0002
+	AccProtected    AccessFlags = 0x0004
+	AccStatic       AccessFlags = 0x0008
+	AccFinal        AccessFlags = 0x0010
+	AccSuper        AccessFlags = 0x0020 // classes
+	AccSynchronized AccessFlags = 0x0020 // methods
+	AccVolatile     AccessFlags = 0x0040 // fields
+	AccBridge       AccessFlags = 0x0040 // methods
+	AccTransient    AccessFlags = 0x0080 // fields
+	AccVarargs      AccessFlags = 0x0080 // methods
+	AccNative       AccessFlags = 0x0100
+	AccInterface    AccessFlags = 0x0200
+	AccAbstract     AccessFlags = 0x0400
+	AccStrict       AccessFlags = 0x0800
+	AccSynthetic    AccessFlags = 0x1000
+	AccAnnotation   AccessFlags = 0x2000
+	AccEnum         AccessFlags = 0x4000
+	AccModule       AccessFlags = 0x8000
+)
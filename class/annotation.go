@@ -0,0 +1,68 @@
+package class
+
+import "github.com/tsatke/jt/classfile"
+
+// Annotation is an annotation of a class, field, method or parameter.
+type Annotation struct {
+	// Type is the annotation type, such as java/lang/Deprecated.
+	Type string
+	// Visible is whether the annotation is retained at runtime.
+	Visible bool
+	// Classes are the classes in the values of the annotation, which are the types of enum
+	// constants, class literals and the types of nested annotations, in the order of the values.
+	Classes []string
+}
+
+// Annotations returns the annotations of the class.
+func (c Class) Annotations() []Annotation {
+	return c.annotations(c.cf.AttributeTable)
+}
+
+// Annotations returns the annotations of the field or method, without the annotations of
+// the parameters of a method.
+func (m member) Annotations() []Annotation {
+	return Class{m.cf}.annotations(m.info.AttributeTable)
+}
+
+func (c Class) annotations(table *classfile.AttributeTable) []Annotation {
+	var annotations []Annotation
+	for _, attr := range table.Attributes() {
+		if attr, ok := attr.(*classfile.AnnotationsAttribute); ok {
+			for _, a := range attr.Annotations {
+				annotations = append(annotations, c.annotation(a, attr.Visible))
+			}
+		}
+	}
+	return annotations
+}
+
+func (c Class) annotation(a classfile.Annotation, visible bool) Annotation {
+	annotation := Annotation{Visible: visible}
+	if types := DescriptorClasses(c.utf8(a.TypeIndex)); len(types) > 0 {
+		annotation.Type = types[0]
+	}
+	for _, element := range a.Elements {
+		annotation.Classes = append(annotation.Classes, c.elementClasses(element.Value)...)
+	}
+	return annotation
+}
+
+// elementClasses returns the classes in the value of an annotation element.
+func (c Class) elementClasses(value classfile.ElementValue) []string {
+	switch value.Tag {
+	case 'c':
+		return DescriptorClasses(c.utf8(value.ConstIndex))
+	case 'e':
+		return DescriptorClasses(c.utf8(value.TypeNameIndex))
+	case '@':
+		nested := c.annotation(*value.Annotation, false)
+		return append([]string{nested.Type}, nested.Classes...)
+	case '[':
+		var classes []string
+		for _, v := range value.Values {
+			classes = append(classes, c.elementClasses(v)...)
+		}
+		return classes
+	}
+	return nil
+}
@@ -46,3 +46,58 @@ func (c Class) Methods() []Method {
 	}
 	return methods
 }
+
+// Interfaces returns the names of the interfaces that the class directly implements,
+// or that the interface directly extends.
+func (c Class) Interfaces() []string {
+	interfaces := make([]string, len(c.cf.Interfaces))
+	for i, index := range c.cf.Interfaces {
+		interfaces[i] = c.className(index)
+	}
+	return interfaces
+}
+
+func (c Class) AccessFlags() AccessFlags {
+	return AccessFlags(c.cf.AccessFlags)
+}
+
+// IsInterface returns whether this is an interface, which includes annotation types.
+func (c Class) IsInterface() bool {
+	return c.AccessFlags()&AccInterface != 0
+}
+
+func (c Class) IsAnnotation() bool {
+	return c.AccessFlags()&AccAnnotation != 0
+}
+
+func (c Class) IsAbstract() bool {
+	return c.AccessFlags()&AccAbstract != 0
+}
+
+func (c Class) IsPublic() bool {
+	return c.AccessFlags()&AccPublic != 0
+}
+
+func (c Class) IsFinal() bool {
+	return c.AccessFlags()&AccFinal != 0
+}
+
+func (c Class) Fields() []Field {
+	fields := make([]Field, len(c.cf.Fields))
+	for i := range fields {
+		fields[i] = Field{member{
+			info: c.cf.Fields[i],
+			cf:   c.cf,
+		}}
+	}
+	return fields
+}
+
+// className returns the name of the class constant at the given index of the constant pool.
+func (c Class) className(index uint16) string {
+	return c.utf8(c.cf.ConstantPool[index].(*classfile.ConstantClassInfo).NameIndex)
+}
+
+func (c Class) utf8(index uint16) string {
+	return c.cf.ConstantPool[index].(*classfile.ConstantUtf8Info).Value
+}
@@ -1,11 +1,13 @@
 package class
 
 import (
+	"bytes"
 	"os"
 	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/suite"
+	"github.com/tsatke/jt/internal/classbuild"
 )
 
 func TestClassSuite(t *testing.T) {
@@ -32,3 +34,240 @@ func (suite *ClassSuite) TestParse() {
 	suite.Equal("<init>", methods[0].Name())
 	suite.Equal("main", methods[1].Name())
 }
+
+func (suite *ClassSuite) TestInterfaces() {
+	b := classbuild.New("com/example/Impl", "java/lang/Object")
+	b.AccessFlags |= classbuild.AccAbstract
+	b.Interfaces = []string{"java/lang/Runnable", "java/io/Closeable"}
+	b.Field(classbuild.AccPrivate, "name", "Ljava/lang/String;")
+	b.Method(classbuild.AccPublic|classbuild.AccStatic, "provider", "()Lcom/example/Impl;")
+
+	c, err := ParseClass(bytes.NewReader(b.Bytes()))
+	suite.Require().NoError(err)
+	suite.Equal([]string{"java/lang/Runnable", "java/io/Closeable"}, c.Interfaces())
+	suite.True(c.IsAbstract())
+	suite.False(c.IsInterface())
+	suite.False(c.IsAnnotation())
+	suite.Nil(c.Module())
+
+	suite.Len(c.Fields(), 1)
+	suite.Equal("Ljava/lang/String;", c.Fields()[0].Descriptor())
+	suite.False(c.Fields()[0].IsPublic())
+
+	method := c.Methods()[0]
+	suite.Equal("()Lcom/example/Impl;", method.Descriptor())
+	suite.True(method.IsStatic())
+	suite.True(method.IsPublic())
+}
+
+func (suite *ClassSuite) TestModule() {
+	b := classbuild.NewModuleInfo()
+	b.Attributes = append(b.Attributes, b.ModuleAttribute("com.example",
+		[]string{"java.base"},
+		[]string{"com/example/api"},
+		[]string{"com/example/api/Plugin"},
+		[]classbuild.Provides{{Service: "com/example/api/Plugin", With: []string{"com/example/impl/A", "com/example/impl/B"}}},
+	))
+
+	c, err := ParseClass(bytes.NewReader(b.Bytes()))
+	suite.Require().NoError(err)
+	suite.Equal(&Module{
+		Name:     "com.example",
+		Requires: []string{"java.base"},
+		Exports:  []string{"com/example/api"},
+		Uses:     []string{"com/example/api/Plugin"},
+		Provides: []Provides{{Service: "com/example/api/Plugin", Providers: []string{"com/example/impl/A", "com/example/impl/B"}}},
+	}, c.Module())
+}
+
+func (suite *ClassSuite) TestCodeReferences() {
+	b := classbuild.New("com/example/App", "java/lang/Object")
+	code := classbuild.Data(
+		byte(0xbb), b.Class("com/example/Service"), // 0: new
+		byte(0x59),                                                      // 3: dup
+		byte(0xb7), b.Methodref("com/example/Service", "<init>", "()V"), // 4: invokespecial
+		byte(0xb2), b.Fieldref("java/lang/System", "out", "Ljava/io/PrintStream;"), // 7: getstatic
+		byte(0x12), byte(b.String("hello")), // 10: ldc
+		byte(0xb9), b.InterfaceMethodref("java/util/List", "size", "()I"), byte(1), byte(0), // 12: invokeinterface
+		byte(0xb1), // 17: return
+	)
+	b.Method(classbuild.AccPublic, "run", "()V", b.Code(code,
+		classbuild.LineNumber{Pc: 0, Line: 10},
+		classbuild.LineNumber{Pc: 7, Line: 11},
+	))
+	b.Method(classbuild.AccPublic|classbuild.AccAbstract, "stop", "()V")
+
+	c, err := ParseClass(bytes.NewReader(b.Bytes()))
+	suite.Require().NoError(err)
+
+	references, err := c.Methods()[0].CodeReferences()
+	suite.Require().NoError(err)
+	suite.Equal([]CodeReference{
+		{Reference{RefClass, "com/example/Service", "", ""}, 0xbb, 0, 10},
+		{Reference{RefMethod, "com/example/Service", "<init>", "()V"}, 0xb7, 4, 10},
+		{Reference{RefField, "java/lang/System", "out", "Ljava/io/PrintStream;"}, 0xb2, 7, 11},
+		{Reference{RefInterfaceMethod, "java/util/List", "size", "()I"}, 0xb9, 12, 11},
+	}, references)
+
+	// abstract methods have no code
+	references, err = c.Methods()[1].CodeReferences()
+	suite.NoError(err)
+	suite.Empty(references)
+
+	field := Reference{RefField, "java/lang/System", "out", "Ljava/io/PrintStream;"}
+	suite.Contains(c.References(), field)
+	suite.Equal([]string{"hello"}, c.Strings())
+	suite.Equal("java/lang/System#out:Ljava/io/PrintStream;", field.String())
+}
+
+func (suite *ClassSuite) TestDescriptorClasses() {
+	suite.Equal([]string{"java/lang/String", "java/util/List"}, DescriptorClasses("(Ljava/lang/String;I[[Ljava/util/List;)V"))
+	suite.Empty(DescriptorClasses("(IJ)[B"))
+
+	suite.Equal("java/lang/String", ElementClass("[[Ljava/lang/String;"))
+	suite.Equal("", ElementClass("[I"))
+	suite.Equal("java/lang/String", ElementClass("java/lang/String"))
+}
+
+func (suite *ClassSuite) TestSignatureClasses() {
+	for signature, classes := range map[string][]string{
+		"Ljava/util/List<Ljava/lang/String;>;": {"java/util/List", "java/lang/String"},
+		"<T:Ljava/lang/Object;LU::Ljava/lang/Comparable<-TLU;>;>Ljava/lang/Object;Ljava/util/function/Supplier<TT;>;": {
+			"java/lang/Object", "java/lang/Comparable", "java/lang/Object", "java/util/function/Supplier",
+		},
+		"<T:Ljava/lang/Object;>(Ljava/util/Map<TT;*>;[I)Lcom/example/Outer<TT;>.Inner<+Ljava/lang/Number;>;^TE;^Ljava/io/IOException;": {
+			"java/lang/Object", "java/util/Map", "com/example/Outer", "com/example/Outer$Inner", "java/lang/Number", "java/io/IOException",
+		},
+		// truncated signatures are parsed as far as possible
+		"Ljava/util/List<Ljava/lang/Str": {"java/util/List"},
+	} {
+		suite.Equalf(classes, SignatureClasses(signature), "classes of %s", signature)
+	}
+}
+
+func (suite *ClassSuite) TestParseSignatures() {
+	c, err := ParseClassSignature("<T:Ljava/lang/Number;:Ljava/lang/Comparable<TT;>;U:Ljava/lang/Object;>Ljava/lang/Object;Ljava/util/function/Supplier<Ljava/util/List<+TT;>;>;")
+	suite.Require().NoError(err)
+	suite.Equal(&ClassSignature{
+		TypeParameters: "<T extends java.lang.Number & java.lang.Comparable<T>, U>",
+		Superclass:     "java.lang.Object",
+		Interfaces:     []string{"java.util.function.Supplier<java.util.List<? extends T>>"},
+	}, c)
+
+	m, err := ParseMethodSignature("<K:Ljava/lang/Object;>(Ljava/util/Map<TK;-TK;>;[[ILcom/example/Outer<TK;>.Inner<*>;)TK;^Ljava/io/IOException;")
+	suite.Require().NoError(err)
+	suite.Equal(&MethodSignature{
+		TypeParameters: "<K>",
+		Parameters:     []string{"java.util.Map<K, ? super K>", "int[][]", "com.example.Outer<K>.Inner<?>"},
+		Result:         "K",
+		Exceptions:     []string{"java.io.IOException"},
+	}, m)
+
+	// descriptors are signatures without generics
+	m, err = ParseMethodSignature("(Ljava/lang/String;J)V")
+	suite.Require().NoError(err)
+	suite.Equal(&MethodSignature{Parameters: []string{"java.lang.String", "long"}, Result: "void"}, m)
+
+	f, err := ParseFieldSignature("[Ljava/util/Map$Entry;")
+	suite.NoError(err)
+	suite.Equal("java.util.Map$Entry[]", f)
+
+	_, err = ParseMethodSignature("(Ljava/lang/Str")
+	suite.Error(err)
+	_, err = ParseFieldSignature("")
+	suite.Error(err)
+	_, err = ParseFieldSignature("II")
+	suite.Error(err)
+}
+
+func (suite *ClassSuite) TestAnnotations() {
+	b := classbuild.New("com/example/App", "java/lang/Object")
+	b.Attributes = append(b.Attributes, b.Annotations(true,
+		b.Annotation("com/example/Config",
+			classbuild.AnnotationElement{Name: "name", Value: b.StringValue("app")},
+			classbuild.AnnotationElement{Name: "mode", Value: b.EnumValue("com/example/Mode", "FAST")},
+			classbuild.AnnotationElement{Name: "types", Value: classbuild.ArrayValue(b.ClassValue("Lcom/example/Type;"), b.ClassValue("V"))},
+			classbuild.AnnotationElement{Name: "nested", Value: classbuild.AnnotationValue(b.Annotation("com/example/Nested"))},
+		),
+	), b.Annotations(false, b.Annotation("com/example/Generated")))
+	b.Method(classbuild.AccPublic, "run", "(Ljava/lang/String;)V",
+		b.Annotations(true, b.Annotation("java/lang/Deprecated")),
+		b.ParameterAnnotations(false, []classbuild.Annotation{b.Annotation("com/example/NonNull")}),
+	)
+
+	c, err := ParseClass(bytes.NewReader(b.Bytes()))
+	suite.Require().NoError(err)
+	suite.Equal([]Annotation{
+		{Type: "com/example/Config", Visible: true, Classes: []string{"com/example/Mode", "com/example/Type", "com/example/Nested"}},
+		{Type: "com/example/Generated"},
+	}, c.Annotations())
+	suite.Equal([]Annotation{{Type: "java/lang/Deprecated", Visible: true}}, c.Methods()[0].Annotations())
+}
+
+func (suite *ClassSuite) TestDependencies() {
+	b := classbuild.New("com/example/App", "java/lang/Object")
+	b.Interfaces = []string{"java/lang/Runnable"}
+	b.Attributes = append(b.Attributes,
+		b.Signature("Ljava/lang/Object;Ljava/lang/Runnable;Ljava/util/function/Supplier<Lcom/example/Result;>;"),
+		b.Annotations(false, b.Annotation("com/example/Generated")),
+	)
+	b.Field(classbuild.AccPrivate, "items", "[Lcom/example/Item;", classbuild.Attribute{
+		Name: "RuntimeVisibleTypeAnnotations",
+		Data: classbuild.Data(2,
+			byte(0x13), byte(0), []byte(b.Annotation("com/example/Size")), // field type, empty type path
+			byte(0x40), 1, 0, 10, 1, byte(1), byte(0), byte(0), []byte(b.Annotation("com/example/Local")), // local variable, path of one step
+		),
+	})
+	b.Method(classbuild.AccPublic, "run", "()V", b.Code(classbuild.Data(
+		byte(0xbb), b.Class("com/example/App"), // 0: new
+		byte(0xbd), b.Class("[Lcom/example/Element;"), // 3: anewarray
+		byte(0xb8), b.Methodref("com/example/Util", "convert", "(Lcom/example/Input;)[I"), // 6: invokestatic
+		byte(0xb1), // 9: return
+	)), b.Exceptions("java/io/IOException"),
+		b.ParameterAnnotations(true, nil, []classbuild.Annotation{b.Annotation("com/example/NonNull")}))
+
+	c, err := ParseClass(bytes.NewReader(b.Bytes()))
+	suite.Require().NoError(err)
+	suite.Equal([]string{
+		"com/example/Element",
+		"com/example/Generated",
+		"com/example/Input",
+		"com/example/Item",
+		"com/example/Local",
+		"com/example/NonNull",
+		"com/example/Result",
+		"com/example/Size",
+		"com/example/Util",
+		"java/io/IOException",
+		"java/lang/Object",
+		"java/lang/Runnable",
+		"java/util/function/Supplier",
+	}, c.Dependencies())
+	suite.Equal([]string{
+		"com/example/Generated",
+		"com/example/Local",
+		"com/example/NonNull",
+		"com/example/Size",
+	}, c.AnnotationDependencies())
+}
+
+func (suite *ClassSuite) TestConstantValue() {
+	b := classbuild.New("com/example/Constants", "java/lang/Object")
+	b.Field(classbuild.AccPublic|classbuild.AccStatic|classbuild.AccFinal, "COUNT", "I", b.ConstantValue(b.Integer(42)))
+	b.Field(classbuild.AccPublic|classbuild.AccStatic|classbuild.AccFinal, "ENABLED", "Z", b.ConstantValue(b.Integer(1)))
+	b.Field(classbuild.AccPublic|classbuild.AccStatic|classbuild.AccFinal, "SEPARATOR", "C", b.ConstantValue(b.Integer('/')))
+	b.Field(classbuild.AccPublic|classbuild.AccStatic|classbuild.AccFinal, "TIMEOUT", "J", b.ConstantValue(b.Long(10)))
+	b.Field(classbuild.AccPublic|classbuild.AccStatic|classbuild.AccFinal, "RATIO", "D", b.ConstantValue(b.Double(2)))
+	b.Field(classbuild.AccPublic|classbuild.AccStatic|classbuild.AccFinal, "NAME", "Ljava/lang/String;", b.ConstantValue(b.String("a \"b\"")))
+	b.Field(classbuild.AccPublic, "mutable", "I")
+
+	c, err := ParseClass(bytes.NewReader(b.Bytes()))
+	suite.Require().NoError(err)
+	var values []string
+	for _, f := range c.Fields() {
+		value, ok := f.ConstantValue()
+		suite.Equal(f.Name() != "mutable", ok)
+		values = append(values, value)
+	}
+	suite.Equal([]string{"42", "true", "'/'", "10L", "2.0", `"a \"b\""`, ""}, values)
+}
@@ -0,0 +1,87 @@
+package class
+
+import (
+	"fmt"
+
+	"github.com/tsatke/jt/classfile"
+)
+
+// CodeReference is an instruction in the code of a method that references a class, field or method.
+type CodeReference struct {
+	Reference
+	Opcode classfile.Opcode
+	// Offset is the offset of the instruction in the code.
+	Offset int
+	// Line is the source line of the instruction, or 0 if the class has no line numbers.
+	Line int
+}
+
+// Code returns the code of the method, or nil if the method is abstract or native.
+func (m Method) Code() *classfile.CodeAttribute {
+	for _, attr := range m.info.AttributeTable.Attributes() {
+		if code, ok := attr.(*classfile.CodeAttribute); ok {
+			return code
+		}
+	}
+	return nil
+}
+
+// CodeReferences decodes the code of the method and returns the instructions that reference
+// a class, field or method, in the order of the code. For invokedynamic, the bootstrap method
+// and the classes and method handles in its arguments are returned, such as the method that
+// a lambda or a method reference calls. Other constants, like strings and method types, are
+// left out.
+func (m Method) CodeReferences() ([]CodeReference, error) {
+	code := m.Code()
+	if code == nil {
+		return nil, nil
+	}
+	instructions, err := code.Instructions()
+	if err != nil {
+		return nil, fmt.Errorf("decode %s%s: %w", m.Name(), m.Descriptor(), err)
+	}
+
+	c := Class{m.cf}
+	var references []CodeReference
+	add := func(instruction classfile.Instruction, index uint16) {
+		if ref := c.reference(index); ref != nil {
+			references = append(references, CodeReference{
+				Reference: *ref,
+				Opcode:    instruction.Opcode,
+				Offset:    instruction.Offset,
+				Line:      code.Line(instruction.Offset),
+			})
+		}
+	}
+	for _, instruction := range instructions {
+		index := instruction.Index()
+		if index == 0 {
+			continue
+		}
+		if instruction.Opcode != classfile.OpInvokedynamic {
+			add(instruction, index)
+			continue
+		}
+		if bootstrap := c.bootstrapMethod(index); bootstrap != nil {
+			add(instruction, bootstrap.MethodRef)
+			for _, argument := range bootstrap.Arguments {
+				add(instruction, argument)
+			}
+		}
+	}
+	return references, nil
+}
+
+// bootstrapMethod returns the bootstrap method of the invokedynamic constant at the given index.
+func (c Class) bootstrapMethod(index uint16) *classfile.BootstrapMethod {
+	info, ok := c.cf.ConstantPool[index].(*classfile.ConstantInvokeDynamicInfo)
+	if !ok {
+		return nil
+	}
+	for _, attr := range c.cf.AttributeTable.Attributes() {
+		if methods, ok := attr.(classfile.BootstrapMethodsAttribute); ok && int(info.BootstrapMethodAttrIndex) < len(methods) {
+			return &methods[info.BootstrapMethodAttrIndex]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package class
+
+import (
+	"sort"
+
+	"github.com/tsatke/jt/classfile"
+)
+
+// Dependencies returns the classes that the class depends on, sorted by name and without the
+// class itself. These are the classes that its constant pool references, which includes the
+// superclass, the interfaces, caught and declared exceptions and the classes of referenced
+// fields and methods, the classes in referenced descriptors, in the descriptors and generic
+// signatures of the class and its members, and in annotations. Arrays are replaced by the
+// classes of their elements.
+func (c Class) Dependencies() []string {
+	return unique(append(c.referencedClasses(), c.annotationClasses()...), c.Name())
+}
+
+// AnnotationDependencies returns the classes that the class depends on only through annotations,
+// sorted by name and without the class itself. These are annotation types and classes in the
+// values of annotations that are not used otherwise, which the JVM doesn't load unless the
+// annotations are read by reflection.
+func (c Class) AnnotationDependencies() []string {
+	referenced := make(map[string]bool)
+	for _, name := range c.referencedClasses() {
+		referenced[name] = true
+	}
+	var classes []string
+	for _, name := range c.annotationClasses() {
+		if !referenced[name] {
+			classes = append(classes, name)
+		}
+	}
+	return unique(classes, c.Name())
+}
+
+// referencedClasses returns the classes that the class depends on, except for those in annotations.
+func (c Class) referencedClasses() []string {
+	var classes []string
+	for _, ref := range c.References() {
+		classes = append(classes, ElementClass(ref.Class))
+		if ref.Kind != RefClass {
+			classes = append(classes, DescriptorClasses(ref.Descriptor)...)
+		}
+	}
+	for _, descriptor := range c.Descriptors() {
+		classes = append(classes, DescriptorClasses(descriptor)...)
+	}
+	classes = append(classes, SignatureClasses(c.Signature())...)
+	for _, f := range c.Fields() {
+		classes = append(classes, DescriptorClasses(f.Descriptor())...)
+		classes = append(classes, SignatureClasses(f.Signature())...)
+	}
+	for _, m := range c.Methods() {
+		classes = append(classes, DescriptorClasses(m.Descriptor())...)
+		classes = append(classes, SignatureClasses(m.Signature())...)
+	}
+	return classes
+}
+
+// unique returns the sorted classes without duplicates, empty names and the given class.
+func unique(classes []string, self string) []string {
+	seen := map[string]bool{"": true, self: true}
+	var dependencies []string
+	for _, name := range classes {
+		if !seen[name] {
+			seen[name] = true
+			dependencies = append(dependencies, name)
+		}
+	}
+	sort.Strings(dependencies)
+	return dependencies
+}
+
+// annotationClasses returns the annotation types and the classes in the values of all annotations
+// of the class, its members, the parameters of its methods and of types used in their code.
+func (c Class) annotationClasses() []string {
+	tables := []*classfile.AttributeTable{c.cf.AttributeTable}
+	for _, f := range c.cf.Fields {
+		tables = append(tables, f.AttributeTable)
+	}
+	for _, m := range c.cf.Methods {
+		tables = append(tables, m.AttributeTable)
+	}
+
+	var classes []string
+	add := func(a classfile.Annotation) {
+		annotation := c.annotation(a, false)
+		classes = append(classes, annotation.Type)
+		classes = append(classes, annotation.Classes...)
+	}
+	for i := 0; i < len(tables); i++ {
+		for _, attr := range tables[i].Attributes() {
+			switch attr := attr.(type) {
+			case *classfile.AnnotationsAttribute:
+				for _, a := range attr.Annotations {
+					add(a)
+				}
+			case *classfile.ParameterAnnotationsAttribute:
+				for _, parameter := range attr.Parameters {
+					for _, a := range parameter {
+						add(a)
+					}
+				}
+			case *classfile.TypeAnnotationsAttribute:
+				for _, a := range attr.Annotations {
+					add(a.Annotation)
+				}
+			case *classfile.AnnotationDefaultAttribute:
+				classes = append(classes, c.elementClasses(attr.Value)...)
+			case *classfile.CodeAttribute:
+				// type annotations of local variables, casts and the like
+				tables = append(tables, attr.Attributes)
+			}
+		}
+	}
+	return classes
+}
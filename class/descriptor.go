@@ -0,0 +1,298 @@
+package class
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DescriptorClasses returns the classes in a field or method descriptor, such as java/lang/String
+// and java/util/List in (Ljava/lang/String;[Ljava/util/List;)V, in the order of the descriptor.
+func DescriptorClasses(descriptor string) []string {
+	var classes []string
+	for i := 0; i < len(descriptor); i++ {
+		if descriptor[i] != 'L' {
+			continue
+		}
+		end := strings.IndexByte(descriptor[i:], ';')
+		if end < 0 {
+			break
+		}
+		classes = append(classes, descriptor[i+1:i+end])
+		i += end
+	}
+	return classes
+}
+
+// ElementClass returns the class of the elements of an array that is referenced with its descriptor,
+// such as java/lang/String for [[Ljava/lang/String;, or an empty string for arrays of primitives.
+// Other names are returned unchanged.
+func ElementClass(name string) string {
+	if !strings.HasPrefix(name, "[") {
+		return name
+	}
+	name = strings.TrimLeft(name, "[")
+	if strings.HasPrefix(name, "L") && strings.HasSuffix(name, ";") {
+		return name[1 : len(name)-1]
+	}
+	return ""
+}
+
+// SignatureClasses returns the classes in a generic signature of a class, field or method,
+// including the classes in type arguments and bounds, in the order of the signature.
+// Inner classes of parameterized classes, such as pkg/Outer<TT;>.Inner, are returned
+// with their binary names, like pkg/Outer$Inner. Malformed signatures are parsed as far as possible.
+func SignatureClasses(signature string) (classes []string) {
+	p := &signatureParser{s: signature}
+	defer func() {
+		// index errors of truncated signatures end the parsing
+		if recover() != nil {
+			classes = p.classes
+		}
+	}()
+	p.parse()
+	return p.classes
+}
+
+// ClassSignature is the generic signature of a class rendered in Java syntax with qualified
+// names, such as java.util.List<? extends T>.
+type ClassSignature struct {
+	// TypeParameters are the type parameters with their bounds, such as <T extends
+	// java.lang.Comparable<T>>, or empty if there are none.
+	TypeParameters string
+	Superclass     string
+	Interfaces     []string
+}
+
+// MethodSignature is the generic signature or the descriptor of a method rendered in Java
+// syntax with qualified names.
+type MethodSignature struct {
+	// TypeParameters are the type parameters with their bounds, or empty if there are none.
+	TypeParameters string
+	Parameters     []string
+	Result         string
+	// Exceptions are the exceptions in the signature, which descriptors and most signatures
+	// leave to the Exceptions attribute.
+	Exceptions []string
+}
+
+// ParseClassSignature parses and renders a generic signature of a class.
+func ParseClassSignature(signature string) (sig *ClassSignature, err error) {
+	p := &signatureParser{s: signature}
+	defer p.recover(&err)
+	sig = &ClassSignature{TypeParameters: p.typeParameters()}
+	sig.Superclass = p.referenceType()
+	for p.pos < len(p.s) {
+		sig.Interfaces = append(sig.Interfaces, p.referenceType())
+	}
+	return sig, nil
+}
+
+// ParseMethodSignature parses and renders a generic signature or a descriptor of a method.
+func ParseMethodSignature(signature string) (sig *MethodSignature, err error) {
+	p := &signatureParser{s: signature}
+	defer p.recover(&err)
+	sig = &MethodSignature{TypeParameters: p.typeParameters()}
+	p.expect('(')
+	for p.peek() != ')' {
+		sig.Parameters = append(sig.Parameters, p.javaType())
+	}
+	p.pos++ // )
+	sig.Result = p.javaType()
+	for p.peek() == '^' {
+		p.pos++
+		sig.Exceptions = append(sig.Exceptions, p.referenceType())
+	}
+	p.end()
+	return sig, nil
+}
+
+// ParseFieldSignature parses and renders a generic signature or a descriptor of a field.
+func ParseFieldSignature(signature string) (typ string, err error) {
+	p := &signatureParser{s: signature}
+	defer p.recover(&err)
+	typ = p.javaType()
+	p.end()
+	return typ, nil
+}
+
+// signatureParser parses generic signatures as described in JVMS 4.7.9.1, and descriptors,
+// which are a subset of them. It collects the classes of the signature and renders the types
+// that it parses in Java syntax.
+type signatureParser struct {
+	s       string
+	pos     int
+	classes []string
+}
+
+// recover sets the error for malformed signatures, which the parser panics on.
+func (p *signatureParser) recover(err *error) {
+	if r := recover(); r != nil {
+		*err = fmt.Errorf("malformed signature %s at %d: %v", p.s, p.pos, r)
+	}
+}
+
+func (p *signatureParser) parse() {
+	p.typeParameters()
+	// the remainder is a superclass with interfaces, a field type, or the parameters,
+	// result and exceptions of a method
+	for p.pos < len(p.s) {
+		switch p.peek() {
+		case '(', ')', '^', 'V':
+			p.pos++
+		default:
+			p.javaType()
+		}
+	}
+}
+
+func (p *signatureParser) peek() byte {
+	if p.pos >= len(p.s) {
+		return 0
+	}
+	return p.s[p.pos]
+}
+
+func (p *signatureParser) expect(c byte) {
+	if p.peek() != c {
+		panic(fmt.Sprintf("expected %c", c))
+	}
+	p.pos++
+}
+
+func (p *signatureParser) end() {
+	if p.pos != len(p.s) {
+		panic("unexpected trailing characters")
+	}
+}
+
+// typeParameters parses the type parameters, if there are any, and renders them like
+// <T extends java.lang.Number & java.lang.Comparable<T>, U>. Bounds of java.lang.Object are left out.
+func (p *signatureParser) typeParameters() string {
+	if p.peek() != '<' {
+		return ""
+	}
+	p.pos++ // <
+	var parameters []string
+	for p.peek() != '>' {
+		// the identifier is followed by a class bound and interface bounds, all optional
+		end := strings.IndexByte(p.s[p.pos:], ':')
+		if end < 0 {
+			panic("malformed type parameter")
+		}
+		parameter := p.s[p.pos : p.pos+end]
+		p.pos += end
+		var bounds []string
+		for p.peek() == ':' {
+			p.pos++
+			if c := p.peek(); c == 'L' || c == 'T' || c == '[' {
+				if bound := p.referenceType(); bound != "java.lang.Object" {
+					bounds = append(bounds, bound)
+				}
+			}
+		}
+		if len(bounds) > 0 {
+			parameter += " extends " + strings.Join(bounds, " & ")
+		}
+		parameters = append(parameters, parameter)
+	}
+	p.pos++ // >
+	return "<" + strings.Join(parameters, ", ") + ">"
+}
+
+var baseTypes = map[byte]string{
+	'B': "byte",
+	'C': "char",
+	'D': "double",
+	'F': "float",
+	'I': "int",
+	'J': "long",
+	'S': "short",
+	'Z': "boolean",
+	'V': "void",
+}
+
+func (p *signatureParser) javaType() string {
+	switch p.peek() {
+	case 'L', 'T', '[':
+		return p.referenceType()
+	}
+	name, ok := baseTypes[p.peek()]
+	if !ok {
+		panic("malformed base type")
+	}
+	p.pos++
+	return name
+}
+
+func (p *signatureParser) referenceType() string {
+	switch p.s[p.pos] {
+	case 'L':
+		return p.classType()
+	case 'T':
+		end := strings.IndexByte(p.s[p.pos:], ';')
+		if end < 0 {
+			panic("malformed type variable")
+		}
+		name := p.s[p.pos+1 : p.pos+end]
+		p.pos += end + 1
+		return name
+	case '[':
+		p.pos++
+		return p.javaType() + "[]"
+	}
+	panic("malformed reference type")
+}
+
+func (p *signatureParser) classType() string {
+	p.pos++ // L
+	name := p.identifier()
+	p.classes = append(p.classes, name)
+	rendered := strings.ReplaceAll(name, "/", ".")
+	if p.peek() == '<' {
+		rendered += p.typeArguments()
+	}
+	for p.peek() == '.' {
+		p.pos++
+		identifier := p.identifier()
+		name += "$" + identifier
+		p.classes = append(p.classes, name)
+		rendered += "." + identifier
+		if p.peek() == '<' {
+			rendered += p.typeArguments()
+		}
+	}
+	p.expect(';')
+	return rendered
+}
+
+func (p *signatureParser) identifier() string {
+	start := p.pos
+	end := strings.IndexAny(p.s[p.pos:], "<;.")
+	if end < 0 {
+		panic("malformed identifier")
+	}
+	p.pos += end
+	return p.s[start:p.pos]
+}
+
+func (p *signatureParser) typeArguments() string {
+	p.pos++ // <
+	var arguments []string
+	for p.peek() != '>' {
+		switch p.s[p.pos] {
+		case '*':
+			p.pos++
+			arguments = append(arguments, "?")
+		case '+':
+			p.pos++
+			arguments = append(arguments, "? extends "+p.referenceType())
+		case '-':
+			p.pos++
+			arguments = append(arguments, "? super "+p.referenceType())
+		default:
+			arguments = append(arguments, p.referenceType())
+		}
+	}
+	p.pos++ // >
+	return "<" + strings.Join(arguments, ", ") + ">"
+}
@@ -1,6 +1,12 @@
 package class
 
-import "github.com/tsatke/jt/classfile"
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/tsatke/jt/classfile"
+)
 
 type Method struct {
 	member
@@ -18,3 +24,125 @@ type member struct {
 func (m member) Name() string {
 	return m.cf.ConstantPool[m.info.NameIndex].(*classfile.ConstantUtf8Info).Value
 }
+
+func (m member) Descriptor() string {
+	return m.cf.ConstantPool[m.info.DescriptorIndex].(*classfile.ConstantUtf8Info).Value
+}
+
+func (m member) AccessFlags() AccessFlags {
+	return AccessFlags(m.info.AccessFlags)
+}
+
+func (m member) IsStatic() bool {
+	return m.AccessFlags()&AccStatic != 0
+}
+
+func (m member) IsPublic() bool {
+	return m.AccessFlags()&AccPublic != 0
+}
+
+func (m member) IsPrivate() bool {
+	return m.AccessFlags()&AccPrivate != 0
+}
+
+func (m member) IsProtected() bool {
+	return m.AccessFlags()&AccProtected != 0
+}
+
+func (m member) IsAbstract() bool {
+	return m.AccessFlags()&AccAbstract != 0
+}
+
+func (m member) IsFinal() bool {
+	return m.AccessFlags()&AccFinal != 0
+}
+
+// IsSynthetic returns whether the compiler generated the member, such as a bridge method.
+func (m member) IsSynthetic() bool {
+	return m.AccessFlags()&AccSynthetic != 0
+}
+
+// Signature returns the generic signature of the member, or an empty string if it has none.
+func (m member) Signature() string {
+	return Class{m.cf}.signature(m.info.AttributeTable)
+}
+
+// Exceptions returns the exceptions that the method declares to throw.
+func (m Method) Exceptions() []string {
+	c := Class{m.cf}
+	var exceptions []string
+	for _, attr := range m.info.AttributeTable.Attributes() {
+		if attr, ok := attr.(*classfile.ExceptionsAttribute); ok {
+			for _, index := range attr.ExceptionIndexTable {
+				exceptions = append(exceptions, c.className(index))
+			}
+		}
+	}
+	return exceptions
+}
+
+// ConstantValue returns the value of a constant field formatted as a Java literal, such as 42,
+// 10L, 1.5f, 'a', true or "text", and whether the field is a constant.
+func (f Field) ConstantValue() (string, bool) {
+	for _, attr := range f.info.AttributeTable.Attributes() {
+		attr, ok := attr.(*classfile.ConstantValueAttribute)
+		if !ok {
+			continue
+		}
+		switch value := f.cf.ConstantPool[attr.ConstantValueIndex].(type) {
+		case *classfile.ConstantIntegerInfo:
+			switch f.Descriptor() {
+			case "Z":
+				return strconv.FormatBool(value.Value != 0), true
+			case "C":
+				return strconv.QuoteRune(rune(value.Value)), true
+			}
+			return strconv.Itoa(int(value.Value)), true
+		case *classfile.ConstantLongInfo:
+			return strconv.FormatInt(value.Value, 10) + "L", true
+		case *classfile.ConstantFloatInfo:
+			return formatFloat(float64(value.Value), 32) + "f", true
+		case *classfile.ConstantDoubleInfo:
+			return formatFloat(value.Value, 64), true
+		case *classfile.ConstantStringInfo:
+			return strconv.Quote(Class{f.cf}.utf8(value.StringIndex)), true
+		}
+	}
+	return "", false
+}
+
+func formatFloat(value float64, bits int) string {
+	switch {
+	case math.IsNaN(value):
+		return "NaN"
+	case math.IsInf(value, 1):
+		return "Infinity"
+	case math.IsInf(value, -1):
+		return "-Infinity"
+	}
+	s := strconv.FormatFloat(value, 'g', -1, bits)
+	if !strings.ContainsAny(s, ".e") {
+		s += ".0"
+	}
+	return s
+}
+
+// Field returns the field with the given name and descriptor, if the class declares it.
+func (c Class) Field(name, descriptor string) (Field, bool) {
+	for _, f := range c.Fields() {
+		if f.Name() == name && f.Descriptor() == descriptor {
+			return f, true
+		}
+	}
+	return Field{}, false
+}
+
+// Method returns the method with the given name and descriptor, if the class declares it.
+func (c Class) Method(name, descriptor string) (Method, bool) {
+	for _, m := range c.Methods() {
+		if m.Name() == name && m.Descriptor() == descriptor {
+			return m, true
+		}
+	}
+	return Method{}, false
+}
@@ -0,0 +1,55 @@
+package class
+
+import "github.com/tsatke/jt/classfile"
+
+// Module is the module declaration of a module-info class.
+type Module struct {
+	Name     string
+	Requires []string
+	// Exports holds the exported packages, such as java/util.
+	Exports  []string
+	Uses     []string
+	Provides []Provides
+}
+
+// Provides is a provides directive, which declares the providers of a service.
+type Provides struct {
+	Service   string
+	Providers []string
+}
+
+// Module returns the module declaration, if this is a module-info class, or nil otherwise.
+func (c Class) Module() *Module {
+	for _, attr := range c.cf.AttributeTable.Attributes() {
+		moduleAttr, ok := attr.(*classfile.ModuleAttribute)
+		if !ok {
+			continue
+		}
+
+		m := &Module{
+			Name: c.moduleName(moduleAttr.NameIndex),
+		}
+		for _, requires := range moduleAttr.Requires {
+			m.Requires = append(m.Requires, c.moduleName(requires.RequiresIndex))
+		}
+		for _, exports := range moduleAttr.Exports {
+			m.Exports = append(m.Exports, c.utf8(c.cf.ConstantPool[exports.Index].(*classfile.ConstantPackageInfo).NameIndex))
+		}
+		for _, index := range moduleAttr.UsesIndex {
+			m.Uses = append(m.Uses, c.className(index))
+		}
+		for _, provides := range moduleAttr.Provides {
+			p := Provides{Service: c.className(provides.ProvidesIndex)}
+			for _, index := range provides.ProvidesWithIndex {
+				p.Providers = append(p.Providers, c.className(index))
+			}
+			m.Provides = append(m.Provides, p)
+		}
+		return m
+	}
+	return nil
+}
+
+func (c Class) moduleName(index uint16) string {
+	return c.utf8(c.cf.ConstantPool[index].(*classfile.ConstantModuleInfo).NameIndex)
+}
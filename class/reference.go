@@ -0,0 +1,151 @@
+package class
+
+import (
+	"github.com/tsatke/jt/classfile"
+)
+
+// RefKind is the kind of a reference in the constant pool.
+type RefKind uint8
+
+const (
+	RefClass RefKind = iota
+	RefField
+	RefMethod
+	RefInterfaceMethod
+)
+
+func (k RefKind) String() string {
+	switch k {
+	case RefClass:
+		return "class"
+	case RefField:
+		return "field"
+	case RefMethod:
+		return "method"
+	case RefInterfaceMethod:
+		return "interface-method"
+	}
+	return "unknown"
+}
+
+// Reference is a class, field or method that is referenced in the constant pool of a class.
+type Reference struct {
+	Kind RefKind
+	// Class is the referenced class, or the class that a field or method is referenced in,
+	// which may be a subclass of the class that declares it. Arrays are referenced with their
+	// descriptor, such as [Ljava/lang/String;.
+	Class string
+	// Name and Descriptor are the name and descriptor of a field or method.
+	Name       string
+	Descriptor string
+}
+
+// String returns the name of a class, or the class and name of a member followed by its
+// descriptor, such as java/io/PrintStream#println(Ljava/lang/String;)V or java/lang/System#out:Ljava/io/PrintStream;.
+func (r Reference) String() string {
+	switch r.Kind {
+	case RefClass:
+		return r.Class
+	case RefField:
+		return r.Class + "#" + r.Name + ":" + r.Descriptor
+	}
+	return r.Class + "#" + r.Name + r.Descriptor
+}
+
+// References returns the classes, fields and methods that are referenced in the constant pool,
+// in the order of the pool. The class itself and its superclass are referenced as well.
+func (c Class) References() []Reference {
+	var references []Reference
+	for i, info := range c.cf.ConstantPool {
+		// method handles reference members that are in the pool anyway
+		if _, ok := info.(*classfile.ConstantMethodHandleInfo); ok {
+			continue
+		}
+		if ref := c.reference(uint16(i)); ref != nil {
+			references = append(references, *ref)
+		}
+	}
+	return references
+}
+
+// reference returns the class, field or method at the given index of the constant pool, or
+// that a method handle at the index references. It returns nil if the entry is something else.
+func (c Class) reference(index uint16) *Reference {
+	if int(index) >= len(c.cf.ConstantPool) {
+		return nil
+	}
+	var kind RefKind
+	var member classfile.ConstantInfo
+	switch info := c.cf.ConstantPool[index].(type) {
+	case *classfile.ConstantClassInfo:
+		return &Reference{Kind: RefClass, Class: c.utf8(info.NameIndex)}
+	case *classfile.ConstantMethodHandleInfo:
+		return c.reference(info.ReferenceIndex)
+	case *classfile.ConstantFieldrefInfo:
+		kind, member = RefField, info
+	case *classfile.ConstantMethodrefInfo:
+		kind, member = RefMethod, info
+	case *classfile.ConstantInterfaceMethodrefInfo:
+		kind, member = RefInterfaceMethod, info
+	default:
+		return nil
+	}
+
+	var classIndex, natIndex uint16
+	switch info := member.(type) {
+	case *classfile.ConstantFieldrefInfo:
+		classIndex, natIndex = info.ClassIndex, info.NameAndTypeIndex
+	case *classfile.ConstantMethodrefInfo:
+		classIndex, natIndex = info.ClassIndex, info.NameAndTypeIndex
+	case *classfile.ConstantInterfaceMethodrefInfo:
+		classIndex, natIndex = info.ClassIndex, info.NameAndTypeIndex
+	}
+	nat := c.cf.ConstantPool[natIndex].(*classfile.ConstantNameAndTypeInfo)
+	return &Reference{
+		Kind:       kind,
+		Class:      c.className(classIndex),
+		Name:       c.utf8(nat.NameIndex),
+		Descriptor: c.utf8(nat.DescriptorIndex),
+	}
+}
+
+// Descriptors returns the descriptors that are referenced in the constant pool, which are the
+// descriptors of fields and methods that are referenced or called by invokedynamic, and method types.
+func (c Class) Descriptors() []string {
+	var descriptors []string
+	for _, info := range c.cf.ConstantPool {
+		switch info := info.(type) {
+		case *classfile.ConstantNameAndTypeInfo:
+			descriptors = append(descriptors, c.utf8(info.DescriptorIndex))
+		case *classfile.ConstantMethodTypeInfo:
+			descriptors = append(descriptors, c.utf8(info.DescriptorIndex))
+		}
+	}
+	return descriptors
+}
+
+// Strings returns the values of the string constants in the constant pool, such as the class
+// names that code passes to Class.forName.
+func (c Class) Strings() []string {
+	var values []string
+	for _, info := range c.cf.ConstantPool {
+		if info, ok := info.(*classfile.ConstantStringInfo); ok {
+			values = append(values, c.utf8(info.StringIndex))
+		}
+	}
+	return values
+}
+
+// Signature returns the generic signature of the class, or an empty string if it has none.
+func (c Class) Signature() string {
+	return c.signature(c.cf.AttributeTable)
+}
+
+func (c Class) signature(table *classfile.AttributeTable) string {
+	for _, attr := range table.Attributes() {
+		if signature, ok := attr.(*classfile.SignatureAttribute); ok {
+			return c.utf8(signature.SignatureIndex)
+		}
+	}
+	return ""
+}
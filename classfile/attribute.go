@@ -6,6 +6,11 @@ type AttributeTable struct {
 
 type Attribute interface{} // interface to make it possible to add attributes externally in the future
 
+// Attributes returns all attributes in the table.
+func (t *AttributeTable) Attributes() []Attribute {
+	return t.attributes
+}
+
 type UnknownAttribute struct {
 	Name    string
 	Length  uint32
@@ -21,6 +26,30 @@ type CodeAttribute struct {
 	Attributes     *AttributeTable
 }
 
+// Instructions decodes the bytecode of the method.
+func (c *CodeAttribute) Instructions() ([]Instruction, error) {
+	return DecodeInstructions(c.Code)
+}
+
+// Line returns the source line of the instruction at the given offset in the code,
+// or 0 if the code has no line numbers.
+func (c *CodeAttribute) Line(pc int) int {
+	line, start := 0, -1
+	for _, attr := range c.Attributes.Attributes() {
+		table, ok := attr.(LineNumberTableAttribute)
+		if !ok {
+			continue
+		}
+		// the entries are not necessarily sorted
+		for _, entry := range table {
+			if int(entry.StartPc) <= pc && int(entry.StartPc) > start {
+				line, start = int(entry.LineNumber), int(entry.StartPc)
+			}
+		}
+	}
+	return line
+}
+
 type ExceptionTable []ExceptionTableEntry
 
 type ExceptionTableEntry struct {
@@ -29,3 +58,125 @@ type ExceptionTableEntry struct {
 	HandlerPc uint16
 	CatchType uint16
 }
+
+// BootstrapMethodsAttribute holds the bootstrap methods of the invokedynamic instructions
+// and dynamic constants of a class.
+type BootstrapMethodsAttribute []BootstrapMethod
+
+// BootstrapMethod is a method handle with its static arguments, which reference the constant pool.
+type BootstrapMethod struct {
+	MethodRef uint16
+	Arguments []uint16
+}
+
+// LineNumberTableAttribute maps offsets in the code of a method to source lines.
+type LineNumberTableAttribute []LineNumber
+
+// LineNumber is the source line of the instructions from StartPc on.
+type LineNumber struct {
+	StartPc    uint16
+	LineNumber uint16
+}
+
+// ConstantValueAttribute holds the index of the constant pool entry with the value of a constant field.
+type ConstantValueAttribute struct {
+	ConstantValueIndex uint16
+}
+
+// SignatureAttribute holds the generic signature of a class, field or method.
+type SignatureAttribute struct {
+	SignatureIndex uint16
+}
+
+// ExceptionsAttribute holds the exceptions that a method declares to throw.
+type ExceptionsAttribute struct {
+	ExceptionIndexTable []uint16
+}
+
+// AnnotationsAttribute holds the annotations of a class, field or method. Visible annotations
+// are retained at runtime and can be read with reflection.
+type AnnotationsAttribute struct {
+	Visible     bool
+	Annotations []Annotation
+}
+
+// ParameterAnnotationsAttribute holds the annotations of the parameters of a method.
+type ParameterAnnotationsAttribute struct {
+	Visible    bool
+	Parameters [][]Annotation
+}
+
+// TypeAnnotationsAttribute holds the annotations on the uses of types in the declaration or
+// code of a class, field or method.
+type TypeAnnotationsAttribute struct {
+	Visible     bool
+	Annotations []TypeAnnotation
+}
+
+// AnnotationDefaultAttribute holds the default value of an element of an annotation type.
+type AnnotationDefaultAttribute struct {
+	Value ElementValue
+}
+
+// Annotation is an annotation with the values of its elements.
+type Annotation struct {
+	// TypeIndex references the field descriptor of the annotation type.
+	TypeIndex uint16
+	Elements  []ElementValuePair
+}
+
+// TypeAnnotation is an annotation on the use of a type. The target and the path to the
+// annotated type are not kept.
+type TypeAnnotation struct {
+	TargetType uint8
+	Annotation
+}
+
+type ElementValuePair struct {
+	NameIndex uint16
+	Value     ElementValue
+}
+
+// ElementValue is the value of an element of an annotation. Tag tells which fields are set:
+// ConstIndex for constants (B, C, D, F, I, J, S, Z and s) and classes (c), where it references
+// the return descriptor of the class, TypeNameIndex and ConstNameIndex for enum constants (e),
+// Annotation for nested annotations (@) and Values for arrays ([).
+type ElementValue struct {
+	Tag            byte
+	ConstIndex     uint16
+	TypeNameIndex  uint16
+	ConstNameIndex uint16
+	Annotation     *Annotation
+	Values         []ElementValue
+}
+
+// ModuleAttribute is the Module attribute of a module-info class, which describes
+// the module. All indices reference the constant pool.
+type ModuleAttribute struct {
+	NameIndex    uint16
+	Flags        uint16
+	VersionIndex uint16
+	Requires     []ModuleRequires
+	Exports      []ModuleExports
+	Opens        []ModuleExports
+	UsesIndex    []uint16
+	Provides     []ModuleProvides
+}
+
+type ModuleRequires struct {
+	RequiresIndex        uint16
+	RequiresFlags        uint16
+	RequiresVersionIndex uint16
+}
+
+// ModuleExports is an exports or opens directive of a module.
+type ModuleExports struct {
+	Index   uint16
+	Flags   uint16
+	ToIndex []uint16
+}
+
+type ModuleProvides struct {
+	ProvidesIndex     uint16
+	ProvidesWithIndex []uint16
+}
@@ -0,0 +1,193 @@
+package classfile
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// Opcode is the opcode of a JVM instruction.
+type Opcode uint8
+
+// opcodes of the instructions that reference the constant pool
+const (
+	OpLdc             Opcode = 0x12
+	OpLdcW            Opcode = 0x13
+	OpLdc2W           Opcode = 0x14
+	OpGetstatic       Opcode = 0xb2
+	OpPutstatic       Opcode = 0xb3
+	OpGetfield        Opcode = 0xb4
+	OpPutfield        Opcode = 0xb5
+	OpInvokevirtual   Opcode = 0xb6
+	OpInvokespecial   Opcode = 0xb7
+	OpInvokestatic    Opcode = 0xb8
+	OpInvokeinterface Opcode = 0xb9
+	OpInvokedynamic   Opcode = 0xba
+	OpNew             Opcode = 0xbb
+	OpAnewarray       Opcode = 0xbd
+	OpCheckcast       Opcode = 0xc0
+	OpInstanceof      Opcode = 0xc1
+	OpMultianewarray  Opcode = 0xc5
+)
+
+// other opcodes that need special handling when decoding
+const (
+	opTableswitch  Opcode = 0xaa
+	opLookupswitch Opcode = 0xab
+	opWide         Opcode = 0xc4
+	opIinc         Opcode = 0x84
+)
+
+var opcodeNames = map[Opcode]string{
+	OpLdc:             "ldc",
+	OpLdcW:            "ldc_w",
+	OpLdc2W:           "ldc2_w",
+	OpGetstatic:       "getstatic",
+	OpPutstatic:       "putstatic",
+	OpGetfield:        "getfield",
+	OpPutfield:        "putfield",
+	OpInvokevirtual:   "invokevirtual",
+	OpInvokespecial:   "invokespecial",
+	OpInvokestatic:    "invokestatic",
+	OpInvokeinterface: "invokeinterface",
+	OpInvokedynamic:   "invokedynamic",
+	OpNew:             "new",
+	OpAnewarray:       "anewarray",
+	OpCheckcast:       "checkcast",
+	OpInstanceof:      "instanceof",
+	OpMultianewarray:  "multianewarray",
+}
+
+// String returns the mnemonic of the instructions that reference the constant pool,
+// and the hexadecimal opcode of all others.
+func (o Opcode) String() string {
+	if name, ok := opcodeNames[o]; ok {
+		return name
+	}
+	return fmt.Sprintf("0x%02x", uint8(o))
+}
+
+// IsInvoke returns whether the opcode invokes a method, except for invokedynamic,
+// which references a call site instead.
+func (o Opcode) IsInvoke() bool {
+	return o >= OpInvokevirtual && o <= OpInvokeinterface
+}
+
+// operandSizes holds the number of operand bytes of every instruction with a fixed size,
+// and -1 for undefined opcodes and instructions with a variable size.
+var operandSizes [256]int
+
+func init() {
+	for i := range operandSizes {
+		operandSizes[i] = -1
+	}
+	set := func(from, to, size int) {
+		for op := from; op <= to; op++ {
+			operandSizes[op] = size
+		}
+	}
+	set(0x00, 0x0f, 0) // nop, constants
+	set(0x10, 0x10, 1) // bipush
+	set(0x11, 0x11, 2) // sipush
+	set(0x12, 0x12, 1) // ldc
+	set(0x13, 0x14, 2) // ldc_w, ldc2_w
+	set(0x15, 0x19, 1) // loads
+	set(0x1a, 0x35, 0) // loads with implicit index, array loads
+	set(0x36, 0x3a, 1) // stores
+	set(0x3b, 0x83, 0) // stores with implicit index, array stores, stack, arithmetic
+	set(0x84, 0x84, 2) // iinc
+	set(0x85, 0x98, 0) // conversions, comparisons
+	set(0x99, 0xa8, 2) // branches, goto, jsr
+	set(0xa9, 0xa9, 1) // ret
+	set(0xac, 0xb1, 0) // returns
+	set(0xb2, 0xb8, 2) // fields, invokevirtual, invokespecial, invokestatic
+	set(0xb9, 0xba, 4) // invokeinterface, invokedynamic
+	set(0xbb, 0xbb, 2) // new
+	set(0xbc, 0xbc, 1) // newarray
+	set(0xbd, 0xbd, 2) // anewarray
+	set(0xbe, 0xbf, 0) // arraylength, athrow
+	set(0xc0, 0xc1, 2) // checkcast, instanceof
+	set(0xc2, 0xc3, 0) // monitorenter, monitorexit
+	set(0xc5, 0xc5, 3) // multianewarray
+	set(0xc6, 0xc7, 2) // ifnull, ifnonnull
+	set(0xc8, 0xc9, 4) // goto_w, jsr_w
+	set(0xca, 0xca, 0) // breakpoint
+	set(0xfe, 0xff, 0) // impdep1, impdep2
+}
+
+// Instruction is a decoded JVM instruction.
+type Instruction struct {
+	// Offset is the offset of the instruction in the code of its method.
+	Offset   int
+	Opcode   Opcode
+	Operands []byte
+}
+
+// Index returns the index of the constant pool entry that the instruction references,
+// or 0 if the instruction doesn't reference the constant pool.
+func (i Instruction) Index() uint16 {
+	switch i.Opcode {
+	case OpLdc:
+		return uint16(i.Operands[0])
+	case OpLdcW, OpLdc2W, OpGetstatic, OpPutstatic, OpGetfield, OpPutfield,
+		OpInvokevirtual, OpInvokespecial, OpInvokestatic, OpInvokeinterface, OpInvokedynamic,
+		OpNew, OpAnewarray, OpCheckcast, OpInstanceof, OpMultianewarray:
+		return binary.BigEndian.Uint16(i.Operands)
+	}
+	return 0
+}
+
+// DecodeInstructions decodes the instructions of the given bytecode.
+func DecodeInstructions(code []byte) ([]Instruction, error) {
+	var instructions []Instruction
+	for pc := 0; pc < len(code); {
+		op := Opcode(code[pc])
+		size := operandSizes[op]
+		switch op {
+		case opTableswitch, opLookupswitch:
+			// the operands are aligned to 4 bytes from the start of the code
+			start := pc + 1 + (4-(pc+1)%4)%4
+			if start+12 > len(code) {
+				return nil, fmt.Errorf("truncated %s at offset %d", switchName(op), pc)
+			}
+			if op == opTableswitch {
+				low := int32(binary.BigEndian.Uint32(code[start+4:]))
+				high := int32(binary.BigEndian.Uint32(code[start+8:]))
+				if high < low {
+					return nil, fmt.Errorf("invalid tableswitch at offset %d", pc)
+				}
+				size = start + 12 + int(high-low+1)*4 - (pc + 1)
+			} else {
+				pairs := int32(binary.BigEndian.Uint32(code[start+4:]))
+				if pairs < 0 {
+					return nil, fmt.Errorf("invalid lookupswitch at offset %d", pc)
+				}
+				size = start + 8 + int(pairs)*8 - (pc + 1)
+			}
+		case opWide:
+			size = 3
+			if pc+1 < len(code) && Opcode(code[pc+1]) == opIinc {
+				size = 5
+			}
+		}
+		if size < 0 {
+			return nil, fmt.Errorf("invalid opcode 0x%02x at offset %d", uint8(op), pc)
+		}
+		if pc+1+size > len(code) {
+			return nil, fmt.Errorf("truncated instruction %s at offset %d", op, pc)
+		}
+		instructions = append(instructions, Instruction{
+			Offset:   pc,
+			Opcode:   op,
+			Operands: code[pc+1 : pc+1+size],
+		})
+		pc += 1 + size
+	}
+	return instructions, nil
+}
+
+func switchName(op Opcode) string {
+	if op == opTableswitch {
+		return "tableswitch"
+	}
+	return "lookupswitch"
+}
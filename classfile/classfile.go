@@ -44,7 +44,10 @@ const (
 	ConstantNameAndType                        = 12
 	ConstantMethodHandle                       = 15
 	ConstantMethodType                         = 16
+	ConstantDynamic                            = 17
 	ConstantInvokeDynamic                      = 18
+	ConstantModule                             = 19
+	ConstantPackage                            = 20
 )
 
 // constantInfoBase is shared by all constant info objects.
@@ -131,9 +134,25 @@ type (
 		DescriptorIndex uint16
 	}
 
+	ConstantDynamicInfo struct {
+		constantInfoBase
+		BootstrapMethodAttrIndex uint16
+		NameAndTypeIndex         uint16
+	}
+
 	ConstantInvokeDynamicInfo struct {
 		constantInfoBase
 		BootstrapMethodAttrIndex uint16
 		NameAndTypeIndex         uint16
 	}
+
+	ConstantModuleInfo struct {
+		constantInfoBase
+		NameIndex uint16
+	}
+
+	ConstantPackageInfo struct {
+		constantInfoBase
+		NameIndex uint16
+	}
 )
@@ -53,11 +53,10 @@ func (rd *contentReader) float64() float64 {
 func (rd *contentReader) raw(n uint) []byte {
 	b := make([]byte, n)
 
-	read, err := rd.Read(b)
-	if err == io.EOF {
-		if uint(read) != n {
-			panic(fmt.Errorf("want to read %d, but only read %d, then EOF", n, read))
-		}
+	// a single read may return less than n bytes, even if the reader is not at EOF
+	read, err := io.ReadFull(rd, b)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		panic(fmt.Errorf("want to read %d, but only read %d, then EOF", n, read))
 	} else if err != nil {
 		panic(err)
 	}
@@ -62,7 +62,11 @@ func parseConstantPool(rd *contentReader) ConstantPool {
 	pool := ConstantPool(make([]ConstantInfo, count))
 	for i := 1; i < count; i++ {
 		pool[i] = parseConstantInfo(rd, pool)
-		// TODO: maybe increment i if info was long or double?
+		// long and double constants take up two entries in the constant pool,
+		// the second one is unusable
+		if tag := pool[i].Tag(); tag == ConstantLong || tag == ConstantDouble {
+			i++
+		}
 	}
 	return pool
 }
@@ -143,12 +147,28 @@ func parseConstantInfo(rd *contentReader, pool ConstantPool) ConstantInfo {
 			constantInfoBase{tag},
 			rd.uint16(),
 		}
+	case ConstantDynamic:
+		return &ConstantDynamicInfo{
+			constantInfoBase{tag},
+			rd.uint16(),
+			rd.uint16(),
+		}
 	case ConstantInvokeDynamic:
 		return &ConstantInvokeDynamicInfo{
 			constantInfoBase{tag},
 			rd.uint16(),
 			rd.uint16(),
 		}
+	case ConstantModule:
+		return &ConstantModuleInfo{
+			constantInfoBase{tag},
+			rd.uint16(),
+		}
+	case ConstantPackage:
+		return &ConstantPackageInfo{
+			constantInfoBase{tag},
+			rd.uint16(),
+		}
 	}
 	panic(fmt.Errorf("unknown constant info tag: %v", tag))
 }
@@ -266,24 +286,43 @@ func parseAttribute(rd *contentReader, pool ConstantPool) Attribute {
 
 	switch attributeName {
 	case "BootstrapMethods":
+		return parseBootstrapMethods(rd)
 	case "Code":
 		return parseCodeAttribute(rd, pool)
 	case "ConstantValue":
+		return &ConstantValueAttribute{rd.uint16()}
 	case "Deprecated":
 	case "EnclosingMethod":
 	case "Exceptions":
+		return &ExceptionsAttribute{parseIndices(rd)}
 	case "InnerClasses":
 	case "LineNumberTable":
+		return parseLineNumberTable(rd)
 	case "LocalVariableTable":
 	case "LocalVariableTypeTable":
 	case "MethodParameters":
-	case "RuntimeInvisibleAnnotations":
-	case "RuntimeInvisibleParameterAnnotation":
-	case "RuntimeInvisibleHypeAnnotations":
-	case "RuntimeVisibleAnnotations":
-	case "RuntimeVisibleParameterAnnotations":
-	case "RuntimeVisibleTypeAnnotations":
+	case "Module":
+		return parseModuleAttribute(rd)
+	case "RuntimeInvisibleAnnotations", "RuntimeVisibleAnnotations":
+		return &AnnotationsAttribute{
+			Visible:     attributeName == "RuntimeVisibleAnnotations",
+			Annotations: parseAnnotations(rd),
+		}
+	case "RuntimeInvisibleParameterAnnotations", "RuntimeVisibleParameterAnnotations":
+		attr := &ParameterAnnotationsAttribute{
+			Visible:    attributeName == "RuntimeVisibleParameterAnnotations",
+			Parameters: make([][]Annotation, rd.uint8()),
+		}
+		for i := range attr.Parameters {
+			attr.Parameters[i] = parseAnnotations(rd)
+		}
+		return attr
+	case "RuntimeInvisibleTypeAnnotations", "RuntimeVisibleTypeAnnotations":
+		return parseTypeAnnotations(rd, attributeName == "RuntimeVisibleTypeAnnotations")
+	case "AnnotationDefault":
+		return &AnnotationDefaultAttribute{parseElementValue(rd)}
 	case "Signature":
+		return &SignatureAttribute{rd.uint16()}
 	case "SourceFile":
 	case "SourceDebugExtension":
 	case "StackMapTable":
@@ -297,6 +336,156 @@ func parseAttribute(rd *contentReader, pool ConstantPool) Attribute {
 	}
 }
 
+func parseBootstrapMethods(rd *contentReader) BootstrapMethodsAttribute {
+	methods := make(BootstrapMethodsAttribute, rd.uint16())
+	for i := range methods {
+		methods[i] = BootstrapMethod{
+			MethodRef: rd.uint16(),
+			Arguments: parseIndices(rd),
+		}
+	}
+	return methods
+}
+
+func parseAnnotations(rd *contentReader) []Annotation {
+	annotations := make([]Annotation, rd.uint16())
+	for i := range annotations {
+		annotations[i] = parseAnnotation(rd)
+	}
+	return annotations
+}
+
+func parseAnnotation(rd *contentReader) Annotation {
+	annotation := Annotation{
+		TypeIndex: rd.uint16(),
+		Elements:  make([]ElementValuePair, rd.uint16()),
+	}
+	for i := range annotation.Elements {
+		annotation.Elements[i] = ElementValuePair{
+			NameIndex: rd.uint16(),
+			Value:     parseElementValue(rd),
+		}
+	}
+	return annotation
+}
+
+func parseElementValue(rd *contentReader) ElementValue {
+	value := ElementValue{Tag: rd.uint8()}
+	switch value.Tag {
+	case 'B', 'C', 'D', 'F', 'I', 'J', 'S', 'Z', 's', 'c':
+		value.ConstIndex = rd.uint16()
+	case 'e':
+		value.TypeNameIndex = rd.uint16()
+		value.ConstNameIndex = rd.uint16()
+	case '@':
+		annotation := parseAnnotation(rd)
+		value.Annotation = &annotation
+	case '[':
+		value.Values = make([]ElementValue, rd.uint16())
+		for i := range value.Values {
+			value.Values[i] = parseElementValue(rd)
+		}
+	default:
+		panic(fmt.Errorf("unknown element value tag %q", value.Tag))
+	}
+	return value
+}
+
+// parseTypeAnnotations parses type annotations, skipping their targets and type paths,
+// whose size depends on the target type, see JVMS 4.7.20.
+func parseTypeAnnotations(rd *contentReader, visible bool) *TypeAnnotationsAttribute {
+	attr := &TypeAnnotationsAttribute{
+		Visible:     visible,
+		Annotations: make([]TypeAnnotation, rd.uint16()),
+	}
+	for i := range attr.Annotations {
+		targetType := rd.uint8()
+		switch {
+		case targetType == 0x00 || targetType == 0x01 || targetType == 0x16:
+			// type parameter, formal parameter
+			rd.raw(1)
+		case (targetType >= 0x10 && targetType <= 0x12) || targetType == 0x17 || (targetType >= 0x42 && targetType <= 0x46):
+			// supertype, type parameter bound, throws, catch, offset
+			rd.raw(2)
+		case targetType >= 0x13 && targetType <= 0x15:
+			// field, return or receiver type without target info
+		case targetType == 0x40 || targetType == 0x41:
+			// local variable table
+			rd.raw(uint(rd.uint16()) * 6)
+		case targetType >= 0x47 && targetType <= 0x4b:
+			// type argument
+			rd.raw(3)
+		default:
+			panic(fmt.Errorf("unknown type annotation target 0x%02x", targetType))
+		}
+		rd.raw(uint(rd.uint8()) * 2) // type path
+		attr.Annotations[i] = TypeAnnotation{
+			TargetType: targetType,
+			Annotation: parseAnnotation(rd),
+		}
+	}
+	return attr
+}
+
+func parseLineNumberTable(rd *contentReader) LineNumberTableAttribute {
+	table := make(LineNumberTableAttribute, rd.uint16())
+	for i := range table {
+		table[i] = LineNumber{
+			StartPc:    rd.uint16(),
+			LineNumber: rd.uint16(),
+		}
+	}
+	return table
+}
+
+func parseModuleAttribute(rd *contentReader) *ModuleAttribute {
+	attr := &ModuleAttribute{
+		NameIndex:    rd.uint16(),
+		Flags:        rd.uint16(),
+		VersionIndex: rd.uint16(),
+	}
+	attr.Requires = make([]ModuleRequires, rd.uint16())
+	for i := range attr.Requires {
+		attr.Requires[i] = ModuleRequires{
+			RequiresIndex:        rd.uint16(),
+			RequiresFlags:        rd.uint16(),
+			RequiresVersionIndex: rd.uint16(),
+		}
+	}
+	attr.Exports = parseModuleExports(rd)
+	attr.Opens = parseModuleExports(rd)
+	attr.UsesIndex = parseIndices(rd)
+	attr.Provides = make([]ModuleProvides, rd.uint16())
+	for i := range attr.Provides {
+		attr.Provides[i] = ModuleProvides{
+			ProvidesIndex:     rd.uint16(),
+			ProvidesWithIndex: parseIndices(rd),
+		}
+	}
+	return attr
+}
+
+func parseModuleExports(rd *contentReader) []ModuleExports {
+	exports := make([]ModuleExports, rd.uint16())
+	for i := range exports {
+		exports[i] = ModuleExports{
+			Index:   rd.uint16(),
+			Flags:   rd.uint16(),
+			ToIndex: parseIndices(rd),
+		}
+	}
+	return exports
+}
+
+// parseIndices parses a list of constant pool indices, prefixed by its length.
+func parseIndices(rd *contentReader) []uint16 {
+	indices := make([]uint16, rd.uint16())
+	for i := range indices {
+		indices[i] = rd.uint16()
+	}
+	return indices
+}
+
 func parseCodeAttribute(rd *contentReader, pool ConstantPool) *CodeAttribute {
 	maxStack := rd.uint16()
 	maxLocals := rd.uint16()
@@ -0,0 +1,114 @@
+package classfile
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+func TestParseSuite(t *testing.T) {
+	suite.Run(t, new(ParseSuite))
+}
+
+type ParseSuite struct {
+	suite.Suite
+}
+
+// classBuilder assembles a class file for tests.
+type classBuilder struct {
+	bytes.Buffer
+}
+
+func (b *classBuilder) u1(v uint8)  { b.WriteByte(v) }
+func (b *classBuilder) u2(v uint16) { _ = binary.Write(b, binary.BigEndian, v) }
+func (b *classBuilder) u4(v uint32) { _ = binary.Write(b, binary.BigEndian, v) }
+func (b *classBuilder) u8(v uint64) { _ = binary.Write(b, binary.BigEndian, v) }
+func (b *classBuilder) utf8(s string) {
+	b.u1(ConstantUtf8)
+	b.u2(uint16(len(s)))
+	b.WriteString(s)
+}
+
+func (suite *ParseSuite) TestParseWideConstants() {
+	b := &classBuilder{}
+	b.u4(0xCAFEBABE)
+	b.u2(0)                    // minor
+	b.u2(61)                   // major
+	b.u2(11)                   // constant pool count
+	b.utf8("Foo")              // #1
+	b.u1(ConstantClass)        // #2
+	b.u2(1)                    //
+	b.utf8("java/lang/Object") // #3
+	b.u1(ConstantClass)        // #4
+	b.u2(3)                    //
+	b.u1(ConstantLong)         // #5 and #6
+	b.u8(42)                   //
+	b.u1(ConstantDouble)       // #7 and #8
+	b.u8(0x400921FB54442D18)   // pi
+	b.utf8("x")                // #9
+	b.u1(ConstantPackage)      // #10
+	b.u2(9)                    //
+	b.u2(0x0021)               // access flags
+	b.u2(2)                    // this class
+	b.u2(4)                    // super class
+	b.u2(0)                    // interfaces
+	b.u2(0)                    // fields
+	b.u2(0)                    // methods
+	b.u2(0)                    // attributes
+
+	cf, err := Parse(bytes.NewReader(b.Bytes()))
+	suite.Require().NoError(err)
+
+	suite.Len(cf.ConstantPool, 11)
+	suite.Equal(int64(42), cf.ConstantPool[5].(*ConstantLongInfo).Value)
+	suite.Nil(cf.ConstantPool[6])
+	suite.InDelta(3.14159, cf.ConstantPool[7].(*ConstantDoubleInfo).Value, 0.0001)
+	suite.Nil(cf.ConstantPool[8])
+	suite.Equal("x", cf.ConstantPool[9].(*ConstantUtf8Info).Value)
+	suite.Equal(uint16(9), cf.ConstantPool[10].(*ConstantPackageInfo).NameIndex)
+	suite.Equal(uint16(2), cf.ThisClass)
+	suite.Equal(uint16(4), cf.SuperClass)
+}
+
+func (suite *ParseSuite) TestParseTruncated() {
+	_, err := Parse(bytes.NewReader([]byte{0xCA, 0xFE, 0xBA, 0xBE, 0x00}))
+	suite.Error(err)
+}
+
+func (suite *ParseSuite) TestDecodeInstructions() {
+	code := []byte{
+		0x2a,             // 0: aload_0
+		0xb6, 0x00, 0x07, // 1: invokevirtual #7
+		0xaa,             // 4: tableswitch, padded to offset 8
+		0x00, 0x00, 0x00, //
+		0x00, 0x00, 0x00, 0x10, // default
+		0x00, 0x00, 0x00, 0x01, // low
+		0x00, 0x00, 0x00, 0x02, // high
+		0x00, 0x00, 0x00, 0x10, // 1
+		0x00, 0x00, 0x00, 0x10, // 2
+		0xc4, 0x84, 0x01, 0x00, 0x00, 0x01, // 28: wide iinc
+		0xc4, 0x15, 0x01, 0x00, // 34: wide iload
+		0x12, 0x03, // 38: ldc #3
+		0xb1, // 40: return
+	}
+	instructions, err := DecodeInstructions(code)
+	suite.Require().NoError(err)
+
+	var offsets []int
+	for _, i := range instructions {
+		offsets = append(offsets, i.Offset)
+	}
+	suite.Equal([]int{0, 1, 4, 28, 34, 38, 40}, offsets)
+	suite.Equal(OpInvokevirtual, instructions[1].Opcode)
+	suite.Equal(uint16(7), instructions[1].Index())
+	suite.Equal("invokevirtual", instructions[1].Opcode.String())
+	suite.Equal(uint16(3), instructions[5].Index())
+	suite.Equal(uint16(0), instructions[6].Index())
+
+	_, err = DecodeInstructions([]byte{0xb6, 0x00})
+	suite.Error(err)
+	_, err = DecodeInstructions([]byte{0xe0})
+	suite.Error(err)
+}
@@ -0,0 +1,211 @@
+package classpath
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/tsatke/jt/class"
+	"github.com/tsatke/jt/jar"
+	"github.com/tsatke/jt/jimage"
+)
+
+// Archive is a container of compiled classes, such as a jar file, a jmod file,
+// a runtime image or a directory with .class files.
+type Archive interface {
+	io.Closer
+	// ListClasses returns the names of all classes in the archive, such as java/lang/Object.
+	ListClasses() []string
+	// OpenClass opens the class with the given name, such as java/lang/Object.
+	OpenClass(name string) (*class.Class, error)
+	// ListResources returns the names of all resources in the archive that are not classes,
+	// such as META-INF/MANIFEST.MF.
+	ListResources() []string
+	// OpenResource opens the resource with the given name.
+	OpenResource(name string) (io.ReadCloser, error)
+	// StatResource returns information about the resource with the given name.
+	StatResource(name string) (fs.FileInfo, error)
+}
+
+var (
+	_ Archive = (*jar.File)(nil)
+	_ Archive = (*jimage.File)(nil)
+	_ Archive = (*directory)(nil)
+)
+
+// OpenArchive opens the archive that the given entry references.
+// Source entries don't contain classes and can't be opened.
+func OpenArchive(entry *Entry) (Archive, error) {
+	switch entry.Type {
+	case EntryTypeJar:
+		f, err := jar.Open(entry.Path)
+		if err != nil {
+			return nil, fmt.Errorf("open jar file: %w", err)
+		}
+		return f, nil
+	case EntryTypeJmod:
+		f, err := jar.OpenJmod(entry.Path)
+		if err != nil {
+			return nil, fmt.Errorf("open jmod file: %w", err)
+		}
+		return f, nil
+	case EntryTypeJImage:
+		f, err := jimage.Open(entry.Path)
+		if err != nil {
+			return nil, fmt.Errorf("open jimage file: %w", err)
+		}
+		return f, nil
+	case EntryTypeOutput:
+		return &directory{path: entry.Path}, nil
+	}
+	return nil, fmt.Errorf("entry %s of type %s does not contain classes", entry.Path, entry.Type)
+}
+
+// directory is a folder that contains .class files, such as the output folder of a project.
+type directory struct {
+	path string
+}
+
+func (d *directory) ListClasses() []string {
+	res := make([]string, 0)
+	_ = filepath.WalkDir(d.path, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			// the output folder doesn't exist if the project has not been compiled
+			return nil
+		}
+		if entry.IsDir() || filepath.Ext(path) != ".class" || entry.Name() == "module-info.class" {
+			return nil
+		}
+		rel, err := filepath.Rel(d.path, path)
+		if err != nil {
+			return nil
+		}
+		res = append(res, strings.TrimSuffix(filepath.ToSlash(rel), ".class"))
+		return nil
+	})
+	return res
+}
+
+func (d *directory) OpenClass(name string) (*class.Class, error) {
+	f, err := os.Open(filepath.Join(d.path, filepath.FromSlash(name)+".class"))
+	if err != nil {
+		return nil, fmt.Errorf("open: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	class, err := class.ParseClass(bufio.NewReader(f))
+	if err != nil {
+		return nil, fmt.Errorf("parse class: %w", err)
+	}
+	return class, nil
+}
+
+func (d *directory) ListResources() []string {
+	res := make([]string, 0)
+	_ = filepath.WalkDir(d.path, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || filepath.Ext(path) == ".class" {
+			return nil
+		}
+		rel, err := filepath.Rel(d.path, path)
+		if err != nil {
+			return nil
+		}
+		res = append(res, filepath.ToSlash(rel))
+		return nil
+	})
+	return res
+}
+
+func (d *directory) OpenResource(name string) (io.ReadCloser, error) {
+	if _, err := d.StatResource(name); err != nil {
+		return nil, err
+	}
+	f, err := os.Open(filepath.Join(d.path, filepath.FromSlash(name)))
+	if err != nil {
+		return nil, fmt.Errorf("open: %w", err)
+	}
+	return f, nil
+}
+
+func (d *directory) StatResource(name string) (fs.FileInfo, error) {
+	info, err := os.Stat(filepath.Join(d.path, filepath.FromSlash(name)))
+	if err != nil {
+		return nil, fmt.Errorf("stat: %w", err)
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("stat %s: is a directory: %w", name, fs.ErrNotExist)
+	}
+	return info, nil
+}
+
+func (d *directory) Close() error {
+	return nil
+}
+
+// NestedEntries returns entries for all archives that are nested in the archive of the given
+// entry, such as the libraries of a Spring Boot jar or a web application. Nested archives are
+// searched recursively, and their paths address them within the archive, as in
+// app.jar!/BOOT-INF/lib/foo.jar.
+func NestedEntries(entry *Entry) ([]*Entry, error) {
+	if entry.Type != EntryTypeJar {
+		return nil, nil
+	}
+
+	f, err := jar.Open(entry.Path)
+	if err != nil {
+		return nil, fmt.Errorf("open jar file: %w", err)
+	}
+	names := f.NestedArchives()
+	_ = f.Close()
+
+	var entries []*Entry
+	for _, name := range names {
+		nested := &Entry{Type: EntryTypeJar, Path: entry.Path + jar.NestedSeparator + name}
+		entries = append(entries, nested)
+
+		nestedEntries, err := NestedEntries(nested)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, nestedEntries...)
+	}
+	return entries, nil
+}
+
+// WalkArchives opens the archive of every entry that contains compiled classes, as well as all
+// archives that are nested in them, like the libraries of fat jars, and calls fn for each of them.
+// Entries without classes, such as source entries, and entries for which skip returns true are
+// not opened. Archives that can't be opened are logged and skipped. The archive is closed when
+// fn returns.
+func (cp *Classpath) WalkArchives(skip func(*Entry) bool, fn func(*Entry, Archive)) {
+	for _, entry := range cp.Entries {
+		if !entry.Type.HasClasses() || (skip != nil && skip(entry)) {
+			continue
+		}
+
+		nested, err := NestedEntries(entry)
+		if err != nil {
+			log.Error().
+				Err(err).
+				Str("entry", entry.Path).
+				Msg("list nested archives")
+		}
+		for _, e := range append([]*Entry{entry}, nested...) {
+			archive, err := cp.OpenArchive(e)
+			if err != nil {
+				log.Error().
+					Err(err).
+					Str("entry", e.Path).
+					Msg("open archive")
+				continue
+			}
+			fn(e, archive)
+			_ = archive.Close()
+		}
+	}
+}
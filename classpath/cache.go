@@ -1,4 +1,4 @@
-package jar
+package classpath
 
 import (
 	"io"
@@ -6,6 +6,8 @@ import (
 	lru "github.com/hashicorp/golang-lru"
 )
 
+// Cache keeps recently used archives open, so that opening many classes
+// from the same archive doesn't reopen it every time.
 type Cache struct {
 	lru *lru.Cache
 }
@@ -22,16 +24,16 @@ func NewCache(size int) (*Cache, error) {
 	}, nil
 }
 
-func (c *Cache) Add(k string, f *File) {
-	c.lru.Add(k, f)
+func (c *Cache) Add(k string, a Archive) {
+	c.lru.Add(k, a)
 }
 
-func (c *Cache) Get(k string) (*File, bool) {
+func (c *Cache) Get(k string) (Archive, bool) {
 	v, ok := c.lru.Get(k)
 	if !ok {
 		return nil, false
 	}
-	return v.(*File), true
+	return v.(Archive), true
 }
 
 func (c *Cache) Close() error {
@@ -15,6 +15,9 @@ import (
 
 type Classpath struct {
 	Entries []*Entry
+	// Release is the Java feature release, such as 11 or 17, for which classes
+	// of multi-release jars are resolved. With 0, only base versions are used.
+	Release int
 
 	// classesWithLocation acts as a cache and holds fully qualified class names
 	// such as java/lang/Object together with the entry in which they have been found.
@@ -28,6 +31,11 @@ type Classpath struct {
 type Entry struct {
 	Type EntryType
 	Path string
+
+	// Inclusions and Exclusions are patterns that restrict which files
+	// below Path belong to this entry, see Includes.
+	Inclusions []string
+	Exclusions []string
 }
 
 type EntryType uint8
@@ -43,8 +51,55 @@ const (
 	// EntryTypeOutput is used for entries that reference a folder containing
 	// compiled sources. These compiled sources usually are .class files.
 	EntryTypeOutput
+	// EntryTypeJImage is used for entries that reference a runtime image
+	// of Java 9 or later, usually lib/modules in the JDK.
+	EntryTypeJImage
+	// EntryTypeJmod is used for entries that reference a jmod file, as found
+	// in the jmods folder of a JDK.
+	EntryTypeJmod
 )
 
+func (t EntryType) String() string {
+	switch t {
+	case EntryTypeJar:
+		return "jar"
+	case EntryTypeSource:
+		return "source"
+	case EntryTypeOutput:
+		return "output"
+	case EntryTypeJImage:
+		return "jimage"
+	case EntryTypeJmod:
+		return "jmod"
+	}
+	return "unknown"
+}
+
+// HasClasses returns whether entries of this type contain compiled classes and can be opened
+// as an archive, see OpenArchive.
+func (t EntryType) HasClasses() bool {
+	switch t {
+	case EntryTypeJar, EntryTypeOutput, EntryTypeJImage, EntryTypeJmod:
+		return true
+	}
+	return false
+}
+
+// EntryTypeOf guesses the type of the entry with the given path from its name.
+// Paths that are not recognized as an archive are considered to be output folders.
+func EntryTypeOf(path string) EntryType {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".jar", ".zip", ".war", ".ear", ".aar":
+		return EntryTypeJar
+	case ".jmod":
+		return EntryTypeJmod
+	}
+	if filepath.Base(path) == "modules" && filepath.Base(filepath.Dir(path)) == "lib" {
+		return EntryTypeJImage
+	}
+	return EntryTypeOutput
+}
+
 func NewClasspath() *Classpath {
 	return &Classpath{
 		Entries:             nil,
@@ -57,12 +112,8 @@ func Parse(cp string) (*Classpath, error) {
 	entries := filepath.SplitList(cp)
 	result := NewClasspath()
 	for _, entry := range entries {
-		typ := EntryTypeJar
-		if !strings.HasSuffix(entry, ".jar") {
-			typ = EntryTypeSource
-		}
 		result.Entries = append(result.Entries, &Entry{
-			Type: typ,
+			Type: EntryTypeOf(entry),
 			Path: entry,
 		})
 	}
@@ -71,41 +122,20 @@ func Parse(cp string) (*Classpath, error) {
 }
 
 func (cp *Classpath) AddEntry(typ EntryType, path string) {
-	cp.Entries = append(cp.Entries, &Entry{typ, path})
+	cp.Entries = append(cp.Entries, &Entry{Type: typ, Path: path})
 }
 
 func (cp *Classpath) OpenClass(name string) (*class.Class, error) {
 	return cp.OpenClassWithCache(name, nil)
 }
 
-func (cp *Classpath) OpenClassWithCache(name string, cache *jar.Cache) (*class.Class, error) {
-	entry := cp.classesWithLocation[name]
-	if entry == nil {
-		// no cache hit, find an entry that contains this class
-		for _, e := range cp.Entries {
-
-			// only search entries that are not loaded into the cache yet
-			if _, ok := cp.cachedEntries[e.Path]; ok {
-				continue
-			}
-
-			if e.Type != EntryTypeJar {
-				continue // FIXME: search in the source directory
-			}
-			if err := cp.loadEntryIntoCache(e); err != nil {
-				return nil, fmt.Errorf("load entry: %w", err)
-			}
-
-			// we cached an entry that contains the class we are looking for
-			if cp.classesWithLocation[name] != nil {
-				break
-			}
-		}
-		entry = cp.classesWithLocation[name]
+func (cp *Classpath) OpenClassWithCache(name string, cache *Cache) (*class.Class, error) {
+	entry, err := cp.Locate(name)
+	if err != nil {
+		return nil, err
 	}
-
 	if entry == nil {
-		// if we still get no match, that means that the class does not exist in this classpath
+		// the class does not exist in this classpath
 		return nil, nil
 	}
 
@@ -114,33 +144,81 @@ func (cp *Classpath) OpenClassWithCache(name string, cache *jar.Cache) (*class.C
 		Str("search", name).
 		Msg("found match")
 
-	var jf *jar.File
-	var err error
+	var archive Archive
 	if cache == nil {
-		jf, err = jar.Open(entry.Path)
+		archive, err = cp.OpenArchive(entry)
 		if err != nil {
-			return nil, fmt.Errorf("open jar file: %w", err)
+			return nil, err
 		}
-		defer func() { _ = jf.Close() }()
+		defer func() { _ = archive.Close() }()
 	} else {
 		var ok bool
-		jf, ok = cache.Get(entry.Path)
+		archive, ok = cache.Get(entry.Path)
 		if !ok {
-			jf, err = jar.Open(entry.Path)
+			archive, err = cp.OpenArchive(entry)
 			if err != nil {
-				return nil, fmt.Errorf("open jar file: %w", err)
+				return nil, err
 			}
-			cache.Add(entry.Path, jf)
+			cache.Add(entry.Path, archive)
 		}
 	}
 
-	class, err := jf.OpenClass(name)
+	class, err := archive.OpenClass(name)
 	if err != nil {
 		return nil, fmt.Errorf("open class: %w", err)
 	}
 	return class, nil
 }
 
+// Locate returns the first entry of the classpath that contains the class with the given name,
+// or nil if the class does not exist in this classpath. Entries that can't be opened are logged
+// and skipped.
+func (cp *Classpath) Locate(name string) (*Entry, error) {
+	entry := cp.classesWithLocation[name]
+	if entry != nil {
+		return entry, nil
+	}
+
+	// no cache hit, find an entry that contains this class
+	for _, e := range cp.Entries {
+
+		// only search entries that are not loaded into the cache yet
+		if _, ok := cp.cachedEntries[e.Path]; ok {
+			continue
+		}
+
+		if !e.Type.HasClasses() {
+			continue // FIXME: search in the source directory
+		}
+		if err := cp.loadEntryIntoCache(e); err != nil {
+			log.Error().
+				Err(err).
+				Str("entry", e.Path).
+				Msg("load entry")
+			continue
+		}
+
+		// we cached an entry that contains the class we are looking for
+		if cp.classesWithLocation[name] != nil {
+			break
+		}
+	}
+	return cp.classesWithLocation[name], nil
+}
+
+// OpenArchive opens the archive of the given entry, resolving multi-release jars for
+// the release of the classpath.
+func (cp *Classpath) OpenArchive(entry *Entry) (Archive, error) {
+	archive, err := OpenArchive(entry)
+	if err != nil {
+		return nil, err
+	}
+	if f, ok := archive.(*jar.File); ok {
+		f.SetRelease(cp.Release)
+	}
+	return archive, nil
+}
+
 func (cp *Classpath) FindClasses(matchFn func(string) bool, resultsCh chan<- string) {
 	start := time.Now()
 
@@ -152,12 +230,15 @@ func (cp *Classpath) FindClasses(matchFn func(string) bool, resultsCh chan<- str
 			continue
 		}
 
-		if e.Type != EntryTypeJar {
+		if !e.Type.HasClasses() {
 			continue // FIXME: search in the source directory
 		}
 		if err := cp.loadEntryIntoCache(e); err != nil {
-			close(resultsCh)
-			return
+			log.Error().
+				Err(err).
+				Str("entry", e.Path).
+				Msg("load entry")
+			continue
 		}
 	}
 
@@ -216,14 +297,16 @@ func (cp *Classpath) FindClasses(matchFn func(string) bool, resultsCh chan<- str
 func (cp *Classpath) loadEntryIntoCache(entry *Entry) error {
 	start := time.Now()
 
-	jar, err := jar.Open(entry.Path)
+	archive, err := cp.OpenArchive(entry)
 	if err != nil {
-		return fmt.Errorf("open jar file: %w", err)
+		// entries that can't be opened are not tried again
+		cp.cachedEntries[entry.Path] = struct{}{}
+		return err
 	}
-	defer func() { _ = jar.Close() }()
+	defer func() { _ = archive.Close() }()
 
 	classes := 0
-	for _, className := range jar.ListClasses() {
+	for _, className := range archive.ListClasses() {
 		// since we work through the classpath top to bottom, don't overwrite entries
 		if cp.classesWithLocation[className] == nil {
 			cp.classesWithLocation[className] = entry
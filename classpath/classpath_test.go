@@ -0,0 +1,202 @@
+package classpath
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"github.com/tsatke/jt/class"
+	"github.com/tsatke/jt/internal/classbuild"
+)
+
+func TestClasspathSuite(t *testing.T) {
+	suite.Run(t, new(ClasspathSuite))
+}
+
+type ClasspathSuite struct {
+	suite.Suite
+}
+
+func (suite *ClasspathSuite) TestEntryTypeOf() {
+	for path, expected := range map[string]EntryType{
+		"lib/foo.jar":              EntryTypeJar,
+		"lib/foo.JAR":              EntryTypeJar,
+		"jdk/jmods/java.base.jmod": EntryTypeJmod,
+		"jdk/lib/modules":          EntryTypeJImage,
+		"modules":                  EntryTypeOutput,
+		"target/classes":           EntryTypeOutput,
+	} {
+		suite.Equalf(expected, EntryTypeOf(filepath.FromSlash(path)), "type of %s", path)
+	}
+}
+
+func (suite *ClasspathSuite) TestOpenClassFromOutput() {
+	cp := NewClasspath()
+	cp.AddEntry(EntryTypeSource, filepath.Join("testdata", "src"))
+	cp.AddEntry(EntryTypeOutput, filepath.Join("testdata", "missing"))
+	cp.AddEntry(EntryTypeOutput, filepath.Join("testdata", "classes"))
+
+	class, err := cp.OpenClass("com/github/tsatke/jt/App")
+	suite.Require().NoError(err)
+	suite.Require().NotNil(class)
+	suite.Equal("java/lang/Object", class.SuperclassName())
+
+	class, err = cp.OpenClass("com/github/tsatke/jt/Missing")
+	suite.NoError(err)
+	suite.Nil(class)
+}
+
+func (suite *ClasspathSuite) TestSkipBrokenEntries() {
+	broken := filepath.Join(suite.T().TempDir(), "broken.jar")
+	suite.Require().NoError(os.WriteFile(broken, []byte("not a jar"), 0644))
+	newClasspath := func() *Classpath {
+		cp := NewClasspath()
+		cp.AddEntry(EntryTypeUnknown, filepath.Join("testdata", "unknown"))
+		cp.AddEntry(EntryTypeJar, broken)
+		cp.AddEntry(EntryTypeOutput, filepath.Join("testdata", "classes"))
+		return cp
+	}
+
+	cp := newClasspath()
+	entry, err := cp.Locate("com/github/tsatke/jt/App")
+	suite.NoError(err)
+	suite.Equal(cp.Entries[2], entry)
+
+	resultsCh := make(chan string)
+	go newClasspath().FindClasses(func(string) bool { return true }, resultsCh)
+	var classes []string
+	for name := range resultsCh {
+		classes = append(classes, name)
+	}
+	suite.Contains(classes, "com/github/tsatke/jt/App")
+}
+
+func (suite *ClasspathSuite) TestExpand() {
+	dir := filepath.Join("testdata", "manifest")
+	cp := NewClasspath()
+	cp.AddEntry(EntryTypeJar, filepath.Join(dir, "launcher.jar"))
+	cp.AddEntry(EntryTypeJar, filepath.Join(dir, "other.jar"))
+	cp.AddEntry(EntryTypeJar, filepath.Join(dir, "lib", "b c.jar"))
+	cp.Expand()
+
+	var entries []*Entry
+	for _, e := range cp.Entries {
+		entries = append(entries, &Entry{Type: e.Type, Path: e.Path})
+	}
+	suite.Equal([]*Entry{
+		{Type: EntryTypeJar, Path: filepath.Join(dir, "launcher.jar")},
+		{Type: EntryTypeJar, Path: filepath.Join(dir, "lib", "a.jar")},
+		{Type: EntryTypeOutput, Path: filepath.Join(dir, "classes")},
+		{Type: EntryTypeJar, Path: filepath.Join(dir, "other.jar")},
+		{Type: EntryTypeJar, Path: filepath.Join(dir, "lib", "b c.jar")},
+	}, entries)
+}
+
+func (suite *ClasspathSuite) TestNestedEntries() {
+	ear := filepath.Join("testdata", "app.ear")
+	entries, err := NestedEntries(&Entry{Type: EntryTypeJar, Path: ear})
+	suite.Require().NoError(err)
+
+	var paths []string
+	for _, e := range entries {
+		suite.Equal(EntryTypeJar, e.Type)
+		paths = append(paths, e.Path)
+	}
+	suite.Equal([]string{
+		ear + "!/web.war",
+		ear + "!/web.war!/WEB-INF/lib/test1.jar",
+		ear + "!/lib/test1.jar",
+	}, paths)
+
+	cp := NewClasspath()
+	cp.AddEntry(EntryTypeOf(paths[1]), paths[1])
+	class, err := cp.OpenClass("com/github/tsatke/jt/App")
+	suite.NoError(err)
+	suite.NotNil(class)
+}
+
+func (suite *ClasspathSuite) TestResources() {
+	launcher := filepath.Join("testdata", "manifest", "launcher.jar")
+	other := filepath.Join("testdata", "manifest", "other.jar")
+	cp := NewClasspath()
+	cp.AddEntry(EntryTypeSource, filepath.Join("testdata", "src"))
+	// entries that can't be opened are skipped
+	cp.AddEntry(EntryTypeUnknown, filepath.Join("testdata", "unknown"))
+	cp.AddEntry(EntryTypeJar, filepath.Join("testdata", "missing.jar"))
+	cp.AddEntry(EntryTypeOutput, filepath.Join("testdata", "classes"))
+	cp.AddEntry(EntryTypeJar, launcher)
+	cp.AddEntry(EntryTypeJar, other)
+
+	entries, err := cp.ResourceLocations("META-INF/MANIFEST.MF")
+	suite.Require().NoError(err)
+	suite.Equal([]*Entry{cp.Entries[4], cp.Entries[5]}, entries)
+
+	rc, entry, err := cp.OpenResource("logback.xml")
+	suite.Require().NoError(err)
+	suite.Equal(cp.Entries[3], entry)
+	data, err := io.ReadAll(rc)
+	suite.NoError(err)
+	suite.NoError(rc.Close())
+	suite.Equal("<configuration/>\n", string(data))
+
+	rc, entry, err = cp.OpenResource("missing.xml")
+	suite.NoError(err)
+	suite.Nil(rc)
+	suite.Nil(entry)
+
+	// directories are not resources
+	rc, _, err = cp.OpenResource("com/github")
+	suite.NoError(err)
+	suite.Nil(rc)
+}
+
+func (suite *ClasspathSuite) TestResolveMember() {
+	dir := suite.T().TempDir()
+	write := func(c *classbuild.Class) { suite.Require().NoError(c.WriteFile(dir)) }
+
+	object := classbuild.New("java/lang/Object", "")
+	object.Method(classbuild.AccPublic, "toString", "()Ljava/lang/String;")
+	write(object)
+	iface := classbuild.New("com/example/Named", "java/lang/Object")
+	iface.AccessFlags = classbuild.AccPublic | classbuild.AccInterface | classbuild.AccAbstract
+	iface.Field(classbuild.AccPublic|classbuild.AccStatic|classbuild.AccFinal, "PREFIX", "Ljava/lang/String;")
+	iface.Method(classbuild.AccPublic, "name", "()Ljava/lang/String;")
+	write(iface)
+	base := classbuild.New("com/example/Base", "java/lang/Object")
+	base.Interfaces = []string{"com/example/Named"}
+	base.Field(classbuild.AccProtected, "id", "I")
+	write(base)
+	write(classbuild.New("com/example/Impl", "com/example/Base"))
+	write(classbuild.New("com/example/Broken", "com/example/Gone"))
+
+	cp := NewClasspath()
+	cp.AddEntry(EntryTypeOutput, dir)
+	for _, tc := range []struct {
+		ref       class.Reference
+		declaring string
+	}{
+		{class.Reference{Kind: class.RefField, Class: "com/example/Impl", Name: "id", Descriptor: "I"}, "com/example/Base"},
+		{class.Reference{Kind: class.RefField, Class: "com/example/Impl", Name: "PREFIX", Descriptor: "Ljava/lang/String;"}, "com/example/Named"},
+		{class.Reference{Kind: class.RefMethod, Class: "com/example/Impl", Name: "name", Descriptor: "()Ljava/lang/String;"}, "com/example/Named"},
+		{class.Reference{Kind: class.RefInterfaceMethod, Class: "com/example/Named", Name: "toString", Descriptor: "()Ljava/lang/String;"}, "java/lang/Object"},
+		{class.Reference{Kind: class.RefMethod, Class: "[Lcom/example/Impl;", Name: "toString", Descriptor: "()Ljava/lang/String;"}, "java/lang/Object"},
+		{class.Reference{Kind: class.RefMethod, Class: "com/example/Impl", Name: "name", Descriptor: "()V"}, ""},
+	} {
+		declaring, err := cp.ResolveMember(tc.ref, nil)
+		suite.Require().NoError(err)
+		if tc.declaring == "" {
+			suite.Nilf(declaring, "declaring class of %s", tc.ref)
+			continue
+		}
+		suite.Require().NotNilf(declaring, "declaring class of %s", tc.ref)
+		suite.Equalf(tc.declaring, declaring.Name(), "declaring class of %s", tc.ref)
+	}
+
+	_, err := cp.ResolveMember(class.Reference{Kind: class.RefMethod, Class: "com/example/Broken", Name: "name", Descriptor: "()V"}, nil)
+	var notFound *ClassNotFoundError
+	suite.Require().True(errors.As(err, &notFound))
+	suite.Equal("com/example/Gone", notFound.Name)
+}
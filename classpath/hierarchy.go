@@ -0,0 +1,196 @@
+package classpath
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+
+	"github.com/tsatke/jt/class"
+	"github.com/tsatke/jt/jimage"
+)
+
+// ClassNotFoundError is returned if a class that is required to answer
+// a question about the class hierarchy is not on the classpath.
+type ClassNotFoundError struct {
+	Name string
+}
+
+func (e *ClassNotFoundError) Error() string {
+	return fmt.Sprintf("class %s not found", e.Name)
+}
+
+const javaLangObject = "java/lang/Object"
+
+// IsSubtype returns whether the class with the given name is the given supertype, extends it
+// or implements it, directly or indirectly. If a class of the hierarchy is not on the classpath,
+// a *ClassNotFoundError is returned. The cache may be nil.
+func (cp *Classpath) IsSubtype(name, supertype string, cache *Cache) (bool, error) {
+	seen := make(map[string]struct{})
+	queue := []string{name}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if current == supertype {
+			return true, nil
+		}
+		if _, ok := seen[current]; ok || current == javaLangObject {
+			// java/lang/Object has no supertypes, so it doesn't need to be on the classpath
+			continue
+		}
+		seen[current] = struct{}{}
+
+		c, err := cp.OpenClassWithCache(current, cache)
+		if err != nil {
+			return false, err
+		}
+		if c == nil {
+			return false, &ClassNotFoundError{Name: current}
+		}
+		if super := c.SuperclassName(); super != "" {
+			queue = append(queue, super)
+		}
+		queue = append(queue, c.Interfaces()...)
+	}
+	return false, nil
+}
+
+// Modules returns the module declarations in the given archive. A runtime image
+// contains many modules, other archives at most one.
+func Modules(archive Archive) ([]*class.Module, error) {
+	if image, ok := archive.(*jimage.File); ok {
+		var modules []*class.Module
+		for _, name := range image.Modules() {
+			c, err := image.OpenModuleInfo(name)
+			if err != nil {
+				return nil, err
+			}
+			if m := c.Module(); m != nil {
+				modules = append(modules, m)
+			}
+		}
+		return modules, nil
+	}
+
+	c, err := archive.OpenClass("module-info")
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if m := c.Module(); m != nil {
+		return []*class.Module{m}, nil
+	}
+	return nil, nil
+}
+
+// ResolveMember returns the class that declares the field or method that the given reference
+// resolves to, like the JVM resolves it (JVMS 5.4.3.2 to 5.4.3.4): fields are looked up in the
+// class, its superinterfaces and then its superclasses, and methods in the class, its superclasses
+// and then its superinterfaces. Signature polymorphic methods, such as MethodHandle.invoke, match
+// every descriptor. It returns nil if the member is not declared anywhere in the hierarchy, and
+// a *ClassNotFoundError if a class of the hierarchy is not on the classpath. The cache may be nil.
+func (cp *Classpath) ResolveMember(ref class.Reference, cache *Cache) (*class.Class, error) {
+	owner := ref.Class
+	if strings.HasPrefix(owner, "[") {
+		// arrays have the members of java/lang/Object, and clone
+		owner = javaLangObject
+	}
+	c, err := cp.openRequired(owner, cache)
+	if err != nil {
+		return nil, err
+	}
+	if ref.Kind == class.RefField {
+		return cp.resolveField(c, ref.Name, ref.Descriptor, cache)
+	}
+
+	// superclasses first, the superclass of interfaces is java/lang/Object
+	var interfaces []string
+	for current := c; ; {
+		if declaresMethod(current, ref.Name, ref.Descriptor) {
+			return current, nil
+		}
+		interfaces = append(interfaces, current.Interfaces()...)
+		super := current.SuperclassName()
+		if super == "" {
+			break
+		}
+		if current, err = cp.openRequired(super, cache); err != nil {
+			return nil, err
+		}
+	}
+
+	seen := make(map[string]bool)
+	for len(interfaces) > 0 {
+		name := interfaces[0]
+		interfaces = interfaces[1:]
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		iface, err := cp.openRequired(name, cache)
+		if err != nil {
+			return nil, err
+		}
+		if declaresMethod(iface, ref.Name, ref.Descriptor) {
+			return iface, nil
+		}
+		interfaces = append(interfaces, iface.Interfaces()...)
+	}
+	return nil, nil
+}
+
+func (cp *Classpath) resolveField(c *class.Class, name, descriptor string, cache *Cache) (*class.Class, error) {
+	if _, ok := c.Field(name, descriptor); ok {
+		return c, nil
+	}
+	for _, iface := range c.Interfaces() {
+		ic, err := cp.openRequired(iface, cache)
+		if err != nil {
+			return nil, err
+		}
+		if declaring, err := cp.resolveField(ic, name, descriptor, cache); declaring != nil || err != nil {
+			return declaring, err
+		}
+	}
+	if super := c.SuperclassName(); super != "" {
+		sc, err := cp.openRequired(super, cache)
+		if err != nil {
+			return nil, err
+		}
+		return cp.resolveField(sc, name, descriptor, cache)
+	}
+	return nil, nil
+}
+
+// declaresMethod returns whether the class declares the method, or a signature polymorphic
+// method with the name.
+func declaresMethod(c *class.Class, name, descriptor string) bool {
+	if _, ok := c.Method(name, descriptor); ok {
+		return true
+	}
+	if n := c.Name(); n != "java/lang/invoke/MethodHandle" && n != "java/lang/invoke/VarHandle" {
+		return false
+	}
+	for _, m := range c.Methods() {
+		const polymorphic = class.AccNative | class.AccVarargs
+		if m.Name() == name && m.AccessFlags()&polymorphic == polymorphic {
+			return true
+		}
+	}
+	return false
+}
+
+// openRequired opens the class with the given name, returning a *ClassNotFoundError
+// if it is not on the classpath.
+func (cp *Classpath) openRequired(name string, cache *Cache) (*class.Class, error) {
+	c, err := cp.OpenClassWithCache(name, cache)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		return nil, &ClassNotFoundError{Name: name}
+	}
+	return c, nil
+}
@@ -0,0 +1,124 @@
+package classpath
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/tsatke/jt/jar"
+)
+
+// Expand adds the entries that are referenced by the Class-Path attribute in the manifest
+// of jar entries, just as the JVM does. Referenced entries are resolved relative to the jar
+// that references them and are inserted right after it, so that they are searched before the
+// entries that follow the jar. References are followed transitively. Entries that are already
+// on the classpath or don't exist are skipped.
+func (cp *Classpath) Expand() {
+	seen := make(map[string]struct{})
+	for _, e := range cp.Entries {
+		seen[filepath.Clean(e.Path)] = struct{}{}
+	}
+
+	expanded := make([]*Entry, 0, len(cp.Entries))
+	for _, e := range cp.Entries {
+		expanded = append(expanded, e)
+		expanded = appendManifestClassPath(expanded, e, seen)
+	}
+	cp.Entries = expanded
+}
+
+func appendManifestClassPath(entries []*Entry, entry *Entry, seen map[string]struct{}) []*Entry {
+	if entry.Type != EntryTypeJar {
+		return entries
+	}
+
+	refs, err := manifestClassPath(entry.Path)
+	if err != nil {
+		log.Debug().
+			Err(err).
+			Str("entry", entry.Path).
+			Msg("read manifest class path")
+		return entries
+	}
+
+	for _, ref := range refs {
+		key := filepath.Clean(ref.Path)
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+
+		if _, err := os.Stat(ref.Path); err != nil {
+			log.Debug().
+				Str("entry", entry.Path).
+				Str("reference", ref.Path).
+				Msg("skip missing manifest class path entry")
+			continue
+		}
+
+		log.Trace().
+			Str("entry", entry.Path).
+			Str("reference", ref.Path).
+			Msg("add manifest class path entry")
+
+		entries = append(entries, ref)
+		entries = appendManifestClassPath(entries, ref, seen)
+	}
+	return entries
+}
+
+// manifestClassPath returns the entries that are referenced by the Class-Path attribute
+// in the manifest of the jar with the given path.
+func manifestClassPath(path string) ([]*Entry, error) {
+	f, err := jar.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open jar file: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	manifest, err := f.Manifest()
+	if err != nil {
+		return nil, fmt.Errorf("manifest: %w", err)
+	}
+
+	var entries []*Entry
+	for _, ref := range manifest.ClassPath() {
+		refPath, ok := resolveClassPathURL(filepath.Dir(path), ref)
+		if !ok {
+			log.Debug().
+				Str("entry", path).
+				Str("reference", ref).
+				Msg("unsupported manifest class path entry")
+			continue
+		}
+
+		typ := EntryTypeOf(refPath)
+		if strings.HasSuffix(ref, "/") {
+			typ = EntryTypeOutput
+		}
+		entries = append(entries, &Entry{Type: typ, Path: refPath})
+	}
+	return entries, nil
+}
+
+// resolveClassPathURL resolves a URL of a Class-Path attribute, which is relative
+// to the directory of the jar, unless it is an absolute file URL. Returns false
+// for URLs that don't reference a local file.
+func resolveClassPathURL(dir, ref string) (string, bool) {
+	u, err := url.Parse(ref)
+	if err != nil {
+		return "", false
+	}
+	if u.Scheme != "" && u.Scheme != "file" {
+		return "", false
+	}
+
+	path := filepath.FromSlash(u.Path)
+	if u.Scheme == "file" || filepath.IsAbs(path) {
+		return filepath.Clean(path), true
+	}
+	return filepath.Join(dir, path), true
+}
@@ -0,0 +1,65 @@
+package classpath
+
+import (
+	"path"
+	"strings"
+)
+
+// Includes returns whether the file with the given slash separated path,
+// relative to the path of this entry, belongs to this entry.
+// A file belongs to the entry if it matches any of the inclusion patterns
+// (or there are no inclusion patterns) and none of the exclusion patterns.
+//
+// Patterns are ant-style patterns like '**/*.java' or 'com/example/', where
+// '**' matches any number of directories and a trailing '/' matches everything
+// in the directory.
+func (e *Entry) Includes(name string) bool {
+	if len(e.Inclusions) > 0 && !matchesAny(e.Inclusions, name) {
+		return false
+	}
+	return !matchesAny(e.Exclusions, name)
+}
+
+func matchesAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if MatchPattern(pattern, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchPattern returns whether the given slash separated path matches the
+// ant-style pattern. See Entry.Includes for the pattern syntax.
+func MatchPattern(pattern, name string) bool {
+	if strings.HasSuffix(pattern, "/") {
+		pattern += "**"
+	}
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			pattern = pattern[1:]
+			if len(pattern) == 0 {
+				return true
+			}
+			for i := range name {
+				if matchSegments(pattern, name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
@@ -0,0 +1,50 @@
+package classpath
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+func TestPatternSuite(t *testing.T) {
+	suite.Run(t, new(PatternSuite))
+}
+
+type PatternSuite struct {
+	suite.Suite
+}
+
+func (suite *PatternSuite) TestMatchPattern() {
+	for _, tc := range []struct {
+		pattern string
+		name    string
+		matches bool
+	}{
+		{"**/*.java", "App.java", true},
+		{"**/*.java", "com/example/App.java", true},
+		{"**/*.java", "com/example/App.class", false},
+		{"**/.svn/**", "com/.svn/entries", true},
+		{"**/.svn/**", "com/svn/entries", false},
+		{"com/example/", "com/example/App.java", true},
+		{"com/example/", "com/other/App.java", false},
+		{"com/*/App.java", "com/example/App.java", true},
+		{"com/*/App.java", "com/example/sub/App.java", false},
+		{"com/**/App.java", "com/example/sub/App.java", true},
+		{"App?.java", "App1.java", true},
+	} {
+		suite.Equalf(tc.matches, MatchPattern(tc.pattern, tc.name), "%s against %s", tc.name, tc.pattern)
+	}
+}
+
+func (suite *PatternSuite) TestIncludes() {
+	entry := &Entry{
+		Inclusions: []string{"**/*"},
+		Exclusions: []string{"**/.svn/**", "**/*.java"},
+	}
+	suite.True(entry.Includes("log4j.xml"))
+	suite.True(entry.Includes("META-INF/services/com.example.Service"))
+	suite.False(entry.Includes("com/example/App.java"))
+	suite.False(entry.Includes(".svn/entries"))
+
+	suite.True((&Entry{}).Includes("anything/at/all"))
+}
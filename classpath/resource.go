@@ -0,0 +1,93 @@
+package classpath
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+
+	"github.com/rs/zerolog/log"
+)
+
+// ResourceLocations returns all entries of the classpath that contain the resource with
+// the given name, such as META-INF/spring.factories, in classpath order. This is what
+// ClassLoader.getResources returns. Entries that can't be opened are logged and skipped.
+func (cp *Classpath) ResourceLocations(name string) ([]*Entry, error) {
+	var entries []*Entry
+	for _, e := range cp.Entries {
+		found, err := cp.containsResource(e, name)
+		if err != nil {
+			return nil, err
+		}
+		if found {
+			entries = append(entries, e)
+		}
+	}
+	return entries, nil
+}
+
+// OpenResource opens the resource with the given name from the first entry of the classpath
+// that contains it, just as ClassLoader.getResource does. If the resource does not exist in
+// this classpath, OpenResource returns nil. Entries that can't be opened are logged and skipped.
+func (cp *Classpath) OpenResource(name string) (io.ReadCloser, *Entry, error) {
+	for _, e := range cp.Entries {
+		found, err := cp.containsResource(e, name)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !found {
+			continue
+		}
+
+		archive, err := cp.OpenArchive(e)
+		if err != nil {
+			return nil, nil, err
+		}
+		rc, err := archive.OpenResource(name)
+		if err != nil {
+			_ = archive.Close()
+			return nil, nil, fmt.Errorf("open resource: %w", err)
+		}
+		return &resourceReader{ReadCloser: rc, archive: archive}, e, nil
+	}
+	return nil, nil, nil
+}
+
+// containsResource returns whether the entry contains the resource with the given name.
+// Entries that can't be opened are logged and don't contain any resources.
+func (cp *Classpath) containsResource(entry *Entry, name string) (bool, error) {
+	if !entry.Type.HasClasses() {
+		return false, nil
+	}
+
+	archive, err := cp.OpenArchive(entry)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str("entry", entry.Path).
+			Msg("open archive")
+		return false, nil
+	}
+	defer func() { _ = archive.Close() }()
+
+	_, err = archive.StatResource(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("stat resource: %w", err)
+	}
+	return true, nil
+}
+
+// resourceReader closes the archive of a resource together with the resource.
+type resourceReader struct {
+	io.ReadCloser
+	archive Archive
+}
+
+func (r *resourceReader) Close() error {
+	err := r.ReadCloser.Close()
+	_ = r.archive.Close()
+	return err
+}
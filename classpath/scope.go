@@ -0,0 +1,52 @@
+package classpath
+
+import "fmt"
+
+// Scope determines which parts of a project end up on a classpath,
+// similar to the dependency scopes known from Maven.
+type Scope uint8
+
+const (
+	// ScopeTest is the widest scope. It contains everything that is visible
+	// to the tests of a project, i.e. main and test sources and all dependencies.
+	ScopeTest Scope = iota
+	// ScopeCompile contains everything that is visible to the production code
+	// at compile time, i.e. main sources, compile, provided and system dependencies.
+	ScopeCompile
+	// ScopeRuntime contains everything that is visible to the production code
+	// at runtime, i.e. main sources, compile and runtime dependencies.
+	ScopeRuntime
+	// ScopeProvided contains main sources and the dependencies that are
+	// expected to be provided by the environment, such as a servlet container.
+	ScopeProvided
+)
+
+var scopeNames = map[Scope]string{
+	ScopeTest:     "test",
+	ScopeCompile:  "compile",
+	ScopeRuntime:  "runtime",
+	ScopeProvided: "provided",
+}
+
+// ParseScope returns the scope with the given name, such as "compile" or "test".
+func ParseScope(name string) (Scope, error) {
+	for scope, scopeName := range scopeNames {
+		if scopeName == name {
+			return scope, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown scope '%s'", name)
+}
+
+func (s Scope) String() string {
+	if name, ok := scopeNames[s]; ok {
+		return name
+	}
+	return fmt.Sprintf("Scope(%d)", s)
+}
+
+// IncludesTests returns whether test sources and test dependencies
+// are part of this scope.
+func (s Scope) IncludesTests() bool {
+	return s == ScopeTest
+}
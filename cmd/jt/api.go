@@ -0,0 +1,86 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"github.com/tsatke/jt/api"
+)
+
+// apiDifferenceRecord is a line of the API snapshot that differs from the current build.
+type apiDifferenceRecord struct {
+	Line string `json:"line"`
+	// Change is either removed or added.
+	Change string `json:"change"`
+}
+
+func runAPIDump(cmd *cobra.Command, args []string) {
+	lines := projectAPI()
+	var b strings.Builder
+	for _, line := range lines {
+		b.WriteString(line + "\n")
+	}
+	if err := os.WriteFile(flagAPIFile, []byte(b.String()), 0644); err != nil {
+		log.Fatal().
+			Err(err).
+			Str("file", flagAPIFile).
+			Msg("write api file")
+	}
+}
+
+func runAPICheck(cmd *cobra.Command, args []string) {
+	data, err := os.ReadFile(flagAPIFile)
+	if errors.Is(err, os.ErrNotExist) {
+		log.Fatal().
+			Str("file", flagAPIFile).
+			Msg("api file does not exist, create it with jt api dump")
+	} else if err != nil {
+		log.Fatal().
+			Err(err).
+			Str("file", flagAPIFile).
+			Msg("read api file")
+	}
+	var snapshot []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			snapshot = append(snapshot, line)
+		}
+	}
+	// the file may have been edited by hand
+	sort.Strings(snapshot)
+
+	differences := api.Diff(snapshot, projectAPI())
+	out := newPrinter()
+	defer out.Close()
+	for _, d := range differences {
+		record := &apiDifferenceRecord{Line: d.Line, Change: "removed"}
+		text := "-" + d.Line
+		if d.Added {
+			record.Change = "added"
+			text = "+" + d.Line
+		}
+		out.Print(text, record)
+	}
+	if len(differences) > 0 {
+		out.Close()
+		os.Exit(1)
+	}
+}
+
+// projectAPI returns the lines of the API of the output folders of the project. The API is
+// always that of the compile scope, regardless of --scope.
+func projectAPI() []string {
+	project := loadProject(cwd())
+	lines, err := api.DumpProject(project, flagRelease)
+	if err != nil {
+		log.Fatal().
+			Err(err).
+			Str("project", project.Name()).
+			Msg("dump api")
+	}
+	return lines
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"path/filepath"
+
+	"github.com/tsatke/jt/classpath"
+	"github.com/tsatke/jt/jar"
+	"github.com/tsatke/jt/workspace"
+)
+
+// resourcePath returns the path of a resource within a classpath entry, such as
+// lib/foo.jar!/META-INF/MANIFEST.MF or target/classes/logback.xml.
+func resourcePath(entry *classpath.Entry, name string) string {
+	if entry.Type == classpath.EntryTypeOutput {
+		return filepath.Join(entry.Path, filepath.FromSlash(name))
+	}
+	return entry.Path + jar.NestedSeparator + name
+}
+
+// identifyEntry returns the Maven coordinates of the given entry like workspace.Artifact,
+// opening the archive of the entry.
+func identifyEntry(cp *classpath.Classpath, entry *classpath.Entry) string {
+	if entry.Type != classpath.EntryTypeJar {
+		return ""
+	}
+	archive, err := cp.OpenArchive(entry)
+	if err != nil {
+		return ""
+	}
+	defer func() { _ = archive.Close() }()
+
+	return workspace.Artifact(entry, archive)
+}
+
+// describeEntry returns the path of the given entry, followed by its Maven coordinates
+// in parentheses if the entry is a jar that can be identified.
+func describeEntry(cp *classpath.Classpath, entry *classpath.Entry) string {
+	if artifact := identifyEntry(cp, entry); artifact != "" {
+		return entry.Path + " (" + artifact + ")"
+	}
+	return entry.Path
+}
+
+// entryRecords creates the records of classpath entries, identifying every entry only once.
+type entryRecords struct {
+	cp      *classpath.Classpath
+	records map[*classpath.Entry]*workspace.Entry
+}
+
+func newEntryRecords(cp *classpath.Classpath) *entryRecords {
+	return &entryRecords{
+		cp:      cp,
+		records: make(map[*classpath.Entry]*workspace.Entry),
+	}
+}
+
+// Get returns the record of the entry, or nil if the entry is nil.
+func (r *entryRecords) Get(entry *classpath.Entry) *workspace.Entry {
+	if entry == nil {
+		return nil
+	}
+	record, ok := r.records[entry]
+	if !ok {
+		record = workspace.NewEntry(entry, identifyEntry(r.cp, entry))
+		r.records[entry] = record
+	}
+	return record
+}
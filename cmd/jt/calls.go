@@ -0,0 +1,197 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"github.com/tsatke/jt/callgraph"
+	"github.com/tsatke/jt/usage"
+	"github.com/tsatke/jt/workspace"
+)
+
+// callRecord is a call that jt callers or jt callees found.
+type callRecord struct {
+	Caller string `json:"caller"`
+	Callee string `json:"callee"`
+	// Lines are the source lines of the calls in the caller, if the class has line numbers.
+	Lines []int `json:"lines,omitempty"`
+	// Depth is the distance of the call from the given method, starting at 1.
+	Depth int `json:"depth"`
+	// Entry is the classpath entry of the caller for jt callers, and of the callee for jt callees.
+	Entry *workspace.Entry `json:"entry"`
+}
+
+func runCallers(_ *cobra.Command, args []string) {
+	runCalls(args[0], true)
+}
+
+func runCallees(_ *cobra.Command, args []string) {
+	runCalls(args[0], false)
+}
+
+// runCalls prints the methods that call the given method, or that it calls, as a tree up to
+// the depth of --depth, or as a graph in DOT format with --dot.
+func runCalls(arg string, callers bool) {
+	target, err := usage.ParseTarget(arg)
+	if err != nil {
+		log.Fatal().
+			Err(err).
+			Msg("parse method")
+	}
+	if target.Member == "" {
+		log.Fatal().
+			Str("method", arg).
+			Msg("missing method name, must be class#method")
+	}
+
+	project := loadProject(cwd())
+	cp := projectClasspath(project)
+	graph := callgraph.Build(cp)
+	roots, err := graph.Methods(target.Class, target.Member, target.Descriptor)
+	if err != nil {
+		log.Fatal().
+			Err(err).
+			Str("method", target.String()).
+			Msg("find method")
+	}
+
+	t := &callTree{
+		graph:    graph,
+		callers:  callers,
+		entries:  newEntryRecords(cp),
+		expanded: make(map[callgraph.Method]bool),
+	}
+	if flagCallsDot {
+		t.dot(roots)
+		return
+	}
+
+	out := newPrinter()
+	defer out.Close()
+	t.out = out
+	for _, root := range roots {
+		if out.Text() {
+			fmt.Println(root)
+		}
+		t.expand(root, 1)
+	}
+}
+
+// callTree walks the calls from or to methods.
+type callTree struct {
+	graph    *callgraph.Graph
+	callers  bool
+	entries  *entryRecords
+	out      *printer
+	expanded map[callgraph.Method]bool
+}
+
+// edge is a method that calls or is called by another method, with the lines of the calls.
+type edge struct {
+	method callgraph.Method
+	lines  []int
+}
+
+// edges returns the callers or callees of the method, with all calls between two methods merged.
+func (t *callTree) edges(m callgraph.Method) []*edge {
+	calls := t.graph.Callees(m)
+	if t.callers {
+		calls = t.graph.Callers(m)
+	}
+	var edges []*edge
+	byMethod := make(map[callgraph.Method]*edge)
+	for _, call := range calls {
+		other := call.Callee
+		if t.callers {
+			other = call.Caller
+		}
+		e, ok := byMethod[other]
+		if !ok {
+			e = &edge{method: other}
+			byMethod[other] = e
+			edges = append(edges, e)
+		}
+		if call.Line > 0 && (len(e.lines) == 0 || e.lines[len(e.lines)-1] != call.Line) {
+			e.lines = append(e.lines, call.Line)
+		}
+	}
+	return edges
+}
+
+// expand prints the callers or callees of the method and expands them until --depth is
+// reached. Methods are expanded only once, so that recursion ends.
+func (t *callTree) expand(m callgraph.Method, depth int) {
+	t.expanded[m] = true
+	for _, e := range t.edges(m) {
+		record := &callRecord{
+			Caller: m.String(),
+			Callee: e.method.String(),
+			Lines:  e.lines,
+			Depth:  depth,
+			Entry:  t.entries.Get(t.graph.Entry(e.method.Class)),
+		}
+		if t.callers {
+			record.Caller, record.Callee = record.Callee, record.Caller
+		}
+		more := flagCallsDepth <= 0 || depth < flagCallsDepth
+		t.out.Print(formatCall(e, depth, more && t.expanded[e.method]), record)
+		if more && !t.expanded[e.method] {
+			t.expand(e.method, depth+1)
+		}
+	}
+}
+
+// formatCall formats a method of the tree like com/example/App#run()V (lines 10, 12), indented
+// by its depth. Methods that were expanded before end with an ellipsis.
+func formatCall(e *edge, depth int, repeated bool) string {
+	text := strings.Repeat("  ", depth) + e.method.String()
+	if len(e.lines) > 0 {
+		lines := make([]string, len(e.lines))
+		for i, line := range e.lines {
+			lines[i] = strconv.Itoa(line)
+		}
+		if len(lines) == 1 {
+			text += " (line " + lines[0] + ")"
+		} else {
+			text += " (lines " + strings.Join(lines, ", ") + ")"
+		}
+	}
+	if repeated {
+		text += " ..."
+	}
+	return text
+}
+
+// dot prints the calls up to --depth as a directed graph in the DOT language of Graphviz,
+// with the given methods in bold.
+func (t *callTree) dot(roots []callgraph.Method) {
+	fmt.Println("digraph calls {")
+	for _, root := range roots {
+		fmt.Printf("  %s [style=bold];\n", strconv.Quote(root.String()))
+	}
+	printed := make(map[[2]callgraph.Method]bool)
+	var walk func(m callgraph.Method, depth int)
+	walk = func(m callgraph.Method, depth int) {
+		t.expanded[m] = true
+		for _, e := range t.edges(m) {
+			from, to := m, e.method
+			if t.callers {
+				from, to = to, from
+			}
+			if !printed[[2]callgraph.Method{from, to}] {
+				printed[[2]callgraph.Method{from, to}] = true
+				fmt.Printf("  %s -> %s;\n", strconv.Quote(from.String()), strconv.Quote(to.String()))
+			}
+			if (flagCallsDepth <= 0 || depth < flagCallsDepth) && !t.expanded[e.method] {
+				walk(e.method, depth+1)
+			}
+		}
+	}
+	for _, root := range roots {
+		walk(root, 1)
+	}
+	fmt.Println("}")
+}
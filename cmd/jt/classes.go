@@ -2,22 +2,91 @@ package main
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
+	"github.com/tsatke/jt/classpath"
 	"github.com/tsatke/jt/jar"
+	"github.com/tsatke/jt/workspace"
 )
 
 func runClasses(cmd *cobra.Command, args []string) {
-	jarFile := args[0]
-	archive, err := jar.Open(jarFile)
-	if err != nil {
+	out := newPrinter()
+	defer out.Close()
+	if err := listClasses(out, args[0]); err != nil {
+		out.Close()
 		log.Fatal().
 			Err(err).
-			Str("jar", jarFile).
-			Msg("open jar file")
+			Str("archive", args[0]).
+			Msg("list classes")
+	}
+}
+
+// listClasses prints the classes of the jar, jmod or jimage file at the given path.
+func listClasses(out *printer, path string) error {
+	entry := &classpath.Entry{
+		Type: classpath.EntryTypeOf(path),
+		Path: path,
+	}
+	if err := printClasses(out, entry, ""); err != nil {
+		return err
+	}
+
+	// classes of nested archives, like the libraries of fat jars, are printed with their location
+	nested, err := classpath.NestedEntries(entry)
+	if err != nil {
+		return fmt.Errorf("list nested archives: %w", err)
+	}
+	for _, e := range nested {
+		if err := printClasses(out, e, fmt.Sprintf(" (via %s)", e.Path)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func printClasses(out *printer, entry *classpath.Entry, suffix string) error {
+	archive, err := classpath.OpenArchive(entry)
+	if err != nil {
+		return fmt.Errorf("open archive: %w", err)
+	}
+	defer func() { _ = archive.Close() }()
+
+	f, isJar := archive.(*jar.File)
+	if isJar {
+		f.SetRelease(flagRelease)
 	}
-	for _, f := range archive.ListClasses() {
-		fmt.Println(f)
+
+	var record *workspace.Entry
+	if !out.Text() {
+		record = workspace.NewEntry(entry, workspace.Artifact(entry, archive))
+	}
+	for _, name := range archive.ListClasses() {
+		var versions []int
+		if isJar {
+			versions = f.Versions(name)
+		}
+		if !out.Text() {
+			classRecord := workspace.NewClass(name, record)
+			classRecord.Versions = versions
+			out.Print("", classRecord)
+		} else if flagClassesVersions && len(versions) > 0 {
+			out.Print(fmt.Sprintf("%s [%s]%s", name, formatVersions(versions), suffix), nil)
+		} else {
+			out.Print(name+suffix, nil)
+		}
+	}
+	return nil
+}
+
+// formatVersions formats the releases of the versioned variants of a class,
+// such as "base, 11, 17".
+func formatVersions(releases []int) string {
+	versions := []string{"base"}
+	for _, release := range releases {
+		versions = append(versions, strconv.Itoa(release))
 	}
+	return strings.Join(versions, ", ")
 }
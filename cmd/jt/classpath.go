@@ -2,21 +2,132 @@ package main
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 
+	"github.com/mattn/go-isatty"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
+	"github.com/tsatke/jt/classpath"
+	"github.com/tsatke/jt/jar"
+	"github.com/tsatke/jt/repository"
+	"github.com/tsatke/jt/workspace"
 )
 
 func runClasspath(cmd *cobra.Command, args []string) {
 	project := loadProject(cwd())
-	cp, err := project.Classpath()
-	if err != nil {
-		log.Fatal().
-			Err(err).
-			Str("project", project.Name()).
-			Msg("get classpath")
+	cp := projectClasspath(project)
+
+	out := newPrinter()
+	defer out.Close()
+
+	if flagClasspathVerify {
+		verifyClasspath(out, cp)
+		return
+	}
+
+	// only print coordinates in terminals, so that the output can be used as a classpath
+	terminal := isatty.IsTerminal(os.Stdout.Fd())
+	for _, entry := range cp.Entries {
+		switch {
+		case !out.Text():
+			out.Print("", workspace.NewEntry(entry, identifyEntry(cp, entry)))
+		case terminal:
+			out.Print(describeEntry(cp, entry), nil)
+		default:
+			out.Print(entry.Path, nil)
+		}
+	}
+}
+
+// checksumRecord is the result of verifying the checksums of a jar.
+type checksumRecord struct {
+	Path     string `json:"path"`
+	Artifact string `json:"artifact"`
+	// OK is true if the jar matches all of its checksums.
+	OK        bool                 `json:"ok"`
+	Checksums []checksumFileRecord `json:"checksums"`
+	// Repositories holds the ids of the repositories that the jar was downloaded from.
+	Repositories []string `json:"repositories"`
+	// Installed is true if the jar was installed locally instead of being downloaded.
+	Installed bool `json:"installed"`
+}
+
+// checksumFileRecord is the comparison of a jar with one of its checksum files.
+type checksumFileRecord struct {
+	// Algorithm is one of sha512, sha256, sha1 or md5.
+	Algorithm string `json:"algorithm"`
+	Expected  string `json:"expected"`
+	Actual    string `json:"actual"`
+	OK        bool   `json:"ok"`
+}
+
+func newChecksumRecord(result *repository.Result, artifact string) *checksumRecord {
+	record := &checksumRecord{
+		Path:         result.Path,
+		Artifact:     artifact,
+		OK:           len(result.Mismatches()) == 0,
+		Checksums:    []checksumFileRecord{},
+		Repositories: append([]string{}, result.Repositories...),
+		Installed:    result.Installed,
+	}
+	for _, c := range result.Checksums {
+		record.Checksums = append(record.Checksums, checksumFileRecord{
+			Algorithm: c.Algorithm,
+			Expected:  c.Expected,
+			Actual:    c.Actual,
+			OK:        c.OK(),
+		})
 	}
+	return record
+}
+
+// verifyClasspath verifies the checksums of all jars on the classpath that are located in
+// a local Maven repository, and exits with 1 if a jar doesn't match its checksums.
+func verifyClasspath(out *printer, cp *classpath.Classpath) {
+	mismatches := 0
 	for _, entry := range cp.Entries {
-		fmt.Println(entry.Path)
+		// the Gradle cache has no checksum files
+		artifact := jar.RepositoryArtifact(entry.Path)
+		if entry.Type != classpath.EntryTypeJar || artifact == nil || strings.Contains(filepath.ToSlash(entry.Path), "/files-2.1/") {
+			continue
+		}
+
+		result, err := repository.Verify(entry.Path)
+		if err != nil {
+			log.Error().
+				Err(err).
+				Str("entry", entry.Path).
+				Msg("verify checksums")
+			continue
+		}
+
+		var problems []string
+		for _, c := range result.Mismatches() {
+			problems = append(problems, fmt.Sprintf("%s mismatch (expected %s, got %s)", c.Algorithm, c.Expected, c.Actual))
+		}
+		if len(result.Mismatches()) > 0 {
+			mismatches++
+		}
+		if len(result.Checksums) == 0 {
+			problems = append(problems, "no checksum")
+		}
+		if result.Installed {
+			problems = append(problems, "installed locally")
+		}
+
+		status := "ok"
+		if len(problems) > 0 {
+			status = strings.Join(problems, ", ")
+		} else if len(result.Repositories) > 0 {
+			status += " (from " + strings.Join(result.Repositories, ", ") + ")"
+		}
+		out.Print(fmt.Sprintf("%s (%s): %s", entry.Path, artifact, status), newChecksumRecord(result, artifact.String()))
+	}
+
+	if mismatches > 0 {
+		out.Close()
+		os.Exit(1)
 	}
 }
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"github.com/tsatke/jt/classpath"
+	"github.com/tsatke/jt/compat"
+)
+
+// changeRecord is a change of the API between two versions of a library.
+type changeRecord struct {
+	Class  string `json:"class"`
+	Member string `json:"member,omitempty"`
+	Kind   string `json:"kind"`
+	Old    string `json:"old,omitempty"`
+	New    string `json:"new,omitempty"`
+	// Source and Binary are whether the change is source or binary incompatible.
+	Source bool `json:"source"`
+	Binary bool `json:"binary"`
+}
+
+func runCompat(cmd *cobra.Command, args []string) {
+	oldArchive, err := openArchive(args[0])
+	if err != nil {
+		log.Fatal().
+			Err(err).
+			Str("path", args[0]).
+			Msg("open archive")
+	}
+	defer func() { _ = oldArchive.Close() }()
+	newArchive, err := openArchive(args[1])
+	if err != nil {
+		log.Fatal().
+			Err(err).
+			Str("path", args[1]).
+			Msg("open archive")
+	}
+	defer func() { _ = newArchive.Close() }()
+
+	changes, err := compat.Compare(oldArchive, newArchive)
+	if err != nil {
+		log.Fatal().
+			Err(err).
+			Msg("compare archives")
+	}
+
+	out := newPrinter()
+	defer out.Close()
+	incompatible := false
+	for _, c := range changes {
+		incompatible = incompatible || c.Source || c.Binary
+		if flagCompatIncompatible && !c.Source && !c.Binary {
+			continue
+		}
+		record := &changeRecord{
+			Class:  c.Class,
+			Member: c.Member,
+			Kind:   c.Kind,
+			Old:    c.Old,
+			New:    c.New,
+			Source: c.Source,
+			Binary: c.Binary,
+		}
+		out.Print(formatChange(record), record)
+	}
+	if incompatible {
+		out.Close()
+		os.Exit(1)
+	}
+}
+
+// openArchive opens the jar, jmod or directory at the given path.
+func openArchive(path string) (classpath.Archive, error) {
+	return classpath.OpenArchive(&classpath.Entry{
+		Type: classpath.EntryTypeOf(path),
+		Path: path,
+	})
+}
+
+// formatChange formats a change like com/lib/Api#helper()V visibility reduced from public to
+// protected (source and binary incompatible).
+func formatChange(c *changeRecord) string {
+	text := c.Class
+	if c.Member != "" {
+		text += "#" + c.Member
+	}
+	text += " " + c.Kind
+	switch {
+	case c.Old != "" && c.New != "":
+		text += fmt.Sprintf(" from %s to %s", c.Old, c.New)
+	case c.Kind == compat.KindConstantChanged:
+		text += " from " + c.Old + " to no constant"
+	case c.Old != "":
+		text += " " + c.Old
+	case c.New != "":
+		text += " " + c.New
+	}
+
+	switch {
+	case c.Source && c.Binary:
+		text += " (source and binary incompatible)"
+	case c.Binary:
+		text += " (binary incompatible)"
+	case c.Source:
+		text += " (source incompatible)"
+	}
+	return text
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"github.com/tsatke/jt/classpath"
+	"github.com/tsatke/jt/workspace"
+)
+
+// levels of jt deps, see --level
+const (
+	depsLevelClass   = "class"
+	depsLevelPackage = "package"
+	depsLevelJar     = "jar"
+)
+
+// depRecord is a dependency of a class, package or jar on another one.
+type depRecord struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	// Entry is the classpath entry that contains the dependency, or nil if it is missing.
+	Entry   *workspace.Entry `json:"entry"`
+	Missing bool             `json:"missing,omitempty"`
+}
+
+func runDeps(cmd *cobra.Command, args []string) {
+	switch flagDepsLevel {
+	case depsLevelClass, depsLevelPackage, depsLevelJar:
+	default:
+		log.Fatal().
+			Str("level", flagDepsLevel).
+			Msg("unknown level, must be class, package or jar")
+	}
+
+	project := loadProject(cwd())
+	cp := projectClasspath(project)
+
+	// without a path, the output folders of the project are analyzed
+	sources := make(map[*classpath.Entry]bool)
+	if len(args) == 1 {
+		path, err := filepath.Abs(args[0])
+		if err != nil {
+			log.Fatal().
+				Err(err).
+				Str("path", args[0]).
+				Msg("get absolute path")
+		}
+		// the classes of the analyzed jar or directory depend on each other
+		entry := &classpath.Entry{Type: classpath.EntryTypeOf(path), Path: path}
+		cp.Entries = append([]*classpath.Entry{entry}, cp.Entries...)
+		sources[entry] = true
+	} else {
+		for _, entry := range cp.Entries {
+			if entry.Type == classpath.EntryTypeOutput {
+				sources[entry] = true
+			}
+		}
+	}
+
+	records := collectDeps(cp, sources)
+
+	out := newPrinter()
+	defer out.Close()
+	entries := newEntryRecords(cp)
+	for _, d := range records {
+		record := &depRecord{
+			From:    d.from,
+			To:      d.to,
+			Entry:   entries.Get(d.entry),
+			Missing: d.entry == nil,
+		}
+		out.Print(formatDep(record), record)
+	}
+	if flagDepsMissing && len(records) > 0 {
+		out.Close()
+		os.Exit(1)
+	}
+}
+
+// dep is a dependency at the level of --level.
+type dep struct {
+	from  string
+	to    string
+	entry *classpath.Entry
+}
+
+// collectDeps returns the dependencies of the classes in the given entries, sorted and merged at
+// the level of --level, without dependencies within the same package or jar. With --missing, only
+// dependencies on classes that are not on the classpath are returned, which are never merged.
+func collectDeps(cp *classpath.Classpath, sources map[*classpath.Entry]bool) []dep {
+	var deps []dep
+	seen := make(map[dep]bool)
+	cp.WalkArchives(func(entry *classpath.Entry) bool {
+		return !sources[entry]
+	}, func(entry *classpath.Entry, archive classpath.Archive) {
+		for _, name := range archive.ListClasses() {
+			c, err := archive.OpenClass(name)
+			if err != nil {
+				log.Error().
+					Err(err).
+					Str("class", name).
+					Str("entry", entry.Path).
+					Msg("open class")
+				continue
+			}
+			for _, dependency := range c.Dependencies() {
+				target, err := cp.Locate(dependency)
+				if err != nil {
+					log.Error().
+						Err(err).
+						Str("class", dependency).
+						Msg("locate class")
+					continue
+				}
+				if flagDepsMissing && target != nil {
+					continue
+				}
+
+				d := dep{from: name, to: dependency, entry: target}
+				switch {
+				case flagDepsLevel == depsLevelJar:
+					d.from = relativeToCwd(entry.Path)
+					if target != nil {
+						d.to = relativeToCwd(target.Path)
+					}
+				case flagDepsLevel == depsLevelPackage:
+					d.from = packageOf(name)
+					if target != nil {
+						d.to = packageOf(dependency)
+					}
+				}
+				if (target == entry && flagDepsLevel == depsLevelJar) || (d.from == d.to) || seen[d] {
+					continue
+				}
+				seen[d] = true
+				deps = append(deps, d)
+			}
+		}
+	})
+	sort.Slice(deps, func(i, j int) bool {
+		if deps[i].from != deps[j].from {
+			return deps[i].from < deps[j].from
+		}
+		return deps[i].to < deps[j].to
+	})
+	return deps
+}
+
+// packageOf returns the package of a class, or <unnamed> for the unnamed package.
+func packageOf(name string) string {
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		return name[:i]
+	}
+	return "<unnamed>"
+}
+
+// formatDep formats a dependency like com/example -> com/google/common/collect (via
+// /path/to/guava.jar, com.google.guava:guava:31.0-jre), where the location is left out for jars.
+func formatDep(d *depRecord) string {
+	switch {
+	case d.Missing:
+		return fmt.Sprintf("%s -> %s (not found)", d.From, d.To)
+	case flagDepsLevel == depsLevelJar:
+		if d.Entry.Artifact != "" {
+			return fmt.Sprintf("%s -> %s (%s)", d.From, d.To, d.Entry.Artifact)
+		}
+		return fmt.Sprintf("%s -> %s", d.From, d.To)
+	}
+	via := relativeToCwd(d.Entry.Path)
+	if d.Entry.Artifact != "" {
+		via += ", " + d.Entry.Artifact
+	}
+	return fmt.Sprintf("%s -> %s (via %s)", d.From, d.To, via)
+}
@@ -2,114 +2,55 @@ package main
 
 import (
 	"fmt"
-	"io/fs"
 	"os"
-	"path/filepath"
-	"sync"
 
 	"github.com/mattn/go-isatty"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
-	"github.com/tsatke/jt"
-	classpath2 "github.com/tsatke/jt/classpath"
-	"github.com/tsatke/jt/jar"
+	"github.com/tsatke/jt/workspace"
 )
 
 func runFind(cmd *cobra.Command, args []string) {
 	searchClass := args[0]
 
-	project := loadProject(cwd())
-
-	// search project and classpath concurrently, since building the classpath takes time
-	wg := &sync.WaitGroup{}
-	wg.Add(3)
-	// search classpath
-	classpathResults := make(chan string, 5)
-	go func(result chan<- string) {
-		defer wg.Done()
-		defer close(result)
-
-		if flagFindNoClasspath {
-			// skip even building the classpath if nocp is enabled
-			return
-		}
-
-		cp, err := project.Classpath()
-		if err != nil {
-			log.Fatal().
-				Err(err).
-				Str("project", project.Name()).
-				Msg("get classpath")
-		}
-
-		for _, entry := range cp.Entries {
-			if entry.Type != classpath2.EntryTypeJar {
-				continue
-			}
-			jar, err := jar.Open(entry.Path)
-			if err != nil {
-				_, _ = fmt.Fprintln(os.Stderr, err)
-				continue
-			}
-
-			for _, path := range jar.ListClasses() {
-				if jt.ClassNameMatches(path, searchClass) {
-					result <- fmt.Sprintf("%s (via %s)", path, entry.Path)
-				}
-			}
-
-			_ = jar.Close()
-		}
-	}(classpathResults)
-	// search project files
-	projectResults := make(chan string, 5)
-	go func(result chan<- string) {
-		defer wg.Done()
-		defer close(result)
-
-		if err := fs.WalkDir(os.DirFS("."), ".", func(path string, d fs.DirEntry, err error) error {
-			if err != nil {
-				return err
-			}
-
-			if d.IsDir() {
-				return nil
-			}
-
-			if filepath.Ext(path) != ".java" {
-				return nil
-			}
-
-			if jt.ClassNameMatches(path, searchClass) {
-				result <- path
-			}
-			return nil
-		}); err != nil {
-			_, _ = fmt.Fprintln(os.Stderr, err)
-		}
-	}(projectResults)
-	// print results
-	go func(prjRes, cpRes <-chan string) {
-		defer wg.Done()
-
-		printWithHeader := func(header string, data <-chan string) {
-			// only print headers in terminals
-			if isatty.IsTerminal(os.Stdout.Fd()) {
-				fmt.Println(header)
-			}
-			for {
-				res := <-data
-				if res == "" {
-					break
-				}
-				fmt.Println(res)
-			}
+	out := newPrinter()
+	defer out.Close()
+
+	// only print headers in terminals
+	headers := out.Text() && isatty.IsTerminal(os.Stdout.Fd())
+	if headers {
+		fmt.Println("Project results:")
+	}
+	classpathHeader := headers && !flagFindNoClasspath
+	err := openWorkspace().Find(searchClass, flagFindNoClasspath, func(class *workspace.Class) {
+		// classes in the sources of the project are found first
+		if class.File == "" && classpathHeader {
+			fmt.Println("Classpath results:")
+			classpathHeader = false
 		}
+		out.Print(formatFound(class), class)
+	})
+	if classpathHeader {
+		fmt.Println("Classpath results:")
+	}
+	if err != nil {
+		out.Close()
+		log.Fatal().
+			Err(err).
+			Str("class", searchClass).
+			Msg("find class")
+	}
+}
 
-		printWithHeader("Project results:", prjRes)
-		if !flagFindNoClasspath {
-			printWithHeader("Classpath results:", cpRes)
-		}
-	}(projectResults, classpathResults)
-	wg.Wait()
+// formatFound returns the source file of a class that was found in the project,
+// or its name followed by the classpath entry that contains it.
+func formatFound(class *workspace.Class) string {
+	if class.File != "" {
+		return relativeToCwd(class.File)
+	}
+	location := class.Entry.Path
+	if class.Entry.Artifact != "" {
+		location += ", " + class.Entry.Artifact
+	}
+	return fmt.Sprintf("%s (via %s)", class.Name, location)
 }
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+func runJavap(cmd *cobra.Command, args []string) {
+	classname := args[0]
+
+	d, err := openWorkspace().Javap(classname)
+	if err != nil {
+		log.Fatal().
+			Err(err).
+			Str("class", classname).
+			Msg("open class")
+	}
+
+	out := newPrinter()
+	defer out.Close()
+	if !out.Text() {
+		out.Print("", d)
+		return
+	}
+	fmt.Println(d.Declaration + " {")
+	for _, member := range d.Members {
+		fmt.Println("  " + member + ";")
+	}
+	fmt.Println("}")
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/tsatke/jt"
+	"github.com/tsatke/jt/jdk"
+)
+
+func runJdks(cmd *cobra.Command, args []string) {
+	jdks := jdk.Discover()
+
+	// mark the JDK of the project in the current directory, if there is one
+	var selected *jdk.JDK
+	if project, err := jt.LoadProject(cwd()); err == nil {
+		selected = project.JDK()
+	}
+	if selected != nil && !containsJdk(jdks, selected) {
+		selected.Source = "project"
+		jdks = append(jdks, selected)
+	}
+
+	out := newPrinter()
+	defer out.Close()
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	for _, j := range jdks {
+		isSelected := selected != nil && j.Home == selected.Home
+		if !out.Text() {
+			out.Print("", &jdkRecord{
+				Version:  j.Version,
+				Vendor:   j.Vendor,
+				Home:     j.Home,
+				Source:   j.Source,
+				Selected: isSelected,
+			})
+			continue
+		}
+		marker := " "
+		if isSelected {
+			marker = "*"
+		}
+		_, _ = fmt.Fprintf(w, "%s %s\t%s\t%s\t%s\n", marker, j.Version, j.Vendor, j.Home, j.Source)
+	}
+	_ = w.Flush()
+}
+
+// jdkRecord describes an installed JDK.
+type jdkRecord struct {
+	Version string `json:"version"`
+	Vendor  string `json:"vendor,omitempty"`
+	Home    string `json:"home"`
+	Source  string `json:"source"`
+	// Selected is true for the JDK of the project in the current directory.
+	Selected bool `json:"selected"`
+}
+
+func containsJdk(jdks []*jdk.JDK, j *jdk.JDK) bool {
+	for _, other := range jdks {
+		if other.Home == j.Home {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"github.com/tsatke/jt/classpath"
+	"github.com/tsatke/jt/linkage"
+	"github.com/tsatke/jt/workspace"
+)
+
+// linkageRecord is a reference that fails to link.
+type linkageRecord struct {
+	Class string `json:"class"`
+	// Member is the method with its descriptor that contains the reference.
+	Member string `json:"member,omitempty"`
+	Line   int    `json:"line,omitempty"`
+	// Error is the error that the JVM throws, such as NoSuchMethodError.
+	Error     string           `json:"error"`
+	Reference string           `json:"reference"`
+	Message   string           `json:"message,omitempty"`
+	Entry     *workspace.Entry `json:"entry"`
+}
+
+func runLinkcheck(cmd *cobra.Command, args []string) {
+	project := loadProject(cwd())
+	cp := projectClasspath(project)
+
+	// the JDK is not checked, even with --all
+	skip := func(entry *classpath.Entry) bool {
+		return entry.Type != classpath.EntryTypeOutput && (!flagLinkcheckAll || entry.Type != classpath.EntryTypeJar)
+	}
+
+	out := newPrinter()
+	defer out.Close()
+	entries := newEntryRecords(cp)
+	problems := 0
+	if err := linkage.Check(cp, skip, func(p *linkage.Problem) {
+		problems++
+		record := &linkageRecord{
+			Class:     p.Class,
+			Member:    p.Member,
+			Line:      p.Line,
+			Error:     p.Error,
+			Reference: p.Reference,
+			Message:   p.Message,
+			Entry:     entries.Get(p.Entry),
+		}
+		out.Print(formatLinkage(record), record)
+	}); err != nil {
+		out.Close()
+		log.Fatal().
+			Err(err).
+			Msg("check linkage")
+	}
+	if problems > 0 {
+		out.Close()
+		os.Exit(1)
+	}
+}
+
+// formatLinkage formats a problem like com/example/App#run()V:42 NoSuchMethodError
+// com/lib/Api#send(I)V (via target/classes), followed by the message if there is one.
+func formatLinkage(p *linkageRecord) string {
+	location := p.Class
+	if p.Member != "" {
+		location += "#" + p.Member
+	}
+	if p.Line > 0 {
+		location += ":" + strconv.Itoa(p.Line)
+	}
+	via := relativeToCwd(p.Entry.Path)
+	if p.Entry.Artifact != "" {
+		via += ", " + p.Entry.Artifact
+	}
+	text := fmt.Sprintf("%s %s %s", location, p.Error, p.Reference)
+	if p.Message != "" {
+		text += ": " + p.Message
+	}
+	return text + " (via " + via + ")"
+}
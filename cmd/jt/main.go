@@ -4,12 +4,16 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/pkg/profile"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
 	"github.com/tsatke/jt"
+	"github.com/tsatke/jt/classpath"
+	"github.com/tsatke/jt/daemon"
 )
 
 var (
@@ -55,17 +59,338 @@ If not run in a terminal (for example if the output is piped), then NO headers w
 		Args: cobra.ExactArgs(1),
 	}
 
-	classpath = &cobra.Command{
+	classpathCmd = &cobra.Command{
 		Use:     "classpath",
 		Aliases: []string{"cp"},
 		Short:   "Prints the classpath of the current project",
-		Run:     runClasspath,
+		Long: `Prints the entries of the classpath of the project in the current directory. In a terminal, jars
+are printed with their Maven coordinates.
+
+With --verify, the jars from a local Maven repository are compared to the checksum files next to them
+(.sha1, .sha256, .sha512 and .md5), and the _remote.repositories file tells whether they were downloaded
+or installed locally. The exit code is 1 if a jar doesn't match its checksums.`,
+		Run:  runClasspath,
+		Args: cobra.NoArgs,
+	}
+
+	which = &cobra.Command{
+		Use:   "which",
+		Short: "Prints the classpath entry that a class is loaded from",
+		Long: `Prints the first entry on the classpath of the project in the current directory that contains
+the given class. If that entry is a multi-release jar, the versioned variants of the class are printed
+as well, and the variant that is used for the release (see --release) is marked with a '*'.`,
+		Run:  runWhich,
+		Args: cobra.ExactArgs(1),
+	}
+
+	javap = &cobra.Command{
+		Use:   "javap",
+		Short: "Prints the declarations of a class and its members",
+		Long: `Prints the declaration of the given class as it is loaded from the classpath of the project in the
+current directory, and the declarations of its fields and methods that are not private, with generic
+types, like javap does without options.`,
+		Example: `jt javap java/util/ArrayList`,
+		Run:     runJavap,
+		Args:    cobra.ExactArgs(1),
+	}
+
+	resources = &cobra.Command{
+		Use:   "resources",
+		Short: "Prints the resources on the classpath that match an optional filter",
+		Long: `Prints all resources (files that are not classes) on the classpath of the project in the current
+directory, together with the classpath entry that contains them. The optional argument is a regex
+pattern that the resource names have to match.`,
+		Example: `Find out which jar provides logback.xml
+jt resources '^logback.xml$'`,
+		Run:  runResources,
+		Args: cobra.RangeArgs(0, 1),
+	}
+
+	cat = &cobra.Command{
+		Use:   "cat",
+		Short: "Prints the content of a resource on the classpath",
+		Long: `Prints the content of the given resource, such as META-INF/spring.factories. Just like
+ClassLoader.getResource, the resource is read from the first classpath entry that contains it.`,
+		Run:  runCat,
+		Args: cobra.ExactArgs(1),
+	}
+
+	grep = &cobra.Command{
+		Use:   "grep",
+		Short: "Searches the content of resources on the classpath",
+		Long: `Prints all lines of resources on the classpath that match the given regex, prefixed with the
+location of the resource and the line number. The optional second argument is a regex pattern that
+the resource names have to match. Binary resources are skipped.`,
+		Example: `Find spring.factories files that mention a class
+jt grep 'MyAutoConfiguration' 'spring.factories$'`,
+		Run:  runGrep,
+		Args: cobra.RangeArgs(1, 2),
+	}
+
+	services = &cobra.Command{
+		Use:   "services",
+		Short: "Prints the service providers on the classpath and checks them",
+		Long: `Prints the providers of services that are declared on the classpath of the project in the current
+directory, grouped by service. Providers are declared in META-INF/services files, in provides directives
+of module declarations, in META-INF/spring.factories and in META-INF/spring/*.imports files.
+
+Every provider is checked to exist on the classpath and to implement the service. Problems are
+marked with [missing], [not a subtype] or [unknown], the latter if the hierarchy of the provider
+is incomplete. If there are problems, the exit code is 1. The optional argument restricts the
+output to the given service.`,
+		Example: `Check the JDBC drivers
+jt services java.sql.Driver
+
+Only print problems
+jt services --problems`,
+		Run:  runServices,
+		Args: cobra.RangeArgs(0, 1),
+	}
+
+	provides = &cobra.Command{
+		Use:   "provides",
+		Short: "Prints the artifacts in the local repository that contain a class",
+		Long: `Prints the jars in the local Maven repository (~/.m2/repository) and the Gradle cache that contain the
+given class, together with their Maven coordinates. This tells you which dependency to add when a class
+is missing, without network access. If the class is given without a package, all classes with that
+simple name are found.
+
+The classes of all jars are kept in an index in the user cache directory. Only jars that were added
+or changed since the last run are read, so the first run takes longer than the following ones.`,
+		Example: `Find the artifact that contains a class
+jt provides org.apache.commons.lang3.StringUtils
+
+Search in other directories of jars
+jt provides --repository /opt/libs StringUtils`,
+		Run:  runProvides,
+		Args: cobra.ExactArgs(1),
+	}
+
+	sbomCmd = &cobra.Command{
+		Use:   "sbom",
+		Short: "Prints a software bill of materials of the classpath",
+		Long: `Prints a software bill of materials (SBOM) of the jars on the classpath of the project in the current
+directory as a CycloneDX or SPDX JSON document. For every jar, including jars nested in fat jars, the document
+contains its Maven coordinates, its SHA-256 checksum and the licenses declared in its pom.xml, its manifest
+and its license files. No network access is needed. Folders and the JDK are not included.
+
+The document is always JSON, --sbom-format selects between CycloneDX and SPDX, and the global --format
+is not supported.`,
+		Example: `jt sbom --scope runtime > bom.json
+jt sbom --sbom-format spdx > bom.spdx.json`,
+		Run:  runSBOM,
+		Args: cobra.NoArgs,
+	}
+
+	verify = &cobra.Command{
+		Use:   "verify",
+		Short: "Verifies the signatures of a jar",
+		Long: `Verifies the signatures of the given jar like jarsigner -verify does, without the need for a JDK.
+For every signer, the certificate chain is printed, and whether the signature is valid and the chain is
+trusted by the system roots. Then, all entries that are unsigned, tampered with (their content doesn't
+match the digest in the manifest), or missing (listed in the manifest, but not in the jar) are printed.
+
+The exit code is 1 if the jar is not signed, or if any entry is unsigned, tampered with or missing.`,
+		Example: `jt verify vendor-lib-1.0.jar`,
+		Run:     runVerify,
+		Args:    cobra.ExactArgs(1),
+	}
+
+	serve = &cobra.Command{
+		Use:   "serve",
+		Short: "Runs a daemon that keeps the classpaths of projects in memory",
+		Long: `Runs a daemon that listens on a Unix socket (see --socket) and answers the questions of find, which,
+superclass and subclass for any project. The classpath of a project is built when the daemon is first asked
+about it and then kept in memory, together with the locations of all classes. The daemon watches pom.xml,
+.classpath and the other project files, as well as the entries of the classpath, and builds the classpath
+again when they change.
+
+While the daemon is running, these commands ask it instead of building the classpath themselves, unless
+--no-daemon is given. Other programs, such as editor integrations, can call the daemon with JSON-RPC 1.0,
+see the README.`,
+		Example: `jt serve &
+jt find MyClass`,
+		Run:  runServe,
+		Args: cobra.NoArgs,
+	}
+
+	jdks = &cobra.Command{
+		Use:   "jdks",
+		Short: "Prints a list of all installed JDKs",
+		Long: `Prints a list of all JDKs that are installed in well known locations, such as JAVA_HOME,
+/usr/lib/jvm, SDKMAN, asdf or ~/.jdks. The JDK that is used for the project in the current
+directory is marked with a '*'.`,
+		Run:  runJdks,
+		Args: cobra.NoArgs,
+	}
+
+	usages = &cobra.Command{
+		Use:   "usages",
+		Short: "Prints the places that use a class, field or method",
+		Long: `Prints every place in the classes on the classpath of the project in the current directory that
+uses the given class, field or method, with the referencing method and source line where the bytecode
+tells them. A class is used by instructions like new, checkcast or invokevirtual, by extending or
+implementing it, and in the types of fields and the signatures of methods. A member is given as
+class#name, optionally followed by the descriptor of a method. References through subclasses that
+inherit the member count as usages as well.
+
+With --project, only the output folders of the project are searched, which is much faster than
+searching all jars.`,
+		Example: `Find all callers of a method before removing it
+jt usages 'com.example.Api#send(Ljava/lang/String;)V' --project
+
+Find all users of a class in the dependencies
+jt usages org/slf4j/impl/StaticLoggerBinder`,
+		Run:  runUsages,
+		Args: cobra.ExactArgs(1),
+	}
+
+	callers = &cobra.Command{
+		Use:   "callers",
+		Short: "Prints the methods that call a method",
+		Long: `Prints the methods in the classes on the classpath of the project in the current directory that call
+the given method, as a tree of the callers of the callers up to --depth, where 0 means no limit. The
+method is given as class#name, optionally followed by its descriptor, otherwise all overloads are used.
+
+Calls of virtual and interface methods are resolved with class hierarchy analysis: a call reaches the
+method in every subclass of the referenced class that can be selected at runtime, so callers of an
+implementation include the callers of the interface method. Methods called by lambdas and method
+references count as called by the method that creates them. With --dot, the calls are printed as a
+graph for Graphviz instead.`,
+		Example: `jt callers 'com.example.Api#send(Ljava/lang/String;)V' --depth 3
+jt callers com/example/Api#send --depth 0 --dot | dot -Tsvg > callers.svg`,
+		Run:  runCallers,
+		Args: cobra.ExactArgs(1),
+	}
+
+	callees = &cobra.Command{
+		Use:   "callees",
+		Short: "Prints the methods that a method calls",
+		Long: `Prints the methods that the given method calls, as a tree of the methods that these call up to
+--depth, where 0 means no limit. The method is given as class#name, optionally followed by its
+descriptor. Calls of virtual and interface methods are resolved with class hierarchy analysis across
+the whole classpath of the project in the current directory, so a call of an interface method lists
+every implementation. With --dot, the calls are printed as a graph for Graphviz instead.`,
+		Example: `jt callees com.example.App#main --depth 2`,
+		Run:     runCallees,
+		Args:    cobra.ExactArgs(1),
+	}
+
+	deps = &cobra.Command{
+		Use:   "deps [jar or directory]",
+		Short: "Prints the classes, packages or jars that compiled classes depend on",
+		Long: `Prints the dependencies of the classes in the given jar or directory, or in the output folders of the
+project in the current directory if none is given, like jdeps does, but resolved on the classpath of the
+project. A class depends on every class that its constant pool references, on the classes in the
+descriptors and generic signatures of its fields and methods, and on the classes in its annotations.
+--level selects whether dependencies are printed between classes, packages or jars; dependencies within
+the same package or jar are left out.
+
+With --missing, only the referenced classes that are not on the classpath are printed, which at runtime
+cause a NoClassDefFoundError when they are used. The exit code is 1 if there are any.`,
+		Example: `jt deps --level jar
+jt deps target/app.jar --level class --missing`,
+		Run:  runDeps,
+		Args: cobra.MaximumNArgs(1),
+	}
+
+	unusedDeps = &cobra.Command{
+		Use:   "unused-deps",
+		Short: "Prints the jars of the classpath that the project doesn't use directly",
+		Long: `Follows the class references of the classes in the output folders of the project in the current
+directory through the jars of the classpath, and prints the jars from which no class is reached, and the
+jars that are only reached through other jars, which should be declared where they are used or not at
+all. Jars whose classes the project only uses in annotations, or only names in string constants like
+those passed to Class.forName, are printed separately, since tools that only follow code references
+report them as unused. The exit code is 1 if there are unused jars or jars that are only used through
+other jars.`,
+		Example: `jt unused-deps --scope runtime`,
+		Run:     runUnusedDeps,
 		Args:    cobra.NoArgs,
 	}
 
+	linkcheck = &cobra.Command{
+		Use:   "linkcheck",
+		Short: "Checks that the references of compiled classes resolve on the classpath",
+		Long: `Checks every class, field and method that the classes in the output folders of the project in the
+current directory reference, and prints the references that would fail to link at runtime with the
+classpath of the project: missing classes (NoClassDefFoundError), missing fields and methods or ones with
+another descriptor (NoSuchFieldError, NoSuchMethodError), static members used as instance members and the
+other way around, or classes used as interfaces (IncompatibleClassChangeError), members that are not
+accessible (IllegalAccessError), and abstract methods that a class doesn't implement (AbstractMethodError).
+Every problem is printed with the method and line that contain the reference.
+
+With --all, the jars on the classpath are checked as well, which finds dependencies that were compiled
+against another version of a library than the one on the classpath. The JDK is never checked.
+The exit code is 1 if there are any problems.`,
+		Example: `Check the project after upgrading a dependency
+jt linkcheck --scope runtime`,
+		Run:  runLinkcheck,
+		Args: cobra.NoArgs,
+	}
+
+	compatCmd = &cobra.Command{
+		Use:   "compat <old jar> <new jar>",
+		Short: "Compares the API of two versions of a library",
+		Long: `Compares the public and protected API of two versions of a library, given as jars or directories of
+classes: removed and added classes and members, changed descriptors, reduced visibility, changed final,
+abstract and static modifiers, changed superclasses and interfaces, and changed constant values. Every
+change is classified by chapter 13 of the Java Language Specification as source incompatible, if code
+that compiles against the old version may not compile against the new one, and as binary incompatible,
+if code that was compiled against the old version may fail to link or run with the new one. Constants
+are inlined by the compiler, so code compiled against the old version keeps using the old value.
+
+With --incompatible, only incompatible changes are printed. The exit code is 1 if there are any.`,
+		Example: `jt compat mylib-1.0.jar mylib-1.1.jar --incompatible`,
+		Run:     runCompat,
+		Args:    cobra.ExactArgs(2),
+	}
+
+	apiCmd = &cobra.Command{
+		Use:   "api",
+		Short: "Writes or checks a snapshot of the public API of the project",
+		Long: `Describes the public API of the output folders of the project as a sorted text file with one line per
+public class and per public or protected field and method, with generic signatures, constant values and
+the annotations whose types are annotated with @Documented, like @Deprecated. Commit the file with jt api
+dump, and jt api check compares it with the current build, so that every change of the API shows up in
+the diff of a change. The API is read from the compile scope regardless of --scope, so that test classes
+are not part of it.`,
+		Example: `jt api dump --file api.txt
+jt api check --file api.txt`,
+	}
+
+	apiDump = &cobra.Command{
+		Use:   "dump",
+		Short: "Writes the public API of the project to the API file",
+		Run:   runAPIDump,
+		Args:  cobra.NoArgs,
+	}
+
+	apiCheck = &cobra.Command{
+		Use:   "check",
+		Short: "Compares the public API of the project with the API file",
+		Long: `Compares the public API of the output folders of the project with the API file written by jt api dump
+and prints the lines that were removed, prefixed with -, and added, prefixed with +. The exit code is 1
+if there are any.`,
+		Run:  runAPICheck,
+		Args: cobra.NoArgs,
+	}
+
+	shellCmd = &cobra.Command{
+		Use:   "shell",
+		Short: "Start an interactive prompt that answers questions from a classpath that is loaded once",
+		Long: `Starts a prompt that loads the classpath of the project in the current directory once and then answers
+find, which, superclass, subclass, classes and classpath like the commands with the same names. cd changes
+into a package and ls lists its subpackages and classes, and classes can be given relative to the current
+package. Class names, packages and commands are completed with Tab, and the history is kept in the user
+cache directory. Type help for all commands and exit or Ctrl-D to leave.`,
+		Run:  runShell,
+		Args: cobra.NoArgs,
+	}
+
 	classes = &cobra.Command{
 		Use:   "classes",
-		Short: "Prints a list of all classes contained in the given jar file",
+		Short: "Prints a list of all classes contained in the given jar, jmod or jimage file",
 		Run:   runClasses,
 		Args:  cobra.ExactArgs(1),
 	}
@@ -77,20 +402,91 @@ var (
 	trace   bool
 	prof    bool
 
-	flagFindNoClasspath bool
-	flagSubclassInvert  bool
+	flagScope   string
+	flagRelease int
+	flagFormat  string
+
+	flagSocket   string
+	flagNoDaemon bool
+
+	flagFindNoClasspath  bool
+	flagSubclassInvert   bool
+	flagClassesVersions  bool
+	flagServicesProblems bool
+
+	flagProvidesRepositories []string
+	flagProvidesReindex      bool
+
+	flagSBOMFormat string
+
+	flagVerifyEntries bool
+
+	flagClasspathVerify bool
+
+	flagServeInterval time.Duration
+
+	flagUsagesProject bool
+
+	flagCallsDepth int
+	flagCallsDot   bool
+
+	flagDepsLevel   string
+	flagDepsMissing bool
+
+	flagLinkcheckAll bool
+
+	flagCompatIncompatible bool
+
+	flagAPIFile string
 )
 
 func init() {
-	root.AddCommand(superclass, subclass, find, classpath, classes)
+	root.AddCommand(superclass, subclass, find, which, javap, classpathCmd, classes, resources, cat, grep, services, provides, sbomCmd, verify, serve, shellCmd, usages, callers, callees, deps, unusedDeps, linkcheck, compatCmd, apiCmd, jdks)
+	apiCmd.AddCommand(apiDump, apiCheck)
 
 	root.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "print debug output")
 	root.PersistentFlags().BoolVar(&prof, "prof", false, "create a cpu profile of the run")
 	root.PersistentFlags().BoolVar(&trace, "trace", false, "print more debug output")
 	_ = root.PersistentFlags().MarkHidden("trace")
+	root.PersistentFlags().StringVar(&flagScope, "scope", "test", "the scope of the project classpath, one of compile, runtime, test or provided")
+	root.PersistentFlags().IntVar(&flagRelease, "release", 0, "the java release for which multi-release jars are resolved, defaults to the release of the project JDK")
+	root.PersistentFlags().StringVar(&flagFormat, "format", formatText, "the output format, one of text, json or ndjson")
+	root.PersistentFlags().StringVar(&flagSocket, "socket", daemon.DefaultSocket(), "the socket of jt serve")
+	root.PersistentFlags().BoolVar(&flagNoDaemon, "no-daemon", false, "don't ask a running jt serve, but load the project")
 
 	find.PersistentFlags().BoolVar(&flagFindNoClasspath, "no-classpath", false, "disable searching on the whole classpath and only search in the project")
 
+	classes.PersistentFlags().BoolVar(&flagClassesVersions, "versions", false, "print the versioned variants of classes in multi-release jars")
+
+	services.PersistentFlags().BoolVar(&flagServicesProblems, "problems", false, "only print providers that are missing or don't implement the service")
+
+	provides.PersistentFlags().StringSliceVar(&flagProvidesRepositories, "repository", nil, "directories of jars to search instead of the local Maven repository and the Gradle cache")
+	provides.PersistentFlags().BoolVar(&flagProvidesReindex, "reindex", false, "rebuild the index from scratch")
+
+	sbomCmd.PersistentFlags().StringVar(&flagSBOMFormat, "sbom-format", "cyclonedx", "the format of the document, one of cyclonedx or spdx")
+
+	verify.PersistentFlags().BoolVar(&flagVerifyEntries, "entries", false, "print the signed entries as well")
+
+	classpathCmd.PersistentFlags().BoolVar(&flagClasspathVerify, "verify", false, "verify the checksums of jars from a local Maven repository")
+
+	serve.PersistentFlags().DurationVar(&flagServeInterval, "interval", 2*time.Second, "the interval in which changes to project files and jars are checked")
+
+	usages.PersistentFlags().BoolVar(&flagUsagesProject, "project", false, "only search the output folders of the project")
+
+	for _, command := range []*cobra.Command{callers, callees} {
+		command.PersistentFlags().IntVar(&flagCallsDepth, "depth", 1, "the depth of the printed calls, 0 for no limit")
+		command.PersistentFlags().BoolVar(&flagCallsDot, "dot", false, "print the calls as a graph in DOT format")
+	}
+
+	deps.PersistentFlags().StringVar(&flagDepsLevel, "level", depsLevelPackage, "the level of the dependencies, one of class, package or jar")
+	deps.PersistentFlags().BoolVar(&flagDepsMissing, "missing", false, "only print dependencies on classes that are not on the classpath")
+
+	linkcheck.PersistentFlags().BoolVar(&flagLinkcheckAll, "all", false, "check the jars on the classpath as well")
+
+	compatCmd.PersistentFlags().BoolVar(&flagCompatIncompatible, "incompatible", false, "only print source or binary incompatible changes")
+
+	apiCmd.PersistentFlags().StringVar(&flagAPIFile, "file", "api.txt", "the API file")
+
 	subclass.PersistentFlags().BoolVar(&flagSubclassInvert, "invert", false, "invert the matching, considering all classes that don't match the pattern")
 }
 
@@ -134,6 +530,32 @@ func loadProject(path string) jt.Project {
 	return project
 }
 
+// projectClasspath returns the classpath of the given project for the scope and release
+// that are selected with the command line flags.
+func projectClasspath(project jt.Project) *classpath.Classpath {
+	cp, err := project.Classpath(scope())
+	if err != nil {
+		log.Fatal().
+			Err(err).
+			Str("project", project.Name()).
+			Msg("get classpath")
+	}
+	if flagRelease > 0 {
+		cp.Release = flagRelease
+	}
+	return cp
+}
+
+func scope() classpath.Scope {
+	scope, err := classpath.ParseScope(flagScope)
+	if err != nil {
+		log.Fatal().
+			Err(err).
+			Msg("parse scope")
+	}
+	return scope
+}
+
 func cwd() string {
 	cwd, err := filepath.Abs(".")
 	if err != nil {
@@ -143,3 +565,13 @@ func cwd() string {
 	}
 	return cwd
 }
+
+// relativeToCwd returns the given path relative to the current working directory,
+// or the path itself if it is not located in the current working directory.
+func relativeToCwd(path string) string {
+	rel, err := filepath.Rel(cwd(), path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return path
+	}
+	return rel
+}
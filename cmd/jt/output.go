@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+)
+
+// output formats, see --format
+const (
+	formatText   = "text"
+	formatJSON   = "json"
+	formatNDJSON = "ndjson"
+)
+
+// printer prints the results of a command in the format that is selected with --format.
+// In text format, results are printed as lines for humans. In ndjson format, every result is
+// printed as a JSON object on its own line as soon as it is available. In json format, all
+// results are printed as one JSON array when the printer is closed. The records of the
+// commands are documented in the README.
+type printer struct {
+	mu      sync.Mutex
+	format  string
+	records []interface{}
+	closed  bool
+}
+
+func newPrinter() *printer {
+	switch flagFormat {
+	case formatText, formatJSON, formatNDJSON:
+	default:
+		log.Fatal().
+			Str("format", flagFormat).
+			Msg("unknown format, must be text, json or ndjson")
+	}
+	return &printer{format: flagFormat}
+}
+
+// Text returns whether results are printed for humans. Commands use it to skip
+// collecting information that only the records contain.
+func (p *printer) Text() bool {
+	return p.format == formatText
+}
+
+// Print prints a result, which is the given text in text format and the record otherwise.
+// It is safe to call Print from multiple goroutines.
+func (p *printer) Print(text string, record interface{}) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	switch p.format {
+	case formatText:
+		fmt.Println(text)
+	case formatNDJSON:
+		p.encode(record)
+	default:
+		p.records = append(p.records, record)
+	}
+}
+
+// Close prints the collected records in json format. It must be called before the command
+// exits, even if it exits with a non-zero exit code. Calling it more than once has no effect.
+func (p *printer) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.format != formatJSON || p.closed {
+		return
+	}
+	p.closed = true
+	if p.records == nil {
+		// print an empty array instead of null
+		p.records = []interface{}{}
+	}
+	p.encode(p.records)
+}
+
+func (p *printer) encode(v interface{}) {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetEscapeHTML(false)
+	if p.format == formatJSON {
+		enc.SetIndent("", "  ")
+	}
+	if err := enc.Encode(v); err != nil {
+		log.Fatal().
+			Err(err).
+			Msg("write output")
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"github.com/tsatke/jt/classpath"
+	"github.com/tsatke/jt/index"
+	"github.com/tsatke/jt/workspace"
+)
+
+func runProvides(cmd *cobra.Command, args []string) {
+	names := workspace.InternalNames(args[0])
+
+	path, err := index.DefaultPath()
+	if err != nil {
+		log.Fatal().
+			Err(err).
+			Msg("locate index")
+	}
+
+	idx := index.New()
+	if !flagProvidesReindex {
+		idx, err = index.Load(path)
+		if err != nil {
+			log.Fatal().
+				Err(err).
+				Str("index", path).
+				Msg("load index")
+		}
+	}
+
+	roots := unique(flagProvidesRepositories)
+	if len(roots) == 0 {
+		roots = index.DefaultRoots()
+	}
+	stats, err := idx.Update(roots)
+	if err != nil {
+		log.Fatal().
+			Err(err).
+			Strs("repositories", roots).
+			Msg("update index")
+	}
+	log.Debug().
+		Int("added", stats.Added).
+		Int("updated", stats.Updated).
+		Int("removed", stats.Removed).
+		Int("unchanged", stats.Unchanged).
+		Msg("update index")
+	if stats.Added+stats.Updated+stats.Removed > 0 || flagProvidesReindex {
+		if err := idx.Save(path); err != nil {
+			log.Fatal().
+				Err(err).
+				Str("index", path).
+				Msg("save index")
+		}
+	}
+
+	// nested classes may be given with dots, like a.b.Outer.Inner
+	var name string
+	var jars []*index.Jar
+	for _, name = range names {
+		if jars = idx.Lookup(name, roots); len(jars) > 0 {
+			break
+		}
+	}
+	out := newPrinter()
+	if len(jars) == 0 {
+		out.Close()
+		_, _ = fmt.Fprintf(os.Stderr, "no jar in %s provides %s\n", strings.Join(roots, ", "), names[0])
+		os.Exit(1)
+	}
+	for _, j := range jars {
+		location := j.Path
+		if j.Artifact != "" {
+			location += ", " + j.Artifact
+		}
+		entry := &workspace.Entry{Path: j.Path, Type: classpath.EntryTypeJar.String(), Artifact: j.Artifact}
+		for _, class := range j.Matches(name) {
+			out.Print(fmt.Sprintf("%s (via %s)", class, location), workspace.NewClass(class, entry))
+		}
+	}
+	out.Close()
+}
+
+// unique returns the given values without duplicates, keeping their order. Flags that can be
+// given multiple times need this, because flags are parsed twice (see main).
+func unique(values []string) []string {
+	seen := make(map[string]struct{}, len(values))
+	var result []string
+	for _, v := range values {
+		if _, ok := seen[v]; !ok {
+			seen[v] = struct{}{}
+			result = append(result, v)
+		}
+	}
+	return result
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"unicode/utf8"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"github.com/tsatke/jt/classpath"
+	"github.com/tsatke/jt/workspace"
+)
+
+func runResources(cmd *cobra.Command, args []string) {
+	pattern := compilePattern(args, 0)
+
+	project := loadProject(cwd())
+	cp := projectClasspath(project)
+
+	out := newPrinter()
+	defer out.Close()
+	cp.WalkArchives(nil, func(entry *classpath.Entry, archive classpath.Archive) {
+		var record *workspace.Entry
+		for _, name := range archive.ListResources() {
+			if !pattern.MatchString(name) {
+				continue
+			}
+			if record == nil && !out.Text() {
+				record = workspace.NewEntry(entry, workspace.Artifact(entry, archive))
+			}
+			out.Print(fmt.Sprintf("%s (via %s)", name, entry.Path), &resourceRecord{
+				Name:  name,
+				Path:  resourcePath(entry, name),
+				Entry: record,
+			})
+		}
+	})
+}
+
+// resourceRecord describes a resource on the classpath.
+type resourceRecord struct {
+	// Name is the name of the resource, such as META-INF/MANIFEST.MF.
+	Name string `json:"name"`
+	// Path is the location of the resource, such as lib/foo.jar!/META-INF/MANIFEST.MF.
+	Path  string           `json:"path"`
+	Entry *workspace.Entry `json:"entry"`
+}
+
+// contentRecord is the content of a resource.
+type contentRecord struct {
+	resourceRecord
+	// Content is the content of the resource. Resources that are not valid UTF-8 are base64 encoded.
+	Content string `json:"content"`
+	// Encoding is base64 if the content is base64 encoded.
+	Encoding string `json:"encoding,omitempty"`
+}
+
+// matchRecord is a line of a resource that matches the regex of jt grep.
+type matchRecord struct {
+	resourceRecord
+	Line int    `json:"line"`
+	Text string `json:"text"`
+}
+
+func runCat(cmd *cobra.Command, args []string) {
+	name := args[0]
+
+	project := loadProject(cwd())
+	cp := projectClasspath(project)
+
+	rc, entry, err := cp.OpenResource(name)
+	if err != nil {
+		log.Fatal().
+			Err(err).
+			Str("project", project.Name()).
+			Str("resource", name).
+			Msg("open resource")
+	}
+	if rc == nil {
+		log.Fatal().
+			Str("project", project.Name()).
+			Str("resource", name).
+			Msg("resource not on classpath")
+	}
+	defer func() { _ = rc.Close() }()
+
+	log.Debug().
+		Str("resource", name).
+		Str("entry", entry.Path).
+		Msg("found resource")
+
+	out := newPrinter()
+	defer out.Close()
+	if out.Text() {
+		if _, err := io.Copy(os.Stdout, rc); err != nil {
+			log.Fatal().
+				Err(err).
+				Str("resource", name).
+				Msg("read resource")
+		}
+		return
+	}
+
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		log.Fatal().
+			Err(err).
+			Str("resource", name).
+			Msg("read resource")
+	}
+	record := &contentRecord{
+		resourceRecord: resourceRecord{
+			Name:  name,
+			Path:  resourcePath(entry, name),
+			Entry: workspace.NewEntry(entry, identifyEntry(cp, entry)),
+		},
+		Content: string(data),
+	}
+	if !utf8.Valid(data) {
+		record.Content = base64.StdEncoding.EncodeToString(data)
+		record.Encoding = "base64"
+	}
+	out.Print("", record)
+}
+
+func runGrep(cmd *cobra.Command, args []string) {
+	regex, err := regexp.Compile(args[0])
+	if err != nil {
+		log.Fatal().
+			Err(err).
+			Str("regex", args[0]).
+			Msg("compile regex")
+	}
+	pattern := compilePattern(args, 1)
+
+	project := loadProject(cwd())
+	cp := projectClasspath(project)
+
+	out := newPrinter()
+	defer out.Close()
+	cp.WalkArchives(nil, func(entry *classpath.Entry, archive classpath.Archive) {
+		var record *workspace.Entry
+		for _, name := range archive.ListResources() {
+			if !pattern.MatchString(name) {
+				continue
+			}
+			if err := grepResource(archive, name, regex, func(line int, text string) {
+				if record == nil && !out.Text() {
+					record = workspace.NewEntry(entry, workspace.Artifact(entry, archive))
+				}
+				path := resourcePath(entry, name)
+				out.Print(fmt.Sprintf("%s:%d:%s", path, line, text), &matchRecord{
+					resourceRecord: resourceRecord{Name: name, Path: path, Entry: record},
+					Line:           line,
+					Text:           text,
+				})
+			}); err != nil {
+				log.Error().
+					Err(err).
+					Str("resource", name).
+					Str("entry", entry.Path).
+					Msg("grep resource")
+			}
+		}
+	})
+}
+
+// grepResource calls fn for every line of the resource that matches the regex.
+// Binary resources are skipped.
+func grepResource(archive classpath.Archive, name string, regex *regexp.Regexp, fn func(int, string)) error {
+	rc, err := archive.OpenResource(name)
+	if err != nil {
+		return fmt.Errorf("open %s: %w", name, err)
+	}
+	defer func() { _ = rc.Close() }()
+
+	rd := bufio.NewReader(rc)
+	// like grep, consider resources with a zero byte in the beginning as binary
+	head, _ := rd.Peek(512)
+	if bytes.IndexByte(head, 0) >= 0 {
+		return nil
+	}
+
+	scanner := bufio.NewScanner(rd)
+	scanner.Buffer(nil, 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if regex.Match(scanner.Bytes()) {
+			fn(line, scanner.Text())
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("read %s: %w", name, err)
+	}
+	return nil
+}
+
+// compilePattern compiles the optional regex pattern at the given index of the arguments.
+// Without the argument, the pattern matches everything.
+func compilePattern(args []string, index int) *regexp.Regexp {
+	expr := ""
+	if len(args) > index {
+		expr = args[index]
+	}
+	pattern, err := regexp.Compile(expr)
+	if err != nil {
+		log.Fatal().
+			Err(err).
+			Str("pattern", expr).
+			Msg("compile pattern")
+	}
+	return pattern
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"github.com/tsatke/jt/sbom"
+)
+
+func runSBOM(cmd *cobra.Command, args []string) {
+	if flagFormat != formatText {
+		log.Fatal().
+			Str("format", flagFormat).
+			Msg("jt sbom always prints a json document, use --sbom-format to choose between cyclonedx and spdx")
+	}
+	if flagSBOMFormat != "cyclonedx" && flagSBOMFormat != "spdx" {
+		log.Fatal().
+			Str("sbom-format", flagSBOMFormat).
+			Msg("unknown sbom format, must be cyclonedx or spdx")
+	}
+
+	project := loadProject(cwd())
+	cp := projectClasspath(project)
+
+	components := sbom.Collect(cp)
+
+	name := project.Name()
+	if name == "" {
+		name = filepath.Base(cwd())
+	}
+	document, err := sbom.New(name, components)
+	if err != nil {
+		log.Fatal().
+			Err(err).
+			Msg("create sbom")
+	}
+
+	if flagSBOMFormat == "spdx" {
+		err = document.WriteSPDX(os.Stdout)
+	} else {
+		err = document.WriteCycloneDX(os.Stdout)
+	}
+	if err != nil {
+		log.Fatal().
+			Err(err).
+			Msg("write sbom")
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"github.com/tsatke/jt/daemon"
+)
+
+func runServe(cmd *cobra.Command, args []string) {
+	// log when workspaces are loaded
+	if !verbose && !trace {
+		log.Logger = log.Logger.Level(zerolog.InfoLevel)
+	}
+
+	l, err := daemon.Listen(flagSocket)
+	if err != nil {
+		log.Fatal().
+			Err(err).
+			Str("socket", flagSocket).
+			Msg("listen")
+	}
+
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		// closing the listener removes the socket and stops serving
+		_ = l.Close()
+	}()
+
+	server := daemon.NewServer()
+	go server.Watch(ctx, flagServeInterval)
+
+	_, _ = fmt.Fprintf(os.Stderr, "listening on %s\n", flagSocket)
+	if err := server.Serve(l); err != nil {
+		log.Fatal().
+			Err(err).
+			Msg("serve")
+	}
+}
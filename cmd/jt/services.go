@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"github.com/tsatke/jt/classpath"
+	"github.com/tsatke/jt/service"
+	"github.com/tsatke/jt/workspace"
+)
+
+func runServices(cmd *cobra.Command, args []string) {
+	var filter string
+	if len(args) > 0 {
+		filter = strings.ReplaceAll(args[0], ".", "/")
+	}
+
+	project := loadProject(cwd())
+	cp := projectClasspath(project)
+
+	providers := service.Discover(cp)
+
+	cache, err := classpath.NewCache(100)
+	if err != nil {
+		log.Fatal().
+			Err(err).
+			Msg("create archive cache")
+	}
+	defer func() { _ = cache.Close() }()
+
+	// group the providers by service, keeping the order in which the services were found
+	var services []string
+	byService := make(map[string][]*service.Provider)
+	for _, p := range providers {
+		if filter != "" && p.Service != filter {
+			continue
+		}
+		if _, ok := byService[p.Service]; !ok {
+			services = append(services, p.Service)
+		}
+		byService[p.Service] = append(byService[p.Service], p)
+	}
+
+	out := newPrinter()
+	entries := newEntryRecords(cp)
+	problems := 0
+	for _, name := range services {
+		printed := false
+		for _, p := range byService[name] {
+			status, err := service.Verify(cp, p, cache)
+			if err != nil {
+				log.Error().
+					Err(err).
+					Str("service", name).
+					Str("provider", p.Class).
+					Str("entry", p.Entry.Path).
+					Msg("verify provider")
+				continue
+			}
+			if status != service.StatusOK {
+				problems++
+			} else if flagServicesProblems {
+				continue
+			}
+			// the service is printed as a header before its first provider
+			if !printed && out.Text() {
+				out.Print(name, nil)
+			}
+			printed = true
+			if out.Text() {
+				out.Print(formatProvider(p, status), nil)
+				continue
+			}
+			out.Print("", &providerRecord{
+				Service:  name,
+				Class:    p.Class,
+				Kind:     p.Kind.String(),
+				Status:   status.String(),
+				Resource: resourcePath(p.Entry, p.Resource),
+				Line:     p.Line,
+				Entry:    entries.Get(p.Entry),
+			})
+		}
+	}
+	out.Close()
+
+	if problems > 0 {
+		os.Exit(1)
+	}
+}
+
+// providerRecord describes a service provider.
+type providerRecord struct {
+	// Service and Class are the internal names of the service and the provider.
+	Service string `json:"service"`
+	Class   string `json:"class"`
+	// Kind is one of service-loader, module, spring-factories or spring-imports.
+	Kind string `json:"kind"`
+	// Status is one of ok, missing, not a subtype or unknown.
+	Status string `json:"status"`
+	// Resource is the location of the file that declares the provider, and Line the
+	// line of the declaration, if the file is a text file.
+	Resource string           `json:"resource"`
+	Line     int              `json:"line,omitempty"`
+	Entry    *workspace.Entry `json:"entry"`
+}
+
+// formatProvider formats a provider like
+// "  com/example/Impl (via lib/foo.jar!/META-INF/services/com.example.Service:2) [missing]".
+func formatProvider(p *service.Provider, status service.Status) string {
+	location := resourcePath(p.Entry, p.Resource)
+	if p.Line > 0 {
+		location += ":" + strconv.Itoa(p.Line)
+	}
+	line := fmt.Sprintf("  %s (via %s)", p.Class, location)
+	if status != service.StatusOK {
+		line += " [" + status.String() + "]"
+	}
+	return line
+}
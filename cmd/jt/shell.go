@@ -0,0 +1,428 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"github.com/tsatke/jt/internal/lineedit"
+	"github.com/tsatke/jt/workspace"
+)
+
+// shellCommand is a command of jt shell.
+type shellCommand struct {
+	usage string
+	help  string
+	run   func(s *shell, args []string) error
+}
+
+// shellCommands are the commands of jt shell by their names. It is filled in init,
+// since the help command refers to it.
+var shellCommands map[string]*shellCommand
+
+func init() {
+	shellCommands = map[string]*shellCommand{
+		"find":       {"find <name>", "find the locations of classes that match", (*shell).find},
+		"which":      {"which <class>", "print where a class is loaded from", (*shell).which},
+		"superclass": {"superclass <class>", "print the superclasses of a class", (*shell).superclass},
+		"subclass":   {"subclass <class> [regex]", "print the subclasses of a class that match an optional filter", (*shell).subclass},
+		"classes":    {"classes <file>", "print the classes of a jar, jmod or jimage file", (*shell).classes},
+		"classpath":  {"classpath", "print the classpath", (*shell).classpath},
+		"cd":         {"cd [package]", "change the current package, .. is the parent and / the root", (*shell).cd},
+		"ls":         {"ls [package]", "list the packages and classes of a package", (*shell).ls},
+		"pwd":        {"pwd", "print the current package", (*shell).pwd},
+		"help":       {"help", "print this help", (*shell).help},
+		"exit":       {"exit", "leave the shell, like Ctrl-D", nil},
+	}
+}
+
+// shell answers questions about the classes of a workspace, whose classpath is loaded once.
+type shell struct {
+	workspace *workspace.Workspace
+	// index holds the names of all classes on the classpath, sorted
+	index []string
+	// pkg is the current package, like java/util, or empty for the root
+	pkg string
+}
+
+func runShell(cmd *cobra.Command, args []string) {
+	w := workspace.New(loadProject(cwd()), scope(), flagRelease)
+	classes, err := w.Classes()
+	if err != nil {
+		log.Fatal().
+			Err(err).
+			Msg("load classes")
+	}
+	s := &shell{
+		workspace: w,
+		index:     classes,
+	}
+
+	editor := lineedit.New(os.Stdin, os.Stdout)
+	editor.Complete = s.complete
+	historyFile := shellHistoryPath()
+	editor.History = readShellHistory(historyFile)
+	defer func() { writeShellHistory(historyFile, editor.History) }()
+
+	for {
+		editor.Prompt = s.prompt()
+		line, err := editor.ReadLine()
+		if errors.Is(err, lineedit.ErrInterrupted) {
+			continue
+		}
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			log.Error().
+				Err(err).
+				Msg("read line")
+			return
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if fields[0] == "exit" || fields[0] == "quit" {
+			return
+		}
+		command, ok := shellCommands[fields[0]]
+		if !ok {
+			fmt.Fprintf(os.Stderr, "unknown command %s, see help\n", fields[0])
+			continue
+		}
+		if err := command.run(s, fields[1:]); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", fields[0], err)
+		}
+	}
+}
+
+func (s *shell) prompt() string {
+	if s.pkg == "" {
+		return "jt> "
+	}
+	return "jt:" + s.pkg + "> "
+}
+
+// resolve returns the class with the given name, which may be relative to the current package
+// or start with a slash to be absolute. Packages can be separated with dots.
+func (s *shell) resolve(name string) string {
+	name = strings.ReplaceAll(name, ".", "/")
+	if strings.HasPrefix(name, "/") {
+		return strings.TrimPrefix(name, "/")
+	}
+	if s.pkg != "" && s.contains(s.pkg+"/"+name) {
+		return s.pkg + "/" + name
+	}
+	return name
+}
+
+// resolvePackage returns the package with the given name relative to the current package,
+// like cd does.
+func (s *shell) resolvePackage(name string) string {
+	segments := strings.Split(name, "/")
+	for i, segment := range segments {
+		if segment != "." && segment != ".." {
+			segments[i] = strings.ReplaceAll(segment, ".", "/")
+		}
+	}
+	name = strings.Join(segments, "/")
+	if !strings.HasPrefix(name, "/") {
+		name = s.pkg + "/" + name
+	}
+	// path.Clean handles .. and returns / for the root
+	return strings.Trim(path.Clean("/"+name), "/")
+}
+
+func (s *shell) contains(class string) bool {
+	i := sort.SearchStrings(s.index, class)
+	return i < len(s.index) && s.index[i] == class
+}
+
+// withPrefix returns the classes whose names start with the given prefix.
+func (s *shell) withPrefix(prefix string) []string {
+	start := sort.SearchStrings(s.index, prefix)
+	end := start
+	for end < len(s.index) && strings.HasPrefix(s.index[end], prefix) {
+		end++
+	}
+	return s.index[start:end]
+}
+
+// isPackage returns whether the classpath contains classes in the given package or its subpackages.
+func (s *shell) isPackage(pkg string) bool {
+	return pkg == "" || len(s.withPrefix(pkg+"/")) > 0
+}
+
+func (s *shell) find(args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: " + shellCommands["find"].usage)
+	}
+	out := newPrinter()
+	defer out.Close()
+	return s.workspace.Find(args[0], false, func(class *workspace.Class) {
+		out.Print(formatFound(class), class)
+	})
+}
+
+func (s *shell) which(args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: " + shellCommands["which"].usage)
+	}
+	class, err := s.workspace.Which(s.resolve(args[0]))
+	if err != nil {
+		return err
+	}
+	out := newPrinter()
+	defer out.Close()
+	printWhich(out, class)
+	return nil
+}
+
+func (s *shell) superclass(args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: " + shellCommands["superclass"].usage)
+	}
+	classes, err := s.workspace.Superclasses(s.resolve(args[0]))
+	out := newPrinter()
+	defer out.Close()
+	for _, class := range classes {
+		out.Print(class.Name, class)
+	}
+	return err
+}
+
+func (s *shell) subclass(args []string) error {
+	if len(args) < 1 || len(args) > 2 {
+		return errors.New("usage: " + shellCommands["subclass"].usage)
+	}
+	filter := ""
+	if len(args) > 1 {
+		filter = args[1]
+	}
+	out := newPrinter()
+	defer out.Close()
+	return s.workspace.Subclasses(s.resolve(args[0]), filter, false, func(class *workspace.Class) {
+		out.Print(class.Name, class)
+	})
+}
+
+func (s *shell) classes(args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: " + shellCommands["classes"].usage)
+	}
+	out := newPrinter()
+	defer out.Close()
+	return listClasses(out, args[0])
+}
+
+func (s *shell) classpath(args []string) error {
+	cp, err := s.workspace.Classpath()
+	if err != nil {
+		return err
+	}
+	out := newPrinter()
+	defer out.Close()
+	records := newEntryRecords(cp)
+	for _, entry := range cp.Entries {
+		out.Print(describeEntry(cp, entry), records.Get(entry))
+	}
+	return nil
+}
+
+func (s *shell) cd(args []string) error {
+	if len(args) > 1 {
+		return errors.New("usage: " + shellCommands["cd"].usage)
+	}
+	pkg := ""
+	if len(args) == 1 {
+		pkg = s.resolvePackage(args[0])
+	}
+	if !s.isPackage(pkg) {
+		return fmt.Errorf("no package %s", pkg)
+	}
+	s.pkg = pkg
+	return nil
+}
+
+func (s *shell) ls(args []string) error {
+	if len(args) > 1 {
+		return errors.New("usage: " + shellCommands["ls"].usage)
+	}
+	pkg := s.pkg
+	if len(args) == 1 {
+		pkg = s.resolvePackage(args[0])
+	}
+	if !s.isPackage(pkg) {
+		return fmt.Errorf("no package %s", pkg)
+	}
+	packages, classes := s.list(pkg)
+	for _, name := range append(packages, classes...) {
+		fmt.Println(name)
+	}
+	return nil
+}
+
+// list returns the subpackages of a package, with a trailing slash, and the simple names of its classes.
+func (s *shell) list(pkg string) (packages, classes []string) {
+	prefix := ""
+	if pkg != "" {
+		prefix = pkg + "/"
+	}
+	for _, name := range s.withPrefix(prefix) {
+		rest := name[len(prefix):]
+		if i := strings.Index(rest, "/"); i >= 0 {
+			if sub := rest[:i+1]; len(packages) == 0 || packages[len(packages)-1] != sub {
+				packages = append(packages, sub)
+			}
+			continue
+		}
+		classes = append(classes, rest)
+	}
+	return packages, classes
+}
+
+func (s *shell) pwd(args []string) error {
+	fmt.Println("/" + s.pkg)
+	return nil
+}
+
+func (s *shell) help(args []string) error {
+	var names []string
+	for name := range shellCommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Printf("  %-26s %s\n", shellCommands[name].usage, shellCommands[name].help)
+	}
+	fmt.Println("Classes are relative to the current package, unless they start with a slash.")
+	return nil
+}
+
+// complete completes the last word of the line, which is the part of the line before the cursor.
+// Command names are completed first, then packages for cd and ls, files for classes, and class
+// names for the other commands, one package at a time.
+func (s *shell) complete(line string) (int, []string) {
+	start := strings.LastIndex(line, " ") + 1
+	word := line[start:]
+	fields := strings.Fields(line)
+	if start == 0 || len(fields) == 0 {
+		var names []string
+		for name := range shellCommands {
+			if strings.HasPrefix(name, word) {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		return start, names
+	}
+
+	switch fields[0] {
+	case "help", "pwd", "classpath", "exit":
+		return start, nil
+	case "classes":
+		return start, completeFile(word)
+	case "cd", "ls":
+		return start, s.completeClass(word, true)
+	default:
+		return start, s.completeClass(word, false)
+	}
+}
+
+// completeClass returns the classes and packages that start with the given word, relative to the
+// current package, and if the word doesn't start with a slash, to the root as well. Packages are
+// completed up to their next slash. If onlyPackages is set, classes are left out.
+func (s *shell) completeClass(word string, onlyPackages bool) []string {
+	seen := make(map[string]bool)
+	var candidates []string
+	add := func(base, prefix string) {
+		for _, name := range s.withPrefix(base + prefix) {
+			rest := name[len(base)+len(prefix):]
+			candidate := prefix + rest
+			if i := strings.Index(rest, "/"); i >= 0 {
+				candidate = prefix + rest[:i+1]
+			} else if onlyPackages {
+				continue
+			}
+			if !seen[candidate] {
+				seen[candidate] = true
+				candidates = append(candidates, candidate)
+			}
+		}
+	}
+
+	if strings.HasPrefix(word, "/") {
+		for _, candidate := range s.completeClass(word[1:], onlyPackages) {
+			candidates = append(candidates, "/"+candidate)
+		}
+		return candidates
+	}
+	if s.pkg != "" {
+		add(s.pkg+"/", word)
+	}
+	add("", word)
+	sort.Strings(candidates)
+	return candidates
+}
+
+// completeFile returns the files and directories that start with the given word.
+func completeFile(word string) []string {
+	matches, _ := filepath.Glob(word + "*")
+	for i, match := range matches {
+		if info, err := os.Stat(match); err == nil && info.IsDir() {
+			matches[i] = match + string(filepath.Separator)
+		}
+	}
+	return matches
+}
+
+// shellHistoryPath returns the location of the history of jt shell in the user cache directory,
+// or an empty string if there is none.
+func shellHistoryPath() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "jt", "shell_history")
+}
+
+func readShellHistory(path string) []string {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer func() { _ = f.Close() }()
+
+	var history []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		history = append(history, scanner.Text())
+	}
+	return history
+}
+
+func writeShellHistory(path string, history []string) {
+	if path == "" || len(history) == 0 {
+		return
+	}
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err == nil {
+		err = os.WriteFile(path, []byte(strings.Join(history, "\n")+"\n"), 0600)
+	}
+	if err != nil {
+		log.Debug().
+			Err(err).
+			Str("file", path).
+			Msg("write shell history")
+	}
+}
@@ -1,8 +1,6 @@
 package main
 
 import (
-	"fmt"
-
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
 )
@@ -10,32 +8,19 @@ import (
 func runSuperclass(cmd *cobra.Command, args []string) {
 	classname := args[0]
 
-	project := loadProject(cwd())
-	classpath, err := project.Classpath()
+	classes, err := openWorkspace().Superclasses(classname)
+
+	// print the classes up to a class that is missing, before failing
+	out := newPrinter()
+	for _, class := range classes {
+		out.Print(class.Name, class)
+	}
+	out.Close()
+
 	if err != nil {
 		log.Fatal().
 			Err(err).
-			Str("project", project.Name()).
-			Msg("get classpath")
-	}
-
-	for classname != "" {
-		fmt.Println(classname)
-		class, err := classpath.OpenClass(classname)
-		if err != nil {
-			log.Fatal().
-				Err(err).
-				Str("project", project.Name()).
-				Str("class", classname).
-				Msg("open class")
-		}
-		if class == nil {
-			log.Fatal().
-				Err(err).
-				Str("project", project.Name()).
-				Str("class", classname).
-				Msg("class not on classpath")
-		}
-		classname = class.SuperclassName()
+			Str("class", classname).
+			Msg("open superclasses")
 	}
 }
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"os"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"github.com/tsatke/jt/classpath"
+	"github.com/tsatke/jt/unused"
+	"github.com/tsatke/jt/workspace"
+)
+
+// unusedDepRecord is a jar of the classpath that project code doesn't use directly.
+type unusedDepRecord struct {
+	Entry *workspace.Entry `json:"entry"`
+	// Usage is one of unused, transitive, annotation or reflection.
+	Usage string `json:"usage"`
+	// Via is the jar through which a transitive dependency is used.
+	Via *workspace.Entry `json:"via,omitempty"`
+	// Classes are the classes that are used in annotations or by reflection.
+	Classes []string `json:"classes,omitempty"`
+}
+
+func runUnusedDeps(cmd *cobra.Command, args []string) {
+	project := loadProject(cwd())
+	cp := projectClasspath(project)
+
+	dependencies, err := unused.Analyze(cp, func(entry *classpath.Entry) bool {
+		return entry.Type == classpath.EntryTypeOutput
+	})
+	if err != nil {
+		log.Fatal().
+			Err(err).
+			Msg("analyze dependencies")
+	}
+
+	out := newPrinter()
+	defer out.Close()
+	entries := newEntryRecords(cp)
+	problems := 0
+	for _, d := range dependencies {
+		if d.Usage == unused.UsageDirect {
+			continue
+		}
+		if d.Usage == unused.UsageUnused || d.Usage == unused.UsageTransitive {
+			problems++
+		}
+		record := &unusedDepRecord{
+			Entry:   entries.Get(d.Entry),
+			Usage:   d.Usage,
+			Via:     entries.Get(d.Via),
+			Classes: d.Classes,
+		}
+		out.Print(formatUnusedDep(record), record)
+	}
+	if problems > 0 {
+		out.Close()
+		os.Exit(1)
+	}
+}
+
+// formatUnusedDep formats a jar like lib/commons-io.jar (commons-io:commons-io:2.11.0) used
+// only through lib/commons-compress.jar (org.apache.commons:commons-compress:1.21).
+func formatUnusedDep(d *unusedDepRecord) string {
+	text := formatEntry(d.Entry)
+	switch d.Usage {
+	case unused.UsageUnused:
+		text += " unused"
+	case unused.UsageTransitive:
+		text += " used only through " + formatEntry(d.Via)
+	case unused.UsageAnnotation:
+		text += " used only in annotations: " + strings.Join(d.Classes, ", ")
+	case unused.UsageReflection:
+		text += " used only by reflection: " + strings.Join(d.Classes, ", ")
+	}
+	return text
+}
+
+// formatEntry formats an entry as its path relative to the working directory, followed by
+// its artifact in parentheses if it is known.
+func formatEntry(entry *workspace.Entry) string {
+	text := relativeToCwd(entry.Path)
+	if entry.Artifact != "" {
+		text += " (" + entry.Artifact + ")"
+	}
+	return text
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"github.com/tsatke/jt/classpath"
+	"github.com/tsatke/jt/usage"
+	"github.com/tsatke/jt/workspace"
+)
+
+// usageRecord is a place where a class, field or method is used.
+type usageRecord struct {
+	Class string `json:"class"`
+	// Member is the field or the method with its descriptor that uses the target.
+	Member string `json:"member,omitempty"`
+	Line   int    `json:"line,omitempty"`
+	// Kind is the instruction that uses the target, such as invokevirtual, or one of extends,
+	// implements, field, signature or constant.
+	Kind  string           `json:"kind"`
+	Entry *workspace.Entry `json:"entry"`
+}
+
+func runUsages(cmd *cobra.Command, args []string) {
+	target, err := usage.ParseTarget(args[0])
+	if err != nil {
+		log.Fatal().
+			Err(err).
+			Msg("parse target")
+	}
+
+	project := loadProject(cwd())
+	cp := projectClasspath(project)
+
+	var skip func(*classpath.Entry) bool
+	if flagUsagesProject {
+		skip = func(entry *classpath.Entry) bool {
+			return entry.Type != classpath.EntryTypeOutput
+		}
+	}
+
+	out := newPrinter()
+	defer out.Close()
+	entries := newEntryRecords(cp)
+	if err := usage.Find(cp, target, skip, func(u *usage.Usage) {
+		record := &usageRecord{
+			Class:  u.Class,
+			Member: u.Member,
+			Line:   u.Line,
+			Kind:   u.Kind,
+			Entry:  entries.Get(u.Entry),
+		}
+		out.Print(formatUsage(record), record)
+	}); err != nil {
+		out.Close()
+		log.Fatal().
+			Err(err).
+			Str("target", target.String()).
+			Msg("find usages")
+	}
+}
+
+// formatUsage formats a usage like com/example/App#run()V:42 invokevirtual (via target/classes).
+func formatUsage(u *usageRecord) string {
+	location := u.Class
+	if u.Member != "" {
+		location += "#" + u.Member
+	}
+	if u.Line > 0 {
+		location += ":" + strconv.Itoa(u.Line)
+	}
+	via := relativeToCwd(u.Entry.Path)
+	if u.Entry.Artifact != "" {
+		via += ", " + u.Entry.Artifact
+	}
+	return fmt.Sprintf("%s %s (via %s)", location, u.Kind, via)
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"crypto/x509"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"github.com/tsatke/jt/jar"
+)
+
+func runVerify(cmd *cobra.Command, args []string) {
+	name := args[0]
+
+	f, err := jar.Open(name)
+	if err != nil {
+		log.Fatal().
+			Err(err).
+			Str("jar", name).
+			Msg("open jar")
+	}
+	defer func() { _ = f.Close() }()
+
+	v, err := f.Verify()
+	if err != nil {
+		log.Fatal().
+			Err(err).
+			Str("jar", name).
+			Msg("verify jar")
+	}
+
+	if out := newPrinter(); !out.Text() {
+		printVerification(out, name, v)
+		return
+	}
+
+	for _, signer := range v.Signers {
+		fmt.Printf("signer %s (%s)\n", signer.SignatureFile, signer.BlockFile)
+		for i, cert := range signer.Certificates {
+			fmt.Printf("  [%d] %s (valid from %s until %s)\n", i, cert.Subject, cert.NotBefore.Format("2006-01-02"), cert.NotAfter.Format("2006-01-02"))
+		}
+		if signer.Err != nil {
+			fmt.Printf("  invalid: %s\n", signer.Err)
+		} else if err := verifyChain(signer.Certificates); err != nil {
+			fmt.Printf("  untrusted: %s\n", err)
+		} else {
+			fmt.Println("  trusted")
+		}
+	}
+
+	printEntries := func(label string, entries []string) {
+		for _, entry := range entries {
+			fmt.Printf("%s %s\n", label, entry)
+		}
+	}
+	if flagVerifyEntries {
+		printEntries("signed", v.Signed)
+	}
+	printEntries("unsigned", v.Unsigned)
+	printEntries("tampered", v.Tampered)
+	printEntries("missing", v.Missing)
+
+	switch {
+	case !v.IsSigned():
+		fmt.Println("jar is not signed")
+		os.Exit(1)
+	case len(v.Tampered) > 0 || len(v.Missing) > 0 || len(v.Unsigned) > 0:
+		fmt.Printf("jar is signed, but %d entries are tampered, %d missing and %d unsigned\n", len(v.Tampered), len(v.Missing), len(v.Unsigned))
+		os.Exit(1)
+	default:
+		fmt.Printf("jar is signed, %d entries verified\n", len(v.Signed))
+	}
+}
+
+// verificationRecord is the result of verifying the signatures of a jar.
+type verificationRecord struct {
+	Path string `json:"path"`
+	// Signed is true if the jar has a valid signer and all of its entries are signed.
+	Signed  bool            `json:"signed"`
+	Signers []signerRecord  `json:"signers"`
+	Entries verifiedEntries `json:"entries"`
+}
+
+// signerRecord describes a signer of a jar.
+type signerRecord struct {
+	SignatureFile string `json:"signatureFile"`
+	BlockFile     string `json:"blockFile"`
+	// Certificates holds the certificate chain, starting with the certificate of the signer.
+	Certificates []certificateRecord `json:"certificates"`
+	// Valid is true if the signature is valid, otherwise Error tells why it isn't.
+	Valid bool   `json:"valid"`
+	Error string `json:"error,omitempty"`
+	// Trusted is true if the certificate chain is trusted by the system roots,
+	// otherwise TrustError tells why it isn't.
+	Trusted    bool   `json:"trusted"`
+	TrustError string `json:"trustError,omitempty"`
+}
+
+type certificateRecord struct {
+	Subject      string    `json:"subject"`
+	Issuer       string    `json:"issuer"`
+	SerialNumber string    `json:"serialNumber"`
+	NotBefore    time.Time `json:"notBefore"`
+	NotAfter     time.Time `json:"notAfter"`
+}
+
+// verifiedEntries holds the names of the entries of a jar by their state.
+type verifiedEntries struct {
+	Signed   []string `json:"signed"`
+	Unsigned []string `json:"unsigned"`
+	Tampered []string `json:"tampered"`
+	Missing  []string `json:"missing"`
+}
+
+// printVerification prints the record of a verification and exits with 1 if the jar is not
+// completely signed.
+func printVerification(out *printer, name string, v *jar.Verification) {
+	record := &verificationRecord{
+		Path:    name,
+		Signers: []signerRecord{},
+		Entries: verifiedEntries{
+			Signed:   append([]string{}, v.Signed...),
+			Unsigned: append([]string{}, v.Unsigned...),
+			Tampered: append([]string{}, v.Tampered...),
+			Missing:  append([]string{}, v.Missing...),
+		},
+	}
+	record.Signed = v.IsSigned() && len(v.Unsigned)+len(v.Tampered)+len(v.Missing) == 0
+	for _, signer := range v.Signers {
+		s := signerRecord{
+			SignatureFile: signer.SignatureFile,
+			BlockFile:     signer.BlockFile,
+			Certificates:  []certificateRecord{},
+			Valid:         signer.Err == nil,
+		}
+		for _, cert := range signer.Certificates {
+			s.Certificates = append(s.Certificates, certificateRecord{
+				Subject:      cert.Subject.String(),
+				Issuer:       cert.Issuer.String(),
+				SerialNumber: cert.SerialNumber.String(),
+				NotBefore:    cert.NotBefore,
+				NotAfter:     cert.NotAfter,
+			})
+		}
+		if signer.Err != nil {
+			s.Error = signer.Err.Error()
+		} else if err := verifyChain(signer.Certificates); err != nil {
+			s.TrustError = err.Error()
+		} else {
+			s.Trusted = true
+		}
+		record.Signers = append(record.Signers, s)
+	}
+
+	out.Print("", record)
+	out.Close()
+	if !record.Signed {
+		os.Exit(1)
+	}
+}
+
+// verifyChain verifies the certificate chain of a signer against the system roots.
+func verifyChain(chain []*x509.Certificate) error {
+	if len(chain) == 0 {
+		return fmt.Errorf("no certificates")
+	}
+	roots, err := x509.SystemCertPool()
+	if err != nil {
+		return fmt.Errorf("load system roots: %w", err)
+	}
+	intermediates := x509.NewCertPool()
+	for _, cert := range chain[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err = chain[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	return err
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"github.com/tsatke/jt/jar"
+	"github.com/tsatke/jt/workspace"
+)
+
+func runWhich(cmd *cobra.Command, args []string) {
+	classname := args[0]
+
+	class, err := openWorkspace().Which(classname)
+	if err != nil {
+		log.Fatal().
+			Err(err).
+			Str("class", classname).
+			Msg("locate class")
+	}
+
+	out := newPrinter()
+	defer out.Close()
+	printWhich(out, class)
+}
+
+// printWhich prints the entry that a class is loaded from, followed by the versioned
+// variants of classes in multi-release jars.
+func printWhich(out *printer, class *workspace.Class) {
+	if !out.Text() {
+		out.Print("", class)
+		return
+	}
+
+	if class.Entry.Artifact != "" {
+		fmt.Printf("%s (%s)\n", class.Entry.Path, class.Entry.Artifact)
+	} else {
+		fmt.Println(class.Entry.Path)
+	}
+
+	// mark the variant in use
+	if len(class.Versions) == 0 {
+		return
+	}
+	for _, release := range append([]int{0}, class.Versions...) {
+		marker := " "
+		if release == *class.SelectedVersion {
+			marker = "*"
+		}
+		if release == 0 {
+			fmt.Printf("%s base\n", marker)
+		} else {
+			fmt.Printf("%s %s%d/%s.class\n", marker, jar.VersionsDir, release, class.Name)
+		}
+	}
+}
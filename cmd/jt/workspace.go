@@ -0,0 +1,42 @@
+package main
+
+import (
+	"github.com/rs/zerolog/log"
+	"github.com/tsatke/jt/daemon"
+	"github.com/tsatke/jt/workspace"
+)
+
+// classQueries answers questions about the classes of the project in the current directory.
+// It is implemented by workspace.Workspace, and by daemon.Client to ask a running jt serve.
+type classQueries interface {
+	Find(name string, onlyProject bool, fn func(*workspace.Class)) error
+	Which(name string) (*workspace.Class, error)
+	Javap(name string) (*workspace.Declarations, error)
+	Superclasses(name string) ([]*workspace.Class, error)
+	Subclasses(name, filter string, invert bool, fn func(*workspace.Class)) error
+}
+
+var (
+	_ classQueries = (*workspace.Workspace)(nil)
+	_ classQueries = (*daemon.Client)(nil)
+)
+
+// openWorkspace returns the workspace of the project in the current directory. If jt serve is
+// running, its warm workspace answers the questions, otherwise the project is loaded.
+func openWorkspace() classQueries {
+	if !flagNoDaemon {
+		client, err := daemon.Dial(flagSocket, daemon.Query{
+			Dir:     cwd(),
+			Scope:   flagScope,
+			Release: flagRelease,
+		})
+		if err == nil {
+			return client
+		}
+		log.Debug().
+			Err(err).
+			Str("socket", flagSocket).
+			Msg("no daemon")
+	}
+	return workspace.New(loadProject(cwd()), scope(), flagRelease)
+}
@@ -0,0 +1,439 @@
+// Package compat compares the public and protected API of two versions of a library and
+// classifies every change by whether it breaks code that is compiled against the new version
+// (source compatibility) or code that was compiled against the old version and runs with the new
+// one (binary compatibility), following chapter 13 of the Java Language Specification.
+package compat
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/tsatke/jt/class"
+	"github.com/tsatke/jt/classpath"
+)
+
+// kinds of changes
+const (
+	KindClassRemoved      = "class removed"
+	KindClassAdded        = "class added"
+	KindKindChanged       = "kind changed"
+	KindVisibilityReduced = "visibility reduced"
+	KindMadeFinal         = "made final"
+	KindMadeNonFinal      = "made non-final"
+	KindMadeAbstract      = "made abstract"
+	KindMadeNonAbstract   = "made non-abstract"
+	KindMadeStatic        = "made static"
+	KindMadeNonStatic     = "made non-static"
+	KindSupertypeRemoved  = "supertype removed"
+	KindSupertypeAdded    = "supertype added"
+	KindSuperclassChanged = "superclass changed"
+	KindFieldRemoved      = "field removed"
+	KindFieldAdded        = "field added"
+	KindMethodRemoved     = "method removed"
+	KindMethodAdded       = "method added"
+	KindAbstractAdded     = "abstract method added"
+	KindMovedToSupertype  = "moved to supertype"
+	KindDescriptorChanged = "descriptor changed"
+	KindConstantChanged   = "constant value changed"
+	KindExceptionsAdded   = "exceptions added"
+)
+
+// Change is a difference between the APIs of two versions.
+type Change struct {
+	// Class is the class that changed.
+	Class string
+	// Member is the name of the field, or the name and descriptor of the method that changed,
+	// or empty if the class itself changed. For KindDescriptorChanged, it is only the name.
+	Member string
+	// Kind is what changed, one of the Kind constants.
+	Kind string
+	// Old and New describe the change for kinds that have values, such as the descriptors for
+	// KindDescriptorChanged or the visibilities for KindVisibilityReduced.
+	Old string
+	New string
+	// Source is whether code that compiles against the old version may fail to compile against
+	// the new one.
+	Source bool
+	// Binary is whether code that was compiled against the old version may fail to link or
+	// run with the new one.
+	Binary bool
+}
+
+// Compare returns the changes from the API of the classes in the old archive to the API of the
+// classes in the new archive, sorted by class and member. The API are the public classes and
+// their public and protected fields and methods, without synthetic ones. Supertypes that are
+// not in the archives, such as classes of the JDK, are compared by name only.
+func Compare(old, new classpath.Archive) ([]*Change, error) {
+	c := &comparison{}
+	var err error
+	if c.old, err = readClasses(old); err != nil {
+		return nil, err
+	}
+	if c.new, err = readClasses(new); err != nil {
+		return nil, err
+	}
+
+	for _, name := range sortedNames(c.old) {
+		o := c.old[name]
+		if !isAPI(o) {
+			continue
+		}
+		if n, ok := c.new[name]; ok {
+			c.compareClass(o, n)
+		} else {
+			c.add(&Change{Class: name, Kind: KindClassRemoved, Source: true, Binary: true})
+		}
+	}
+	for _, name := range sortedNames(c.new) {
+		if o, ok := c.old[name]; isAPI(c.new[name]) && (!ok || !isAPI(o)) {
+			c.add(&Change{Class: name, Kind: KindClassAdded})
+		}
+	}
+
+	sort.SliceStable(c.changes, func(i, j int) bool {
+		a, b := c.changes[i], c.changes[j]
+		if a.Class != b.Class {
+			return a.Class < b.Class
+		}
+		return a.Member < b.Member
+	})
+	return c.changes, nil
+}
+
+func readClasses(archive classpath.Archive) (map[string]*class.Class, error) {
+	classes := make(map[string]*class.Class)
+	for _, name := range archive.ListClasses() {
+		if strings.HasSuffix(name, "module-info") || strings.HasSuffix(name, "package-info") {
+			continue
+		}
+		c, err := archive.OpenClass(name)
+		if err != nil {
+			return nil, fmt.Errorf("open class %s: %w", name, err)
+		}
+		classes[name] = c
+	}
+	return classes, nil
+}
+
+func sortedNames(classes map[string]*class.Class) []string {
+	names := make([]string, 0, len(classes))
+	for name := range classes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// isAPI returns whether the class is public and not generated by the compiler. Nested classes
+// that are protected are public in their class files.
+func isAPI(c *class.Class) bool {
+	return c.IsPublic() && c.AccessFlags()&class.AccSynthetic == 0
+}
+
+// member is a field or a method.
+type member interface {
+	Name() string
+	Descriptor() string
+	AccessFlags() class.AccessFlags
+	IsSynthetic() bool
+}
+
+// isAPIMember returns whether the field or method is public or protected and not generated
+// by the compiler.
+func isAPIMember(m member) bool {
+	return m.AccessFlags()&(class.AccPublic|class.AccProtected) != 0 && !m.IsSynthetic() && m.Name() != "<clinit>"
+}
+
+// visibility returns the visibility of a class or member.
+func visibility(flags class.AccessFlags) string {
+	switch {
+	case flags&class.AccPublic != 0:
+		return "public"
+	case flags&class.AccProtected != 0:
+		return "protected"
+	case flags&class.AccPrivate != 0:
+		return "private"
+	}
+	return "package-private"
+}
+
+// visibilityRank orders visibilities from private to public.
+var visibilityRank = map[string]int{"private": 0, "package-private": 1, "protected": 2, "public": 3}
+
+type comparison struct {
+	old     map[string]*class.Class
+	new     map[string]*class.Class
+	changes []*Change
+}
+
+func (c *comparison) add(change *Change) {
+	c.changes = append(c.changes, change)
+}
+
+func (c *comparison) compareClass(o, n *class.Class) {
+	name := o.Name()
+	if !isAPI(n) {
+		c.add(&Change{Class: name, Kind: KindVisibilityReduced, Old: "public", New: visibility(n.AccessFlags()), Source: true, Binary: true})
+		return
+	}
+	if o.IsInterface() != n.IsInterface() {
+		c.add(&Change{Class: name, Kind: KindKindChanged, Old: kind(o), New: kind(n), Source: true, Binary: true})
+		return
+	}
+
+	// JLS 13.4.1 and 13.4.2
+	if !o.IsFinal() && n.IsFinal() {
+		c.add(&Change{Class: name, Kind: KindMadeFinal, Source: true, Binary: true})
+	} else if o.IsFinal() && !n.IsFinal() {
+		c.add(&Change{Class: name, Kind: KindMadeNonFinal})
+	}
+	if !o.IsInterface() {
+		if !o.IsAbstract() && n.IsAbstract() {
+			c.add(&Change{Class: name, Kind: KindMadeAbstract, Source: true, Binary: true})
+		} else if o.IsAbstract() && !n.IsAbstract() {
+			c.add(&Change{Class: name, Kind: KindMadeNonAbstract})
+		}
+	}
+
+	// JLS 13.4.4: the supertypes may change as long as none is lost
+	oldSupertypes, newSupertypes := supertypes(o, c.old), supertypes(n, c.new)
+	for _, s := range oldSupertypes {
+		if !contains(newSupertypes, s) {
+			c.add(&Change{Class: name, Kind: KindSupertypeRemoved, Old: s, Source: true, Binary: true})
+		}
+	}
+	for _, s := range newSupertypes {
+		if !contains(oldSupertypes, s) {
+			c.add(&Change{Class: name, Kind: KindSupertypeAdded, New: s})
+		}
+	}
+	if o.SuperclassName() != n.SuperclassName() && contains(newSupertypes, o.SuperclassName()) {
+		c.add(&Change{Class: name, Kind: KindSuperclassChanged, Old: o.SuperclassName(), New: n.SuperclassName()})
+	}
+
+	c.compareFields(o, n)
+	c.compareMethods(o, n)
+}
+
+func kind(c *class.Class) string {
+	switch {
+	case c.IsAnnotation():
+		return "annotation"
+	case c.IsInterface():
+		return "interface"
+	}
+	return "class"
+}
+
+func (c *comparison) compareFields(o, n *class.Class) {
+	name := o.Name()
+	for _, of := range o.Fields() {
+		if !isAPIMember(of) {
+			continue
+		}
+		var nf *class.Field
+		for _, f := range n.Fields() {
+			if f.Name() == of.Name() {
+				f := f
+				nf = &f
+			}
+		}
+
+		switch {
+		case nf == nil:
+			if s := c.inherited(n, of.Name(), of.Descriptor(), true); s != "" {
+				c.add(&Change{Class: name, Member: of.Name(), Kind: KindMovedToSupertype, New: s})
+			} else {
+				c.add(&Change{Class: name, Member: of.Name(), Kind: KindFieldRemoved, Source: true, Binary: true})
+			}
+			continue
+		case nf.Descriptor() != of.Descriptor():
+			c.add(&Change{Class: name, Member: of.Name(), Kind: KindDescriptorChanged, Old: of.Descriptor(), New: nf.Descriptor(), Source: true, Binary: true})
+			continue
+		}
+
+		c.compareModifiers(name, of.Name(), of, nf, false)
+		// JLS 13.4.9: constants are inlined, binaries compiled against the old version keep the old value
+		oldValue, isConstant := of.ConstantValue()
+		if newValue, _ := nf.ConstantValue(); isConstant && oldValue != newValue {
+			c.add(&Change{Class: name, Member: of.Name(), Kind: KindConstantChanged, Old: oldValue, New: newValue})
+		}
+	}
+
+	for _, nf := range n.Fields() {
+		if isAPIMember(nf) && !declaresField(o, nf.Name()) {
+			c.add(&Change{Class: name, Member: nf.Name(), Kind: KindFieldAdded})
+		}
+	}
+}
+
+func declaresField(c *class.Class, name string) bool {
+	for _, f := range c.Fields() {
+		if f.Name() == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *comparison) compareMethods(o, n *class.Class) {
+	name := o.Name()
+	// a single method with a name whose descriptor changed is reported as such, instead of
+	// as a removed and an added method
+	changed := make(map[string]bool)
+	for _, om := range o.Methods() {
+		if !isAPIMember(om) {
+			continue
+		}
+		key := om.Name() + om.Descriptor()
+		nm, ok := n.Method(om.Name(), om.Descriptor())
+		if !ok {
+			if replacement := c.replacement(o, n, om.Name()); replacement != nil {
+				changed[replacement.Descriptor()] = true
+				c.add(&Change{Class: name, Member: om.Name(), Kind: KindDescriptorChanged, Old: om.Descriptor(), New: replacement.Descriptor(), Source: true, Binary: true})
+			} else if s := c.inherited(n, om.Name(), om.Descriptor(), false); s != "" && om.Name() != "<init>" {
+				c.add(&Change{Class: name, Member: key, Kind: KindMovedToSupertype, New: s})
+			} else {
+				c.add(&Change{Class: name, Member: key, Kind: KindMethodRemoved, Source: true, Binary: true})
+			}
+			continue
+		}
+
+		c.compareModifiers(name, key, om, nm, n.IsFinal())
+		// JLS 13.4.16
+		if !om.IsAbstract() && nm.IsAbstract() {
+			c.add(&Change{Class: name, Member: key, Kind: KindMadeAbstract, Source: true, Binary: true})
+		} else if om.IsAbstract() && !nm.IsAbstract() {
+			c.add(&Change{Class: name, Member: key, Kind: KindMadeNonAbstract})
+		}
+		// JLS 13.4.22: callers that don't handle the new checked exceptions don't compile
+		var added []string
+		for _, e := range nm.Exceptions() {
+			if !contains(om.Exceptions(), e) {
+				added = append(added, e)
+			}
+		}
+		if len(added) > 0 {
+			c.add(&Change{Class: name, Member: key, Kind: KindExceptionsAdded, New: strings.Join(added, ", "), Source: true})
+		}
+	}
+
+	for _, nm := range n.Methods() {
+		if !isAPIMember(nm) || changed[nm.Descriptor()] {
+			continue
+		}
+		if om, ok := o.Method(nm.Name(), nm.Descriptor()); ok && isAPIMember(om) {
+			continue
+		}
+		// JLS 13.4.12 and 13.5.3: subclasses and implementations that don't implement an added
+		// abstract method don't compile, but existing binaries only fail when it is called
+		if nm.IsAbstract() {
+			c.add(&Change{Class: name, Member: nm.Name() + nm.Descriptor(), Kind: KindAbstractAdded, Source: true})
+		} else {
+			c.add(&Change{Class: name, Member: nm.Name() + nm.Descriptor(), Kind: KindMethodAdded})
+		}
+	}
+}
+
+// replacement returns the method with the given name in the new class if both classes have
+// exactly one API method with the name, and the descriptors differ.
+func (c *comparison) replacement(o, n *class.Class, name string) *class.Method {
+	var oldMethods, newMethods []class.Method
+	for _, m := range o.Methods() {
+		if m.Name() == name && isAPIMember(m) {
+			oldMethods = append(oldMethods, m)
+		}
+	}
+	for _, m := range n.Methods() {
+		if m.Name() == name && isAPIMember(m) {
+			newMethods = append(newMethods, m)
+		}
+	}
+	if len(oldMethods) != 1 || len(newMethods) != 1 || oldMethods[0].Descriptor() == newMethods[0].Descriptor() {
+		return nil
+	}
+	return &newMethods[0]
+}
+
+// compareModifiers compares the visibility, static and final modifiers of a field or method.
+func (c *comparison) compareModifiers(className, memberName string, o, n member, finalClass bool) {
+	oldFlags, newFlags := o.AccessFlags(), n.AccessFlags()
+	if oldVisibility, newVisibility := visibility(oldFlags), visibility(newFlags); visibilityRank[newVisibility] < visibilityRank[oldVisibility] {
+		c.add(&Change{Class: className, Member: memberName, Kind: KindVisibilityReduced, Old: oldVisibility, New: newVisibility, Source: true, Binary: true})
+	}
+
+	// JLS 13.4.10 and 13.4.19
+	static := newFlags&class.AccStatic != 0
+	if oldStatic := oldFlags&class.AccStatic != 0; !oldStatic && static {
+		c.add(&Change{Class: className, Member: memberName, Kind: KindMadeStatic, Source: true, Binary: true})
+	} else if oldStatic && !static {
+		c.add(&Change{Class: className, Member: memberName, Kind: KindMadeNonStatic, Source: true, Binary: true})
+	}
+
+	// JLS 13.4.9 and 13.4.17: final fields can't be assigned and final methods can't be overridden,
+	// while final static methods only can't be hidden in source
+	_, isField := o.(class.Field)
+	if oldFlags&class.AccFinal == 0 && newFlags&class.AccFinal != 0 {
+		switch {
+		case isField:
+			c.add(&Change{Class: className, Member: memberName, Kind: KindMadeFinal, Source: true, Binary: true})
+		case finalClass:
+			// methods of final classes can't be overridden anyway
+		case static:
+			c.add(&Change{Class: className, Member: memberName, Kind: KindMadeFinal, Source: true})
+		default:
+			c.add(&Change{Class: className, Member: memberName, Kind: KindMadeFinal, Source: true, Binary: true})
+		}
+	} else if oldFlags&class.AccFinal != 0 && newFlags&class.AccFinal == 0 {
+		c.add(&Change{Class: className, Member: memberName, Kind: KindMadeNonFinal})
+	}
+}
+
+// supertypes returns the superclasses and superinterfaces of the class, directly or indirectly,
+// as far as they are in the given classes.
+func supertypes(c *class.Class, classes map[string]*class.Class) []string {
+	var result []string
+	queue := append([]string{c.SuperclassName()}, c.Interfaces()...)
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		if name == "" || contains(result, name) {
+			continue
+		}
+		result = append(result, name)
+		if s, ok := classes[name]; ok {
+			queue = append(queue, s.SuperclassName())
+			queue = append(queue, s.Interfaces()...)
+		}
+	}
+	return result
+}
+
+// inherited returns the supertype of the new class that declares an API field or method with
+// the given name and descriptor, or an empty string if there is none in the new classes.
+func (c *comparison) inherited(n *class.Class, name, descriptor string, field bool) string {
+	for _, s := range supertypes(n, c.new) {
+		sc, ok := c.new[s]
+		if !ok {
+			continue
+		}
+		if field {
+			if f, ok := sc.Field(name, descriptor); ok && isAPIMember(f) {
+				return s
+			}
+		} else if m, ok := sc.Method(name, descriptor); ok && isAPIMember(m) {
+			return s
+		}
+	}
+	return ""
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
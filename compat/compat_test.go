@@ -0,0 +1,100 @@
+package compat
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"github.com/tsatke/jt/classpath"
+	"github.com/tsatke/jt/internal/classbuild"
+)
+
+func TestCompatSuite(t *testing.T) {
+	suite.Run(t, new(CompatSuite))
+}
+
+type CompatSuite struct {
+	suite.Suite
+}
+
+func (suite *CompatSuite) archive(classes ...*classbuild.Class) classpath.Archive {
+	dir := suite.T().TempDir()
+	for _, c := range classes {
+		path := filepath.Join(dir, filepath.FromSlash(c.Name)+".class")
+		suite.Require().NoError(os.MkdirAll(filepath.Dir(path), 0755))
+		suite.Require().NoError(os.WriteFile(path, c.Bytes(), 0644))
+	}
+	archive, err := classpath.OpenArchive(&classpath.Entry{Type: classpath.EntryTypeOutput, Path: dir})
+	suite.Require().NoError(err)
+	suite.T().Cleanup(func() { _ = archive.Close() })
+	return archive
+}
+
+func (suite *CompatSuite) TestCompare() {
+	const public, static, final = classbuild.AccPublic, classbuild.AccStatic, classbuild.AccFinal
+
+	oldBase := classbuild.New("com/lib/Base", "java/lang/Object")
+	oldAPI := classbuild.New("com/lib/Api", "com/lib/Base")
+	oldAPI.Interfaces = []string{"java/io/Closeable"}
+	oldAPI.Field(public|static|final, "VERSION", "I", oldAPI.ConstantValue(oldAPI.Integer(1)))
+	oldAPI.Field(public, "count", "I")
+	oldAPI.Field(public, "name", "Ljava/lang/String;")
+	oldAPI.Method(public, "send", "(Ljava/lang/String;)V")
+	oldAPI.Method(public, "close", "()V")
+	oldAPI.Method(public, "helper", "()V")
+	oldAPI.Method(public, "run", "()V")
+	oldAPI.Method(public, "stop", "()V")
+	oldAPI.Method(public, "moved", "()V")
+	oldAPI.Method(classbuild.AccPrivate, "internal", "()V")
+	oldRemoved := classbuild.New("com/lib/Removed", "java/lang/Object")
+	oldHidden := classbuild.New("com/lib/Hidden", "java/lang/Object")
+	hidden := classbuild.New("com/lib/internal/Impl", "java/lang/Object")
+	hidden.AccessFlags = classbuild.AccSuper
+
+	newBase := classbuild.New("com/lib/Base", "java/lang/Object")
+	newBase.Method(public, "moved", "()V")
+	newAPI := classbuild.New("com/lib/Api", "com/lib/Base")
+	newAPI.Field(public|static|final, "VERSION", "I", newAPI.ConstantValue(newAPI.Integer(2)))
+	newAPI.Field(public|final, "count", "I")
+	newAPI.Field(public, "name", "Ljava/lang/CharSequence;")
+	newAPI.Method(public, "send", "(Ljava/lang/CharSequence;)V")
+	newAPI.Method(public, "close", "()V", newAPI.Exceptions("java/io/IOException"))
+	newAPI.Method(classbuild.AccProtected, "helper", "()V")
+	newAPI.Method(public|static, "run", "()V")
+	newAPI.Method(public|final, "stop", "()V")
+	newAPI.Method(classbuild.AccPrivate, "internal", "()V")
+	newAPI.Method(public, "added", "()V")
+	newHidden := classbuild.New("com/lib/Hidden", "java/lang/Object")
+	newHidden.AccessFlags = classbuild.AccSuper
+	listener := classbuild.New("com/lib/Listener", "java/lang/Object")
+	listener.AccessFlags = public | classbuild.AccInterface | classbuild.AccAbstract
+
+	changes, err := Compare(
+		suite.archive(oldBase, oldAPI, oldRemoved, oldHidden, hidden),
+		suite.archive(newBase, newAPI, newHidden, hidden, listener),
+	)
+	suite.Require().NoError(err)
+
+	var actual []Change
+	for _, c := range changes {
+		actual = append(actual, *c)
+	}
+	suite.Equal([]Change{
+		{Class: "com/lib/Api", Kind: KindSupertypeRemoved, Old: "java/io/Closeable", Source: true, Binary: true},
+		{Class: "com/lib/Api", Member: "VERSION", Kind: KindConstantChanged, Old: "1", New: "2"},
+		{Class: "com/lib/Api", Member: "added()V", Kind: KindMethodAdded},
+		{Class: "com/lib/Api", Member: "close()V", Kind: KindExceptionsAdded, New: "java/io/IOException", Source: true},
+		{Class: "com/lib/Api", Member: "count", Kind: KindMadeFinal, Source: true, Binary: true},
+		{Class: "com/lib/Api", Member: "helper()V", Kind: KindVisibilityReduced, Old: "public", New: "protected", Source: true, Binary: true},
+		{Class: "com/lib/Api", Member: "moved()V", Kind: KindMovedToSupertype, New: "com/lib/Base"},
+		{Class: "com/lib/Api", Member: "name", Kind: KindDescriptorChanged, Old: "Ljava/lang/String;", New: "Ljava/lang/CharSequence;", Source: true, Binary: true},
+		{Class: "com/lib/Api", Member: "run()V", Kind: KindMadeStatic, Source: true, Binary: true},
+		{Class: "com/lib/Api", Member: "send", Kind: KindDescriptorChanged, Old: "(Ljava/lang/String;)V", New: "(Ljava/lang/CharSequence;)V", Source: true, Binary: true},
+		{Class: "com/lib/Api", Member: "stop()V", Kind: KindMadeFinal, Source: true, Binary: true},
+		{Class: "com/lib/Base", Member: "moved()V", Kind: KindMethodAdded},
+		{Class: "com/lib/Hidden", Kind: KindVisibilityReduced, Old: "public", New: "package-private", Source: true, Binary: true},
+		{Class: "com/lib/Listener", Kind: KindClassAdded},
+		{Class: "com/lib/Removed", Kind: KindClassRemoved, Source: true, Binary: true},
+	}, actual)
+}
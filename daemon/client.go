@@ -0,0 +1,88 @@
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"time"
+
+	"github.com/tsatke/jt/classpath"
+	"github.com/tsatke/jt/workspace"
+)
+
+// Client asks a running daemon about the workspace of a query. Its methods have the same
+// signatures as those of workspace.Workspace, so that commands can use either of them.
+type Client struct {
+	query  Query
+	client *rpc.Client
+}
+
+// Dial connects to the daemon that listens on the given socket.
+func Dial(socket string, q Query) (*Client, error) {
+	conn, err := net.DialTimeout("unix", socket, time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("dial: %w", err)
+	}
+	return &Client{
+		query:  q,
+		client: jsonrpc.NewClient(conn),
+	}, nil
+}
+
+func (c *Client) Close() error {
+	return c.client.Close()
+}
+
+func (c *Client) Find(name string, onlyProject bool, fn func(*workspace.Class)) error {
+	var classes []*workspace.Class
+	if err := c.client.Call(ServiceName+".Find", &FindArgs{Query: c.query, Name: name, OnlyProject: onlyProject}, &classes); err != nil {
+		return err
+	}
+	for _, class := range classes {
+		fn(class)
+	}
+	return nil
+}
+
+func (c *Client) Which(name string) (*workspace.Class, error) {
+	var class workspace.Class
+	if err := c.client.Call(ServiceName+".Which", &ClassArgs{Query: c.query, Name: name}, &class); err != nil {
+		return nil, err
+	}
+	return &class, nil
+}
+
+func (c *Client) Javap(name string) (*workspace.Declarations, error) {
+	var d workspace.Declarations
+	if err := c.client.Call(ServiceName+".Javap", &ClassArgs{Query: c.query, Name: name}, &d); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+func (c *Client) Superclasses(name string) ([]*workspace.Class, error) {
+	var reply SuperclassReply
+	if err := c.client.Call(ServiceName+".Superclass", &ClassArgs{Query: c.query, Name: name}, &reply); err != nil {
+		return nil, err
+	}
+	switch {
+	case reply.NotFound != "":
+		return reply.Classes, &classpath.ClassNotFoundError{Name: reply.NotFound}
+	case reply.Error != "":
+		return reply.Classes, errors.New(reply.Error)
+	}
+	return reply.Classes, nil
+}
+
+func (c *Client) Subclasses(name, filter string, invert bool, fn func(*workspace.Class)) error {
+	var classes []*workspace.Class
+	if err := c.client.Call(ServiceName+".Subclass", &SubclassArgs{Query: c.query, Name: name, Filter: filter, Invert: invert}, &classes); err != nil {
+		return err
+	}
+	for _, class := range classes {
+		fn(class)
+	}
+	return nil
+}
@@ -0,0 +1,208 @@
+// Package daemon implements jt serve, a long-running process that keeps the workspaces of projects
+// in memory and answers questions about their classes over JSON-RPC on a Unix socket, as well as
+// the client that the commands use to ask a running daemon instead of loading the project themselves.
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/tsatke/jt"
+	"github.com/tsatke/jt/classpath"
+	"github.com/tsatke/jt/workspace"
+)
+
+// ServiceName is the name of the JSON-RPC service, whose methods are called like JT.Find.
+const ServiceName = "JT"
+
+// DefaultSocket returns the path of the socket in the runtime directory of the user,
+// or in the temporary directory if there is none.
+func DefaultSocket() string {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, "jt.sock")
+	}
+	return filepath.Join(os.TempDir(), "jt-"+strconv.Itoa(os.Getuid())+".sock")
+}
+
+// Listen listens on the socket with the given path. A socket that is left over from a daemon
+// that didn't shut down cleanly is removed, but if another daemon is listening on the socket,
+// an error is returned.
+func Listen(socket string) (net.Listener, error) {
+	if _, err := os.Stat(socket); err == nil {
+		if conn, err := net.DialTimeout("unix", socket, time.Second); err == nil {
+			_ = conn.Close()
+			return nil, fmt.Errorf("daemon is already listening on %s", socket)
+		}
+		if err := os.Remove(socket); err != nil {
+			return nil, fmt.Errorf("remove stale socket: %w", err)
+		}
+	}
+
+	l, err := net.Listen("unix", socket)
+	if err != nil {
+		return nil, fmt.Errorf("listen: %w", err)
+	}
+	// only the user may ask questions about their projects
+	if err := os.Chmod(socket, 0600); err != nil {
+		_ = l.Close()
+		return nil, fmt.Errorf("chmod socket: %w", err)
+	}
+	return l, nil
+}
+
+// Server holds the workspaces of the projects that it was asked about.
+type Server struct {
+	// LoadProject loads the project in the given directory, which is jt.LoadProject by default.
+	LoadProject func(dir string) (jt.Project, error)
+
+	mu         sync.Mutex
+	workspaces map[Query]*loadedWorkspace
+}
+
+// loadedWorkspace is a workspace that is loaded once, together with the snapshot
+// of the files that it was loaded from.
+type loadedWorkspace struct {
+	once      sync.Once
+	workspace *workspace.Workspace
+	snapshot  snapshot
+	err       error
+}
+
+func NewServer() *Server {
+	return &Server{
+		LoadProject: jt.LoadProject,
+		workspaces:  make(map[Query]*loadedWorkspace),
+	}
+}
+
+// Serve answers the requests of all connections that are accepted by the listener,
+// until the listener is closed.
+func (s *Server) Serve(l net.Listener) error {
+	server := rpc.NewServer()
+	if err := server.RegisterName(ServiceName, &Service{server: s}); err != nil {
+		return fmt.Errorf("register service: %w", err)
+	}
+	for {
+		conn, err := l.Accept()
+		if errors.Is(err, net.ErrClosed) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("accept: %w", err)
+		}
+		go server.ServeCodec(jsonrpc.NewServerCodec(conn))
+	}
+}
+
+// Watch checks the files that the loaded workspaces depend on in the given interval, such as
+// pom.xml, .classpath and the jars on the classpath, until the context is done. Workspaces whose
+// files changed are loaded again.
+func (s *Server) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		for _, q := range s.changed() {
+			log.Info().
+				Str("dir", q.Dir).
+				Str("scope", q.Scope).
+				Msg("reload workspace")
+			s.mu.Lock()
+			delete(s.workspaces, q)
+			s.mu.Unlock()
+			if _, err := s.workspace(q); err != nil {
+				log.Error().
+					Err(err).
+					Str("dir", q.Dir).
+					Msg("reload workspace")
+			}
+		}
+	}
+}
+
+// changed returns the queries of the loaded workspaces whose files changed since they were loaded.
+func (s *Server) changed() []Query {
+	s.mu.Lock()
+	snapshots := make(map[Query]snapshot, len(s.workspaces))
+	for q, lw := range s.workspaces {
+		// workspaces that are still loading have no snapshot yet
+		if lw.snapshot != nil {
+			snapshots[q] = lw.snapshot
+		}
+	}
+	s.mu.Unlock()
+
+	var changed []Query
+	for q, snap := range snapshots {
+		if snap.changed() {
+			changed = append(changed, q)
+		}
+	}
+	return changed
+}
+
+// workspace returns the workspace for the given query, loading it if it isn't loaded yet.
+// Workspaces that fail to load are not kept, so that they are loaded again on the next request.
+func (s *Server) workspace(q Query) (*workspace.Workspace, error) {
+	s.mu.Lock()
+	lw, ok := s.workspaces[q]
+	if !ok {
+		lw = &loadedWorkspace{}
+		s.workspaces[q] = lw
+	}
+	s.mu.Unlock()
+
+	lw.once.Do(func() {
+		w, snap, err := s.load(q)
+		s.mu.Lock()
+		lw.workspace, lw.snapshot, lw.err = w, snap, err
+		if err != nil && s.workspaces[q] == lw {
+			delete(s.workspaces, q)
+		}
+		s.mu.Unlock()
+	})
+	return lw.workspace, lw.err
+}
+
+func (s *Server) load(q Query) (*workspace.Workspace, snapshot, error) {
+	start := time.Now()
+
+	scope, err := classpath.ParseScope(q.Scope)
+	if err != nil {
+		return nil, nil, err
+	}
+	project, err := s.LoadProject(q.Dir)
+	if err != nil {
+		return nil, nil, fmt.Errorf("load project: %w", err)
+	}
+	w := workspace.New(project, scope, q.Release)
+	if err := w.Preload(); err != nil {
+		return nil, nil, err
+	}
+	cp, err := w.Classpath()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	log.Info().
+		Str("dir", q.Dir).
+		Str("scope", q.Scope).
+		Stringer("took", time.Since(start)).
+		Msg("load workspace")
+	return w, takeSnapshot(q.Dir, project, cp), nil
+}
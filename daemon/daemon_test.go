@@ -0,0 +1,176 @@
+package daemon
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"github.com/tsatke/jt"
+	"github.com/tsatke/jt/classpath"
+	"github.com/tsatke/jt/internal/classbuild"
+	"github.com/tsatke/jt/workspace"
+)
+
+func TestDaemonSuite(t *testing.T) {
+	suite.Run(t, new(DaemonSuite))
+}
+
+type DaemonSuite struct {
+	suite.Suite
+
+	dir    string
+	socket string
+	server *Server
+}
+
+func (suite *DaemonSuite) SetupTest() {
+	suite.dir = suite.T().TempDir()
+	suite.write(".project", []byte(`<projectDescription><name>example</name></projectDescription>`))
+	suite.write(".classpath", []byte(`<classpath>
+<classpathentry kind="src" path="src"/>
+<classpathentry kind="output" path="bin"/>
+</classpath>`))
+	suite.write("src/com/example/Base.java", []byte("package com.example;\npublic class Base {}\n"))
+	suite.write("bin/java/lang/Object.class", classbuild.New("java/lang/Object", "").Bytes())
+	suite.write("bin/com/example/Base.class", classbuild.New("com/example/Base", "java/lang/Object").Bytes())
+	suite.write("bin/com/example/Impl.class", classbuild.New("com/example/Impl", "com/example/Base").Bytes())
+	suite.write("bin/com/example/Broken.class", classbuild.New("com/example/Broken", "com/example/Gone").Bytes())
+
+	// unix socket paths are limited to about 100 characters, which long temp dirs may exceed
+	socketDir, err := os.MkdirTemp("", "jt")
+	suite.Require().NoError(err)
+	suite.T().Cleanup(func() { _ = os.RemoveAll(socketDir) })
+	suite.socket = filepath.Join(socketDir, "jt.sock")
+
+	l, err := Listen(suite.socket)
+	suite.Require().NoError(err)
+	suite.T().Cleanup(func() { _ = l.Close() })
+	server := NewServer()
+	go func() { _ = server.Serve(l) }()
+	suite.server = server
+}
+
+func (suite *DaemonSuite) write(name string, data []byte) {
+	path := filepath.Join(suite.dir, filepath.FromSlash(name))
+	suite.Require().NoError(os.MkdirAll(filepath.Dir(path), 0755))
+	suite.Require().NoError(os.WriteFile(path, data, 0644))
+}
+
+func (suite *DaemonSuite) dial() *Client {
+	client, err := Dial(suite.socket, Query{Dir: suite.dir, Scope: "test"})
+	suite.Require().NoError(err)
+	suite.T().Cleanup(func() { _ = client.Close() })
+	return client
+}
+
+func (suite *DaemonSuite) TestListenTwice() {
+	_, err := Listen(suite.socket)
+	suite.Error(err)
+}
+
+func (suite *DaemonSuite) TestQueries() {
+	client := suite.dial()
+
+	c, err := client.Which("com/example/Impl")
+	suite.Require().NoError(err)
+	suite.Equal(filepath.Join(suite.dir, "bin"), c.Entry.Path)
+	suite.Equal(11, c.ClassVersion.Release)
+
+	_, err = client.Which("com/example/Missing")
+	suite.EqualError(err, "class com/example/Missing not found")
+
+	d, err := client.Javap("com/example/Impl")
+	suite.Require().NoError(err)
+	suite.Equal("public class com.example.Impl extends com.example.Base", d.Declaration)
+	suite.Equal("com/example/Impl", d.Class.Name)
+
+	classes, err := client.Superclasses("com/example/Impl")
+	suite.NoError(err)
+	suite.Len(classes, 3)
+
+	// the classes up to a missing class are returned together with the error
+	classes, err = client.Superclasses("com/example/Broken")
+	var notFound *classpath.ClassNotFoundError
+	suite.Require().True(errors.As(err, &notFound))
+	suite.Equal("com/example/Gone", notFound.Name)
+	suite.Require().Len(classes, 1)
+	suite.Equal("com/example/Broken", classes[0].Name)
+
+	var subclasses []string
+	suite.NoError(client.Subclasses("com/example/Base", "", false, func(c *workspace.Class) {
+		subclasses = append(subclasses, c.Name)
+	}))
+	suite.Equal([]string{"com/example/Impl"}, subclasses)
+
+	var found []string
+	suite.NoError(client.Find("Base", false, func(c *workspace.Class) {
+		found = append(found, c.Name)
+	}))
+	suite.Equal([]string{"com/example/Base"}, found)
+
+	// the workspace is loaded once for all questions
+	suite.Len(suite.server.workspaces, 1)
+}
+
+func (suite *DaemonSuite) TestUnknownProject() {
+	client, err := Dial(suite.socket, Query{Dir: suite.T().TempDir(), Scope: "test"})
+	suite.Require().NoError(err)
+	defer func() { _ = client.Close() }()
+
+	_, err = client.Which("com/example/Impl")
+	suite.Error(err)
+	suite.Empty(suite.server.workspaces)
+}
+
+func (suite *DaemonSuite) TestChanged() {
+	client := suite.dial()
+	_, err := client.Which("com/example/Impl")
+	suite.Require().NoError(err)
+	suite.Empty(suite.server.changed())
+
+	// a class in a new package changes the directories of the output folder
+	suite.write("bin/com/example/sub/Sub.class", classbuild.New("com/example/sub/Sub", "com/example/Base").Bytes())
+	suite.Len(suite.server.changed(), 1)
+
+	suite.server.workspaces = make(map[Query]*loadedWorkspace)
+	_, err = client.Which("com/example/sub/Sub")
+	suite.Require().NoError(err)
+	suite.Empty(suite.server.changed())
+
+	// so does a change of the project files
+	later := time.Now().Add(time.Minute)
+	suite.Require().NoError(os.Chtimes(filepath.Join(suite.dir, ".classpath"), later, later))
+	suite.Len(suite.server.changed(), 1)
+}
+
+// filesProject is a project that is loaded from the given files.
+type filesProject struct {
+	jt.Project
+	files []string
+}
+
+func (p filesProject) Files() []string {
+	return p.files
+}
+
+func (suite *DaemonSuite) TestSnapshotFiles() {
+	suite.write("core/core.iml", []byte(`<module/>`))
+	module := filepath.Join(suite.dir, "core", "core.iml")
+	library := filepath.Join(suite.dir, ".idea", "libraries", "junit.xml")
+
+	s := takeSnapshot(suite.dir, filesProject{files: []string{module, library}}, classpath.NewClasspath())
+	suite.False(s.changed())
+
+	// files that the project is loaded from are watched, wherever they are located
+	later := time.Now().Add(time.Minute)
+	suite.Require().NoError(os.Chtimes(module, later, later))
+	suite.True(s.changed())
+
+	// including those that don't exist yet
+	s = takeSnapshot(suite.dir, filesProject{files: []string{module, library}}, classpath.NewClasspath())
+	suite.write(".idea/libraries/junit.xml", []byte(`<component/>`))
+	suite.True(s.changed())
+}
@@ -0,0 +1,120 @@
+package daemon
+
+import (
+	"errors"
+
+	"github.com/tsatke/jt/classpath"
+	"github.com/tsatke/jt/workspace"
+)
+
+// Query identifies the workspace that a request is about.
+type Query struct {
+	// Dir is the absolute path of the project directory.
+	Dir string `json:"dir"`
+	// Scope is the scope of the classpath, one of compile, runtime, test or provided.
+	Scope string `json:"scope"`
+	// Release is the Java release for which multi-release jars are resolved,
+	// or 0 for the release of the project JDK.
+	Release int `json:"release"`
+}
+
+// FindArgs are the arguments of JT.Find.
+type FindArgs struct {
+	Query
+	Name        string `json:"name"`
+	OnlyProject bool   `json:"onlyProject"`
+}
+
+// ClassArgs are the arguments of JT.Which, JT.Javap and JT.Superclass.
+type ClassArgs struct {
+	Query
+	Name string `json:"name"`
+}
+
+// SubclassArgs are the arguments of JT.Subclass.
+type SubclassArgs struct {
+	Query
+	Name   string `json:"name"`
+	Filter string `json:"filter"`
+	Invert bool   `json:"invert"`
+}
+
+// SuperclassReply is the reply of JT.Superclass. The classes up to a class that fails to open
+// are returned together with the error, since net/rpc drops the reply of methods that fail.
+type SuperclassReply struct {
+	Classes []*workspace.Class `json:"classes"`
+	// NotFound is the class that is not on the classpath, if it ends the superclasses early.
+	NotFound string `json:"notFound,omitempty"`
+	// Error is any other error that ends the superclasses early.
+	Error string `json:"error,omitempty"`
+}
+
+// Service is the JSON-RPC service of the daemon. Its methods answer the questions of the
+// commands with the same names, see the methods of workspace.Workspace.
+type Service struct {
+	server *Server
+}
+
+func (s *Service) Find(args *FindArgs, reply *[]*workspace.Class) error {
+	w, err := s.server.workspace(args.Query)
+	if err != nil {
+		return err
+	}
+	*reply = []*workspace.Class{}
+	return w.Find(args.Name, args.OnlyProject, func(c *workspace.Class) {
+		*reply = append(*reply, c)
+	})
+}
+
+func (s *Service) Which(args *ClassArgs, reply *workspace.Class) error {
+	w, err := s.server.workspace(args.Query)
+	if err != nil {
+		return err
+	}
+	c, err := w.Which(args.Name)
+	if err != nil {
+		return err
+	}
+	*reply = *c
+	return nil
+}
+
+func (s *Service) Javap(args *ClassArgs, reply *workspace.Declarations) error {
+	w, err := s.server.workspace(args.Query)
+	if err != nil {
+		return err
+	}
+	d, err := w.Javap(args.Name)
+	if err != nil {
+		return err
+	}
+	*reply = *d
+	return nil
+}
+
+func (s *Service) Superclass(args *ClassArgs, reply *SuperclassReply) error {
+	w, err := s.server.workspace(args.Query)
+	if err != nil {
+		return err
+	}
+	classes, err := w.Superclasses(args.Name)
+	reply.Classes = classes
+	var notFound *classpath.ClassNotFoundError
+	if errors.As(err, &notFound) {
+		reply.NotFound = notFound.Name
+	} else if err != nil {
+		reply.Error = err.Error()
+	}
+	return nil
+}
+
+func (s *Service) Subclass(args *SubclassArgs, reply *[]*workspace.Class) error {
+	w, err := s.server.workspace(args.Query)
+	if err != nil {
+		return err
+	}
+	*reply = []*workspace.Class{}
+	return w.Subclasses(args.Name, args.Filter, args.Invert, func(c *workspace.Class) {
+		*reply = append(*reply, c)
+	})
+}
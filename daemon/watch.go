@@ -0,0 +1,98 @@
+package daemon
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/tsatke/jt"
+	"github.com/tsatke/jt/classpath"
+	"github.com/tsatke/jt/internal/eclipse"
+	"github.com/tsatke/jt/internal/intellij"
+	"github.com/tsatke/jt/internal/maven"
+)
+
+// projectFiles are the files in the directory of a project that describe its classpath.
+var projectFiles = []string{
+	maven.PomFileName,
+	eclipse.ClasspathFileName,
+	eclipse.ProjectFileName,
+	filepath.Join(intellij.IdeaDirName, intellij.ModulesFileName),
+	filepath.Join(intellij.IdeaDirName, intellij.MiscFileName),
+}
+
+// fileLister is implemented by projects that know the files they are loaded from beyond
+// projectFiles, such as the library and module files of IntelliJ projects.
+type fileLister interface {
+	Files() []string
+}
+
+// snapshot holds the modification times of the files that a workspace was loaded from, by their
+// path. Files that don't exist have the zero time, so that creating them is noticed as well.
+type snapshot map[string]time.Time
+
+// takeSnapshot records the modification times of the project files in the given directory, of
+// the files that the project is loaded from and of the entries of the classpath. For output folders, the modification times of all directories
+// below them are recorded, since they change whenever classes are added or removed. Changed
+// content of classes doesn't matter, because classes are read from disk whenever they are needed.
+func takeSnapshot(dir string, project jt.Project, cp *classpath.Classpath) snapshot {
+	s := make(snapshot)
+	for _, name := range projectFiles {
+		s.add(filepath.Join(dir, name))
+	}
+	if l, ok := project.(fileLister); ok {
+		for _, path := range l.Files() {
+			s.add(path)
+		}
+	}
+
+	for _, entry := range cp.Entries {
+		switch entry.Type {
+		case classpath.EntryTypeSource:
+			// sources are searched on every request
+		case classpath.EntryTypeOutput:
+			s.addTree(entry.Path)
+		default:
+			s.add(entry.Path)
+		}
+	}
+	return s
+}
+
+func (s snapshot) add(path string) {
+	info, err := os.Stat(path)
+	if err != nil {
+		s[path] = time.Time{}
+		return
+	}
+	s[path] = info.ModTime()
+}
+
+func (s snapshot) addTree(root string) {
+	s.add(root)
+	_ = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() || path == root {
+			return nil
+		}
+		s.add(path)
+		return nil
+	})
+}
+
+// changed returns whether any of the files changed since the snapshot was taken.
+func (s snapshot) changed() bool {
+	for path, modTime := range s {
+		info, err := os.Stat(path)
+		if err != nil {
+			if !modTime.IsZero() {
+				return true
+			}
+			continue
+		}
+		if !info.ModTime().Equal(modTime) {
+			return true
+		}
+	}
+	return false
+}
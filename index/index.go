@@ -0,0 +1,339 @@
+// Package index maintains an index of the classes in the jars of local repositories, such as
+// ~/.m2/repository, which answers which artifact provides a class without network access.
+package index
+
+import (
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/tsatke/jt/jar"
+)
+
+// formatVersion is incremented whenever the structure of the index changes,
+// so that indices written by older versions are rebuilt.
+const formatVersion = 1
+
+// Index maps jars in local repositories to the classes that they contain.
+type Index struct {
+	Version int
+	// Jars holds the indexed jars by their path.
+	Jars map[string]*Jar
+}
+
+// Jar is an indexed jar. Size and ModTime are used to detect whether the jar
+// has changed since it was indexed.
+type Jar struct {
+	Path    string
+	Size    int64
+	ModTime time.Time
+	// Artifact holds the Maven coordinates of the jar, or is empty if the jar
+	// could not be identified.
+	Artifact string
+	Classes  []string
+}
+
+// Stats holds the number of jars that were touched by an update.
+type Stats struct {
+	Added     int
+	Updated   int
+	Removed   int
+	Unchanged int
+}
+
+// New creates an empty index.
+func New() *Index {
+	return &Index{
+		Version: formatVersion,
+		Jars:    make(map[string]*Jar),
+	}
+}
+
+// DefaultPath returns the location of the index in the user cache directory.
+func DefaultPath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("user cache dir: %w", err)
+	}
+	return filepath.Join(dir, "jt", "index.gob"), nil
+}
+
+// DefaultRoots returns the local Maven repository and the Gradle cache of the current user,
+// if they exist.
+func DefaultRoots() []string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil
+	}
+	var roots []string
+	for _, root := range []string{
+		filepath.Join(home, ".m2", "repository"),
+		filepath.Join(home, ".gradle", "caches", "modules-2", "files-2.1"),
+	} {
+		if info, err := os.Stat(root); err == nil && info.IsDir() {
+			roots = append(roots, root)
+		}
+	}
+	return roots
+}
+
+// Load reads the index at the given path. If there is no index yet, or it was written
+// in an outdated format, an empty index is returned.
+func Load(path string) (*Index, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return New(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	idx := New()
+	if err := gob.NewDecoder(f).Decode(idx); err != nil || idx.Version != formatVersion {
+		log.Debug().
+			AnErr("error", err).
+			Int("version", idx.Version).
+			Msg("discard outdated index")
+		return New(), nil
+	}
+	return idx, nil
+}
+
+// Save writes the index to the given path. The index is written to a temporary file first,
+// so that concurrent readers never see a partially written index.
+func (idx *Index) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("create directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("create: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if err := gob.NewEncoder(tmp).Encode(idx); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("encode: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("close: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("rename: %w", err)
+	}
+	return nil
+}
+
+// walkDir walks the roots of the index, it is replaced in tests.
+var walkDir = filepath.WalkDir
+
+// Update brings the index up to date with the jars below the given roots. Only jars that
+// are new or have changed since the last update are read, and jars that no longer exist
+// are removed. Jars below other roots are kept, as well as the jars below roots that could
+// not be walked completely, such as when files disappear while a build downloads them.
+func (idx *Index) Update(roots []string) (Stats, error) {
+	var stats Stats
+	seen := make(map[string]struct{})
+	var pending []*Jar
+	var walked []string
+	for _, root := range roots {
+		complete := true
+		err := walkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if path == root {
+					return err
+				}
+				log.Warn().
+					Err(err).
+					Str("path", path).
+					Msg("skip path")
+				complete = false
+				return nil
+			}
+			if d.IsDir() || !isIndexed(path) {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				log.Warn().
+					Err(err).
+					Str("path", path).
+					Msg("skip jar")
+				complete = false
+				return nil
+			}
+
+			seen[path] = struct{}{}
+			if existing := idx.Jars[path]; existing != nil && existing.Size == info.Size() && existing.ModTime.Equal(info.ModTime()) {
+				stats.Unchanged++
+				return nil
+			}
+			pending = append(pending, &Jar{
+				Path:    path,
+				Size:    info.Size(),
+				ModTime: info.ModTime(),
+			})
+			return nil
+		})
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return stats, fmt.Errorf("walk %s: %w", root, err)
+		}
+		// the jars of a root that does not exist are removed
+		if complete {
+			walked = append(walked, root)
+		}
+	}
+
+	for path := range idx.Jars {
+		if _, ok := seen[path]; ok || !within(path, walked) {
+			continue
+		}
+		delete(idx.Jars, path)
+		stats.Removed++
+	}
+
+	readJars(pending)
+	for _, j := range pending {
+		if idx.Jars[j.Path] == nil {
+			stats.Added++
+		} else {
+			stats.Updated++
+		}
+		idx.Jars[j.Path] = j
+	}
+	return stats, nil
+}
+
+// readJars reads the classes and artifacts of the given jars concurrently.
+// Jars that can't be read are indexed without classes, so that they are not
+// read again until they change.
+func readJars(jars []*Jar) {
+	ch := make(chan *Jar)
+	go func() {
+		for _, j := range jars {
+			ch <- j
+		}
+		close(ch)
+	}()
+
+	wg := &sync.WaitGroup{}
+	for i := 0; i < runtime.NumCPU(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range ch {
+				if err := readJar(j); err != nil {
+					log.Debug().
+						Err(err).
+						Str("jar", j.Path).
+						Msg("skip jar")
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func readJar(j *Jar) error {
+	f, err := jar.Open(j.Path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	j.Classes = f.ListClasses()
+	sort.Strings(j.Classes)
+	artifact, err := f.Identify(j.Path)
+	if err != nil {
+		return err
+	}
+	if artifact != nil {
+		j.Artifact = artifact.String()
+	}
+	return nil
+}
+
+// Lookup returns the jars within the given roots that contain the class with the given
+// name, such as org/apache/commons/lang3/StringUtils, sorted by their path. If the name has
+// no package, classes with that simple name in any package are found. The index keeps the
+// jars of other roots, which are not returned.
+func (idx *Index) Lookup(name string, roots []string) []*Jar {
+	simple := !strings.Contains(name, "/")
+	var result []*Jar
+	for _, j := range idx.Jars {
+		if !within(j.Path, roots) {
+			continue
+		}
+		if simple {
+			if containsSimpleName(j.Classes, name) {
+				result = append(result, j)
+			}
+		} else {
+			i := sort.SearchStrings(j.Classes, name)
+			if i < len(j.Classes) && j.Classes[i] == name {
+				result = append(result, j)
+			}
+		}
+	}
+	sort.Slice(result, func(i, k int) bool {
+		return result[i].Path < result[k].Path
+	})
+	return result
+}
+
+// Matches returns the classes of the jar that match the given name like Lookup does.
+func (j *Jar) Matches(name string) []string {
+	if strings.Contains(name, "/") {
+		return []string{name}
+	}
+	var matches []string
+	for _, class := range j.Classes {
+		if simpleName(class) == name {
+			matches = append(matches, class)
+		}
+	}
+	return matches
+}
+
+func containsSimpleName(classes []string, name string) bool {
+	for _, class := range classes {
+		if simpleName(class) == name {
+			return true
+		}
+	}
+	return false
+}
+
+func simpleName(class string) string {
+	return class[strings.LastIndexByte(class, '/')+1:]
+}
+
+// isIndexed returns whether the file with the given path is a jar that may contain classes.
+// Source and javadoc jars are skipped.
+func isIndexed(path string) bool {
+	name := strings.ToLower(filepath.Base(path))
+	return strings.HasSuffix(name, ".jar") &&
+		!strings.HasSuffix(name, "-sources.jar") &&
+		!strings.HasSuffix(name, "-javadoc.jar")
+}
+
+// within returns whether the given path is located below one of the roots.
+func within(path string, roots []string) bool {
+	for _, root := range roots {
+		rel, err := filepath.Rel(root, path)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
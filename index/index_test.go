@@ -0,0 +1,150 @@
+package index
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+func TestIndexSuite(t *testing.T) {
+	suite.Run(t, new(IndexSuite))
+}
+
+type IndexSuite struct {
+	suite.Suite
+
+	repository string
+}
+
+func (suite *IndexSuite) SetupTest() {
+	suite.repository = filepath.Join(suite.T().TempDir(), "repository")
+	suite.copyJar("test1.jar", "com/example/app/1.0/app-1.0.jar")
+	suite.copyJar("test1.jar", "com/example/app/1.0/app-1.0-sources.jar")
+	suite.copyJar("boot.jar", "org/example/boot/2.0/boot-2.0.jar")
+	suite.write("org/example/broken/1.0/broken-1.0.jar", []byte("not a jar"))
+}
+
+func (suite *IndexSuite) copyJar(name, target string) {
+	data, err := os.ReadFile(filepath.Join("..", "jar", "testdata", "jars", name))
+	suite.Require().NoError(err)
+	suite.write(target, data)
+}
+
+func (suite *IndexSuite) write(name string, data []byte) {
+	path := filepath.Join(suite.repository, filepath.FromSlash(name))
+	suite.Require().NoError(os.MkdirAll(filepath.Dir(path), 0755))
+	suite.Require().NoError(os.WriteFile(path, data, 0644))
+}
+
+func (suite *IndexSuite) roots() []string {
+	return []string{suite.repository}
+}
+
+func (suite *IndexSuite) artifacts(jars []*Jar) []string {
+	var artifacts []string
+	for _, j := range jars {
+		artifacts = append(artifacts, j.Artifact)
+	}
+	return artifacts
+}
+
+func (suite *IndexSuite) TestUpdate() {
+	idx := New()
+	stats, err := idx.Update([]string{suite.repository})
+	suite.Require().NoError(err)
+	suite.Equal(Stats{Added: 3}, stats)
+
+	suite.Equal([]string{"com.example:app:1.0"}, suite.artifacts(idx.Lookup("com/github/tsatke/jt/App", suite.roots())))
+	suite.Equal([]string{"com.example:app:1.0"}, suite.artifacts(idx.Lookup("App", suite.roots())))
+	suite.Empty(idx.Lookup("com/github/tsatke/jt/Missing", suite.roots()))
+	suite.Empty(idx.Lookup("tsatke/jt/App", suite.roots()))
+
+	broken := idx.Jars[filepath.Join(suite.repository, "org", "example", "broken", "1.0", "broken-1.0.jar")]
+	suite.Require().NotNil(broken)
+	suite.Empty(broken.Classes)
+
+	// nothing changed
+	stats, err = idx.Update([]string{suite.repository})
+	suite.Require().NoError(err)
+	suite.Equal(Stats{Unchanged: 3}, stats)
+
+	// a new, a changed and a removed jar
+	suite.copyJar("test1.jar", "com/example/app/1.1/app-1.1.jar")
+	changed := filepath.Join(suite.repository, "org", "example", "broken", "1.0", "broken-1.0.jar")
+	suite.Require().NoError(os.Chtimes(changed, time.Now(), time.Now().Add(time.Hour)))
+	suite.Require().NoError(os.Remove(filepath.Join(suite.repository, "org", "example", "boot", "2.0", "boot-2.0.jar")))
+
+	stats, err = idx.Update([]string{suite.repository})
+	suite.Require().NoError(err)
+	suite.Equal(Stats{Added: 1, Updated: 1, Removed: 1, Unchanged: 1}, stats)
+	suite.Equal([]string{"com.example:app:1.0", "com.example:app:1.1"}, suite.artifacts(idx.Lookup("com/github/tsatke/jt/App", suite.roots())))
+
+	// jars of other roots are kept
+	stats, err = idx.Update([]string{filepath.Join(suite.repository, "org")})
+	suite.Require().NoError(err)
+	suite.Equal(Stats{Unchanged: 1}, stats)
+	suite.Len(idx.Jars, 3)
+
+	// but only the jars of the given roots are looked up
+	suite.Empty(idx.Lookup("com/github/tsatke/jt/App", []string{filepath.Join(suite.repository, "org")}))
+}
+
+func (suite *IndexSuite) TestUpdateIncompleteWalk() {
+	idx := New()
+	_, err := idx.Update(suite.roots())
+	suite.Require().NoError(err)
+	suite.Require().NoError(os.Remove(filepath.Join(suite.repository, "com", "example", "app", "1.0", "app-1.0.jar")))
+
+	// the org directory can't be read, like a directory that is removed during the walk
+	defer func() { walkDir = filepath.WalkDir }()
+	walkDir = func(root string, fn fs.WalkDirFunc) error {
+		return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if d != nil && d.IsDir() && d.Name() == "org" {
+				if err := fn(path, d, errors.New("permission denied")); err != nil {
+					return err
+				}
+				return fs.SkipDir
+			}
+			return fn(path, d, err)
+		})
+	}
+	stats, err := idx.Update(suite.roots())
+	suite.Require().NoError(err)
+	// nothing is removed from a root that was not walked completely
+	suite.Equal(Stats{}, stats)
+	suite.Len(idx.Jars, 3)
+
+	// a missing root is empty
+	walkDir = filepath.WalkDir
+	suite.Require().NoError(os.RemoveAll(suite.repository))
+	stats, err = idx.Update(suite.roots())
+	suite.Require().NoError(err)
+	suite.Equal(Stats{Removed: 3}, stats)
+}
+
+func (suite *IndexSuite) TestSaveLoad() {
+	path := filepath.Join(suite.T().TempDir(), "cache", "index.gob")
+
+	idx, err := Load(path)
+	suite.Require().NoError(err)
+	suite.Empty(idx.Jars)
+
+	_, err = idx.Update([]string{suite.repository})
+	suite.Require().NoError(err)
+	suite.Require().NoError(idx.Save(path))
+
+	loaded, err := Load(path)
+	suite.Require().NoError(err)
+	suite.Len(loaded.Jars, 3)
+	suite.Equal([]string{"com.example:app:1.0"}, suite.artifacts(loaded.Lookup("com/github/tsatke/jt/App", suite.roots())))
+
+	suite.Require().NoError(os.WriteFile(path, []byte("garbage"), 0644))
+	loaded, err = Load(path)
+	suite.Require().NoError(err)
+	suite.Empty(loaded.Jars)
+}
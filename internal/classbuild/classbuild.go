@@ -0,0 +1,503 @@
+// Package classbuild assembles class files, which is used to create classes for tests
+// without a java compiler.
+package classbuild
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+)
+
+const (
+	AccPublic     = 0x0001
+	AccPrivate    = 0x0002
+	AccProtected  = 0x0004
+	AccStatic     = 0x0008
+	AccFinal      = 0x0010
+	AccSuper      = 0x0020
+	AccBridge     = 0x0040
+	AccVarargs    = 0x0080
+	AccInterface  = 0x0200
+	AccAbstract   = 0x0400
+	AccSynthetic  = 0x1000
+	AccAnnotation = 0x2000
+	AccEnum       = 0x4000
+	AccModule     = 0x8000
+)
+
+// Class is a class file under construction. Constant pool entries are created on demand
+// and deduplicated, so that their indices can be used in attributes and code.
+type Class struct {
+	Major       uint16
+	AccessFlags uint16
+	Name        string
+	Super       string
+	Interfaces  []string
+	Fields      []*Member
+	Methods     []*Member
+	Attributes  []Attribute
+
+	pool      bytes.Buffer
+	poolCount uint16
+	indices   map[string]uint16
+}
+
+// Member is a field or a method.
+type Member struct {
+	AccessFlags uint16
+	Name        string
+	Descriptor  string
+	Attributes  []Attribute
+}
+
+// Attribute is an attribute with its raw content.
+type Attribute struct {
+	Name string
+	Data []byte
+}
+
+// New creates a public class with the given name and superclass.
+// The class has the class file version of Java 11.
+func New(name, super string) *Class {
+	return &Class{
+		Major:       55,
+		AccessFlags: AccPublic | AccSuper,
+		Name:        name,
+		Super:       super,
+		poolCount:   1,
+		indices:     make(map[string]uint16),
+	}
+}
+
+// Field adds a field to the class.
+func (c *Class) Field(access uint16, name, descriptor string, attributes ...Attribute) *Member {
+	m := &Member{AccessFlags: access, Name: name, Descriptor: descriptor, Attributes: attributes}
+	c.Fields = append(c.Fields, m)
+	return m
+}
+
+// Method adds a method to the class.
+func (c *Class) Method(access uint16, name, descriptor string, attributes ...Attribute) *Member {
+	m := &Member{AccessFlags: access, Name: name, Descriptor: descriptor, Attributes: attributes}
+	c.Methods = append(c.Methods, m)
+	return m
+}
+
+func (c *Class) constant(key string, size uint16, write func(b *bytes.Buffer)) uint16 {
+	if index, ok := c.indices[key]; ok {
+		return index
+	}
+	index := c.poolCount
+	write(&c.pool)
+	c.poolCount += size
+	c.indices[key] = index
+	return index
+}
+
+func (c *Class) Utf8(s string) uint16 {
+	return c.constant("utf8:"+s, 1, func(b *bytes.Buffer) {
+		b.WriteByte(1)
+		writeU2(b, uint16(len(s)))
+		b.WriteString(s)
+	})
+}
+
+func (c *Class) Integer(v int32) uint16 {
+	key := fmt.Sprint("int:", v)
+	return c.constant(key, 1, func(b *bytes.Buffer) {
+		b.WriteByte(3)
+		writeU4(b, uint32(v))
+	})
+}
+
+func (c *Class) Long(v int64) uint16 {
+	key := fmt.Sprint("long:", v)
+	return c.constant(key, 2, func(b *bytes.Buffer) {
+		b.WriteByte(5)
+		_ = binary.Write(b, binary.BigEndian, v)
+	})
+}
+
+func (c *Class) Double(v float64) uint16 {
+	key := fmt.Sprint("double:", math.Float64bits(v))
+	return c.constant(key, 2, func(b *bytes.Buffer) {
+		b.WriteByte(6)
+		_ = binary.Write(b, binary.BigEndian, math.Float64bits(v))
+	})
+}
+
+func (c *Class) Class(name string) uint16 {
+	nameIndex := c.Utf8(name)
+	return c.constant("class:"+name, 1, func(b *bytes.Buffer) {
+		b.WriteByte(7)
+		writeU2(b, nameIndex)
+	})
+}
+
+func (c *Class) String(s string) uint16 {
+	index := c.Utf8(s)
+	return c.constant("string:"+s, 1, func(b *bytes.Buffer) {
+		b.WriteByte(8)
+		writeU2(b, index)
+	})
+}
+
+func (c *Class) NameAndType(name, descriptor string) uint16 {
+	nameIndex := c.Utf8(name)
+	descriptorIndex := c.Utf8(descriptor)
+	return c.constant("nat:"+name+":"+descriptor, 1, func(b *bytes.Buffer) {
+		b.WriteByte(12)
+		writeU2(b, nameIndex)
+		writeU2(b, descriptorIndex)
+	})
+}
+
+func (c *Class) ref(tag byte, owner, name, descriptor string) uint16 {
+	classIndex := c.Class(owner)
+	natIndex := c.NameAndType(name, descriptor)
+	return c.constant(fmt.Sprint(tag)+"ref:"+owner+"."+name+":"+descriptor, 1, func(b *bytes.Buffer) {
+		b.WriteByte(tag)
+		writeU2(b, classIndex)
+		writeU2(b, natIndex)
+	})
+}
+
+func (c *Class) Fieldref(owner, name, descriptor string) uint16 {
+	return c.ref(9, owner, name, descriptor)
+}
+
+func (c *Class) Methodref(owner, name, descriptor string) uint16 {
+	return c.ref(10, owner, name, descriptor)
+}
+
+func (c *Class) InterfaceMethodref(owner, name, descriptor string) uint16 {
+	return c.ref(11, owner, name, descriptor)
+}
+
+func (c *Class) MethodType(descriptor string) uint16 {
+	index := c.Utf8(descriptor)
+	return c.constant("methodtype:"+descriptor, 1, func(b *bytes.Buffer) {
+		b.WriteByte(16)
+		writeU2(b, index)
+	})
+}
+
+// MethodHandle creates a method handle constant of the given kind, such as 6 for
+// REF_invokeStatic, for a field or method reference.
+func (c *Class) MethodHandle(kind byte, ref uint16) uint16 {
+	return c.constant(fmt.Sprint("methodhandle:", kind, ":", ref), 1, func(b *bytes.Buffer) {
+		b.WriteByte(15)
+		b.WriteByte(kind)
+		writeU2(b, ref)
+	})
+}
+
+// InvokeDynamic creates the constant of an invokedynamic call site, whose bootstrap method
+// is at the given index of the BootstrapMethods attribute.
+func (c *Class) InvokeDynamic(bootstrap uint16, name, descriptor string) uint16 {
+	natIndex := c.NameAndType(name, descriptor)
+	return c.constant(fmt.Sprint("indy:", bootstrap, ":", natIndex), 1, func(b *bytes.Buffer) {
+		b.WriteByte(18)
+		writeU2(b, bootstrap)
+		writeU2(b, natIndex)
+	})
+}
+
+// BootstrapMethod is a method handle with its static arguments, which are constant pool indices.
+type BootstrapMethod struct {
+	MethodRef uint16
+	Arguments []uint16
+}
+
+// BootstrapMethods creates the BootstrapMethods attribute of a class.
+func (c *Class) BootstrapMethods(methods ...BootstrapMethod) Attribute {
+	values := []interface{}{len(methods)}
+	for _, m := range methods {
+		values = append(values, m.MethodRef, len(m.Arguments))
+		for _, a := range m.Arguments {
+			values = append(values, a)
+		}
+	}
+	return Attribute{Name: "BootstrapMethods", Data: Data(values...)}
+}
+
+func (c *Class) Module(name string) uint16 {
+	index := c.Utf8(name)
+	return c.constant("module:"+name, 1, func(b *bytes.Buffer) {
+		b.WriteByte(19)
+		writeU2(b, index)
+	})
+}
+
+func (c *Class) Package(name string) uint16 {
+	index := c.Utf8(name)
+	return c.constant("package:"+name, 1, func(b *bytes.Buffer) {
+		b.WriteByte(20)
+		writeU2(b, index)
+	})
+}
+
+// LineNumber maps an offset in the code of a method to a source line.
+type LineNumber struct {
+	Pc   int
+	Line int
+}
+
+// Code creates the Code attribute of a method with the given bytecode, which can be assembled
+// with Data, and an optional LineNumberTable.
+func (c *Class) Code(code []byte, lines ...LineNumber) Attribute {
+	var attributes []Attribute
+	if len(lines) > 0 {
+		values := []interface{}{len(lines)}
+		for _, l := range lines {
+			values = append(values, l.Pc, l.Line)
+		}
+		attributes = append(attributes, Attribute{Name: "LineNumberTable", Data: Data(values...)})
+	}
+
+	var b bytes.Buffer
+	writeU2(&b, 10) // max stack
+	writeU2(&b, 10) // max locals
+	writeU4(&b, uint32(len(code)))
+	b.Write(code)
+	writeU2(&b, 0) // exception table
+	c.writeAttributes(&b, attributes)
+	return Attribute{Name: "Code", Data: b.Bytes()}
+}
+
+// Signature creates the Signature attribute of a class, field or method.
+func (c *Class) Signature(signature string) Attribute {
+	return Attribute{Name: "Signature", Data: Data(c.Utf8(signature))}
+}
+
+// ConstantValue creates the ConstantValue attribute of a field with the constant at the given index.
+func (c *Class) ConstantValue(index uint16) Attribute {
+	return Attribute{Name: "ConstantValue", Data: Data(index)}
+}
+
+// Exceptions creates the Exceptions attribute of a method.
+func (c *Class) Exceptions(exceptions ...string) Attribute {
+	values := []interface{}{len(exceptions)}
+	for _, e := range exceptions {
+		values = append(values, c.Class(e))
+	}
+	return Attribute{Name: "Exceptions", Data: Data(values...)}
+}
+
+// Annotation is an encoded annotation, see Class.Annotation.
+type Annotation []byte
+
+// ElementValue is the encoded value of an element of an annotation.
+type ElementValue []byte
+
+// AnnotationElement is an element of an annotation with its value.
+type AnnotationElement struct {
+	Name  string
+	Value ElementValue
+}
+
+// Annotation creates an annotation of the given type with the values of its elements.
+func (c *Class) Annotation(typ string, elements ...AnnotationElement) Annotation {
+	values := []interface{}{c.Utf8("L" + typ + ";"), len(elements)}
+	for _, e := range elements {
+		values = append(values, c.Utf8(e.Name), []byte(e.Value))
+	}
+	return Data(values...)
+}
+
+// StringValue creates a string value of an annotation element.
+func (c *Class) StringValue(s string) ElementValue {
+	return Data(byte('s'), c.Utf8(s))
+}
+
+// EnumValue creates the value of an annotation element that is a constant of the given enum class.
+func (c *Class) EnumValue(enum, name string) ElementValue {
+	return Data(byte('e'), c.Utf8("L"+enum+";"), c.Utf8(name))
+}
+
+// ClassValue creates a class literal value of an annotation element, which is given
+// as the return descriptor of the class, such as Ljava/lang/String; or V.
+func (c *Class) ClassValue(descriptor string) ElementValue {
+	return Data(byte('c'), c.Utf8(descriptor))
+}
+
+// AnnotationValue creates the value of an annotation element that is a nested annotation.
+func AnnotationValue(a Annotation) ElementValue {
+	return Data(byte('@'), []byte(a))
+}
+
+// ArrayValue creates the value of an annotation element that is an array.
+func ArrayValue(values ...ElementValue) ElementValue {
+	data := []interface{}{byte('['), len(values)}
+	for _, v := range values {
+		data = append(data, []byte(v))
+	}
+	return Data(data...)
+}
+
+// Annotations creates the RuntimeVisibleAnnotations or RuntimeInvisibleAnnotations attribute
+// of a class, field or method.
+func (c *Class) Annotations(visible bool, annotations ...Annotation) Attribute {
+	name := "RuntimeInvisibleAnnotations"
+	if visible {
+		name = "RuntimeVisibleAnnotations"
+	}
+	values := []interface{}{len(annotations)}
+	for _, a := range annotations {
+		values = append(values, []byte(a))
+	}
+	return Attribute{Name: name, Data: Data(values...)}
+}
+
+// ParameterAnnotations creates the RuntimeVisibleParameterAnnotations or
+// RuntimeInvisibleParameterAnnotations attribute of a method.
+func (c *Class) ParameterAnnotations(visible bool, parameters ...[]Annotation) Attribute {
+	name := "RuntimeInvisibleParameterAnnotations"
+	if visible {
+		name = "RuntimeVisibleParameterAnnotations"
+	}
+	values := []interface{}{byte(len(parameters))}
+	for _, annotations := range parameters {
+		values = append(values, len(annotations))
+		for _, a := range annotations {
+			values = append(values, []byte(a))
+		}
+	}
+	return Attribute{Name: name, Data: Data(values...)}
+}
+
+// NewModuleInfo creates the module-info class of a module.
+func NewModuleInfo() *Class {
+	c := New("module-info", "")
+	c.AccessFlags = AccModule
+	return c
+}
+
+// Provides is a provides directive of a module.
+type Provides struct {
+	Service string
+	With    []string
+}
+
+// ModuleAttribute creates the Module attribute of a module-info class.
+func (c *Class) ModuleAttribute(name string, requires, exports, uses []string, provides []Provides) Attribute {
+	values := []interface{}{c.Module(name), 0, 0}
+	values = append(values, len(requires))
+	for _, r := range requires {
+		values = append(values, c.Module(r), 0, 0)
+	}
+	values = append(values, len(exports))
+	for _, e := range exports {
+		values = append(values, c.Package(e), 0, 0)
+	}
+	values = append(values, 0) // opens
+	values = append(values, len(uses))
+	for _, u := range uses {
+		values = append(values, c.Class(u))
+	}
+	values = append(values, len(provides))
+	for _, p := range provides {
+		values = append(values, c.Class(p.Service), len(p.With))
+		for _, with := range p.With {
+			values = append(values, c.Class(with))
+		}
+	}
+	return Attribute{Name: "Module", Data: Data(values...)}
+}
+
+// WriteFile assembles the class file and writes it below the given directory, such as an
+// output folder, in the location that matches its name.
+func (c *Class) WriteFile(dir string) error {
+	path := filepath.Join(dir, filepath.FromSlash(c.Name)+".class")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("create directory: %w", err)
+	}
+	if err := os.WriteFile(path, c.Bytes(), 0644); err != nil {
+		return fmt.Errorf("write class file: %w", err)
+	}
+	return nil
+}
+
+// Bytes assembles the class file.
+func (c *Class) Bytes() []byte {
+	// resolve all constants before writing the pool
+	thisClass := c.Class(c.Name)
+	var superClass uint16
+	if c.Super != "" {
+		superClass = c.Class(c.Super)
+	}
+	interfaces := make([]uint16, len(c.Interfaces))
+	for i, iface := range c.Interfaces {
+		interfaces[i] = c.Class(iface)
+	}
+	var body bytes.Buffer
+	writeU2(&body, c.AccessFlags)
+	writeU2(&body, thisClass)
+	writeU2(&body, superClass)
+	writeU2(&body, uint16(len(interfaces)))
+	for _, index := range interfaces {
+		writeU2(&body, index)
+	}
+	for _, members := range [][]*Member{c.Fields, c.Methods} {
+		writeU2(&body, uint16(len(members)))
+		for _, m := range members {
+			writeU2(&body, m.AccessFlags)
+			writeU2(&body, c.Utf8(m.Name))
+			writeU2(&body, c.Utf8(m.Descriptor))
+			c.writeAttributes(&body, m.Attributes)
+		}
+	}
+	c.writeAttributes(&body, c.Attributes)
+
+	var b bytes.Buffer
+	writeU4(&b, 0xCAFEBABE)
+	writeU2(&b, 0)
+	writeU2(&b, c.Major)
+	writeU2(&b, c.poolCount)
+	b.Write(c.pool.Bytes())
+	b.Write(body.Bytes())
+	return b.Bytes()
+}
+
+func (c *Class) writeAttributes(b *bytes.Buffer, attributes []Attribute) {
+	writeU2(b, uint16(len(attributes)))
+	for _, attr := range attributes {
+		writeU2(b, c.Utf8(attr.Name))
+		writeU4(b, uint32(len(attr.Data)))
+		b.Write(attr.Data)
+	}
+}
+
+// Data assembles the content of an attribute from u1 (byte), u2 (uint16), u4 (uint32)
+// values and raw byte slices.
+func Data(values ...interface{}) []byte {
+	var b bytes.Buffer
+	for _, v := range values {
+		switch v := v.(type) {
+		case byte:
+			b.WriteByte(v)
+		case uint16:
+			writeU2(&b, v)
+		case uint32:
+			writeU4(&b, v)
+		case int:
+			writeU2(&b, uint16(v))
+		case []byte:
+			b.Write(v)
+		default:
+			panic("unsupported attribute value")
+		}
+	}
+	return b.Bytes()
+}
+
+func writeU2(b *bytes.Buffer, v uint16) {
+	_ = binary.Write(b, binary.BigEndian, v)
+}
+
+func writeU4(b *bytes.Buffer, v uint32) {
+	_ = binary.Write(b, binary.BigEndian, v)
+}
@@ -0,0 +1,203 @@
+package eclipse
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/tsatke/jt/classpath"
+	"github.com/tsatke/jt/internal/maven"
+)
+
+const (
+	jreContainer    = "org.eclipse.jdt.launching.JRE_CONTAINER"
+	mavenContainer  = "org.eclipse.m2e.MAVEN2_CLASSPATH_CONTAINER"
+	userLibraryKind = "org.eclipse.jdt.USER_LIBRARY"
+)
+
+// classpathBuilder builds the classpath of a project, following references
+// to other projects in the workspace.
+type classpathBuilder struct {
+	scope classpath.Scope
+	cp    *classpath.Classpath
+
+	// paths holds all paths that are already on the classpath,
+	// since entries may be reachable through multiple projects.
+	paths map[string]struct{}
+	// projects holds the paths of all projects that have been added,
+	// to prevent endless recursion in cyclic project references.
+	projects map[string]struct{}
+}
+
+func (b *classpathBuilder) add(entry *classpath.Entry) {
+	if _, ok := b.paths[entry.Path]; ok {
+		return
+	}
+	b.paths[entry.Path] = struct{}{}
+	b.cp.Entries = append(b.cp.Entries, entry)
+}
+
+// addProject adds the entries of the given project to the classpath. For projects that
+// are referenced by other projects, only the output folders and exported entries are added.
+func (b *classpathBuilder) addProject(p *project, exportedOnly bool) error {
+	if _, ok := b.projects[p.path]; ok {
+		return nil
+	}
+	b.projects[p.path] = struct{}{}
+
+	ws := p.workspace
+	for _, entry := range p.entries(b.scope) {
+		if exportedOnly && !entry.Exported && entry.Kind != "src" && entry.Kind != "output" {
+			continue
+		}
+
+		switch entry.Kind {
+		case "src":
+			if entry.IsProjectReference() {
+				if exportedOnly && !entry.Exported {
+					continue
+				}
+				if err := b.addReferencedProject(p, strings.TrimPrefix(entry.Path, "/")); err != nil {
+					return err
+				}
+				continue
+			}
+
+			path, err := ws.resolvePath(p.path, entry.Path)
+			if err != nil {
+				return fmt.Errorf("resolve path: %w", err)
+			}
+			if !exportedOnly {
+				b.add(&classpath.Entry{
+					Type:       classpath.EntryTypeSource,
+					Path:       path,
+					Inclusions: splitPatterns(entry.Including),
+					Exclusions: splitPatterns(entry.Excluding),
+				})
+			}
+			if entry.Output != "" {
+				output, err := ws.resolvePath(p.path, entry.Output)
+				if err != nil {
+					return fmt.Errorf("resolve path: %w", err)
+				}
+				b.add(&classpath.Entry{Type: classpath.EntryTypeOutput, Path: output})
+			}
+		case "output":
+			path, err := ws.resolvePath(p.path, entry.Path)
+			if err != nil {
+				return fmt.Errorf("resolve path: %w", err)
+			}
+			b.add(&classpath.Entry{Type: classpath.EntryTypeOutput, Path: path})
+		case "lib":
+			path, err := ws.resolvePath(p.path, entry.Path)
+			if err != nil {
+				return fmt.Errorf("resolve path: %w", err)
+			}
+			b.addLibrary(path)
+		case "var":
+			path, ok := b.resolveVariablePath(ws, entry.Path)
+			if !ok {
+				log.Warn().
+					Str("project", p.Name()).
+					Str("path", entry.Path).
+					Msg("unresolved classpath variable")
+				continue
+			}
+			b.addLibrary(path)
+		case "con":
+			if err := b.addContainer(p, entry.Path); err != nil {
+				return fmt.Errorf("container %s: %w", entry.Path, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (b *classpathBuilder) addReferencedProject(p *project, name string) error {
+	location, ok := p.workspace.projectLocation(name)
+	if !ok {
+		log.Warn().
+			Str("project", p.Name()).
+			Str("reference", name).
+			Msg("referenced project not found in workspace")
+		return nil
+	}
+
+	referenced, err := loadProject(location, p.workspace)
+	if err != nil {
+		return fmt.Errorf("load referenced project %s: %w", name, err)
+	}
+	return b.addProject(referenced, true)
+}
+
+// addLibrary adds a jar file, or a folder containing class files.
+func (b *classpathBuilder) addLibrary(path string) {
+	typ := classpath.EntryTypeJar
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		typ = classpath.EntryTypeOutput
+	}
+	b.add(&classpath.Entry{Type: typ, Path: path})
+}
+
+func (b *classpathBuilder) resolveVariablePath(ws *workspace, path string) (string, bool) {
+	fragments := strings.SplitN(path, "/", 2)
+	resolved, ok := ws.variable(fragments[0])
+	if !ok {
+		return "", false
+	}
+	if len(fragments) == 1 {
+		return resolved, true
+	}
+	return filepath.Join(resolved, filepath.FromSlash(fragments[1])), true
+}
+
+func (b *classpathBuilder) addContainer(p *project, path string) error {
+	segments := strings.Split(path, "/")
+	switch segments[0] {
+	case jreContainer:
+		// the JRE is not added here, since it has to be at the beginning of the classpath, see project.JDK
+	case mavenContainer:
+		if !maven.IsMavenProject(p.path) {
+			log.Warn().
+				Str("project", p.Name()).
+				Msg("maven classpath container in project without pom")
+			return nil
+		}
+		mavenProject, err := maven.LoadProject(p.path)
+		if err != nil {
+			return fmt.Errorf("load maven project: %w", err)
+		}
+		dependencies, err := mavenProject.Dependencies(b.scope)
+		if err != nil {
+			return fmt.Errorf("maven dependencies: %w", err)
+		}
+		for _, entry := range dependencies.Entries {
+			b.add(entry)
+		}
+	case userLibraryKind:
+		name := strings.Join(segments[1:], "/")
+		archives, ok := p.workspace.userLibraries[name]
+		if !ok {
+			log.Warn().
+				Str("project", p.Name()).
+				Str("library", name).
+				Msg("user library not defined in workspace")
+			return nil
+		}
+		for _, archive := range archives {
+			path, err := p.workspace.resolvePath(p.path, archive)
+			if err != nil {
+				return fmt.Errorf("resolve path: %w", err)
+			}
+			b.addLibrary(path)
+		}
+	default:
+		log.Debug().
+			Str("project", p.Name()).
+			Str("container", path).
+			Msg("unsupported classpath container")
+	}
+	return nil
+}
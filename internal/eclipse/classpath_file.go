@@ -4,6 +4,8 @@ import (
 	"encoding/xml"
 	"fmt"
 	"io"
+	"path"
+	"strings"
 )
 
 type ClasspathFile struct {
@@ -17,6 +19,35 @@ type ClasspathEntry struct {
 	Including  string `xml:"including,attr"`
 	Excluding  string `xml:"excluding,attr"`
 	Sourcepath string `xml:"sourcepath,attr"`
+	Exported   bool   `xml:"exported,attr"`
+
+	Attributes []ClasspathAttribute `xml:"attributes>attribute"`
+}
+
+type ClasspathAttribute struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+// Attribute returns the value of the attribute with the given name,
+// or the empty string if the entry has no such attribute.
+func (e ClasspathEntry) Attribute(name string) string {
+	for _, attribute := range e.Attributes {
+		if attribute.Name == name {
+			return attribute.Value
+		}
+	}
+	return ""
+}
+
+// IsTest returns whether this entry is only visible to tests.
+// Newer versions of Eclipse mark such entries with the 'test' attribute,
+// older projects imported from Maven only use the 'test-classes' output folder.
+func (e ClasspathEntry) IsTest() bool {
+	if e.Attribute("test") == "true" {
+		return true
+	}
+	return e.Output != "" && path.Base(e.Output) == "test-classes"
 }
 
 func parseClasspathFile(rd io.Reader) (*ClasspathFile, error) {
@@ -27,3 +58,9 @@ func parseClasspathFile(rd io.Reader) (*ClasspathFile, error) {
 	}
 	return cp, nil
 }
+
+// IsProjectReference returns whether this entry references another project
+// in the workspace, such as '/OtherProject'.
+func (e ClasspathEntry) IsProjectReference() bool {
+	return e.Kind == "src" && strings.HasPrefix(e.Path, "/")
+}
@@ -43,3 +43,42 @@ func (suite *ClasspathFileSuite) TestParseClasspath() {
 		{Kind: "var", Path: "M2_REPO/org/hamcrest/hamcrest-core/2.2/hamcrest-core-2.2.jar", Sourcepath: "M2_REPO/org/hamcrest/hamcrest-core/2.2/hamcrest-core-2.2-sources.jar"},
 	}, entries)
 }
+
+func (suite *ClasspathFileSuite) TestParseClasspathAttributes() {
+	fsys := os.DirFS(testdataClasspaths)
+
+	f, err := fsys.Open("classpath2")
+	suite.NoError(err)
+	defer func() { _ = f.Close() }()
+
+	cp, err := parseClasspathFile(f)
+	suite.NoError(err)
+
+	entries := cp.Entries
+	suite.Len(entries, 4)
+	suite.Equal("true", entries[0].Attribute("maven.pomderived"))
+	suite.Equal("", entries[0].Attribute("test"))
+
+	var tests []bool
+	for _, entry := range entries {
+		tests = append(tests, entry.IsTest())
+	}
+	suite.Equal([]bool{false, true, true, false}, tests)
+}
+
+func (suite *ClasspathFileSuite) TestIsTestByOutput() {
+	fsys := os.DirFS(testdataClasspaths)
+
+	f, err := fsys.Open("classpath1")
+	suite.NoError(err)
+	defer func() { _ = f.Close() }()
+
+	cp, err := parseClasspathFile(f)
+	suite.NoError(err)
+
+	var tests []bool
+	for _, entry := range cp.Entries {
+		tests = append(tests, entry.IsTest())
+	}
+	suite.Equal([]bool{false, false, true, true, false, false, false, false}, tests)
+}
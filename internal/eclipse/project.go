@@ -10,6 +10,8 @@ import (
 
 	"github.com/rs/zerolog/log"
 	"github.com/tsatke/jt/classpath"
+	"github.com/tsatke/jt/internal/properties"
+	"github.com/tsatke/jt/jdk"
 )
 
 const (
@@ -40,11 +42,21 @@ type project struct {
 
 	classpathFile      *ClasspathFile
 	projectDescription *ProjectDescription
+	workspace          *workspace
 
-	classpath *classpath.Classpath // nil until computed
+	classpaths map[classpath.Scope]*classpath.Classpath // scopes are missing until computed
+	jdk        *jdk.JDK                                 // nil until computed
 }
 
 func LoadProject(path string) (*project, error) {
+	ws, err := findWorkspace(path)
+	if err != nil {
+		return nil, fmt.Errorf("find workspace: %w", err)
+	}
+	return loadProject(path, ws)
+}
+
+func loadProject(path string, ws *workspace) (*project, error) {
 	start := time.Now()
 
 	fsys := os.DirFS(path)
@@ -78,6 +90,8 @@ func LoadProject(path string) (*project, error) {
 		path:               path,
 		classpathFile:      cp,
 		projectDescription: projectDescription,
+		workspace:          ws,
+		classpaths:         make(map[classpath.Scope]*classpath.Classpath),
 	}, nil
 }
 
@@ -85,64 +99,132 @@ func (p *project) Name() string {
 	return p.projectDescription.Name
 }
 
-func (p *project) Classpath() (*classpath.Classpath, error) {
-	if p.classpath == nil {
-		cp, err := p.buildClasspath()
+func (p *project) Classpath(scope classpath.Scope) (*classpath.Classpath, error) {
+	if p.classpaths[scope] == nil {
+		cp, err := p.buildClasspath(scope)
 		if err != nil {
 			return nil, err
 		}
-		p.classpath = cp
+		p.classpaths[scope] = cp
 	}
 
-	return p.classpath, nil
+	return p.classpaths[scope], nil
 }
 
-func (p *project) buildClasspath() (*classpath.Classpath, error) {
-	cp := classpath.NewClasspath()
-	for _, entry := range p.classpathFile.Entries {
-		switch entry.Kind { // TODO: handle 'src' (if necessary) and 'con'
-		case "output":
-			path, err := filepath.Abs(entry.Path)
-			if err != nil {
-				return nil, fmt.Errorf("make path absolute:%w", err)
-			}
-			cp.AddEntry(classpath.EntryTypeOutput, path)
-		case "var":
-			fragments := strings.Split(entry.Path, "/")
-			if resolvedVariable := os.Getenv(fragments[0]); resolvedVariable != "" {
-				fragments[0] = resolvedVariable
-			}
-			path := filepath.Join(fragments...)
-			cp.AddEntry(classpath.EntryTypeJar, path)
+func (p *project) Sources(scope classpath.Scope) ([]*classpath.Entry, error) {
+	var entries []*classpath.Entry
+	for _, entry := range p.entries(scope) {
+		if entry.Kind != "src" || entry.IsProjectReference() {
+			continue
 		}
+		path, err := p.workspace.resolvePath(p.path, entry.Path)
+		if err != nil {
+			return nil, fmt.Errorf("resolve path: %w", err)
+		}
+		entries = append(entries, &classpath.Entry{
+			Type:       classpath.EntryTypeSource,
+			Path:       path,
+			Inclusions: splitPatterns(entry.Including),
+			Exclusions: splitPatterns(entry.Excluding),
+		})
 	}
+	return entries, nil
+}
 
-	// add JAVA_HOME at the beginning of the classpath
-	javaHome := os.Getenv("JAVA_HOME")
-	if javaHome != "" {
-		if err := fs.WalkDir(os.DirFS(javaHome), ".", func(path string, d fs.DirEntry, err error) error {
-			if err != nil {
-				return err
-			}
-
-			if d.IsDir() {
-				return nil
-			}
-
-			if filepath.Ext(path) != ".jar" {
-				return nil
-			}
-
-			entry := &classpath.Entry{
-				Type: classpath.EntryTypeJar,
-				Path: filepath.Join(javaHome, path),
-			}
-			cp.Entries = append([]*classpath.Entry{entry}, cp.Entries...)
-			return nil
-		}); err != nil {
-			_, _ = fmt.Fprintln(os.Stderr, err)
+// entries returns all entries of the classpath file that are visible in the given scope.
+// Eclipse only differentiates between test and non-test entries.
+func (p *project) entries(scope classpath.Scope) []ClasspathEntry {
+	var entries []ClasspathEntry
+	for _, entry := range p.classpathFile.Entries {
+		if entry.IsTest() && !scope.IncludesTests() {
+			continue
 		}
+		entries = append(entries, entry)
 	}
+	return entries
+}
+
+func (p *project) buildClasspath(scope classpath.Scope) (*classpath.Classpath, error) {
+	b := &classpathBuilder{
+		scope:    scope,
+		cp:       classpath.NewClasspath(),
+		paths:    make(map[string]struct{}),
+		projects: make(map[string]struct{}),
+	}
+	if err := b.addProject(p, false); err != nil {
+		return nil, err
+	}
+	cp := b.cp
+
+	// add the JDK at the beginning of the classpath
+	if j := p.JDK(); j != nil {
+		cp.Entries = append(j.BootClasspath(), cp.Entries...)
+		cp.Release = j.FeatureVersion()
+	}
+
+	// follow the Class-Path attributes of jars, just as the JVM does
+	cp.Expand()
 
 	return cp, nil
 }
+
+// JDK returns the JDK that the JRE container of the project references. The container either
+// references a JRE that is defined in the workspace, or an execution environment like 'JavaSE-17',
+// in which case an installed JDK is selected. Without an execution environment, the JDK fits the
+// toolchain of the Gradle build or the compliance of the project. Returns nil if no JDK is installed.
+func (p *project) JDK() *jdk.JDK {
+	if p.jdk != nil {
+		return p.jdk
+	}
+
+	var home string
+	release := 0
+	for _, entry := range p.classpathFile.Entries {
+		segments := strings.Split(entry.Path, "/")
+		if entry.Kind != "con" || segments[0] != jreContainer {
+			continue
+		}
+		home = p.workspace.vm(segments[1:])
+		if len(segments) > 1 {
+			release, _ = jdk.ParseRelease(segments[len(segments)-1])
+		}
+		break
+	}
+	if release == 0 {
+		// projects that are imported from Gradle may configure a toolchain
+		release = jdk.GradleToolchain(p.path)
+	}
+	if release == 0 {
+		release = p.compliance()
+	}
+	if home == "" && release == 0 {
+		home = p.workspace.defaultVM
+	}
+
+	p.jdk = jdk.FindHome(home, release)
+	return p.jdk
+}
+
+// compliance returns the java release that is configured in the project specific
+// compiler settings, or zero if the project has no specific settings.
+func (p *project) compliance() int {
+	f, err := os.Open(filepath.Join(p.path, ".settings", jdtCorePrefsFileName))
+	if err != nil {
+		return 0
+	}
+	defer func() { _ = f.Close() }()
+
+	prefs, err := properties.Parse(f)
+	if err != nil {
+		return 0
+	}
+	release, _ := jdk.ParseRelease(prefs.Get("org.eclipse.jdt.core.compiler.compliance"))
+	return release
+}
+
+func splitPatterns(patterns string) []string {
+	if patterns == "" {
+		return nil
+	}
+	return strings.Split(patterns, "|")
+}
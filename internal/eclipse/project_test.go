@@ -0,0 +1,140 @@
+package eclipse
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"github.com/tsatke/jt/classpath"
+)
+
+var (
+	testdataWorkspace = filepath.Join("testdata", "workspace")
+)
+
+func TestProjectSuite(t *testing.T) {
+	suite.Run(t, new(ProjectSuite))
+}
+
+type ProjectSuite struct {
+	suite.Suite
+
+	workspace string
+}
+
+func (suite *ProjectSuite) SetupTest() {
+	ws, err := filepath.Abs(testdataWorkspace)
+	suite.Require().NoError(err)
+	suite.workspace = ws
+}
+
+type testEntry struct {
+	Type classpath.EntryType
+	Path string
+}
+
+// relativeEntries returns the entries of the classpath with paths relative to the
+// test workspace. Paths outside the workspace remain absolute. Entries of the JDK
+// are omitted, since they depend on the JDKs installed on the machine.
+func (suite *ProjectSuite) relativeEntries(p *project, cp *classpath.Classpath) []testEntry {
+	var jdkEntries []*classpath.Entry
+	if j := p.JDK(); j != nil {
+		jdkEntries = j.BootClasspath()
+	}
+
+	var entries []testEntry
+	for _, entry := range cp.Entries[len(jdkEntries):] {
+		path := entry.Path
+		if rel, err := filepath.Rel(suite.workspace, entry.Path); err == nil && rel[0] != '.' {
+			path = filepath.ToSlash(rel)
+		}
+		entries = append(entries, testEntry{entry.Type, path})
+	}
+	return entries
+}
+
+func (suite *ProjectSuite) TestIsEclipseProject() {
+	suite.True(IsEclipseProject(filepath.Join(suite.workspace, "app")))
+	suite.False(IsEclipseProject(suite.workspace))
+}
+
+func (suite *ProjectSuite) TestWorkspace() {
+	project, err := LoadProject(filepath.Join(suite.workspace, "app"))
+	suite.Require().NoError(err)
+
+	ws := project.workspace
+	suite.Equal(suite.workspace, ws.root)
+	suite.Equal(map[string]string{"LIBS": "/opt/libs"}, ws.variables)
+	suite.Equal(map[string][]string{"MyLib": {"/opt/mylib/a.jar", "/core/lib/b.jar"}}, ws.userLibraries)
+	suite.Equal("/opt/jdk-17", ws.defaultVM)
+	suite.Equal("/opt/jdk-8", ws.vm([]string{"org.eclipse.jdt.internal.debug.ui.launcher.StandardVMType", "jdk-8"}))
+	suite.Equal("", ws.vm([]string{"org.eclipse.jdt.internal.debug.ui.launcher.StandardVMType", "JavaSE-11"}))
+	suite.Equal("", ws.vm(nil))
+
+	location, ok := ws.projectLocation("core")
+	suite.True(ok)
+	suite.Equal(filepath.Join(suite.workspace, "core"), location)
+	_, ok = ws.projectLocation("missing")
+	suite.False(ok)
+}
+
+func (suite *ProjectSuite) TestClasspath() {
+	suite.T().Setenv("JAVA_HOME", "")
+
+	project, err := LoadProject(filepath.Join(suite.workspace, "app"))
+	suite.Require().NoError(err)
+
+	cp, err := project.Classpath(classpath.ScopeTest)
+	suite.Require().NoError(err)
+	suite.Equal([]testEntry{
+		{classpath.EntryTypeSource, "app/src/main/java"},
+		{classpath.EntryTypeSource, "app/src/test/java"},
+		{classpath.EntryTypeOutput, "app/target/test-classes"},
+		{classpath.EntryTypeJar, "core/lib/exported.jar"},
+		{classpath.EntryTypeOutput, "core/bin"},
+		{classpath.EntryTypeJar, "app/lib/local.jar"},
+		{classpath.EntryTypeJar, filepath.FromSlash("/opt/libs/x.jar")},
+		{classpath.EntryTypeJar, filepath.FromSlash("/opt/mylib/a.jar")},
+		{classpath.EntryTypeJar, "core/lib/b.jar"},
+		{classpath.EntryTypeOutput, "app/target/classes"},
+	}, suite.relativeEntries(project, cp))
+	suite.Equal([]string{"**/package.html"}, cp.Entries[0].Exclusions)
+}
+
+func (suite *ProjectSuite) TestClasspathCompileScope() {
+	suite.T().Setenv("JAVA_HOME", "")
+
+	project, err := LoadProject(filepath.Join(suite.workspace, "app"))
+	suite.Require().NoError(err)
+
+	cp, err := project.Classpath(classpath.ScopeCompile)
+	suite.Require().NoError(err)
+	suite.Equal([]testEntry{
+		{classpath.EntryTypeSource, "app/src/main/java"},
+		{classpath.EntryTypeJar, "core/lib/exported.jar"},
+		{classpath.EntryTypeOutput, "core/bin"},
+		{classpath.EntryTypeJar, "app/lib/local.jar"},
+		{classpath.EntryTypeJar, filepath.FromSlash("/opt/libs/x.jar")},
+		{classpath.EntryTypeJar, filepath.FromSlash("/opt/mylib/a.jar")},
+		{classpath.EntryTypeJar, "core/lib/b.jar"},
+		{classpath.EntryTypeOutput, "app/target/classes"},
+	}, suite.relativeEntries(project, cp))
+
+	sources, err := project.Sources(classpath.ScopeCompile)
+	suite.Require().NoError(err)
+	suite.Len(sources, 1)
+}
+
+func (suite *ProjectSuite) TestParseLocationFile() {
+	uri := "URI//file:/home/user/projects/core"
+	data := append([]byte{0x40, 0xB1, 0x8B, 0x81, 0x23, 0xBC, 0x00, 0x14, 0xD7, 0x78, 0x4D, 0x6F, 0x67, 0x1A, 0x69, 0x23}, byte(len(uri)>>8), byte(len(uri)))
+	data = append(data, uri...)
+	data = append(data, 0, 0, 0, 0)
+
+	location, ok := parseLocationFile(data)
+	suite.True(ok)
+	suite.Equal(filepath.FromSlash("/home/user/projects/core"), location)
+
+	_, ok = parseLocationFile([]byte("garbage"))
+	suite.False(ok)
+}
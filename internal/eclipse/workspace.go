@@ -0,0 +1,299 @@
+package eclipse
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/tsatke/jt/internal/properties"
+)
+
+const (
+	MetadataDirName = ".metadata"
+
+	jdtCorePrefsFileName      = "org.eclipse.jdt.core.prefs"
+	jdtLaunchingPrefsFileName = "org.eclipse.jdt.launching.prefs"
+
+	classpathVariablePrefix = "org.eclipse.jdt.core.classpathVariable."
+	userLibraryPrefix       = "org.eclipse.jdt.core.userLibrary."
+	vmXMLKey                = "org.eclipse.jdt.launching.PREF_VM_XML"
+)
+
+var (
+	prefsPath    = filepath.Join(".plugins", "org.eclipse.core.runtime", ".settings")
+	projectsPath = filepath.Join(".plugins", "org.eclipse.core.resources", ".projects")
+)
+
+// workspace holds the settings of an eclipse workspace that are relevant
+// for building a classpath, such as classpath variables and user libraries.
+// A project does not have to be located inside a workspace, in which case
+// the workspace root is the parent directory of the project and no settings
+// are available.
+type workspace struct {
+	root     string
+	metadata string // empty if the workspace has no metadata directory
+
+	variables     map[string]string
+	userLibraries map[string][]string
+	vms           map[string]string
+	defaultVM     string
+
+	projectLocations map[string]string // cache for projectLocation
+}
+
+// findWorkspace finds the workspace of the project at the given path, which
+// is the first parent directory that contains a .metadata directory.
+func findWorkspace(projectPath string) (*workspace, error) {
+	abs, err := filepath.Abs(projectPath)
+	if err != nil {
+		return nil, fmt.Errorf("make path absolute: %w", err)
+	}
+
+	ws := &workspace{
+		root:             filepath.Dir(abs),
+		variables:        make(map[string]string),
+		userLibraries:    make(map[string][]string),
+		vms:              make(map[string]string),
+		projectLocations: make(map[string]string),
+	}
+
+	for dir := filepath.Dir(abs); ; dir = filepath.Dir(dir) {
+		if info, err := os.Stat(filepath.Join(dir, MetadataDirName)); err == nil && info.IsDir() {
+			ws.root = dir
+			ws.metadata = filepath.Join(dir, MetadataDirName)
+			break
+		}
+		if filepath.Dir(dir) == dir {
+			break
+		}
+	}
+
+	if ws.metadata == "" {
+		return ws, nil
+	}
+
+	if err := ws.loadCorePrefs(); err != nil {
+		return nil, fmt.Errorf("load %s: %w", jdtCorePrefsFileName, err)
+	}
+	if err := ws.loadLaunchingPrefs(); err != nil {
+		return nil, fmt.Errorf("load %s: %w", jdtLaunchingPrefsFileName, err)
+	}
+
+	log.Debug().
+		Str("workspace", ws.root).
+		Int("variables", len(ws.variables)).
+		Int("userLibraries", len(ws.userLibraries)).
+		Int("vms", len(ws.vms)).
+		Msg("load workspace")
+
+	return ws, nil
+}
+
+func (ws *workspace) loadPrefs(name string) (*properties.Properties, error) {
+	f, err := os.Open(filepath.Join(ws.metadata, prefsPath, name))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	return properties.Parse(f)
+}
+
+type userLibrary struct {
+	Archives []struct {
+		Path string `xml:"path,attr"`
+	} `xml:"archive"`
+}
+
+func (ws *workspace) loadCorePrefs() error {
+	prefs, err := ws.loadPrefs(jdtCorePrefsFileName)
+	if err != nil || prefs == nil {
+		return err
+	}
+
+	for _, key := range prefs.Keys {
+		switch {
+		case strings.HasPrefix(key, classpathVariablePrefix):
+			ws.variables[strings.TrimPrefix(key, classpathVariablePrefix)] = prefs.Get(key)
+		case strings.HasPrefix(key, userLibraryPrefix):
+			lib := userLibrary{}
+			if err := xml.Unmarshal([]byte(prefs.Get(key)), &lib); err != nil {
+				return fmt.Errorf("decode user library %s: %w", key, err)
+			}
+			name := strings.TrimPrefix(key, userLibraryPrefix)
+			for _, archive := range lib.Archives {
+				ws.userLibraries[name] = append(ws.userLibraries[name], archive.Path)
+			}
+		}
+	}
+	return nil
+}
+
+type vmSettings struct {
+	DefaultVM string `xml:"defaultVM,attr"`
+	VMTypes   []struct {
+		ID  string `xml:"id,attr"`
+		VMs []struct {
+			ID   string `xml:"id,attr"`
+			Name string `xml:"name,attr"`
+			Path string `xml:"path,attr"`
+		} `xml:"vm"`
+	} `xml:"vmType"`
+}
+
+func (ws *workspace) loadLaunchingPrefs() error {
+	prefs, err := ws.loadPrefs(jdtLaunchingPrefsFileName)
+	if err != nil || prefs == nil {
+		return err
+	}
+
+	vmXML, ok := prefs.Lookup(vmXMLKey)
+	if !ok {
+		return nil
+	}
+
+	settings := vmSettings{}
+	if err := xml.Unmarshal([]byte(vmXML), &settings); err != nil {
+		return fmt.Errorf("decode vm settings: %w", err)
+	}
+	for _, vmType := range settings.VMTypes {
+		for _, vm := range vmType.VMs {
+			ws.vms[vm.Name] = vm.Path
+			// the default vm is referenced as '<type id length>,<type id><vm id length>,<vm id>'
+			if strings.Contains(settings.DefaultVM, ","+vmType.ID) && strings.HasSuffix(settings.DefaultVM, ","+vm.ID) {
+				ws.defaultVM = vm.Path
+			}
+		}
+	}
+	return nil
+}
+
+// variable resolves the classpath variable with the given name. Variables defined
+// in the workspace take precedence over environment variables.
+func (ws *workspace) variable(name string) (string, bool) {
+	if value, ok := ws.variables[name]; ok {
+		return value, true
+	}
+	if value := os.Getenv(name); value != "" {
+		return value, true
+	}
+	if name == "M2_REPO" {
+		// m2e defines M2_REPO dynamically as the local maven repository
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, ".m2", "repository"), true
+		}
+	}
+	return "", false
+}
+
+// resolvePath resolves a path as it appears in a classpath file. Absolute paths whose
+// first segment is the name of a project in this workspace are resolved relative to
+// that project, other absolute paths are file system paths, and relative paths are
+// resolved relative to the given project directory.
+func (ws *workspace) resolvePath(projectPath, path string) (string, error) {
+	if !strings.HasPrefix(path, "/") {
+		return filepath.Abs(filepath.Join(projectPath, filepath.FromSlash(path)))
+	}
+
+	segments := strings.SplitN(strings.TrimPrefix(path, "/"), "/", 2)
+	if location, ok := ws.projectLocation(segments[0]); ok {
+		if len(segments) == 1 {
+			return location, nil
+		}
+		return filepath.Join(location, filepath.FromSlash(segments[1])), nil
+	}
+	return filepath.FromSlash(path), nil
+}
+
+// projectLocation finds the directory of the project with the given name.
+// Projects are looked up in the workspace metadata, and if they are not found
+// there, in the workspace root directory.
+func (ws *workspace) projectLocation(name string) (string, bool) {
+	if location, ok := ws.projectLocations[name]; ok {
+		return location, location != ""
+	}
+
+	location := ws.findProjectLocation(name)
+	ws.projectLocations[name] = location
+	return location, location != ""
+}
+
+func (ws *workspace) findProjectLocation(name string) string {
+	if ws.metadata != "" {
+		data, err := os.ReadFile(filepath.Join(ws.metadata, projectsPath, name, ".location"))
+		if err == nil {
+			if location, ok := parseLocationFile(data); ok {
+				return location
+			}
+		}
+	}
+
+	candidates, err := os.ReadDir(ws.root)
+	if err != nil {
+		return ""
+	}
+	// projects usually live in a directory with the same name, so try that first
+	for _, candidate := range candidates {
+		if candidate.Name() == name && ws.isProjectNamed(filepath.Join(ws.root, name), name) {
+			return filepath.Join(ws.root, name)
+		}
+	}
+	for _, candidate := range candidates {
+		if candidate.IsDir() && ws.isProjectNamed(filepath.Join(ws.root, candidate.Name()), name) {
+			return filepath.Join(ws.root, candidate.Name())
+		}
+	}
+	return ""
+}
+
+func (ws *workspace) isProjectNamed(path, name string) bool {
+	f, err := os.Open(filepath.Join(path, ProjectFileName))
+	if err != nil {
+		return false
+	}
+	defer func() { _ = f.Close() }()
+
+	desc, err := parseProjectDescription(f)
+	return err == nil && desc.Name == name
+}
+
+// parseLocationFile extracts the project location from a .location file, which
+// eclipse writes for projects outside the workspace root. The location is stored
+// as a URI in the java modified UTF-8 format (length prefixed), like 'URI//file:/path'.
+func parseLocationFile(data []byte) (string, bool) {
+	const uriPrefix = "URI//"
+
+	index := bytes.Index(data, []byte(uriPrefix))
+	if index < 2 {
+		return "", false
+	}
+	length := int(binary.BigEndian.Uint16(data[index-2 : index]))
+	if index+length > len(data) {
+		return "", false
+	}
+
+	u, err := url.Parse(string(data[index+len(uriPrefix) : index+length]))
+	if err != nil || u.Scheme != "file" {
+		return "", false
+	}
+	return filepath.FromSlash(u.Path), true
+}
+
+// vm returns the install location of the JRE that is referenced by the given segments
+// of a JRE container path, which are the vm type and the name of the vm. If the segments
+// don't reference a JRE that is defined in the workspace, the empty string is returned.
+func (ws *workspace) vm(segments []string) string {
+	if len(segments) < 2 {
+		return ""
+	}
+	return ws.vms[segments[len(segments)-1]]
+}
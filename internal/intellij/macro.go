@@ -0,0 +1,90 @@
+package intellij
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// expand converts an IntelliJ url like 'file://$MODULE_DIR$/src' or 'jar://$MAVEN_REPOSITORY$/a/b.jar!/'
+// into a file system path, expanding the path macros that IntelliJ uses. The module directory may be
+// empty if the url is not part of a module.
+func (p *project) expand(url, moduleDir string) string {
+	path := url
+	for _, prefix := range []string{"file://", "jar://", "jrt://"} {
+		path = strings.TrimPrefix(path, prefix)
+	}
+	path = strings.TrimSuffix(path, "!/")
+
+	replacements := []string{
+		"$PROJECT_DIR$", filepath.ToSlash(p.path),
+		"$MAVEN_REPOSITORY$", filepath.ToSlash(mavenRepository()),
+	}
+	if moduleDir != "" {
+		replacements = append(replacements, "$MODULE_DIR$", filepath.ToSlash(moduleDir))
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		replacements = append(replacements, "$USER_HOME$", filepath.ToSlash(home))
+	}
+	path = strings.NewReplacer(replacements...).Replace(path)
+
+	return filepath.Clean(filepath.FromSlash(path))
+}
+
+// mavenRepository returns the location of the local maven repository,
+// which is what IntelliJ uses for the $MAVEN_REPOSITORY$ macro.
+func mavenRepository() string {
+	if repo := os.Getenv("M2_REPO"); repo != "" {
+		return repo
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".m2", "repository")
+}
+
+type jdkTable struct {
+	JDKs []struct {
+		Name     valueElement `xml:"name"`
+		HomePath valueElement `xml:"homePath"`
+	} `xml:"component>jdk"`
+}
+
+type valueElement struct {
+	Value string `xml:"value,attr"`
+}
+
+// findJdkHome looks up the home directory of the JDK with the given name in the
+// jdk.table.xml of the installed IntelliJ versions. If there are multiple IntelliJ
+// versions, the most recent one wins. Returns the empty string if the JDK is unknown.
+func findJdkHome(name string) string {
+	if name == "" {
+		return ""
+	}
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+
+	tables, _ := filepath.Glob(filepath.Join(configDir, "JetBrains", "*", "options", "jdk.table.xml"))
+	// the glob is sorted, so later versions come last
+	for i := len(tables) - 1; i >= 0; i-- {
+		table := &jdkTable{}
+		if err := decodeFile(tables[i], table); err != nil {
+			continue
+		}
+		for _, jdk := range table.JDKs {
+			if jdk.Name.Value == name {
+				home := strings.ReplaceAll(jdk.HomePath.Value, "$USER_HOME$", userHome())
+				return filepath.FromSlash(home)
+			}
+		}
+	}
+	return ""
+}
+
+func userHome() string {
+	home, _ := os.UserHomeDir()
+	return filepath.ToSlash(home)
+}
@@ -0,0 +1,123 @@
+package intellij
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+// ModulesFile is the content of .idea/modules.xml, which lists all modules of the project.
+type ModulesFile struct {
+	Components []struct {
+		Name    string `xml:"name,attr"`
+		Modules []struct {
+			FileURL  string `xml:"fileurl,attr"`
+			FilePath string `xml:"filepath,attr"`
+		} `xml:"modules>module"`
+	} `xml:"component"`
+}
+
+// MiscFile is the content of .idea/misc.xml, which contains the project JDK
+// and the project's compiler output.
+type MiscFile struct {
+	Components []struct {
+		Name           string    `xml:"name,attr"`
+		LanguageLevel  string    `xml:"languageLevel,attr"`
+		ProjectJdkName string    `xml:"project-jdk-name,attr"`
+		ProjectJdkType string    `xml:"project-jdk-type,attr"`
+		Output         OutputURL `xml:"output"`
+	} `xml:"component"`
+}
+
+// LibraryFile is the content of a file in .idea/libraries, which
+// defines a single project library.
+type LibraryFile struct {
+	Library Library `xml:"library"`
+}
+
+type Library struct {
+	Name         string         `xml:"name,attr"`
+	Classes      []Root         `xml:"CLASSES>root"`
+	JarDirectory []JarDirectory `xml:"jarDirectory"`
+}
+
+type Root struct {
+	URL string `xml:"url,attr"`
+}
+
+type JarDirectory struct {
+	URL       string `xml:"url,attr"`
+	Recursive bool   `xml:"recursive,attr"`
+}
+
+type OutputURL struct {
+	URL string `xml:"url,attr"`
+}
+
+// ModuleFile is the content of a *.iml file, which describes a single module.
+type ModuleFile struct {
+	Type       string `xml:"type,attr"`
+	Components []struct {
+		Name                  string       `xml:"name,attr"`
+		InheritCompilerOutput bool         `xml:"inherit-compiler-output,attr"`
+		Output                OutputURL    `xml:"output"`
+		OutputTest            OutputURL    `xml:"output-test"`
+		Contents              []Content    `xml:"content"`
+		OrderEntries          []OrderEntry `xml:"orderEntry"`
+	} `xml:"component"`
+}
+
+type Content struct {
+	URL           string         `xml:"url,attr"`
+	SourceFolders []SourceFolder `xml:"sourceFolder"`
+}
+
+type SourceFolder struct {
+	URL          string `xml:"url,attr"`
+	IsTestSource bool   `xml:"isTestSource,attr"`
+	Type         string `xml:"type,attr"`
+}
+
+// IsJava returns whether this folder contains java sources, as opposed to resources.
+func (f SourceFolder) IsJava() bool {
+	return f.Type == "" || f.Type == "java-source" || f.Type == "java-test"
+}
+
+// IsTest returns whether this folder is only visible to tests.
+func (f SourceFolder) IsTest() bool {
+	return f.IsTestSource || f.Type == "java-test" || f.Type == "java-test-resource"
+}
+
+type OrderEntry struct {
+	Type       string   `xml:"type,attr"`
+	Name       string   `xml:"name,attr"`
+	Level      string   `xml:"level,attr"`
+	Scope      string   `xml:"scope,attr"`
+	Exported   *string  `xml:"exported,attr"` // IntelliJ writes exported="" for exported entries
+	ModuleName string   `xml:"module-name,attr"`
+	JdkName    string   `xml:"jdkName,attr"`
+	Library    *Library `xml:"library"`
+}
+
+// IsExported returns whether the entry is visible to modules that depend on the module of this entry.
+func (e OrderEntry) IsExported() bool {
+	return e.Exported != nil
+}
+
+const (
+	rootManagerComponent    = "NewModuleRootManager"
+	projectRootManager      = "ProjectRootManager"
+	projectModuleManager    = "ProjectModuleManager"
+	orderEntryLibrary       = "library"
+	orderEntryModuleLibrary = "module-library"
+	orderEntryModule        = "module"
+	orderEntryJdk           = "jdk"
+	orderEntryInheritedJdk  = "inheritedJdk"
+)
+
+func decode(rd io.Reader, v interface{}) error {
+	if err := xml.NewDecoder(rd).Decode(v); err != nil {
+		return fmt.Errorf("decode: %w", err)
+	}
+	return nil
+}
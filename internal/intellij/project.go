@@ -0,0 +1,461 @@
+package intellij
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/tsatke/jt/classpath"
+	"github.com/tsatke/jt/jdk"
+)
+
+const (
+	IdeaDirName     = ".idea"
+	ModulesFileName = "modules.xml"
+	MiscFileName    = "misc.xml"
+	LibrariesDir    = "libraries"
+	NameFileName    = ".name"
+)
+
+func IsIntellijProject(path string) bool {
+	return IsIntellijProjectFs(os.DirFS(path))
+}
+
+func IsIntellijProjectFs(fsys fs.FS) bool {
+	info, err := fs.Stat(fsys, IdeaDirName+"/"+ModulesFileName)
+	return err == nil && info != nil && !info.IsDir()
+}
+
+type project struct {
+	path string
+	name string
+
+	misc      *MiscFile
+	modules   []*module
+	libraries map[string]Library
+
+	classpaths map[classpath.Scope]*classpath.Classpath // scopes are missing until computed
+	jdks       map[string]*jdk.JDK                      // JDKs by name, missing until computed
+
+	files []string // the files that the project is loaded from, see Files
+}
+
+type module struct {
+	name string
+	path string // the directory containing the .iml file
+	file *ModuleFile
+}
+
+func LoadProject(path string) (*project, error) {
+	start := time.Now()
+
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("make path absolute: %w", err)
+	}
+
+	p := &project{
+		path:       path,
+		name:       filepath.Base(path),
+		libraries:  make(map[string]Library),
+		classpaths: make(map[classpath.Scope]*classpath.Classpath),
+		jdks:       make(map[string]*jdk.JDK),
+	}
+
+	ideaDir := filepath.Join(path, IdeaDirName)
+	p.files = append(p.files,
+		filepath.Join(ideaDir, NameFileName),
+		filepath.Join(ideaDir, ModulesFileName),
+		filepath.Join(ideaDir, MiscFileName),
+		// libraries that are added or removed change the directory
+		filepath.Join(ideaDir, LibrariesDir),
+	)
+	if name, err := os.ReadFile(filepath.Join(ideaDir, NameFileName)); err == nil {
+		p.name = strings.TrimSpace(string(name))
+	}
+
+	modulesFile := &ModulesFile{}
+	if err := decodeFile(filepath.Join(ideaDir, ModulesFileName), modulesFile); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", ModulesFileName, err)
+	}
+
+	p.misc = &MiscFile{}
+	if err := decodeFile(filepath.Join(ideaDir, MiscFileName), p.misc); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("parse %s: %w", MiscFileName, err)
+	}
+
+	libraryFiles, err := filepath.Glob(filepath.Join(ideaDir, LibrariesDir, "*.xml"))
+	if err != nil {
+		return nil, fmt.Errorf("find libraries: %w", err)
+	}
+	for _, libraryFile := range libraryFiles {
+		lib := &LibraryFile{}
+		if err := decodeFile(libraryFile, lib); err != nil {
+			return nil, fmt.Errorf("parse library %s: %w", filepath.Base(libraryFile), err)
+		}
+		p.libraries[lib.Library.Name] = lib.Library
+		p.files = append(p.files, libraryFile)
+	}
+
+	for _, component := range modulesFile.Components {
+		if component.Name != projectModuleManager {
+			continue
+		}
+		for _, m := range component.Modules {
+			imlPath := p.expand(m.FilePath, "")
+			moduleFile := &ModuleFile{}
+			if err := decodeFile(imlPath, moduleFile); err != nil {
+				return nil, fmt.Errorf("parse module %s: %w", filepath.Base(imlPath), err)
+			}
+			p.files = append(p.files, imlPath)
+			p.modules = append(p.modules, &module{
+				name: strings.TrimSuffix(filepath.Base(imlPath), ".iml"),
+				path: filepath.Dir(imlPath),
+				file: moduleFile,
+			})
+		}
+	}
+
+	log.Debug().
+		Stringer("took", time.Since(start)).
+		Int("modules", len(p.modules)).
+		Int("libraries", len(p.libraries)).
+		Msg("parse intellij project")
+
+	return p, nil
+}
+
+// Files returns the files that the project is loaded from: the files in the .idea directory,
+// including those that don't exist, and the module files, wherever they are located.
+func (p *project) Files() []string {
+	return p.files
+}
+
+func decodeFile(path string, v interface{}) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	return decode(f, v)
+}
+
+func (p *project) Name() string {
+	return p.name
+}
+
+func (p *project) Classpath(scope classpath.Scope) (*classpath.Classpath, error) {
+	if p.classpaths[scope] == nil {
+		cp, err := p.buildClasspath(scope)
+		if err != nil {
+			return nil, fmt.Errorf("build classpath: %w", err)
+		}
+		p.classpaths[scope] = cp
+	}
+	return p.classpaths[scope], nil
+}
+
+func (p *project) Sources(scope classpath.Scope) ([]*classpath.Entry, error) {
+	var entries []*classpath.Entry
+	for _, m := range p.modules {
+		entries = append(entries, p.moduleSources(m, scope)...)
+	}
+	return entries, nil
+}
+
+func (p *project) moduleSources(m *module, scope classpath.Scope) []*classpath.Entry {
+	var entries []*classpath.Entry
+	for _, component := range m.file.Components {
+		if component.Name != rootManagerComponent {
+			continue
+		}
+		for _, content := range component.Contents {
+			for _, folder := range content.SourceFolders {
+				if !folder.IsJava() || (folder.IsTest() && !scope.IncludesTests()) {
+					continue
+				}
+				entries = append(entries, &classpath.Entry{
+					Type: classpath.EntryTypeSource,
+					Path: p.expand(folder.URL, m.path),
+				})
+			}
+		}
+	}
+	return entries
+}
+
+// moduleOutputs returns the output folders of the given module. If the module inherits the compiler
+// output, the output folders are located in the project's output folder.
+func (p *project) moduleOutputs(m *module, scope classpath.Scope) []*classpath.Entry {
+	var entries []*classpath.Entry
+	for _, component := range m.file.Components {
+		if component.Name != rootManagerComponent {
+			continue
+		}
+
+		output, outputTest := component.Output.URL, component.OutputTest.URL
+		if component.InheritCompilerOutput {
+			projectOutput := p.projectOutput()
+			if projectOutput == "" {
+				continue
+			}
+			output = filepath.Join(projectOutput, "production", m.name)
+			outputTest = filepath.Join(projectOutput, "test", m.name)
+		}
+
+		if scope.IncludesTests() && outputTest != "" {
+			entries = append(entries, &classpath.Entry{Type: classpath.EntryTypeOutput, Path: p.expand(outputTest, m.path)})
+		}
+		if output != "" {
+			entries = append(entries, &classpath.Entry{Type: classpath.EntryTypeOutput, Path: p.expand(output, m.path)})
+		}
+	}
+	return entries
+}
+
+func (p *project) projectOutput() string {
+	for _, component := range p.misc.Components {
+		if component.Name == projectRootManager && component.Output.URL != "" {
+			return p.expand(component.Output.URL, "")
+		}
+	}
+	return ""
+}
+
+func (p *project) projectJdkName() string {
+	for _, component := range p.misc.Components {
+		if component.Name == projectRootManager {
+			return component.ProjectJdkName
+		}
+	}
+	return ""
+}
+
+// visibleInScope returns whether an order entry with the given IntelliJ scope
+// (COMPILE, TEST, RUNTIME or PROVIDED) is visible in the given scope.
+func visibleInScope(entryScope string, scope classpath.Scope) bool {
+	if entryScope == "" {
+		entryScope = "COMPILE"
+	}
+	switch scope {
+	case classpath.ScopeTest:
+		return true
+	case classpath.ScopeCompile:
+		return entryScope == "COMPILE" || entryScope == "PROVIDED"
+	case classpath.ScopeRuntime:
+		return entryScope == "COMPILE" || entryScope == "RUNTIME"
+	case classpath.ScopeProvided:
+		return entryScope == "PROVIDED"
+	}
+	return false
+}
+
+func (p *project) buildClasspath(scope classpath.Scope) (*classpath.Classpath, error) {
+	b := &classpathBuilder{
+		project: p,
+		scope:   scope,
+		cp:      classpath.NewClasspath(),
+		paths:   make(map[string]struct{}),
+		modules: make(map[string]struct{}),
+		jdk:     p.projectJdkName(),
+	}
+
+	// the classpath of the project is the union of the classpaths of all modules
+	for _, m := range p.modules {
+		b.addModule(m, false)
+	}
+	cp := b.cp
+
+	// add the JDK at the beginning of the classpath
+	if j := p.jdkNamed(b.jdk); j != nil {
+		cp.Entries = append(j.BootClasspath(), cp.Entries...)
+		cp.Release = j.FeatureVersion()
+	}
+
+	// follow the Class-Path attributes of jars, just as the JVM does
+	cp.Expand()
+
+	return cp, nil
+}
+
+// JDK returns the project JDK, which is looked up in the JDK table of IntelliJ. If IntelliJ
+// does not know the JDK, an installed JDK that fits the project's language level, or the
+// toolchain of its Gradle build, is selected.
+// Returns nil if no JDK is installed.
+func (p *project) JDK() *jdk.JDK {
+	return p.jdkNamed(p.projectJdkName())
+}
+
+func (p *project) jdkNamed(name string) *jdk.JDK {
+	if j, ok := p.jdks[name]; ok {
+		return j
+	}
+
+	release, ok := jdk.ParseRelease(p.languageLevel())
+	if !ok {
+		// projects that are imported from Gradle may configure a toolchain
+		release = jdk.GradleToolchain(p.path)
+	}
+	if release == 0 {
+		// JDKs are usually named after their version, like '17' or '1.8'
+		release, _ = jdk.ParseRelease(name)
+	}
+	j := jdk.FindHome(findJdkHome(name), release)
+	p.jdks[name] = j
+	return j
+}
+
+func (p *project) languageLevel() string {
+	for _, component := range p.misc.Components {
+		if component.Name == projectRootManager {
+			return component.LanguageLevel
+		}
+	}
+	return ""
+}
+
+type classpathBuilder struct {
+	project *project
+	scope   classpath.Scope
+	cp      *classpath.Classpath
+
+	paths   map[string]struct{} // paths already on the classpath
+	modules map[string]struct{} // modules already on the classpath, see addModule
+	jdk     string              // name of the JDK
+}
+
+func (b *classpathBuilder) add(typ classpath.EntryType, path string) {
+	if _, ok := b.paths[path]; ok {
+		return
+	}
+	b.paths[path] = struct{}{}
+	b.cp.AddEntry(typ, path)
+}
+
+// addModule adds the sources, outputs and dependencies of the given module.
+// If the module is a dependency of another module, only the outputs and exported
+// dependencies are added.
+func (b *classpathBuilder) addModule(m *module, dependency bool) {
+	// a module may be added as a dependency of another module first,
+	// and later completely, so track both separately
+	key := m.name
+	if dependency {
+		key += "#dependency"
+	}
+	if _, ok := b.modules[key]; ok {
+		return
+	}
+	b.modules[key] = struct{}{}
+
+	if !dependency {
+		for _, source := range b.project.moduleSources(m, b.scope) {
+			b.add(source.Type, source.Path)
+		}
+	}
+	for _, output := range b.project.moduleOutputs(m, b.scope) {
+		b.add(output.Type, output.Path)
+	}
+
+	for _, component := range m.file.Components {
+		if component.Name != rootManagerComponent {
+			continue
+		}
+		for _, entry := range component.OrderEntries {
+			if !visibleInScope(entry.Scope, b.scope) || (dependency && !entry.IsExported()) {
+				continue
+			}
+
+			switch entry.Type {
+			case orderEntryJdk:
+				// the project JDK takes precedence, since the classpath is the union of all modules
+				if !dependency && entry.JdkName != "" && b.jdk == "" {
+					b.jdk = entry.JdkName
+				}
+			case orderEntryModule:
+				dependencyModule := b.project.module(entry.ModuleName)
+				if dependencyModule == nil {
+					log.Warn().
+						Str("module", m.name).
+						Str("dependency", entry.ModuleName).
+						Msg("module dependency not found in project")
+					continue
+				}
+				b.addModule(dependencyModule, true)
+			case orderEntryLibrary:
+				lib, ok := b.project.libraries[entry.Name]
+				if !ok {
+					log.Warn().
+						Str("module", m.name).
+						Str("library", entry.Name).
+						Str("level", entry.Level).
+						Msg("library not found in project")
+					continue
+				}
+				b.addLibrary(lib, b.project.path)
+			case orderEntryModuleLibrary:
+				if entry.Library != nil {
+					b.addLibrary(*entry.Library, m.path)
+				}
+			}
+		}
+	}
+}
+
+func (b *classpathBuilder) addLibrary(lib Library, moduleDir string) {
+	for _, root := range lib.Classes {
+		path := b.project.expand(root.URL, moduleDir)
+		if jarDirectory := b.jarDirectory(lib, root.URL); jarDirectory != nil {
+			b.addJarDirectory(path, jarDirectory.Recursive)
+			continue
+		}
+		if strings.HasPrefix(root.URL, "jar://") {
+			b.add(classpath.EntryTypeJar, path)
+		} else {
+			b.add(classpath.EntryTypeOutput, path)
+		}
+	}
+}
+
+func (b *classpathBuilder) jarDirectory(lib Library, url string) *JarDirectory {
+	for i := range lib.JarDirectory {
+		if lib.JarDirectory[i].URL == url {
+			return &lib.JarDirectory[i]
+		}
+	}
+	return nil
+}
+
+func (b *classpathBuilder) addJarDirectory(dir string, recursive bool) {
+	var jars []string
+	_ = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() && path != dir && !recursive {
+			return filepath.SkipDir
+		}
+		if !d.IsDir() && filepath.Ext(path) == ".jar" {
+			jars = append(jars, path)
+		}
+		return nil
+	})
+	sort.Strings(jars)
+	for _, jar := range jars {
+		b.add(classpath.EntryTypeJar, jar)
+	}
+}
+
+func (p *project) module(name string) *module {
+	for _, m := range p.modules {
+		if m.name == name {
+			return m
+		}
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package intellij
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"github.com/tsatke/jt/classpath"
+)
+
+func TestIntellijProjectSuite(t *testing.T) {
+	suite.Run(t, new(IntellijProjectSuite))
+}
+
+type IntellijProjectSuite struct {
+	suite.Suite
+
+	path string
+}
+
+func (suite *IntellijProjectSuite) SetupTest() {
+	path, err := filepath.Abs(filepath.Join("testdata", "projects", "test1"))
+	suite.Require().NoError(err)
+	suite.path = path
+
+	suite.T().Setenv("JAVA_HOME", "")
+	suite.T().Setenv("M2_REPO", filepath.FromSlash("/m2"))
+}
+
+type testEntry struct {
+	Type classpath.EntryType
+	Path string
+}
+
+func (suite *IntellijProjectSuite) classpath(scope classpath.Scope) []testEntry {
+	project, err := LoadProject(suite.path)
+	suite.Require().NoError(err)
+
+	cp, err := project.Classpath(scope)
+	suite.Require().NoError(err)
+
+	// omit the JDK, since it depends on the JDKs installed on the machine
+	var jdkEntries []*classpath.Entry
+	if j := project.JDK(); j != nil {
+		jdkEntries = j.BootClasspath()
+	}
+
+	var entries []testEntry
+	for _, entry := range cp.Entries[len(jdkEntries):] {
+		path := entry.Path
+		if rel, err := filepath.Rel(suite.path, entry.Path); err == nil && rel[0] != '.' {
+			path = filepath.ToSlash(rel)
+		}
+		entries = append(entries, testEntry{entry.Type, path})
+	}
+	return entries
+}
+
+func (suite *IntellijProjectSuite) TestIsIntellijProject() {
+	suite.True(IsIntellijProject(suite.path))
+	suite.False(IsIntellijProject(filepath.Join(suite.path, "app")))
+}
+
+func (suite *IntellijProjectSuite) TestLoadProject() {
+	project, err := LoadProject(suite.path)
+	suite.Require().NoError(err)
+
+	suite.Equal("legacy", project.Name())
+	suite.Len(project.modules, 2)
+	suite.Len(project.libraries, 2)
+	suite.Equal("17", project.projectJdkName())
+}
+
+func (suite *IntellijProjectSuite) TestFiles() {
+	project, err := LoadProject(suite.path)
+	suite.Require().NoError(err)
+
+	ideaDir := filepath.Join(suite.path, IdeaDirName)
+	suite.ElementsMatch([]string{
+		filepath.Join(ideaDir, NameFileName),
+		filepath.Join(ideaDir, ModulesFileName),
+		filepath.Join(ideaDir, MiscFileName),
+		filepath.Join(ideaDir, LibrariesDir),
+		filepath.Join(ideaDir, LibrariesDir, "commons.xml"),
+		filepath.Join(ideaDir, LibrariesDir, "junit.xml"),
+		filepath.Join(suite.path, "app", "app.iml"),
+		filepath.Join(suite.path, "core", "core.iml"),
+	}, project.Files())
+}
+
+func (suite *IntellijProjectSuite) TestClasspathTestScope() {
+	suite.Equal([]testEntry{
+		{classpath.EntryTypeSource, "app/src"},
+		{classpath.EntryTypeSource, "app/test"},
+		{classpath.EntryTypeOutput, "out/test/app"},
+		{classpath.EntryTypeOutput, "out/production/app"},
+		{classpath.EntryTypeOutput, "core/build/test-classes"},
+		{classpath.EntryTypeOutput, "core/build/classes"},
+		{classpath.EntryTypeJar, "core/lib/commons-a.jar"},
+		{classpath.EntryTypeJar, "core/lib/commons-b.jar"},
+		{classpath.EntryTypeJar, filepath.FromSlash("/m2/junit/junit/4.12/junit-4.12.jar")},
+		{classpath.EntryTypeJar, "app/lib/servlet-api.jar"},
+		{classpath.EntryTypeSource, "core/src/main/java"},
+		{classpath.EntryTypeJar, "core/lib/private.jar"},
+	}, suite.classpath(classpath.ScopeTest))
+}
+
+func (suite *IntellijProjectSuite) TestClasspathCompileScope() {
+	suite.Equal([]testEntry{
+		{classpath.EntryTypeSource, "app/src"},
+		{classpath.EntryTypeOutput, "out/production/app"},
+		{classpath.EntryTypeOutput, "core/build/classes"},
+		{classpath.EntryTypeJar, "core/lib/commons-a.jar"},
+		{classpath.EntryTypeJar, "core/lib/commons-b.jar"},
+		{classpath.EntryTypeJar, "app/lib/servlet-api.jar"},
+		{classpath.EntryTypeSource, "core/src/main/java"},
+	}, suite.classpath(classpath.ScopeCompile))
+}
+
+func (suite *IntellijProjectSuite) TestClasspathRuntimeScope() {
+	suite.Equal([]testEntry{
+		{classpath.EntryTypeSource, "app/src"},
+		{classpath.EntryTypeOutput, "out/production/app"},
+		{classpath.EntryTypeOutput, "core/build/classes"},
+		{classpath.EntryTypeJar, "core/lib/commons-a.jar"},
+		{classpath.EntryTypeJar, "core/lib/commons-b.jar"},
+		{classpath.EntryTypeSource, "core/src/main/java"},
+		{classpath.EntryTypeJar, "core/lib/private.jar"},
+	}, suite.classpath(classpath.ScopeRuntime))
+}
+
+func (suite *IntellijProjectSuite) TestSources() {
+	project, err := LoadProject(suite.path)
+	suite.Require().NoError(err)
+
+	sources, err := project.Sources(classpath.ScopeCompile)
+	suite.NoError(err)
+
+	var paths []string
+	for _, source := range sources {
+		rel, err := filepath.Rel(suite.path, source.Path)
+		suite.NoError(err)
+		paths = append(paths, filepath.ToSlash(rel))
+	}
+	suite.Equal([]string{"app/src", "core/src/main/java"}, paths)
+}
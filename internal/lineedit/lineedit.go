@@ -0,0 +1,315 @@
+// Package lineedit reads lines from a terminal with basic editing, a history and tab completion.
+// If the input is not a terminal, lines are read as they are, without a prompt.
+package lineedit
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/mattn/go-isatty"
+)
+
+// ErrInterrupted is returned by ReadLine if the line was aborted with Ctrl-C.
+var ErrInterrupted = errors.New("interrupted")
+
+// maxHistory is the number of lines that are kept in the history.
+const maxHistory = 1000
+
+// Editor reads lines.
+type Editor struct {
+	Prompt string
+	// Complete returns the candidates for completing the given line, which is the part of the
+	// line before the cursor, together with the index in the line where the completed word starts.
+	// It may be nil.
+	Complete func(line string) (int, []string)
+	// History holds the previous lines, the most recent one last.
+	History []string
+
+	in  *bufio.Reader
+	out io.Writer
+	// makeRaw puts the terminal into raw mode and returns a function that restores it.
+	// It is nil if the input is not a terminal.
+	makeRaw func() (func(), error)
+}
+
+// New creates an editor that reads lines from the given input and echoes them to the given output.
+func New(in *os.File, out io.Writer) *Editor {
+	e := &Editor{
+		in:  bufio.NewReader(in),
+		out: out,
+	}
+	if isatty.IsTerminal(in.Fd()) {
+		fd := int(in.Fd())
+		e.makeRaw = func() (func(), error) {
+			return makeRaw(fd)
+		}
+	}
+	return e
+}
+
+// AddHistory appends a line to the history, unless it is empty or repeats the last line.
+func (e *Editor) AddHistory(line string) {
+	if strings.TrimSpace(line) == "" || (len(e.History) > 0 && e.History[len(e.History)-1] == line) {
+		return
+	}
+	e.History = append(e.History, line)
+	if len(e.History) > maxHistory {
+		e.History = e.History[len(e.History)-maxHistory:]
+	}
+}
+
+// ReadLine reads a line, which is edited after the prompt in terminals. It returns io.EOF at the end of the input
+// or if Ctrl-D is pressed on an empty line, and ErrInterrupted if Ctrl-C is pressed.
+// Lines that are read from a terminal are added to the history.
+func (e *Editor) ReadLine() (string, error) {
+	if e.makeRaw != nil {
+		restore, err := e.makeRaw()
+		if err == nil {
+			defer restore()
+			line, err := e.edit()
+			if err == nil {
+				e.AddHistory(line)
+			}
+			return line, err
+		}
+	}
+
+	line, err := e.in.ReadString('\n')
+	if err == io.EOF && line != "" {
+		err = nil
+	}
+	return strings.TrimRight(line, "\r\n"), err
+}
+
+// control keys
+const (
+	keyCtrlA     = 1
+	keyCtrlB     = 2
+	keyCtrlC     = 3
+	keyCtrlD     = 4
+	keyCtrlE     = 5
+	keyCtrlF     = 6
+	keyBackspace = 8
+	keyTab       = 9
+	keyLF        = 10
+	keyCtrlK     = 11
+	keyCtrlL     = 12
+	keyCR        = 13
+	keyCtrlN     = 14
+	keyCtrlP     = 16
+	keyCtrlU     = 21
+	keyCtrlW     = 23
+	keyEscape    = 27
+	keyDelete    = 127
+)
+
+// line is the state of the line that is being edited.
+type line struct {
+	buf []rune
+	pos int
+}
+
+func (l *line) insert(r ...rune) {
+	l.buf = append(l.buf[:l.pos], append(r, l.buf[l.pos:]...)...)
+	l.pos += len(r)
+}
+
+func (l *line) set(s string) {
+	l.buf = []rune(s)
+	l.pos = len(l.buf)
+}
+
+// edit reads a line in raw mode, interpreting control keys and escape sequences.
+func (e *Editor) edit() (string, error) {
+	l := &line{}
+	// history is navigated on a copy, so that edits of previous lines are discarded
+	history := append(append([]string{}, e.History...), "")
+	index := len(history) - 1
+	lastTab := false
+
+	e.refresh(l)
+	for {
+		r, _, err := e.in.ReadRune()
+		if err != nil {
+			_, _ = fmt.Fprint(e.out, "\r\n")
+			return "", err
+		}
+		tab := false
+
+		switch r {
+		case keyCR, keyLF:
+			_, _ = fmt.Fprint(e.out, "\r\n")
+			return string(l.buf), nil
+		case keyCtrlC:
+			_, _ = fmt.Fprint(e.out, "^C\r\n")
+			return "", ErrInterrupted
+		case keyCtrlD:
+			if len(l.buf) == 0 {
+				_, _ = fmt.Fprint(e.out, "\r\n")
+				return "", io.EOF
+			}
+			if l.pos < len(l.buf) {
+				l.buf = append(l.buf[:l.pos], l.buf[l.pos+1:]...)
+			}
+		case keyBackspace, keyDelete:
+			if l.pos > 0 {
+				l.buf = append(l.buf[:l.pos-1], l.buf[l.pos:]...)
+				l.pos--
+			}
+		case keyCtrlA:
+			l.pos = 0
+		case keyCtrlE:
+			l.pos = len(l.buf)
+		case keyCtrlB:
+			if l.pos > 0 {
+				l.pos--
+			}
+		case keyCtrlF:
+			if l.pos < len(l.buf) {
+				l.pos++
+			}
+		case keyCtrlK:
+			l.buf = l.buf[:l.pos]
+		case keyCtrlU:
+			l.buf = l.buf[l.pos:]
+			l.pos = 0
+		case keyCtrlW:
+			start := l.pos
+			for start > 0 && l.buf[start-1] == ' ' {
+				start--
+			}
+			for start > 0 && l.buf[start-1] != ' ' {
+				start--
+			}
+			l.buf = append(l.buf[:start], l.buf[l.pos:]...)
+			l.pos = start
+		case keyCtrlL:
+			_, _ = fmt.Fprint(e.out, "\x1b[H\x1b[2J")
+		case keyCtrlP:
+			index = e.recall(l, history, index, index-1)
+		case keyCtrlN:
+			index = e.recall(l, history, index, index+1)
+		case keyTab:
+			tab = true
+			e.complete(l, lastTab)
+		case keyEscape:
+			switch e.readEscape() {
+			case 'A':
+				index = e.recall(l, history, index, index-1)
+			case 'B':
+				index = e.recall(l, history, index, index+1)
+			case 'C':
+				if l.pos < len(l.buf) {
+					l.pos++
+				}
+			case 'D':
+				if l.pos > 0 {
+					l.pos--
+				}
+			case 'H':
+				l.pos = 0
+			case 'F':
+				l.pos = len(l.buf)
+			case '3':
+				if l.pos < len(l.buf) {
+					l.buf = append(l.buf[:l.pos], l.buf[l.pos+1:]...)
+				}
+			}
+		default:
+			if r >= ' ' {
+				l.insert(r)
+			}
+		}
+		lastTab = tab
+		e.refresh(l)
+	}
+}
+
+// readEscape reads the rest of an escape sequence, such as "[A" for the up arrow, and returns
+// the letter that identifies the key. Sequences like "[3~" return their digit, and Home and End
+// are mapped to 'H' and 'F' in all of their variants.
+func (e *Editor) readEscape() rune {
+	r, _, err := e.in.ReadRune()
+	if err != nil || (r != '[' && r != 'O') {
+		return 0
+	}
+	r, _, err = e.in.ReadRune()
+	if err != nil {
+		return 0
+	}
+	if r < '0' || r > '9' {
+		return r
+	}
+	// numbered sequences end with a tilde
+	digit := r
+	for r != '~' {
+		if r, _, err = e.in.ReadRune(); err != nil {
+			return 0
+		}
+	}
+	switch digit {
+	case '1', '7':
+		return 'H'
+	case '4', '8':
+		return 'F'
+	}
+	return digit
+}
+
+// recall replaces the line with the entry of the history at the given index, remembering the
+// edits of the current entry. It returns the new index.
+func (e *Editor) recall(l *line, history []string, index, next int) int {
+	if next < 0 || next >= len(history) {
+		return index
+	}
+	history[index] = string(l.buf)
+	l.set(history[next])
+	return next
+}
+
+// complete completes the word before the cursor as far as all candidates agree. If that doesn't
+// change the line, the candidates are printed on the second tab in a row.
+func (e *Editor) complete(l *line, again bool) {
+	if e.Complete == nil {
+		return
+	}
+	head := string(l.buf[:l.pos])
+	start, candidates := e.Complete(head)
+	if len(candidates) == 0 {
+		return
+	}
+
+	completion := commonPrefix(candidates)
+	if len(candidates) == 1 && !strings.HasSuffix(completion, "/") {
+		completion += " "
+	}
+	if word := head[start:]; completion != word && strings.HasPrefix(completion, word) {
+		l.insert([]rune(completion[len(word):])...)
+		return
+	}
+	if again && len(candidates) > 1 {
+		_, _ = fmt.Fprintf(e.out, "\r\n%s\r\n", strings.Join(candidates, "  "))
+	}
+}
+
+// refresh redraws the prompt and the line, and moves the cursor to its position.
+func (e *Editor) refresh(l *line) {
+	_, _ = fmt.Fprintf(e.out, "\r%s%s\x1b[K", e.Prompt, string(l.buf))
+	if back := len(l.buf) - l.pos; back > 0 {
+		_, _ = fmt.Fprintf(e.out, "\x1b[%dD", back)
+	}
+}
+
+func commonPrefix(values []string) string {
+	prefix := values[0]
+	for _, v := range values[1:] {
+		for !strings.HasPrefix(v, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	return prefix
+}
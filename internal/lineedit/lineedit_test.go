@@ -0,0 +1,140 @@
+package lineedit
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+func TestEditorSuite(t *testing.T) {
+	suite.Run(t, new(EditorSuite))
+}
+
+type EditorSuite struct {
+	suite.Suite
+
+	out bytes.Buffer
+}
+
+func (suite *EditorSuite) SetupTest() {
+	suite.out.Reset()
+}
+
+// terminal returns an editor that reads the given keys as if they were typed in a terminal.
+func (suite *EditorSuite) terminal(keys string) *Editor {
+	return &Editor{
+		Prompt: "> ",
+		in:     bufio.NewReader(strings.NewReader(keys)),
+		out:    &suite.out,
+		makeRaw: func() (func(), error) {
+			return func() {}, nil
+		},
+	}
+}
+
+func (suite *EditorSuite) TestEditing() {
+	for _, tc := range []struct {
+		keys string
+		line string
+	}{
+		{"hello\r", "hello"},
+		{"helo\x1b[Dl\r", "hello"},
+		{"hello x\x7f\x7f\r", "hello"},
+		{"world\x01hello \r", "hello world"},
+		{"hello\x01\x06\x06\x0b\r", "he"},
+		{"hello world\x17\r", "hello "},
+		{"hello world\x02\x02\x02\x02\x02\x15\r", "world"},
+		{"hello\x1b[H\x1b[3~\x1b[F!\r", "ello!"},
+	} {
+		line, err := suite.terminal(tc.keys).ReadLine()
+		suite.NoError(err)
+		suite.Equalf(tc.line, line, "keys %q", tc.keys)
+	}
+}
+
+func (suite *EditorSuite) TestControl() {
+	_, err := suite.terminal("hello\x03").ReadLine()
+	suite.ErrorIs(err, ErrInterrupted)
+
+	_, err = suite.terminal("\x04").ReadLine()
+	suite.ErrorIs(err, io.EOF)
+
+	// Ctrl-D deletes if the line is not empty
+	line, err := suite.terminal("ab\x02\x04\r").ReadLine()
+	suite.NoError(err)
+	suite.Equal("a", line)
+}
+
+func (suite *EditorSuite) TestHistory() {
+	e := suite.terminal("one\rtwo\rtwo\r\x1b[A\x1b[A\x1b[A\x1b[B!\r")
+	for i := 0; i < 3; i++ {
+		_, err := e.ReadLine()
+		suite.Require().NoError(err)
+	}
+	suite.Equal([]string{"one", "two"}, e.History)
+
+	// navigating beyond the oldest line stays there
+	line, err := e.ReadLine()
+	suite.NoError(err)
+	suite.Equal("two!", line)
+	suite.Equal([]string{"one", "two", "two!"}, e.History)
+}
+
+func (suite *EditorSuite) TestComplete() {
+	complete := func(line string) (int, []string) {
+		start := strings.LastIndex(line, " ") + 1
+		var candidates []string
+		for _, c := range []string{"java/util/", "java/util/List", "java/util/Map", "java/util/Map$Entry"} {
+			if strings.HasPrefix(c, line[start:]) {
+				candidates = append(candidates, c)
+			}
+		}
+		return start, candidates
+	}
+
+	for _, tc := range []struct {
+		keys string
+		line string
+	}{
+		{"find ja\t\r", "find java/util/"},
+		{"find java/util/L\t\r", "find java/util/List "},
+		{"find java/util/M\t\r", "find java/util/Map"},
+		{"find x\t\r", "find x"},
+	} {
+		e := suite.terminal(tc.keys)
+		e.Complete = complete
+		line, err := e.ReadLine()
+		suite.NoError(err)
+		suite.Equalf(tc.line, line, "keys %q", tc.keys)
+	}
+
+	// the candidates are printed on the second tab
+	e := suite.terminal("find java/util/Map\t\t\r")
+	e.Complete = complete
+	_, err := e.ReadLine()
+	suite.NoError(err)
+	suite.Contains(suite.out.String(), "java/util/Map  java/util/Map$Entry")
+}
+
+func (suite *EditorSuite) TestNoTerminal() {
+	e := &Editor{
+		Prompt: "> ",
+		in:     bufio.NewReader(strings.NewReader("one\r\ntwo")),
+		out:    &suite.out,
+	}
+	line, err := e.ReadLine()
+	suite.NoError(err)
+	suite.Equal("one", line)
+	line, err = e.ReadLine()
+	suite.NoError(err)
+	suite.Equal("two", line)
+	_, err = e.ReadLine()
+	suite.ErrorIs(err, io.EOF)
+
+	suite.Empty(suite.out.String())
+	suite.Empty(e.History)
+}
@@ -0,0 +1,10 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd && !dragonfly
+
+package lineedit
+
+import "errors"
+
+// makeRaw is not supported on this platform, so lines are read without editing.
+func makeRaw(int) (func(), error) {
+	return nil, errors.New("raw terminal mode is not supported")
+}
@@ -7,15 +7,22 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/rs/zerolog/log"
 	"github.com/tsatke/jt/classpath"
+	"github.com/tsatke/jt/jdk"
 	"github.com/vifraa/gopom"
 )
 
 const (
 	PomFileName = "pom.xml"
+
+	defaultSourceDirectory     = "src/main/java"
+	defaultTestSourceDirectory = "src/test/java"
+	defaultOutputDirectory     = "target/classes"
+	defaultTestOutputDirectory = "target/test-classes"
 )
 
 func IsMavenProject(path string) bool {
@@ -30,8 +37,9 @@ func IsMavenProjectFs(fsys fs.FS) bool {
 type project struct {
 	path string
 
-	pom       *gopom.Project
-	classpath *classpath.Classpath // nil until computed
+	pom        *gopom.Project
+	classpaths map[classpath.Scope]*classpath.Classpath // scopes are missing until computed
+	jdk        *jdk.JDK                                 // nil until computed
 }
 
 func LoadProject(path string) (*project, error) {
@@ -47,8 +55,9 @@ func LoadProject(path string) (*project, error) {
 		Msg("parse pom")
 
 	return &project{
-		path: path,
-		pom:  pom,
+		path:       path,
+		pom:        pom,
+		classpaths: make(map[classpath.Scope]*classpath.Classpath),
 	}, nil
 }
 
@@ -56,18 +65,103 @@ func (p *project) Name() string {
 	return p.pom.Name
 }
 
-func (p *project) Classpath() (*classpath.Classpath, error) {
-	if p.classpath == nil {
-		cp, err := p.buildClasspath()
+func (p *project) Classpath(scope classpath.Scope) (*classpath.Classpath, error) {
+	if p.classpaths[scope] == nil {
+		cp, err := p.buildClasspath(scope)
 		if err != nil {
 			return nil, fmt.Errorf("build classpath: %w", err)
 		}
-		p.classpath = cp
+		p.classpaths[scope] = cp
+	}
+	return p.classpaths[scope], nil
+}
+
+func (p *project) Sources(scope classpath.Scope) ([]*classpath.Entry, error) {
+	var entries []*classpath.Entry
+	if scope.IncludesTests() {
+		path, err := p.projectPath(p.pom.Build.TestSourceDirectory, defaultTestSourceDirectory)
+		if err != nil {
+			return nil, fmt.Errorf("test source directory: %w", err)
+		}
+		entries = append(entries, &classpath.Entry{Type: classpath.EntryTypeSource, Path: path})
+	}
+	path, err := p.projectPath(p.pom.Build.SourceDirectory, defaultSourceDirectory)
+	if err != nil {
+		return nil, fmt.Errorf("source directory: %w", err)
+	}
+	entries = append(entries, &classpath.Entry{Type: classpath.EntryTypeSource, Path: path})
+	return entries, nil
+}
+
+func (p *project) outputs(scope classpath.Scope) ([]*classpath.Entry, error) {
+	var entries []*classpath.Entry
+	if scope.IncludesTests() {
+		path, err := p.projectPath(p.pom.Build.TestOutputDirectory, defaultTestOutputDirectory)
+		if err != nil {
+			return nil, fmt.Errorf("test output directory: %w", err)
+		}
+		entries = append(entries, &classpath.Entry{Type: classpath.EntryTypeOutput, Path: path})
+	}
+	path, err := p.projectPath(p.pom.Build.OutputDirectory, defaultOutputDirectory)
+	if err != nil {
+		return nil, fmt.Errorf("output directory: %w", err)
 	}
-	return p.classpath, nil
+	entries = append(entries, &classpath.Entry{Type: classpath.EntryTypeOutput, Path: path})
+	return entries, nil
 }
 
-func (p *project) buildClasspath() (*classpath.Classpath, error) {
+// projectPath returns the absolute path of the given project directory.
+// If the directory is not configured (empty), the maven default is used as a fallback.
+// Relative directories are resolved against the project directory.
+func (p *project) projectPath(configured, fallback string) (string, error) {
+	if configured == "" {
+		configured = fallback
+	}
+	if filepath.IsAbs(configured) {
+		return configured, nil
+	}
+	return filepath.Abs(filepath.Join(p.path, configured))
+}
+
+// JDK returns the JDK that fits the release configured with the maven compiler plugin properties
+// maven.compiler.release, maven.compiler.source or maven.compiler.target, or nil if no JDK is installed.
+func (p *project) JDK() *jdk.JDK {
+	if p.jdk == nil {
+		p.jdk = jdk.Find(p.release())
+	}
+	return p.jdk
+}
+
+// release returns the java release that the project is compiled for, or zero if it is not configured.
+func (p *project) release() int {
+	for _, property := range []string{"maven.compiler.release", "maven.compiler.source", "maven.compiler.target"} {
+		if release, ok := jdk.ParseRelease(p.property(property)); ok {
+			return release
+		}
+	}
+	return 0
+}
+
+// property returns the value of the property with the given name from the pom, resolving
+// references to other properties like ${java.version}.
+func (p *project) property(name string) string {
+	value := p.pom.Properties.Entries[name]
+	for i := 0; i < 10 && strings.HasPrefix(value, "${") && strings.HasSuffix(value, "}"); i++ {
+		value = p.pom.Properties.Entries[value[2:len(value)-1]]
+	}
+	return value
+}
+
+// includeScope returns the value for the 'includeScope' parameter of the
+// maven dependency plugin, which has the same semantics as classpath.Scope.
+func includeScope(scope classpath.Scope) string {
+	return scope.String()
+}
+
+// Dependencies returns a classpath that only contains the dependencies of the project
+// in the given scope, as computed by the maven dependency plugin. Neither the project's
+// source and output folders nor the JDK are part of that classpath.
+func (p *project) Dependencies(scope classpath.Scope) (*classpath.Classpath, error) {
 	start := time.Now()
 
 	file, err := os.CreateTemp("", "output.*")
@@ -83,6 +177,7 @@ func (p *project) buildClasspath() (*classpath.Classpath, error) {
 		"-f", filepath.Join(p.path, PomFileName),
 		"-Dmdep.outputFile="+file.Name(),
 		"-Dmdep.regenerateFile=true",
+		"-Dmdep.includeScope="+includeScope(scope),
 	)
 	data, err := buildCp.CombinedOutput()
 	if err != nil {
@@ -90,6 +185,7 @@ func (p *project) buildClasspath() (*classpath.Classpath, error) {
 	}
 	log.Trace().
 		Stringer("command", buildCp).
+		Stringer("scope", scope).
 		Msg("build classpath with command")
 
 	buf := new(bytes.Buffer)
@@ -100,52 +196,42 @@ func (p *project) buildClasspath() (*classpath.Classpath, error) {
 
 	log.Debug().
 		Stringer("took", time.Since(start)).
+		Stringer("scope", scope).
 		Msg("build classpath")
 
 	cp, err := classpath.Parse(buf.String())
 	if err != nil {
 		return nil, fmt.Errorf("parse classpath: %w", err)
 	}
+	return cp, nil
+}
 
-	// add JAVA_HOME at the beginning of the classpath
-	javaHome := os.Getenv("JAVA_HOME")
-	if javaHome != "" {
-		if err := fs.WalkDir(os.DirFS(javaHome), ".", func(path string, d fs.DirEntry, err error) error {
-			if err != nil {
-				return err
-			}
-
-			if d.IsDir() {
-				return nil
-			}
-
-			if filepath.Ext(path) != ".jar" {
-				return nil
-			}
-
-			entry := &classpath.Entry{
-				Type: classpath.EntryTypeJar,
-				Path: filepath.Join(javaHome, path),
-			}
-			cp.Entries = append([]*classpath.Entry{entry}, cp.Entries...)
-			return nil
-		}); err != nil {
-			_, _ = fmt.Fprintln(os.Stderr, err)
-		}
+func (p *project) buildClasspath(scope classpath.Scope) (*classpath.Classpath, error) {
+	cp, err := p.Dependencies(scope)
+	if err != nil {
+		return nil, err
 	}
 
-	// add the maven project source folder at the beginning of the classpath
-	sourceDirectoryPath := p.pom.Build.SourceDirectory
-	if sourceDirectoryPath == "" {
-		// if no source directory path is set, use the maven default as a fallback
-		sourceDirectoryPath = "src/main/java" // TODO: make this a constant
+	// add the JDK at the beginning of the classpath
+	if j := p.JDK(); j != nil {
+		cp.Entries = append(j.BootClasspath(), cp.Entries...)
+		cp.Release = j.FeatureVersion()
 	}
-	absoluteSourceDirectoryPath, err := filepath.Abs(sourceDirectoryPath)
+
+	// add the maven project source and output folders at the beginning of the classpath,
+	// test folders come first, so that test classes shadow main classes, just as in surefire
+	sources, err := p.Sources(scope)
+	if err != nil {
+		return nil, fmt.Errorf("sources: %w", err)
+	}
+	outputs, err := p.outputs(scope)
 	if err != nil {
-		return nil, fmt.Errorf("unable to make source directory path absolute: %w", err)
+		return nil, fmt.Errorf("outputs: %w", err)
 	}
-	sourceFolder := &classpath.Entry{Type: classpath.EntryTypeSource, Path: absoluteSourceDirectoryPath}
-	cp.Entries = append([]*classpath.Entry{sourceFolder}, cp.Entries...)
+	cp.Entries = append(append(sources, outputs...), cp.Entries...)
+
+	// follow the Class-Path attributes of jars, just as the JVM does
+	cp.Expand()
 
 	return cp, nil
 }
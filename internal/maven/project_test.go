@@ -6,6 +6,7 @@ import (
 
 	"github.com/spf13/afero"
 	"github.com/stretchr/testify/suite"
+	"github.com/tsatke/jt/classpath"
 )
 
 func TestMavenProjectSuite(t *testing.T) {
@@ -44,7 +45,7 @@ func (suite *MavenProjectSuite) TestClasspath() {
 	project, err := LoadProject(path)
 	suite.NoError(err)
 
-	cp, err := project.Classpath()
+	cp, err := project.Classpath(classpath.ScopeTest)
 	suite.NoError(err)
 
 	var entries []string
@@ -55,3 +56,30 @@ func (suite *MavenProjectSuite) TestClasspath() {
 		suite.Contains(entries, elem)
 	}
 }
+
+func (suite *MavenProjectSuite) TestSources() {
+	path := filepath.Join("testdata", "projects", "maven", "test1")
+	project, err := LoadProject(path)
+	suite.NoError(err)
+
+	abs, err := filepath.Abs(path)
+	suite.NoError(err)
+
+	sourcesOf := func(scope classpath.Scope) []string {
+		sources, err := project.Sources(scope)
+		suite.NoError(err)
+
+		var paths []string
+		for _, source := range sources {
+			suite.Equal(classpath.EntryTypeSource, source.Type)
+			rel, err := filepath.Rel(abs, source.Path)
+			suite.NoError(err)
+			paths = append(paths, filepath.ToSlash(rel))
+		}
+		return paths
+	}
+
+	suite.Equal([]string{"src/test/java", "src/main/java"}, sourcesOf(classpath.ScopeTest))
+	suite.Equal([]string{"src/main/java"}, sourcesOf(classpath.ScopeCompile))
+	suite.Equal([]string{"src/main/java"}, sourcesOf(classpath.ScopeRuntime))
+}
@@ -0,0 +1,166 @@
+// Package properties implements a parser for the java properties file format,
+// as described in the javadoc of java.util.Properties#load.
+package properties
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Properties holds key-value pairs in the order in which they appeared in the file.
+type Properties struct {
+	Keys   []string
+	values map[string]string
+}
+
+// Get returns the value for the given key, or the empty string if the key is not present.
+func (p *Properties) Get(key string) string {
+	return p.values[key]
+}
+
+// Lookup returns the value for the given key and whether the key is present.
+func (p *Properties) Lookup(key string) (string, bool) {
+	v, ok := p.values[key]
+	return v, ok
+}
+
+// Parse reads a properties file from the given reader. Comments, line continuations
+// and escape sequences are handled like java.util.Properties does. If a key appears
+// multiple times, the last value wins.
+func Parse(rd io.Reader) (*Properties, error) {
+	props := &Properties{
+		values: make(map[string]string),
+	}
+
+	scanner := bufio.NewScanner(rd)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	var logical strings.Builder
+	continued := false
+	for scanner.Scan() {
+		line := scanner.Text()
+		if continued {
+			line = strings.TrimLeft(line, " \t\f")
+		} else {
+			line = strings.TrimLeft(line, " \t\f")
+			if line == "" || line[0] == '#' || line[0] == '!' {
+				continue
+			}
+		}
+
+		continued = endsWithContinuation(line)
+		if continued {
+			line = line[:len(line)-1]
+		}
+		logical.WriteString(line)
+		if continued {
+			continue
+		}
+
+		key, value, err := splitKeyValue(logical.String())
+		if err != nil {
+			return nil, err
+		}
+		logical.Reset()
+
+		if _, ok := props.values[key]; !ok {
+			props.Keys = append(props.Keys, key)
+		}
+		props.values[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("scan: %w", err)
+	}
+	if logical.Len() > 0 {
+		key, value, err := splitKeyValue(logical.String())
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := props.values[key]; !ok {
+			props.Keys = append(props.Keys, key)
+		}
+		props.values[key] = value
+	}
+
+	return props, nil
+}
+
+// endsWithContinuation returns whether the line ends with an odd number of backslashes.
+func endsWithContinuation(line string) bool {
+	backslashes := 0
+	for i := len(line) - 1; i >= 0 && line[i] == '\\'; i-- {
+		backslashes++
+	}
+	return backslashes%2 == 1
+}
+
+func splitKeyValue(line string) (string, string, error) {
+	// find the first unescaped separator
+	end := len(line)
+	for i := 0; i < len(line); i++ {
+		if line[i] == '\\' {
+			i++
+			continue
+		}
+		if line[i] == '=' || line[i] == ':' || line[i] == ' ' || line[i] == '\t' || line[i] == '\f' {
+			end = i
+			break
+		}
+	}
+
+	key, err := unescape(line[:end])
+	if err != nil {
+		return "", "", fmt.Errorf("key: %w", err)
+	}
+
+	rest := strings.TrimLeft(line[end:], " \t\f")
+	if rest != "" && (rest[0] == '=' || rest[0] == ':') {
+		rest = strings.TrimLeft(rest[1:], " \t\f")
+	}
+	value, err := unescape(rest)
+	if err != nil {
+		return "", "", fmt.Errorf("value of %s: %w", key, err)
+	}
+	return key, value, nil
+}
+
+func unescape(s string) (string, error) {
+	if !strings.Contains(s, "\\") {
+		return s, nil
+	}
+
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i == len(s)-1 {
+			sb.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 't':
+			sb.WriteByte('\t')
+		case 'n':
+			sb.WriteByte('\n')
+		case 'r':
+			sb.WriteByte('\r')
+		case 'f':
+			sb.WriteByte('\f')
+		case 'u':
+			if i+4 >= len(s) {
+				return "", fmt.Errorf("malformed \\uxxxx encoding")
+			}
+			r, err := strconv.ParseUint(s[i+1:i+5], 16, 16)
+			if err != nil {
+				return "", fmt.Errorf("malformed \\uxxxx encoding: %w", err)
+			}
+			sb.WriteRune(rune(r))
+			i += 4
+		default:
+			sb.WriteByte(s[i])
+		}
+	}
+	return sb.String(), nil
+}
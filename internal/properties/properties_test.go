@@ -0,0 +1,68 @@
+package properties
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+func TestPropertiesSuite(t *testing.T) {
+	suite.Run(t, new(PropertiesSuite))
+}
+
+type PropertiesSuite struct {
+	suite.Suite
+}
+
+func (suite *PropertiesSuite) TestParse() {
+	props, err := Parse(strings.NewReader(`# a comment
+! another comment
+key1=value1
+key2 = value2
+key3:value3
+key4 value4
+  key5   =   value5 with spaces
+empty=
+multi=first, \
+      second, \
+      third
+escaped\=key=escaped\:value\ttab
+unicode=caf\u00e9
+path=C\:\\Program Files\\Java
+`))
+	suite.NoError(err)
+
+	suite.Equal([]string{"key1", "key2", "key3", "key4", "key5", "empty", "multi", "escaped=key", "unicode", "path"}, props.Keys)
+	suite.Equal("value1", props.Get("key1"))
+	suite.Equal("value2", props.Get("key2"))
+	suite.Equal("value3", props.Get("key3"))
+	suite.Equal("value4", props.Get("key4"))
+	suite.Equal("value5 with spaces", props.Get("key5"))
+	suite.Equal("first, second, third", props.Get("multi"))
+	suite.Equal("escaped:value\ttab", props.Get("escaped=key"))
+	suite.Equal("café", props.Get("unicode"))
+	suite.Equal(`C:\Program Files\Java`, props.Get("path"))
+
+	value, ok := props.Lookup("empty")
+	suite.True(ok)
+	suite.Equal("", value)
+
+	_, ok = props.Lookup("missing")
+	suite.False(ok)
+}
+
+func (suite *PropertiesSuite) TestParseDuplicateKey() {
+	props, err := Parse(strings.NewReader("a=1\nb=2\na=3"))
+	suite.NoError(err)
+
+	suite.Equal([]string{"a", "b"}, props.Keys)
+	suite.Equal("3", props.Get("a"))
+}
+
+func (suite *PropertiesSuite) TestParseEscapedLineBreaks() {
+	props, err := Parse(strings.NewReader(`xml=<?xml version\="1.0"?>\r\n<lib>\r\n</lib>\r\n`))
+	suite.NoError(err)
+
+	suite.Equal("<?xml version=\"1.0\"?>\r\n<lib>\r\n</lib>\r\n", props.Get("xml"))
+}
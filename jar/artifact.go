@@ -0,0 +1,305 @@
+package jar
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/tsatke/jt/internal/properties"
+)
+
+const (
+	mavenMetadataDir = "META-INF/maven/"
+
+	AttributeImplementationTitle    = "Implementation-Title"
+	AttributeImplementationVersion  = "Implementation-Version"
+	AttributeImplementationVendorID = "Implementation-Vendor-Id"
+	AttributeBundleSymbolicName     = "Bundle-SymbolicName"
+	AttributeBundleVersion          = "Bundle-Version"
+)
+
+// ArtifactSource is the information from which an artifact was identified.
+type ArtifactSource uint8
+
+const (
+	// ArtifactSourceRepository means that the artifact was identified from the location
+	// of the jar in a local Maven repository or the Gradle cache.
+	ArtifactSourceRepository ArtifactSource = iota
+	// ArtifactSourcePom means that the artifact was identified from the pom.properties
+	// or pom.xml in META-INF/maven.
+	ArtifactSourcePom
+	// ArtifactSourceManifest means that the artifact was identified from the
+	// Implementation-* or Bundle-* attributes of the manifest. The group id
+	// may be missing in that case.
+	ArtifactSourceManifest
+)
+
+func (s ArtifactSource) String() string {
+	switch s {
+	case ArtifactSourceRepository:
+		return "repository"
+	case ArtifactSourcePom:
+		return "pom"
+	case ArtifactSourceManifest:
+		return "manifest"
+	}
+	return "unknown"
+}
+
+// Artifact holds the Maven coordinates of a jar.
+type Artifact struct {
+	GroupID    string
+	ArtifactID string
+	Version    string
+	Source     ArtifactSource
+}
+
+// String returns the coordinates in the form groupId:artifactId:version.
+func (a Artifact) String() string {
+	return a.GroupID + ":" + a.ArtifactID + ":" + a.Version
+}
+
+// Identify determines the coordinates of the jar with the given path, which is the path that
+// the file was opened with and may be empty. The location of the jar in a local repository is
+// the most reliable information and takes precedence, followed by the Maven metadata in the jar
+// and the attributes of the manifest. If the jar can't be identified, nil is returned.
+func (f *File) Identify(name string) (*Artifact, error) {
+	if artifact := RepositoryArtifact(name); artifact != nil {
+		return artifact, nil
+	}
+
+	artifacts, err := f.Artifacts()
+	if err != nil {
+		return nil, err
+	}
+	manifest, err := f.Manifest()
+	if err != nil {
+		return nil, err
+	}
+	if artifact := selectArtifact(artifacts, path.Base(filepath.ToSlash(name)), manifest); artifact != nil {
+		return artifact, nil
+	}
+	return manifestArtifact(manifest), nil
+}
+
+// Artifacts returns the artifacts of which the jar contains Maven metadata in META-INF/maven,
+// sorted by their coordinates. Usually that is only the artifact of the jar itself, but shaded
+// jars also contain the metadata of the artifacts that were shaded into them. The metadata is
+// read from pom.properties, or from pom.xml if there are no properties.
+func (f *File) Artifacts() ([]*Artifact, error) {
+	// directories in META-INF/maven/<groupId>/<artifactId>/ with their metadata files
+	poms := make(map[string]map[string]struct{})
+	for _, file := range f.archive.File {
+		if !strings.HasPrefix(file.Name, mavenMetadataDir) {
+			continue
+		}
+		dir, base := path.Split(file.Name)
+		if base != "pom.properties" && base != "pom.xml" {
+			continue
+		}
+		if strings.Count(strings.TrimPrefix(dir, mavenMetadataDir), "/") != 2 {
+			continue
+		}
+		if poms[dir] == nil {
+			poms[dir] = make(map[string]struct{})
+		}
+		poms[dir][base] = struct{}{}
+	}
+
+	var artifacts []*Artifact
+	for dir, files := range poms {
+		var artifact *Artifact
+		var err error
+		if _, ok := files["pom.properties"]; ok {
+			artifact, err = f.readPomProperties(dir + "pom.properties")
+		} else {
+			artifact, err = f.readPomXML(dir + "pom.xml")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", dir, err)
+		}
+		if artifact != nil {
+			artifacts = append(artifacts, artifact)
+		}
+	}
+	sort.Slice(artifacts, func(i, j int) bool {
+		return artifacts[i].String() < artifacts[j].String()
+	})
+	return artifacts, nil
+}
+
+func (f *File) readPomProperties(name string) (*Artifact, error) {
+	rc, err := f.archive.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("open: %w", err)
+	}
+	defer func() { _ = rc.Close() }()
+
+	props, err := properties.Parse(rc)
+	if err != nil {
+		return nil, fmt.Errorf("parse pom.properties: %w", err)
+	}
+	artifact := &Artifact{
+		GroupID:    props.Get("groupId"),
+		ArtifactID: props.Get("artifactId"),
+		Version:    props.Get("version"),
+		Source:     ArtifactSourcePom,
+	}
+	if artifact.ArtifactID == "" || artifact.Version == "" {
+		return nil, nil
+	}
+	return artifact, nil
+}
+
+// pom is the part of a pom.xml that holds the coordinates of the artifact.
+type pom struct {
+	GroupID    string `xml:"groupId"`
+	ArtifactID string `xml:"artifactId"`
+	Version    string `xml:"version"`
+	Parent     struct {
+		GroupID string `xml:"groupId"`
+		Version string `xml:"version"`
+	} `xml:"parent"`
+	Licenses []License `xml:"licenses>license"`
+}
+
+func (f *File) readPom(name string) (*pom, error) {
+	rc, err := f.archive.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("open: %w", err)
+	}
+	defer func() { _ = rc.Close() }()
+
+	var p pom
+	if err := xml.NewDecoder(rc).Decode(&p); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("parse pom.xml: %w", err)
+	}
+	return &p, nil
+}
+
+func (f *File) readPomXML(name string) (*Artifact, error) {
+	p, err := f.readPom(name)
+	if err != nil {
+		return nil, err
+	}
+	// group id and version are inherited from the parent if they are not declared
+	artifact := &Artifact{
+		GroupID:    p.GroupID,
+		ArtifactID: p.ArtifactID,
+		Version:    p.Version,
+		Source:     ArtifactSourcePom,
+	}
+	if artifact.GroupID == "" {
+		artifact.GroupID = p.Parent.GroupID
+	}
+	if artifact.Version == "" {
+		artifact.Version = p.Parent.Version
+	}
+	// properties like ${revision} can't be resolved without the build
+	if artifact.ArtifactID == "" || artifact.Version == "" || strings.Contains(artifact.String(), "${") {
+		return nil, nil
+	}
+	return artifact, nil
+}
+
+// selectArtifact selects the artifact of the jar from the artifacts in its metadata. If there
+// are several, the one that matches the file name or the manifest is selected, since the others
+// have most likely been shaded into the jar.
+func selectArtifact(artifacts []*Artifact, fileName string, manifest *Manifest) *Artifact {
+	if len(artifacts) == 1 {
+		return artifacts[0]
+	}
+	for _, artifact := range artifacts {
+		if strings.HasPrefix(fileName, artifact.ArtifactID+"-"+artifact.Version) {
+			return artifact
+		}
+	}
+	if m := manifestArtifact(manifest); m != nil {
+		for _, artifact := range artifacts {
+			if artifact.ArtifactID == m.ArtifactID && artifact.Version == m.Version {
+				return artifact
+			}
+		}
+	}
+	return nil
+}
+
+// manifestArtifact identifies an artifact from the Implementation-* or Bundle-* attributes
+// of the manifest, or returns nil if they are missing.
+func manifestArtifact(manifest *Manifest) *Artifact {
+	main := manifest.Main
+	if title, version := main.Get(AttributeImplementationTitle), main.Get(AttributeImplementationVersion); title != "" && version != "" && !strings.Contains(title, " ") {
+		return &Artifact{
+			GroupID:    main.Get(AttributeImplementationVendorID),
+			ArtifactID: title,
+			Version:    version,
+			Source:     ArtifactSourceManifest,
+		}
+	}
+	if name, version := main.Get(AttributeBundleSymbolicName), main.Get(AttributeBundleVersion); name != "" && version != "" {
+		// directives may follow the name, as in "org.example.foo;singleton:=true"
+		if i := strings.IndexByte(name, ';'); i >= 0 {
+			name = name[:i]
+		}
+		return &Artifact{
+			ArtifactID: strings.TrimSpace(name),
+			Version:    version,
+			Source:     ArtifactSourceManifest,
+		}
+	}
+	return nil
+}
+
+// RepositoryArtifact identifies the artifact of a jar from its location in a local Maven
+// repository (<repository>/<group path>/<artifactId>/<version>/<artifactId>-<version>.jar)
+// or in the Gradle cache (files-2.1/<groupId>/<artifactId>/<version>/<hash>/<file>.jar).
+// Repositories are recognized by a directory named repository, such as ~/.m2/repository.
+// If the path doesn't match either layout, nil is returned.
+func RepositoryArtifact(name string) *Artifact {
+	if _, nested := SplitNestedPath(name); nested != "" {
+		return nil
+	}
+	segments := strings.Split(filepath.ToSlash(filepath.Clean(name)), "/")
+	n := len(segments)
+	if n < 5 {
+		return nil
+	}
+	fileName := segments[n-1]
+
+	// Gradle: files-2.1/<groupId>/<artifactId>/<version>/<hash>/<file>
+	if n >= 6 && segments[n-6] == "files-2.1" {
+		group, artifactID, version := segments[n-5], segments[n-4], segments[n-3]
+		if strings.HasPrefix(fileName, artifactID+"-"+version) {
+			return &Artifact{GroupID: group, ArtifactID: artifactID, Version: version, Source: ArtifactSourceRepository}
+		}
+		return nil
+	}
+
+	// Maven: <repository>/<group path>/<artifactId>/<version>/<file>
+	artifactID, version := segments[n-3], segments[n-2]
+	prefix := artifactID + "-" + version
+	if strings.HasSuffix(version, "-SNAPSHOT") {
+		// snapshots may be stored with a timestamp instead of SNAPSHOT
+		prefix = strings.TrimSuffix(prefix, "SNAPSHOT")
+	}
+	if !strings.HasPrefix(fileName, prefix) {
+		return nil
+	}
+	for i := n - 4; i > 0; i-- {
+		if segments[i-1] != "repository" {
+			continue
+		}
+		return &Artifact{
+			GroupID:    strings.Join(segments[i:n-3], "."),
+			ArtifactID: artifactID,
+			Version:    version,
+			Source:     ArtifactSourceRepository,
+		}
+	}
+	return nil
+}
@@ -3,8 +3,11 @@ package jar
 import (
 	"archive/zip"
 	"bufio"
+	"bytes"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
@@ -15,7 +18,17 @@ import (
 var _ io.Closer = (*File)(nil)
 
 type File struct {
+	rd      io.ReaderAt
+	size    int64
 	archive *zip.Reader
+	layout  Layout
+	// classRoot is the directory within the archive that contains
+	// the classes, e.g. "classes/" in a jmod file.
+	classRoot string
+	// versions holds the releases of the versioned variants of classes
+	// by the class name, if the jar is a multi-release jar
+	versions map[string][]int
+	release  int
 	io.Closer
 }
 
@@ -24,32 +37,109 @@ type readerAtCloser interface {
 	io.Closer
 }
 
+// Open opens the jar file with the given name. The name may address an archive
+// that is nested in another archive, such as app.jar!/BOOT-INF/lib/foo.jar.
 func Open(name string) (*File, error) {
+	name, nested := SplitNestedPath(name)
+
 	f, err := os.Open(name)
 	if err != nil {
 		return nil, fmt.Errorf("open: %w", err)
 	}
 	stat, err := f.Stat()
 	if err != nil {
+		_ = f.Close()
 		return nil, fmt.Errorf("stat: %w", err)
 	}
-	return New(f, stat.Size())
+	file, err := newFile(name, f, stat.Size(), f)
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	if nested == "" {
+		return file, nil
+	}
+
+	nestedFile, err := file.openNestedPath(nested)
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	// closing the nested archive closes the archive that it is nested in
+	nestedFile.Closer = chainCloser{nestedFile.Closer, file}
+	return nestedFile, nil
 }
 
-func New(rd readerAtCloser, size int64) (*File, error) {
-	archive, err := zip.NewReader(rd, size)
+const moduleInfo = "module-info.class"
+
+// jmodMagic is the header of a jmod file, which is followed by a regular zip archive.
+var jmodMagic = []byte{'J', 'M', 0x01, 0x00}
+
+// OpenJmod opens a jmod file, as found in the jmods directory of a JDK.
+// The classes of a jmod file are located in its classes directory.
+func OpenJmod(name string) (*File, error) {
+	f, err := os.Open(name)
 	if err != nil {
+		return nil, fmt.Errorf("open: %w", err)
+	}
+	stat, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("stat: %w", err)
+	}
+
+	header := make([]byte, len(jmodMagic))
+	if _, err := f.ReadAt(header, 0); err != nil || !bytes.Equal(header, jmodMagic) {
+		_ = f.Close()
+		return nil, fmt.Errorf("not a jmod file")
+	}
+
+	headerSize := int64(len(jmodMagic))
+	rd := io.NewSectionReader(f, headerSize, stat.Size()-headerSize)
+	archive, err := zip.NewReader(rd, rd.Size())
+	if err != nil {
+		_ = f.Close()
 		return nil, fmt.Errorf("open zip: %w", err)
 	}
 
 	return &File{
-		archive: archive,
-		Closer:  rd,
+		rd:        rd,
+		size:      rd.Size(),
+		archive:   archive,
+		classRoot: "classes/",
+		Closer:    f,
 	}, nil
 }
 
+// New reads a jar file from the given reader. Spring Boot jars are detected from their
+// content, so that their classes are found in BOOT-INF/classes. Since the layouts of
+// web applications and other archives are detected from the file name, use Open for them.
+func New(rd readerAtCloser, size int64) (*File, error) {
+	return newFile("", rd, size, rd)
+}
+
+// newFile reads the archive with the given file name, from which its layout is detected.
+func newFile(name string, rd io.ReaderAt, size int64, closer io.Closer) (*File, error) {
+	archive, err := zip.NewReader(rd, size)
+	if err != nil {
+		return nil, fmt.Errorf("open zip: %w", err)
+	}
+
+	layout := detectLayout(name, archive)
+	f := &File{
+		rd:        rd,
+		size:      size,
+		archive:   archive,
+		layout:    layout,
+		classRoot: layout.classRoot(),
+		Closer:    closer,
+	}
+	f.loadVersions()
+	return f, nil
+}
+
 func (f *File) OpenClass(name string) (*class.Class, error) {
-	classFile, err := f.archive.Open(name + ".class")
+	classFile, err := f.archive.Open(f.resolve(name + ".class"))
 	if err != nil {
 		return nil, fmt.Errorf("open: %w", err)
 	}
@@ -64,14 +154,66 @@ func (f *File) OpenClass(name string) (*class.Class, error) {
 	return class, nil
 }
 
+// ListClasses returns the names of all classes in the archive, such as java/lang/Object.
+// Module descriptors (module-info) are not included. In a multi-release jar, versioned
+// classes are listed with their name, not their location, and only if they are visible
+// for the target release.
 func (f *File) ListClasses() []string {
 	res := make([]string, 0)
 
 	for _, file := range f.archive.File {
-		if filepath.Ext(file.Name) == ".class" {
-			res = append(res, strings.TrimSuffix(file.Name, ".class"))
+		if !strings.HasPrefix(file.Name, f.classRoot) {
+			continue
+		}
+		name := strings.TrimPrefix(file.Name, f.classRoot)
+		if f.IsMultiRelease() && strings.HasPrefix(name, VersionsDir) {
+			continue
+		}
+		if filepath.Ext(name) == ".class" && name != moduleInfo {
+			res = append(res, strings.TrimSuffix(name, ".class"))
+		}
+	}
+
+	// classes that only exist in versioned variants
+	if f.IsMultiRelease() {
+		base := make(map[string]struct{}, len(res))
+		for _, name := range res {
+			base[name+".class"] = struct{}{}
+		}
+		for _, entry := range f.versionedOnly(base) {
+			if filepath.Ext(entry) == ".class" && entry != moduleInfo {
+				res = append(res, strings.TrimSuffix(entry, ".class"))
+			}
 		}
 	}
 
 	return res
 }
+
+// Content returns a reader for the raw content of the archive, which is used to compute
+// checksums, also of nested archives. For jmod files, the header is not included.
+func (f *File) Content() *io.SectionReader {
+	return io.NewSectionReader(f.rd, 0, f.size)
+}
+
+// Manifest reads and parses the manifest of the jar file. If the jar file
+// doesn't contain a manifest, an empty manifest is returned.
+func (f *File) Manifest() (*Manifest, error) {
+	manifestFile, err := f.archive.Open(ManifestName)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &Manifest{
+			Main:    make(Attributes),
+			Entries: make(map[string]Attributes),
+		}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open: %w", err)
+	}
+	defer func() { _ = manifestFile.Close() }()
+
+	manifest, err := ParseManifest(manifestFile)
+	if err != nil {
+		return nil, fmt.Errorf("parse manifest: %w", err)
+	}
+	return manifest, nil
+}
@@ -1,6 +1,10 @@
 package jar
 
 import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"io/fs"
 	"path/filepath"
 	"testing"
 
@@ -36,3 +40,325 @@ func (suite *JarSuite) TestListClasses() {
 
 	suite.ElementsMatch([]string{"com/github/tsatke/jt/App"}, jar.ListClasses())
 }
+
+func (suite *JarSuite) TestOpenJmod() {
+	jmod, err := OpenJmod(filepath.Join("testdata", "jmods", "test.jmod"))
+	suite.Require().NoError(err)
+	defer func() { _ = jmod.Close() }()
+
+	suite.ElementsMatch([]string{"com/github/tsatke/jt/App"}, jmod.ListClasses())
+
+	class, err := jmod.OpenClass("com/github/tsatke/jt/App")
+	suite.NoError(err)
+	suite.Equal("com/github/tsatke/jt/App", class.Name())
+}
+
+func (suite *JarSuite) TestOpenJmodInvalid() {
+	_, err := OpenJmod(filepath.Join("testdata", "jars", "test1.jar"))
+	suite.Error(err)
+}
+
+func (suite *JarSuite) TestSpringBoot() {
+	boot, err := Open(filepath.Join("testdata", "jars", "boot.jar"))
+	suite.Require().NoError(err)
+	defer func() { _ = boot.Close() }()
+
+	suite.Equal(LayoutSpringBoot, boot.Layout())
+	suite.Equal([]string{"com/example/Main"}, boot.ListClasses())
+	suite.Equal([]string{"BOOT-INF/lib/test1.jar"}, boot.NestedArchives())
+
+	lib, err := boot.OpenNested("BOOT-INF/lib/test1.jar")
+	suite.Require().NoError(err)
+	suite.Equal([]string{"com/github/tsatke/jt/App"}, lib.ListClasses())
+	class, err := lib.OpenClass("com/github/tsatke/jt/App")
+	suite.NoError(err)
+	suite.Equal("com/github/tsatke/jt/App", class.Name())
+	suite.NoError(lib.Close())
+
+	_, err = boot.OpenNested("BOOT-INF/lib/missing.jar")
+	suite.Error(err)
+}
+
+func (suite *JarSuite) TestOpenNestedPath() {
+	lib, err := Open(filepath.Join("testdata", "jars", "app.ear") + "!/web.war!/WEB-INF/lib/test1.jar")
+	suite.Require().NoError(err)
+	suite.Equal([]string{"com/github/tsatke/jt/App"}, lib.ListClasses())
+	suite.NoError(lib.Close())
+
+	war, err := Open(filepath.Join("testdata", "jars", "app.ear") + "!/web.war")
+	suite.Require().NoError(err)
+	suite.Equal(LayoutWar, war.Layout())
+	suite.Equal([]string{"com/example/Servlet"}, war.ListClasses())
+	suite.NoError(war.Close())
+}
+
+func (suite *JarSuite) TestNestedArchives() {
+	for name, expected := range map[string][]string{
+		"test1.jar": nil,
+		"app.ear":   {"web.war", "lib/test1.jar"},
+		"lib.aar":   {"classes.jar"},
+	} {
+		f, err := Open(filepath.Join("testdata", "jars", name))
+		suite.Require().NoError(err)
+		suite.Equalf(expected, f.NestedArchives(), "nested archives of %s", name)
+		suite.NoError(f.Close())
+	}
+}
+
+func (suite *JarSuite) TestDetectLayout() {
+	for _, test := range []struct {
+		name     string
+		entries  []string
+		expected Layout
+	}{
+		{"app.war", []string{"WEB-INF/web.xml", "WEB-INF/classes/App.class"}, LayoutWar},
+		// plain jars may contain web resources
+		{"lib.jar", []string{"WEB-INF/web.xml", "App.class"}, LayoutJar},
+		// the deployment descriptor of an ear is optional
+		{"app.ear", []string{"web.war", "lib/lib.jar"}, LayoutEar},
+		{"app.ear", []string{"META-INF/application.xml"}, LayoutJar},
+		{"lib.jar", []string{"lib/lib.jar"}, LayoutJar},
+		{"lib.aar", []string{"AndroidManifest.xml", "classes.jar"}, LayoutAar},
+		{"lib.jar", []string{"AndroidManifest.xml", "App.class"}, LayoutJar},
+		{"app.jar", []string{"BOOT-INF/classes/App.class"}, LayoutSpringBoot},
+	} {
+		var buf bytes.Buffer
+		w := zip.NewWriter(&buf)
+		for _, entry := range test.entries {
+			_, err := w.Create(entry)
+			suite.Require().NoError(err)
+		}
+		suite.Require().NoError(w.Close())
+		archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		suite.Require().NoError(err)
+		suite.Equalf(test.expected, detectLayout(test.name, archive), "layout of %s with %v", test.name, test.entries)
+	}
+}
+
+func (suite *JarSuite) TestMultiRelease() {
+	jar, err := Open(filepath.Join("testdata", "jars", "multirelease.jar"))
+	suite.Require().NoError(err)
+	defer func() { _ = jar.Close() }()
+
+	suite.True(jar.IsMultiRelease())
+	suite.Equal([]int{11, 17}, jar.Versions("com/github/tsatke/jt/App"))
+	suite.Equal([]int{11}, jar.Versions("com/github/tsatke/jt/Only11"))
+
+	for release, expected := range map[int]string{
+		0:  "com/github/tsatke/jt/App.class",
+		8:  "com/github/tsatke/jt/App.class",
+		11: "META-INF/versions/11/com/github/tsatke/jt/App.class",
+		16: "META-INF/versions/11/com/github/tsatke/jt/App.class",
+		21: "META-INF/versions/17/com/github/tsatke/jt/App.class",
+	} {
+		jar.SetRelease(release)
+		suite.Equalf(expected, jar.resolve("com/github/tsatke/jt/App.class"), "release %d", release)
+	}
+
+	jar.SetRelease(8)
+	suite.Equal([]string{"com/github/tsatke/jt/App"}, jar.ListClasses())
+
+	jar.SetRelease(11)
+	suite.Equal([]string{"com/github/tsatke/jt/App", "com/github/tsatke/jt/Only11"}, jar.ListClasses())
+	suite.Equal(11, jar.SelectedVersion("com/github/tsatke/jt/App"))
+	class, err := jar.OpenClass("com/github/tsatke/jt/Only11")
+	suite.NoError(err)
+	suite.NotNil(class)
+}
+
+func (suite *JarSuite) TestNotMultiRelease() {
+	jar, err := Open(filepath.Join("testdata", "jars", "versioned.jar"))
+	suite.Require().NoError(err)
+	defer func() { _ = jar.Close() }()
+
+	// without 'Multi-Release: true', versioned classes are regular entries
+	jar.SetRelease(17)
+	suite.False(jar.IsMultiRelease())
+	suite.Nil(jar.Versions("com/github/tsatke/jt/App"))
+	suite.Equal("com/github/tsatke/jt/App.class", jar.resolve("com/github/tsatke/jt/App.class"))
+	suite.Len(jar.ListClasses(), 5)
+}
+
+func (suite *JarSuite) TestResources() {
+	boot, err := Open(filepath.Join("testdata", "jars", "boot.jar"))
+	suite.Require().NoError(err)
+	defer func() { _ = boot.Close() }()
+
+	// resources are relative to the class root
+	suite.Equal([]string{"application.properties"}, boot.ListResources())
+
+	info, err := boot.StatResource("application.properties")
+	suite.Require().NoError(err)
+	suite.Equal(int64(len("server.port=8080\n")), info.Size())
+
+	rc, err := boot.OpenResource("application.properties")
+	suite.Require().NoError(err)
+	data, err := io.ReadAll(rc)
+	suite.NoError(err)
+	suite.NoError(rc.Close())
+	suite.Equal("server.port=8080\n", string(data))
+
+	_, err = boot.OpenResource("missing.properties")
+	suite.ErrorIs(err, fs.ErrNotExist)
+	_, err = boot.StatResource("missing.properties")
+	suite.ErrorIs(err, fs.ErrNotExist)
+}
+
+func (suite *JarSuite) TestResourcesMultiRelease() {
+	jar, err := Open(filepath.Join("testdata", "jars", "multirelease.jar"))
+	suite.Require().NoError(err)
+	defer func() { _ = jar.Close() }()
+
+	suite.Equal([]string{"META-INF/MANIFEST.MF", "config.properties"}, jar.ListResources())
+
+	jar.SetRelease(11)
+	suite.Equal([]string{"META-INF/MANIFEST.MF", "config.properties", "only11.properties"}, jar.ListResources())
+
+	rc, err := jar.OpenResource("config.properties")
+	suite.Require().NoError(err)
+	data, err := io.ReadAll(rc)
+	suite.NoError(err)
+	suite.NoError(rc.Close())
+	suite.Equal("release=11\n", string(data))
+}
+
+func (suite *JarSuite) TestArtifacts() {
+	shaded, err := Open(filepath.Join("testdata", "jars", "foo-shaded-1.2.jar"))
+	suite.Require().NoError(err)
+	defer func() { _ = shaded.Close() }()
+
+	artifacts, err := shaded.Artifacts()
+	suite.Require().NoError(err)
+	suite.Equal([]*Artifact{
+		{GroupID: "com.example", ArtifactID: "foo-shaded", Version: "1.2", Source: ArtifactSourcePom},
+		{GroupID: "com.google.guava", ArtifactID: "guava", Version: "31.1-jre", Source: ArtifactSourcePom},
+		{GroupID: "org.example", ArtifactID: "child", Version: "2.0", Source: ArtifactSourcePom},
+	}, artifacts)
+}
+
+func (suite *JarSuite) TestIdentify() {
+	for name, expected := range map[string]string{
+		"foo-shaded-1.2.jar": "com.example:foo-shaded:1.2",
+		"bundle.jar":         ":org.example.bundle:3.1.0",
+		"test1.jar":          "com.github.tsatke.jt:test1:1.0-SNAPSHOT",
+		"versioned.jar":      "",
+	} {
+		f, err := Open(filepath.Join("testdata", "jars", name))
+		suite.Require().NoError(err)
+
+		artifact, err := f.Identify(filepath.Join("testdata", "jars", name))
+		suite.NoError(err)
+		if expected == "" {
+			suite.Nilf(artifact, "artifact of %s", name)
+		} else if suite.NotNilf(artifact, "artifact of %s", name) {
+			suite.Equal(expected, artifact.String())
+		}
+		_ = f.Close()
+	}
+}
+
+func (suite *JarSuite) TestRepositoryArtifact() {
+	for path, expected := range map[string]string{
+		"/home/me/.m2/repository/org/apache/commons/commons-lang3/3.12.0/commons-lang3-3.12.0.jar":              "org.apache.commons:commons-lang3:3.12.0",
+		"/home/me/.m2/repository/com/example/app/1.0-SNAPSHOT/app-1.0-20220101.120000-1.jar":                    "com.example:app:1.0-SNAPSHOT",
+		"/home/me/.m2/repository/com/example/app/1.0/app-1.0-tests.jar":                                         "com.example:app:1.0",
+		"/home/me/.gradle/caches/modules-2/files-2.1/com.google.guava/guava/31.1-jre/abc123/guava-31.1-jre.jar": "com.google.guava:guava:31.1-jre",
+		"/home/me/.m2/repository/app/1.0/app-1.0.jar":                                                           "",
+		"/opt/lib/commons-lang3/3.12.0/commons-lang3-3.12.0.jar":                                                "",
+		"/home/me/.m2/repository/com/example/app/1.0/other-1.0.jar":                                             "",
+		"/home/me/.m2/repository/com/example/app/1.0/app-1.0.jar!/lib/foo-1.0.jar":                              "",
+	} {
+		artifact := RepositoryArtifact(filepath.FromSlash(path))
+		if expected == "" {
+			suite.Nilf(artifact, "artifact of %s", path)
+		} else if suite.NotNilf(artifact, "artifact of %s", path) {
+			suite.Equal(expected, artifact.String())
+			suite.Equal(ArtifactSourceRepository, artifact.Source)
+		}
+	}
+}
+
+func (suite *JarSuite) TestLicenses() {
+	f, err := Open(filepath.Join("testdata", "jars", "licensed-1.0.jar"))
+	suite.Require().NoError(err)
+	defer func() { _ = f.Close() }()
+
+	licenses, err := f.Licenses()
+	suite.Require().NoError(err)
+	suite.Equal([]License{
+		{Name: "The Apache Software License, Version 2.0", URL: "https://www.apache.org/licenses/LICENSE-2.0.txt"},
+		{Name: "Example Custom License"},
+		{URL: "https://opensource.org/licenses/MIT"},
+		{Name: "MIT", File: "META-INF/LICENSE.txt"},
+	}, licenses)
+}
+
+func (suite *JarSuite) TestDetectLicense() {
+	suite.Equal("Apache-2.0", DetectLicense(`
+                                 Apache License
+                           Version 2.0, January 2004
+                        http://www.apache.org/licenses/`))
+	suite.Equal("BSD-3-Clause", DetectLicense(`Redistributions of source code must retain the above copyright notice.
+Neither the name of the copyright holder nor the names of its contributors may be used`))
+	suite.Equal("BSD-2-Clause", DetectLicense("Redistributions of source code must retain the above copyright notice."))
+	suite.Equal("", DetectLicense("All rights reserved."))
+}
+
+func (suite *JarSuite) verify(name string) *Verification {
+	f, err := Open(filepath.Join("testdata", "signed", name))
+	suite.Require().NoError(err)
+	defer func() { _ = f.Close() }()
+
+	v, err := f.Verify()
+	suite.Require().NoError(err)
+	return v
+}
+
+func (suite *JarSuite) TestVerify() {
+	for _, name := range []string{"signed.jar", "signed-ec.jar", "signed-dsa.jar"} {
+		v := suite.verify(name)
+		suite.Truef(v.IsSigned(), "%s is signed", name)
+		suite.Require().Len(v.Signers, 1)
+		suite.NoErrorf(v.Signers[0].Err, "signer of %s", name)
+		suite.Equalf([]string{"com/example/A.class", "data.txt"}, v.Signed, "signed entries of %s", name)
+		suite.Empty(v.Unsigned)
+		suite.Empty(v.Tampered)
+		suite.Empty(v.Missing)
+	}
+
+	v := suite.verify("signed.jar")
+	signer := v.Signers[0]
+	suite.Equal("META-INF/SIGNER.SF", signer.SignatureFile)
+	suite.Equal("META-INF/SIGNER.RSA", signer.BlockFile)
+	suite.Require().Len(signer.Certificates, 2)
+	suite.Equal("Example Signer", signer.Certificates[0].Subject.CommonName)
+	suite.Equal("Example Root CA", signer.Certificates[1].Subject.CommonName)
+
+	suite.Equal("META-INF/ECSIGNER.EC", suite.verify("signed-ec.jar").Signers[0].BlockFile)
+}
+
+func (suite *JarSuite) TestVerifyTampered() {
+	v := suite.verify("tampered.jar")
+	suite.True(v.IsSigned())
+	suite.Equal([]string{"com/example/A.class"}, v.Signed)
+	suite.Equal([]string{"extra.txt"}, v.Unsigned)
+	suite.Equal([]string{"data.txt"}, v.Tampered)
+	suite.Equal([]string{"gone.txt"}, v.Missing)
+}
+
+func (suite *JarSuite) TestVerifyInvalidSignature() {
+	v := suite.verify("badsig.jar")
+	suite.False(v.IsSigned())
+	suite.Require().Len(v.Signers, 1)
+	suite.Error(v.Signers[0].Err)
+	suite.Empty(v.Signed)
+	suite.Equal([]string{"com/example/A.class", "data.txt"}, v.Unsigned)
+}
+
+func (suite *JarSuite) TestVerifyUnsigned() {
+	v := suite.verify("../jars/test1.jar")
+	suite.False(v.IsSigned())
+	suite.Empty(v.Signers)
+	suite.Empty(v.Signed)
+	suite.NotEmpty(v.Unsigned)
+}
@@ -0,0 +1,170 @@
+package jar
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+)
+
+// AttributeBundleLicense is the manifest attribute of OSGi bundles that names their licenses.
+const AttributeBundleLicense = "Bundle-License"
+
+// licenseTextLimit is the number of bytes of a license file that are used to detect the license.
+const licenseTextLimit = 4096
+
+// License is a license that a jar declares.
+type License struct {
+	// Name is the name of the license, such as "Apache License, Version 2.0" or an SPDX
+	// identifier like MIT. It may be empty if only the URL is known.
+	Name string `xml:"name"`
+	URL  string `xml:"url"`
+	// File is the name of the license file that the license was detected in, such as
+	// META-INF/LICENSE.txt, or empty if the license is declared in a pom.xml or the manifest.
+	File string `xml:"-"`
+}
+
+// Licenses returns the licenses that the jar declares in the pom.xml files in META-INF/maven,
+// in the Bundle-License attribute of the manifest, and in license files like META-INF/LICENSE.
+// Shaded jars may contain the licenses of the artifacts that were shaded into them as well.
+// License files are only reported if the license can be recognized from their text.
+func (f *File) Licenses() ([]License, error) {
+	var licenses []License
+	seen := make(map[string]struct{})
+	add := func(l License) {
+		key := strings.ToLower(l.Name + "|" + l.URL)
+		if _, ok := seen[key]; ok {
+			return
+		}
+		seen[key] = struct{}{}
+		licenses = append(licenses, l)
+	}
+
+	var poms, files []string
+	for _, file := range f.archive.File {
+		dir, base := path.Split(file.Name)
+		switch {
+		case strings.HasPrefix(dir, mavenMetadataDir) && base == "pom.xml":
+			poms = append(poms, file.Name)
+		case (dir == "" || dir == "META-INF/") && isLicenseFile(base):
+			files = append(files, file.Name)
+		}
+	}
+	sort.Strings(poms)
+	sort.Strings(files)
+
+	for _, name := range poms {
+		p, err := f.readPom(name)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		for _, l := range p.Licenses {
+			l.Name, l.URL = strings.TrimSpace(l.Name), strings.TrimSpace(l.URL)
+			if l.Name != "" || l.URL != "" {
+				add(l)
+			}
+		}
+	}
+
+	manifest, err := f.Manifest()
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range parseBundleLicense(manifest.Main.Get(AttributeBundleLicense)) {
+		add(l)
+	}
+
+	for _, name := range files {
+		text, err := f.readPrefix(name, licenseTextLimit)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		if license := DetectLicense(text); license != "" {
+			add(License{Name: license, File: name})
+		}
+	}
+	return licenses, nil
+}
+
+func (f *File) readPrefix(name string, limit int64) (string, error) {
+	rc, err := f.archive.Open(name)
+	if err != nil {
+		return "", fmt.Errorf("open: %w", err)
+	}
+	defer func() { _ = rc.Close() }()
+
+	data, err := io.ReadAll(io.LimitReader(rc, limit))
+	if err != nil {
+		return "", fmt.Errorf("read: %w", err)
+	}
+	return string(data), nil
+}
+
+// isLicenseFile returns whether the file with the given name is a license file,
+// such as LICENSE, LICENSE.txt or LICENSE-junit.txt.
+func isLicenseFile(name string) bool {
+	name = strings.ToUpper(name)
+	return strings.HasPrefix(name, "LICENSE") || strings.HasPrefix(name, "LICENCE")
+}
+
+// parseBundleLicense parses the value of the Bundle-License attribute, which is a comma
+// separated list of license names or URLs, each of which may be followed by attributes
+// like ;link=https://... or ;description=....
+func parseBundleLicense(value string) []License {
+	var licenses []License
+	for _, clause := range strings.Split(value, ",") {
+		parts := strings.Split(clause, ";")
+		l := License{}
+		name := strings.Trim(strings.TrimSpace(parts[0]), `"`)
+		if strings.HasPrefix(name, "http://") || strings.HasPrefix(name, "https://") {
+			l.URL = name
+		} else {
+			l.Name = name
+		}
+		for _, attr := range parts[1:] {
+			if i := strings.IndexByte(attr, '='); i >= 0 && strings.TrimSpace(attr[:i]) == "link" {
+				l.URL = strings.Trim(strings.TrimSpace(attr[i+1:]), `"`)
+			}
+		}
+		if l.Name != "" || l.URL != "" {
+			licenses = append(licenses, l)
+		}
+	}
+	return licenses
+}
+
+// licenseTexts maps phrases that occur in the text of common licenses to their SPDX identifier.
+// All phrases of an entry have to occur, and more specific entries come first.
+var licenseTexts = []struct {
+	phrases []string
+	id      string
+}{
+	{[]string{"apache license, version 2.0"}, "Apache-2.0"},
+	{[]string{"apache license version 2.0"}, "Apache-2.0"},
+	{[]string{"eclipse public license - v 2.0"}, "EPL-2.0"},
+	{[]string{"eclipse public license v2.0"}, "EPL-2.0"},
+	{[]string{"eclipse public license - v 1.0"}, "EPL-1.0"},
+	{[]string{"eclipse public license v1.0"}, "EPL-1.0"},
+	{[]string{"gnu lesser general public license version 2.1"}, "LGPL-2.1-only"},
+	{[]string{"gnu general public license", "version 2", "classpath exception"}, "GPL-2.0-only WITH Classpath-exception-2.0"},
+	{[]string{"permission is hereby granted, free of charge, to any person obtaining a copy"}, "MIT"},
+	{[]string{"redistributions of source code must retain", "neither the name"}, "BSD-3-Clause"},
+	{[]string{"redistributions of source code must retain"}, "BSD-2-Clause"},
+}
+
+// DetectLicense returns the SPDX identifier of the license with the given text,
+// or the empty string if it is not recognized.
+func DetectLicense(text string) string {
+	text = strings.Join(strings.Fields(strings.ToLower(text)), " ")
+	for _, t := range licenseTexts {
+		matches := true
+		for _, phrase := range t.phrases {
+			matches = matches && strings.Contains(text, phrase)
+		}
+		if matches {
+			return t.id
+		}
+	}
+	return ""
+}
@@ -0,0 +1,150 @@
+package jar
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	ManifestName = "META-INF/MANIFEST.MF"
+
+	AttributeManifestVersion = "Manifest-Version"
+	AttributeClassPath       = "Class-Path"
+	AttributeMainClass       = "Main-Class"
+	AttributeName            = "Name"
+)
+
+// Manifest is the content of the META-INF/MANIFEST.MF file of a jar.
+type Manifest struct {
+	// Main holds the attributes of the main section.
+	Main Attributes
+	// Entries holds the attributes of the per-entry sections by the value
+	// of their Name attribute, such as com/example/Foo.class.
+	Entries map[string]Attributes
+}
+
+// Attributes are the attributes of a manifest section. Attribute names are
+// case-insensitive, so use Get to look up values.
+type Attributes map[string]string
+
+// Get returns the value of the attribute with the given name, ignoring case,
+// or an empty string if there is no such attribute.
+func (a Attributes) Get(name string) string {
+	if v, ok := a[name]; ok {
+		return v
+	}
+	for k, v := range a {
+		if strings.EqualFold(k, name) {
+			return v
+		}
+	}
+	return ""
+}
+
+// ClassPath returns the relative URLs in the Class-Path attribute of the main section.
+func (m *Manifest) ClassPath() []string {
+	return strings.Fields(m.Main.Get(AttributeClassPath))
+}
+
+// ParseManifest parses a manifest as specified in the jar file specification.
+// Lines that are longer than 72 bytes are continued on the next line, which
+// starts with a single space. Sections are separated by empty lines.
+func ParseManifest(rd io.Reader) (*Manifest, error) {
+	lines, err := manifestLines(rd)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Manifest{
+		Main:    make(Attributes),
+		Entries: make(map[string]Attributes),
+	}
+
+	section := m.Main
+	main := true
+	for i, line := range lines {
+		if line == "" {
+			// the next section starts with a Name attribute
+			section = nil
+			main = false
+			continue
+		}
+
+		sep := strings.Index(line, ": ")
+		if sep <= 0 {
+			return nil, fmt.Errorf("line %d: invalid attribute '%s'", i+1, line)
+		}
+		name, value := line[:sep], line[sep+2:]
+
+		if section == nil {
+			if !strings.EqualFold(name, AttributeName) {
+				return nil, fmt.Errorf("line %d: section does not start with %s", i+1, AttributeName)
+			}
+			section = m.Entries[value]
+			if section == nil {
+				section = make(Attributes)
+				m.Entries[value] = section
+			}
+			continue
+		}
+		if main || !strings.EqualFold(name, AttributeName) {
+			section[name] = value
+		}
+	}
+
+	return m, nil
+}
+
+// manifestLines reads the lines of a manifest, joining continued lines.
+// Consecutive empty lines are collapsed into one.
+func manifestLines(rd io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(rd)
+	scanner.Split(scanManifestLines)
+
+	var lines []string
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, " "):
+			if len(lines) == 0 || lines[len(lines)-1] == "" {
+				return nil, fmt.Errorf("line %d: continuation without attribute", len(lines)+1)
+			}
+			lines[len(lines)-1] += line[1:]
+		case line == "" && (len(lines) == 0 || lines[len(lines)-1] == ""):
+			// skip consecutive empty lines
+		default:
+			lines = append(lines, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read manifest: %w", err)
+	}
+	return lines, nil
+}
+
+// scanManifestLines is a bufio.SplitFunc that splits lines that end with
+// CR LF, LF or CR.
+func scanManifestLines(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		if data[i] == '\r' {
+			if i+1 == len(data) && !atEOF {
+				// need more data to know whether a LF follows
+				return 0, nil, nil
+			}
+			if i+1 < len(data) && data[i+1] == '\n' {
+				return i + 2, data[:i], nil
+			}
+		}
+		return i + 1, data[:i], nil
+	}
+	if atEOF {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
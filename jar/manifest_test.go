@@ -0,0 +1,61 @@
+package jar
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+func TestManifestSuite(t *testing.T) {
+	suite.Run(t, new(ManifestSuite))
+}
+
+type ManifestSuite struct {
+	suite.Suite
+}
+
+func (suite *ManifestSuite) TestParse() {
+	m, err := ParseManifest(strings.NewReader("" +
+		"Manifest-Version: 1.0\r\n" +
+		"Main-Class: com.example.Main\r\n" +
+		"Class-Path: lib/first-library-with-a-very-long-name-1.0.0.jar lib/sec\r\n" +
+		" ond.jar\r\n" +
+		"\r\n" +
+		"Name: com/example/Main.class\r\n" +
+		"SHA-256-Digest: abc=\r\n" +
+		"\r\n" +
+		"\r\n" +
+		"Name: com/example/\n" +
+		"Sealed: true\n"))
+	suite.Require().NoError(err)
+
+	suite.Equal("1.0", m.Main.Get(AttributeManifestVersion))
+	suite.Equal("com.example.Main", m.Main.Get("main-class"), "attribute names are case-insensitive")
+	suite.Equal([]string{"lib/first-library-with-a-very-long-name-1.0.0.jar", "lib/second.jar"}, m.ClassPath())
+	suite.Equal(Attributes{"SHA-256-Digest": "abc="}, m.Entries["com/example/Main.class"])
+	suite.Equal(Attributes{"Sealed": "true"}, m.Entries["com/example/"])
+	suite.Len(m.Entries, 2)
+}
+
+func (suite *ManifestSuite) TestParseInvalid() {
+	for _, input := range []string{
+		"Manifest-Version 1.0\n",
+		" continued\n",
+		"Manifest-Version: 1.0\n\nSealed: true\n",
+	} {
+		_, err := ParseManifest(strings.NewReader(input))
+		suite.Errorf(err, "parse %q", input)
+	}
+}
+
+func (suite *ManifestSuite) TestManifest() {
+	jar, err := Open(filepath.Join("testdata", "jars", "test1.jar"))
+	suite.Require().NoError(err)
+	defer func() { _ = jar.Close() }()
+
+	m, err := jar.Manifest()
+	suite.Require().NoError(err)
+	suite.Equal("1.0", m.Main.Get(AttributeManifestVersion))
+}
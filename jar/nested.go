@@ -0,0 +1,218 @@
+package jar
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// NestedSeparator separates the path of an archive from the name of an archive
+// that is nested in it, as in app.jar!/BOOT-INF/lib/foo.jar.
+const NestedSeparator = "!/"
+
+// Layout is the structure of an archive, which determines where classes
+// and nested archives are located.
+type Layout uint8
+
+const (
+	// LayoutJar is a plain jar, with classes at the root and no nested archives.
+	LayoutJar Layout = iota
+	// LayoutSpringBoot is a Spring Boot fat jar, with classes in BOOT-INF/classes
+	// and nested jars in BOOT-INF/lib.
+	LayoutSpringBoot
+	// LayoutWar is a web application archive, with classes in WEB-INF/classes
+	// and nested jars in WEB-INF/lib (and WEB-INF/lib-provided for Spring Boot).
+	LayoutWar
+	// LayoutEar is an enterprise application archive, which contains modules
+	// (jars and wars) at the root and libraries in lib.
+	LayoutEar
+	// LayoutAar is an Android library, which contains its classes in classes.jar
+	// and nested jars in libs.
+	LayoutAar
+)
+
+func (l Layout) String() string {
+	switch l {
+	case LayoutSpringBoot:
+		return "spring-boot"
+	case LayoutWar:
+		return "war"
+	case LayoutEar:
+		return "ear"
+	case LayoutAar:
+		return "aar"
+	}
+	return "jar"
+}
+
+func (l Layout) classRoot() string {
+	switch l {
+	case LayoutSpringBoot:
+		return "BOOT-INF/classes/"
+	case LayoutWar:
+		return "WEB-INF/classes/"
+	}
+	return ""
+}
+
+// isNested returns whether the archive entry with the given name is a nested archive.
+func (l Layout) isNested(name string) bool {
+	dir, file := path.Split(name)
+	ext := path.Ext(file)
+	switch l {
+	case LayoutSpringBoot:
+		return dir == "BOOT-INF/lib/" && ext == ".jar"
+	case LayoutWar:
+		return (dir == "WEB-INF/lib/" || dir == "WEB-INF/lib-provided/") && ext == ".jar"
+	case LayoutEar:
+		return (dir == "" && (ext == ".jar" || ext == ".war")) || (dir == "lib/" && ext == ".jar")
+	case LayoutAar:
+		return name == "classes.jar" || (dir == "libs/" && ext == ".jar")
+	}
+	return false
+}
+
+// detectLayout detects the layout of the archive with the given file name. Wars, ears and aars
+// are recognized by their extension, so that plain jars that happen to contain web or Android
+// resources keep their classes at the root. Ears are recognized by their modules, since
+// META-INF/application.xml is optional.
+func detectLayout(name string, archive *zip.Reader) Layout {
+	ext := strings.ToLower(path.Ext(name))
+	var war, ear, aar bool
+	for _, file := range archive.File {
+		switch {
+		case strings.HasPrefix(file.Name, "BOOT-INF/"):
+			return LayoutSpringBoot
+		case strings.HasPrefix(file.Name, "WEB-INF/"):
+			war = ext == ".war"
+		case LayoutEar.isNested(file.Name):
+			ear = ext == ".ear"
+		case file.Name == "AndroidManifest.xml":
+			aar = ext == ".aar"
+		}
+	}
+	switch {
+	case war:
+		return LayoutWar
+	case ear:
+		return LayoutEar
+	case aar:
+		return LayoutAar
+	}
+	return LayoutJar
+}
+
+// SplitNestedPath splits a path like app.jar!/BOOT-INF/lib/foo.jar into the path of the
+// outermost archive and the name of the nested archive within it, which may itself
+// address a nested archive. The nested name is empty if the path is not nested.
+func SplitNestedPath(name string) (string, string) {
+	if i := strings.Index(name, NestedSeparator); i >= 0 {
+		return name[:i], name[i+len(NestedSeparator):]
+	}
+	return name, ""
+}
+
+// Layout returns the layout of the archive.
+func (f *File) Layout() Layout {
+	return f.layout
+}
+
+// NestedArchives returns the names of all archives that are nested in this archive
+// according to its layout, such as the libraries in BOOT-INF/lib of a Spring Boot jar.
+func (f *File) NestedArchives() []string {
+	var res []string
+	for _, file := range f.archive.File {
+		if f.layout.isNested(file.Name) {
+			res = append(res, file.Name)
+		}
+	}
+	return res
+}
+
+// OpenNested opens the archive with the given name that is nested in this archive.
+// The nested archive is not extracted to disk, and must be closed before this archive.
+// Closing the nested archive does not close this archive.
+func (f *File) OpenNested(name string) (*File, error) {
+	var file *zip.File
+	for _, candidate := range f.archive.File {
+		if candidate.Name == name {
+			file = candidate
+			break
+		}
+	}
+	if file == nil {
+		return nil, fmt.Errorf("open %s: nested archive not found", name)
+	}
+
+	// stored archives, like the libraries of Spring Boot jars, are read in place,
+	// compressed archives have to be decompressed into memory
+	var rd io.ReaderAt
+	size := int64(file.UncompressedSize64)
+	if file.Method == zip.Store {
+		offset, err := file.DataOffset()
+		if err != nil {
+			return nil, fmt.Errorf("open %s: %w", name, err)
+		}
+		rd = io.NewSectionReader(f.rd, offset, size)
+	} else {
+		content, err := file.Open()
+		if err != nil {
+			return nil, fmt.Errorf("open %s: %w", name, err)
+		}
+		data, err := io.ReadAll(content)
+		_ = content.Close()
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", name, err)
+		}
+		rd = bytes.NewReader(data)
+		size = int64(len(data))
+	}
+
+	nested, err := newFile(name, rd, size, nopCloser{})
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", name, err)
+	}
+	return nested, nil
+}
+
+// openNestedPath opens a nested archive, which may be nested multiple levels deep,
+// like app.ear!/web.war!/WEB-INF/lib/foo.jar. Closing the returned archive closes
+// all intermediate archives, but not this archive.
+func (f *File) openNestedPath(name string) (*File, error) {
+	name, rest := SplitNestedPath(name)
+	nested, err := f.OpenNested(name)
+	if err != nil {
+		return nil, err
+	}
+	if rest == "" {
+		return nested, nil
+	}
+
+	inner, err := nested.openNestedPath(rest)
+	if err != nil {
+		_ = nested.Close()
+		return nil, err
+	}
+	inner.Closer = chainCloser{inner.Closer, nested}
+	return inner, nil
+}
+
+// chainCloser closes multiple closers in order.
+type chainCloser []io.Closer
+
+func (c chainCloser) Close() error {
+	var firstErr error
+	for _, closer := range c {
+		if err := closer.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+type nopCloser struct{}
+
+func (nopCloser) Close() error { return nil }
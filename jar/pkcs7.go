@@ -0,0 +1,212 @@
+package jar
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/dsa"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// The structures of PKCS#7 signed data (RFC 2315), as used by the signature block files
+// of signed jars. Only the parts that are needed to verify a detached signature are parsed.
+
+var (
+	oidSignedData    = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidMessageDigest = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 4}
+
+	oidSHA1   = asn1.ObjectIdentifier{1, 3, 14, 3, 2, 26}
+	oidSHA256 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	oidSHA384 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 2}
+	oidSHA512 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 3}
+)
+
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"explicit,optional,tag:0"`
+}
+
+type signedData struct {
+	Version          int
+	DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+	ContentInfo      asn1.RawValue
+	Certificates     asn1.RawValue `asn1:"optional,tag:0"`
+	CRLs             asn1.RawValue `asn1:"optional,tag:1"`
+	SignerInfos      []signerInfo  `asn1:"set"`
+}
+
+type issuerAndSerialNumber struct {
+	Issuer       asn1.RawValue
+	SerialNumber *big.Int
+}
+
+type signerInfo struct {
+	Version                   int
+	IssuerAndSerialNumber     issuerAndSerialNumber
+	DigestAlgorithm           pkix.AlgorithmIdentifier
+	AuthenticatedAttributes   asn1.RawValue `asn1:"optional,tag:0"`
+	DigestEncryptionAlgorithm pkix.AlgorithmIdentifier
+	EncryptedDigest           []byte
+	UnauthenticatedAttributes asn1.RawValue `asn1:"optional,tag:1"`
+}
+
+type attribute struct {
+	Type   asn1.ObjectIdentifier
+	Values asn1.RawValue `asn1:"set"`
+}
+
+type dsaSignature struct {
+	R, S *big.Int
+}
+
+// parseSignatureBlock parses a PKCS#7 signature block, such as META-INF/CERT.RSA.
+func parseSignatureBlock(data []byte) (*signedData, []*x509.Certificate, error) {
+	var info contentInfo
+	if _, err := asn1.Unmarshal(data, &info); err != nil {
+		return nil, nil, fmt.Errorf("parse content info: %w", err)
+	}
+	if !info.ContentType.Equal(oidSignedData) {
+		return nil, nil, fmt.Errorf("unsupported content type %s", info.ContentType)
+	}
+
+	var sd signedData
+	if _, err := asn1.Unmarshal(info.Content.Bytes, &sd); err != nil {
+		return nil, nil, fmt.Errorf("parse signed data: %w", err)
+	}
+	certificates, err := x509.ParseCertificates(sd.Certificates.Bytes)
+	if err != nil {
+		return nil, nil, fmt.Errorf("parse certificates: %w", err)
+	}
+	return &sd, certificates, nil
+}
+
+// verify verifies that the signer signed the given content with the key of the given certificate.
+func (si *signerInfo) verify(content []byte, cert *x509.Certificate) error {
+	hash, err := hashOf(si.DigestAlgorithm.Algorithm)
+	if err != nil {
+		return err
+	}
+
+	signed := content
+	if len(si.AuthenticatedAttributes.Bytes) > 0 {
+		// the signature covers the authenticated attributes, which contain the digest of the content
+		digest, err := si.messageDigest()
+		if err != nil {
+			return err
+		}
+		h := hash.New()
+		h.Write(content)
+		if !bytes.Equal(h.Sum(nil), digest) {
+			return errors.New("message digest does not match the signature file")
+		}
+		// the attributes are signed as an explicit SET OF instead of the implicitly tagged [0]
+		signed = append([]byte(nil), si.AuthenticatedAttributes.FullBytes...)
+		signed[0] = 0x31
+	}
+
+	h := hash.New()
+	h.Write(signed)
+	hashed := h.Sum(nil)
+
+	switch pub := cert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		err = rsa.VerifyPKCS1v15(pub, hash, hashed, si.EncryptedDigest)
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(pub, hashed, si.EncryptedDigest) {
+			err = errors.New("ecdsa verification failure")
+		}
+	case *dsa.PublicKey:
+		var sig dsaSignature
+		if _, err := asn1.Unmarshal(si.EncryptedDigest, &sig); err != nil {
+			return fmt.Errorf("parse dsa signature: %w", err)
+		}
+		// DSA signs the leftmost bits of the hash, at most the size of the subgroup
+		if size := (pub.Q.BitLen() + 7) / 8; len(hashed) > size {
+			hashed = hashed[:size]
+		}
+		if !dsa.Verify(pub, hashed, sig.R, sig.S) {
+			err = errors.New("dsa verification failure")
+		}
+	default:
+		return fmt.Errorf("unsupported public key %T", cert.PublicKey)
+	}
+	if err != nil {
+		return fmt.Errorf("verify signature: %w", err)
+	}
+	return nil
+}
+
+func (si *signerInfo) messageDigest() ([]byte, error) {
+	rest := si.AuthenticatedAttributes.Bytes
+	for len(rest) > 0 {
+		var attr attribute
+		var err error
+		rest, err = asn1.Unmarshal(rest, &attr)
+		if err != nil {
+			return nil, fmt.Errorf("parse authenticated attributes: %w", err)
+		}
+		if !attr.Type.Equal(oidMessageDigest) {
+			continue
+		}
+		var digest []byte
+		if _, err := asn1.Unmarshal(attr.Values.Bytes, &digest); err != nil {
+			return nil, fmt.Errorf("parse message digest: %w", err)
+		}
+		return digest, nil
+	}
+	return nil, errors.New("authenticated attributes contain no message digest")
+}
+
+// certificate returns the certificate of the signer.
+func (si *signerInfo) certificate(certificates []*x509.Certificate) *x509.Certificate {
+	for _, cert := range certificates {
+		if bytes.Equal(cert.RawIssuer, si.IssuerAndSerialNumber.Issuer.FullBytes) &&
+			cert.SerialNumber.Cmp(si.IssuerAndSerialNumber.SerialNumber) == 0 {
+			return cert
+		}
+	}
+	return nil
+}
+
+func hashOf(oid asn1.ObjectIdentifier) (crypto.Hash, error) {
+	switch {
+	case oid.Equal(oidSHA1):
+		return crypto.SHA1, nil
+	case oid.Equal(oidSHA256):
+		return crypto.SHA256, nil
+	case oid.Equal(oidSHA384):
+		return crypto.SHA384, nil
+	case oid.Equal(oidSHA512):
+		return crypto.SHA512, nil
+	}
+	return 0, fmt.Errorf("unsupported digest algorithm %s", oid)
+}
+
+// certificateChain orders the given certificates into a chain that starts with the given
+// certificate and follows the issuers as far as they are contained.
+func certificateChain(cert *x509.Certificate, certificates []*x509.Certificate) []*x509.Certificate {
+	chain := []*x509.Certificate{cert}
+	for {
+		current := chain[len(chain)-1]
+		if bytes.Equal(current.RawIssuer, current.RawSubject) {
+			return chain
+		}
+		var issuer *x509.Certificate
+		for _, candidate := range certificates {
+			if bytes.Equal(candidate.RawSubject, current.RawIssuer) && current.CheckSignatureFrom(candidate) == nil {
+				issuer = candidate
+				break
+			}
+		}
+		if issuer == nil || len(chain) > len(certificates) {
+			return chain
+		}
+		chain = append(chain, issuer)
+	}
+}
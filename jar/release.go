@@ -0,0 +1,121 @@
+package jar
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	AttributeMultiRelease = "Multi-Release"
+
+	// VersionsDir is the directory below the class root of a multi-release jar, which
+	// contains the versioned classes and resources, e.g. META-INF/versions/11/com/example/Foo.class.
+	VersionsDir = "META-INF/versions/"
+)
+
+// loadVersions detects whether the jar is a multi-release jar and indexes its versioned entries.
+func (f *File) loadVersions() {
+	hasVersions := false
+	for _, file := range f.archive.File {
+		if strings.HasPrefix(file.Name, f.classRoot+VersionsDir) {
+			hasVersions = true
+			break
+		}
+	}
+	if !hasVersions {
+		return
+	}
+
+	// versioned entries are ignored unless the manifest declares the jar as multi-release
+	manifest, err := f.Manifest()
+	if err != nil || !strings.EqualFold(manifest.Main.Get(AttributeMultiRelease), "true") {
+		return
+	}
+
+	f.versions = make(map[string][]int)
+	for _, file := range f.archive.File {
+		name := strings.TrimPrefix(file.Name, f.classRoot+VersionsDir)
+		if len(name) == len(file.Name) || strings.HasSuffix(name, "/") {
+			continue
+		}
+		slash := strings.IndexByte(name, '/')
+		if slash < 0 {
+			continue
+		}
+		release, err := strconv.Atoi(name[:slash])
+		if err != nil || release < 9 {
+			// versions before 9 are ignored, just as the JVM does
+			continue
+		}
+		entry := name[slash+1:]
+		f.versions[entry] = append(f.versions[entry], release)
+	}
+	for _, releases := range f.versions {
+		sort.Ints(releases)
+	}
+}
+
+// IsMultiRelease returns whether the jar is a multi-release jar, i.e. whether its manifest
+// contains 'Multi-Release: true' and it contains versioned classes.
+func (f *File) IsMultiRelease() bool {
+	return f.versions != nil
+}
+
+// Release returns the target release of the jar, see SetRelease.
+func (f *File) Release() int {
+	return f.release
+}
+
+// SetRelease sets the Java feature release, such as 11 or 17, for which classes of
+// a multi-release jar are resolved. OpenClass and ListClasses consider the versioned
+// classes for the highest release that is lower than or equal to the target release.
+// With a target release of 0, only the base versions of classes are considered.
+func (f *File) SetRelease(release int) {
+	f.release = release
+}
+
+// Versions returns the releases for which the jar contains a versioned variant of the class
+// with the given name, in ascending order. The base version is not included. Versions returns
+// nil if the jar is not a multi-release jar.
+func (f *File) Versions(name string) []int {
+	return f.versions[name+".class"]
+}
+
+// SelectedVersion returns the release of the versioned variant of the class with the given
+// name that is used for the target release, or 0 if the base version is used.
+func (f *File) SelectedVersion(name string) int {
+	return f.selectedVersion(name + ".class")
+}
+
+func (f *File) selectedVersion(entry string) int {
+	releases := f.versions[entry]
+	for i := len(releases) - 1; i >= 0; i-- {
+		if releases[i] <= f.release {
+			return releases[i]
+		}
+	}
+	return 0
+}
+
+// resolve returns the name of the archive entry that contains the class or resource
+// with the given name, such as com/example/Foo.class, for the target release.
+func (f *File) resolve(entry string) string {
+	if release := f.selectedVersion(entry); release > 0 {
+		return f.classRoot + VersionsDir + strconv.Itoa(release) + "/" + entry
+	}
+	return f.classRoot + entry
+}
+
+// versionedOnly returns the names of entries that only exist in versioned variants and
+// are visible for the target release, in lexical order.
+func (f *File) versionedOnly(base map[string]struct{}) []string {
+	var res []string
+	for entry, releases := range f.versions {
+		if _, ok := base[entry]; !ok && releases[0] <= f.release {
+			res = append(res, entry)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
@@ -0,0 +1,63 @@
+package jar
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// ListResources returns the names of all resources in the archive that are not classes,
+// such as META-INF/MANIFEST.MF or logback.xml. Directories are not included. Like classes,
+// resources are named relative to the class root of the archive, and versioned resources
+// of multi-release jars are listed with their name, if they are visible for the target release.
+func (f *File) ListResources() []string {
+	res := make([]string, 0)
+
+	for _, file := range f.archive.File {
+		if !strings.HasPrefix(file.Name, f.classRoot) || strings.HasSuffix(file.Name, "/") {
+			continue
+		}
+		name := strings.TrimPrefix(file.Name, f.classRoot)
+		if f.IsMultiRelease() && strings.HasPrefix(name, VersionsDir) {
+			continue
+		}
+		if path.Ext(name) != ".class" {
+			res = append(res, name)
+		}
+	}
+
+	// resources that only exist in versioned variants
+	if f.IsMultiRelease() {
+		base := make(map[string]struct{}, len(res))
+		for _, name := range res {
+			base[name] = struct{}{}
+		}
+		for _, entry := range f.versionedOnly(base) {
+			if path.Ext(entry) != ".class" {
+				res = append(res, entry)
+			}
+		}
+	}
+
+	return res
+}
+
+// OpenResource opens the resource with the given name, such as META-INF/MANIFEST.MF.
+func (f *File) OpenResource(name string) (io.ReadCloser, error) {
+	rc, err := f.archive.Open(f.resolve(name))
+	if err != nil {
+		return nil, fmt.Errorf("open: %w", err)
+	}
+	return rc, nil
+}
+
+// StatResource returns information about the resource with the given name.
+func (f *File) StatResource(name string) (fs.FileInfo, error) {
+	info, err := fs.Stat(f.archive, f.resolve(name))
+	if err != nil {
+		return nil, fmt.Errorf("stat: %w", err)
+	}
+	return info, nil
+}
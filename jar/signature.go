@@ -0,0 +1,392 @@
+package jar
+
+import (
+	"bytes"
+	"crypto"
+	_ "crypto/md5" // register the digest algorithms that manifests may use
+	_ "crypto/sha1"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+)
+
+const (
+	metaInf = "META-INF/"
+
+	signatureFileExt = ".SF"
+)
+
+// signatureBlockExts are the extensions of signature block files, which hold the
+// PKCS#7 signature of a signature file and the certificates of the signer.
+var signatureBlockExts = []string{".RSA", ".DSA", ".EC"}
+
+// Signer is a signer of a jar, which consists of a signature file like META-INF/MYKEY.SF
+// and the signature block file that signs it, like META-INF/MYKEY.RSA.
+type Signer struct {
+	SignatureFile string
+	BlockFile     string
+	// Certificates holds the certificate chain of the signer, starting with
+	// the certificate of the signer. It is empty if the block file can't be parsed.
+	Certificates []*x509.Certificate
+	// Err is set if the signature is invalid, for example because the signature file
+	// doesn't match the manifest or the signature doesn't match the signature file.
+	Err error
+
+	// sections are the names of the manifest sections that are verified by this signer
+	sections map[string]struct{}
+}
+
+// Verification is the result of verifying the signatures of a jar.
+type Verification struct {
+	Signers []*Signer
+	// Signed holds the entries that are signed by at least one valid signer.
+	Signed []string
+	// Unsigned holds the entries that are not signed, because they are missing in the
+	// manifest or in the signature files.
+	Unsigned []string
+	// Tampered holds the entries whose content doesn't match the digest in the manifest,
+	// and entries whose manifest section doesn't match the digest in a signature file.
+	Tampered []string
+	// Missing holds the entries that are listed in the manifest, but don't exist.
+	Missing []string
+}
+
+// IsSigned returns whether the jar has at least one valid signer.
+func (v *Verification) IsSigned() bool {
+	for _, s := range v.Signers {
+		if s.Err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Verify verifies the signatures of the jar like jarsigner -verify does. The signature of every
+// signer is checked against its signature file, the signature file against the manifest and the
+// digests in the manifest against the entries. Signature related files in META-INF, like the
+// manifest itself, are not considered to be entries. Whether the certificates of the signers
+// are trusted is not checked.
+func (f *File) Verify() (*Verification, error) {
+	manifestData, err := f.readEntry(ManifestName)
+	if errors.Is(err, errNoEntry) {
+		manifestData = nil
+	} else if err != nil {
+		return nil, err
+	}
+	manifest, err := ParseManifest(bytes.NewReader(manifestData))
+	if err != nil {
+		return nil, fmt.Errorf("parse manifest: %w", err)
+	}
+	mainSection, sections := manifestSections(manifestData)
+
+	v := &Verification{}
+	for _, file := range f.archive.File {
+		dir, base := path.Split(file.Name)
+		if dir != metaInf || path.Ext(base) != signatureFileExt {
+			continue
+		}
+		signer := &Signer{SignatureFile: file.Name, sections: make(map[string]struct{})}
+		signer.Err = f.verifySigner(signer, manifestData, mainSection, sections, v)
+		v.Signers = append(v.Signers, signer)
+	}
+
+	exists := make(map[string]struct{}, len(f.archive.File))
+	for _, file := range f.archive.File {
+		name := file.Name
+		exists[name] = struct{}{}
+		if strings.HasSuffix(name, "/") || isSignatureRelated(name) {
+			continue
+		}
+		attributes, ok := manifest.Entries[name]
+		if !ok || !hasDigest(attributes) {
+			v.Unsigned = append(v.Unsigned, name)
+			continue
+		}
+		if ok, err := f.verifyEntryDigests(name, attributes); err != nil {
+			return nil, err
+		} else if !ok {
+			if !contains(v.Tampered, name) {
+				v.Tampered = append(v.Tampered, name)
+			}
+			continue
+		}
+		if signedBy(v.Signers, name) {
+			v.Signed = append(v.Signed, name)
+		} else if !contains(v.Tampered, name) {
+			v.Unsigned = append(v.Unsigned, name)
+		}
+	}
+
+	for name, attributes := range manifest.Entries {
+		if _, ok := exists[name]; !ok && hasDigest(attributes) {
+			v.Missing = append(v.Missing, name)
+		}
+	}
+	sort.Strings(v.Missing)
+	return v, nil
+}
+
+// verifySigner verifies the signature of a signer and the digests of its signature file
+// against the manifest. Manifest sections that don't match their digest are added to the
+// tampered entries.
+func (f *File) verifySigner(s *Signer, manifestData, mainSection []byte, sections map[string][]byte, v *Verification) error {
+	base := strings.TrimSuffix(s.SignatureFile, signatureFileExt)
+	var block []byte
+	for _, ext := range signatureBlockExts {
+		data, err := f.readEntry(base + ext)
+		if errors.Is(err, errNoEntry) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		s.BlockFile, block = base+ext, data
+		break
+	}
+	if s.BlockFile == "" {
+		return errors.New("no signature block file")
+	}
+
+	sfData, err := f.readEntry(s.SignatureFile)
+	if err != nil {
+		return err
+	}
+	sd, certificates, err := parseSignatureBlock(block)
+	if err != nil {
+		return err
+	}
+	if len(sd.SignerInfos) == 0 {
+		return errors.New("no signer in signature block")
+	}
+	si := sd.SignerInfos[0]
+	cert := si.certificate(certificates)
+	if cert == nil {
+		return errors.New("no certificate for the signer")
+	}
+	s.Certificates = certificateChain(cert, certificates)
+	if err := si.verify(sfData, cert); err != nil {
+		return err
+	}
+
+	sf, err := ParseManifest(bytes.NewReader(sfData))
+	if err != nil {
+		return fmt.Errorf("parse signature file: %w", err)
+	}
+
+	// if the digest of the whole manifest matches, all sections are verified
+	if ok, found := matchesDigests(sf.Main, "-Digest-Manifest", manifestData); found && ok {
+		for name := range sections {
+			s.sections[name] = struct{}{}
+		}
+		return nil
+	}
+	if ok, found := matchesDigests(sf.Main, "-Digest-Manifest-Main-Attributes", mainSection); found && !ok {
+		return errors.New("signature file does not match the main attributes of the manifest")
+	}
+	for name, attributes := range sf.Entries {
+		section, ok := sections[name]
+		if !ok {
+			continue
+		}
+		if ok, found := matchesDigests(attributes, "-Digest", section); !found || !ok {
+			if !contains(v.Tampered, name) {
+				v.Tampered = append(v.Tampered, name)
+			}
+			continue
+		}
+		s.sections[name] = struct{}{}
+	}
+	return nil
+}
+
+// verifyEntryDigests returns whether the content of the entry matches all digests
+// in its manifest section.
+func (f *File) verifyEntryDigests(name string, attributes Attributes) (bool, error) {
+	data, err := f.readEntry(name)
+	if err != nil {
+		return false, err
+	}
+	ok, _ := matchesDigests(attributes, "-Digest", data)
+	return ok, nil
+}
+
+// matchesDigests checks the attributes with the given suffix, like SHA-256-Digest, against the
+// given data. It returns whether all digests match, and whether there is a digest at all.
+// Digests of unknown algorithms are ignored.
+func matchesDigests(attributes Attributes, suffix string, data []byte) (bool, bool) {
+	found := false
+	for key, value := range attributes {
+		if !strings.HasSuffix(strings.ToUpper(key), strings.ToUpper(suffix)) {
+			continue
+		}
+		hash, ok := digestAlgorithm(key[:len(key)-len(suffix)])
+		if !ok {
+			continue
+		}
+		expected, err := base64.StdEncoding.DecodeString(strings.TrimSpace(value))
+		if err != nil {
+			return false, true
+		}
+		found = true
+		h := hash.New()
+		h.Write(data)
+		if !bytes.Equal(h.Sum(nil), expected) {
+			return false, true
+		}
+	}
+	return found, found
+}
+
+func hasDigest(attributes Attributes) bool {
+	const suffix = "-DIGEST"
+	for key := range attributes {
+		key = strings.ToUpper(key)
+		if !strings.HasSuffix(key, suffix) {
+			continue
+		}
+		if _, ok := digestAlgorithm(key[:len(key)-len(suffix)]); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// digestAlgorithm returns the hash of a digest algorithm name as used in manifests, like SHA-256.
+func digestAlgorithm(name string) (crypto.Hash, bool) {
+	switch strings.ToUpper(name) {
+	case "MD5":
+		return crypto.MD5, true
+	case "SHA1", "SHA-1":
+		return crypto.SHA1, true
+	case "SHA-256", "SHA256":
+		return crypto.SHA256, true
+	case "SHA-384", "SHA384":
+		return crypto.SHA384, true
+	case "SHA-512", "SHA512":
+		return crypto.SHA512, true
+	}
+	return 0, false
+}
+
+func signedBy(signers []*Signer, name string) bool {
+	for _, s := range signers {
+		if _, ok := s.sections[name]; ok && s.Err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// isSignatureRelated returns whether the entry is the manifest or a file of a signature,
+// which are not signed themselves.
+func isSignatureRelated(name string) bool {
+	dir, base := path.Split(name)
+	if dir != metaInf {
+		return false
+	}
+	upper := strings.ToUpper(base)
+	if upper == "MANIFEST.MF" || strings.HasPrefix(upper, "SIG-") {
+		return true
+	}
+	ext := path.Ext(upper)
+	if ext == signatureFileExt {
+		return true
+	}
+	for _, blockExt := range signatureBlockExts {
+		if ext == blockExt {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+var errNoEntry = errors.New("no such entry")
+
+// readEntry reads the archive entry with the given name, regardless of the class root.
+func (f *File) readEntry(name string) ([]byte, error) {
+	for _, file := range f.archive.File {
+		if file.Name != name {
+			continue
+		}
+		rc, err := file.Open()
+		if err != nil {
+			return nil, fmt.Errorf("open %s: %w", name, err)
+		}
+		defer func() { _ = rc.Close() }()
+		data, err := io.ReadAll(rc)
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", name, err)
+		}
+		return data, nil
+	}
+	return nil, fmt.Errorf("%s: %w", name, errNoEntry)
+}
+
+// manifestSections splits the raw manifest into its main section and the sections of the
+// entries by their name. The raw bytes of a section include the empty line that terminates it,
+// since that is what the digests in signature files are computed over.
+func manifestSections(data []byte) ([]byte, map[string][]byte) {
+	sections := make(map[string][]byte)
+	var main []byte
+	first := true
+	for len(data) > 0 {
+		end := sectionEnd(data)
+		section := data[:end]
+		data = data[end:]
+		if first {
+			main = section
+			first = false
+			continue
+		}
+		if name := sectionName(section); name != "" {
+			sections[name] = section
+		}
+	}
+	return main, sections
+}
+
+// sectionEnd returns the index after the empty line that terminates the first section,
+// or the length of the data if the section is not terminated.
+func sectionEnd(data []byte) int {
+	lineStart := 0
+	for i := 0; i < len(data); i++ {
+		if data[i] != '\r' && data[i] != '\n' {
+			continue
+		}
+		next := i + 1
+		if data[i] == '\r' && next < len(data) && data[next] == '\n' {
+			next++
+		}
+		if i == lineStart {
+			// an empty line terminates the section
+			return next
+		}
+		lineStart = next
+		i = next - 1
+	}
+	return len(data)
+}
+
+func sectionName(section []byte) string {
+	m, err := ParseManifest(bytes.NewReader(section))
+	if err != nil {
+		return ""
+	}
+	return m.Main.Get(AttributeName)
+}
@@ -0,0 +1,206 @@
+package jdk
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+)
+
+// location is a directory that contains JDK installations.
+type location struct {
+	source string
+	// pattern is a glob pattern that matches the home directories of the JDKs in this location.
+	pattern string
+}
+
+func locations() []location {
+	var locs []location
+	switch runtime.GOOS {
+	case "darwin":
+		locs = append(locs,
+			location{"system", "/Library/Java/JavaVirtualMachines/*/Contents/Home"},
+		)
+	case "windows":
+		for _, programFiles := range []string{os.Getenv("ProgramFiles"), os.Getenv("ProgramFiles(x86)")} {
+			if programFiles == "" {
+				continue
+			}
+			locs = append(locs,
+				location{"system", filepath.Join(programFiles, "Java", "*")},
+				location{"system", filepath.Join(programFiles, "Eclipse Adoptium", "*")},
+			)
+		}
+	default:
+		locs = append(locs,
+			location{"system", "/usr/lib/jvm/*"},
+			location{"system", "/usr/java/*"},
+			location{"system", "/opt/java/*"},
+		)
+	}
+
+	if home, err := os.UserHomeDir(); err == nil {
+		sdkmanDir := os.Getenv("SDKMAN_DIR")
+		if sdkmanDir == "" {
+			sdkmanDir = filepath.Join(home, ".sdkman")
+		}
+		asdfDir := os.Getenv("ASDF_DATA_DIR")
+		if asdfDir == "" {
+			asdfDir = filepath.Join(home, ".asdf")
+		}
+
+		locs = append(locs,
+			location{"sdkman", filepath.Join(sdkmanDir, "candidates", "java", "*")},
+			location{"asdf", filepath.Join(asdfDir, "installs", "java", "*")},
+			location{"intellij", filepath.Join(home, ".jdks", "*")},
+		)
+		if runtime.GOOS == "darwin" {
+			locs = append(locs,
+				location{"intellij", filepath.Join(home, "Library", "Java", "JavaVirtualMachines", "*", "Contents", "Home")},
+			)
+		}
+	}
+	return locs
+}
+
+var (
+	discoverOnce sync.Once
+	discovered   []*JDK
+)
+
+// Discover finds all JDKs that are installed in well known locations, such as JAVA_HOME,
+// /usr/lib/jvm, SDKMAN, asdf or ~/.jdks. Each JDK is only reported once, even if it is
+// reachable through multiple locations (e.g. because of symlinks). The result is cached,
+// so the file system is only searched once per process.
+func Discover() []*JDK {
+	discoverOnce.Do(func() {
+		discovered = discover()
+	})
+	return discovered
+}
+
+func discover() []*JDK {
+	var jdks []*JDK
+	seen := make(map[string]struct{})
+	add := func(home, source string) {
+		realHome, err := filepath.EvalSymlinks(home)
+		if err != nil {
+			return
+		}
+		if _, ok := seen[realHome]; ok {
+			return
+		}
+
+		j, err := Read(home)
+		if err != nil {
+			log.Trace().
+				Err(err).
+				Str("home", home).
+				Msg("not a jdk")
+			return
+		}
+		seen[realHome] = struct{}{}
+		j.Source = source
+		jdks = append(jdks, j)
+	}
+
+	if javaHome := os.Getenv("JAVA_HOME"); javaHome != "" {
+		add(javaHome, "JAVA_HOME")
+	}
+	for _, loc := range locations() {
+		homes, _ := filepath.Glob(loc.pattern)
+		sort.Strings(homes)
+		for _, home := range homes {
+			if filepath.Base(home) == "current" {
+				// sdkman and others link the default JDK as 'current', which is a duplicate
+				continue
+			}
+			add(home, loc.source)
+		}
+	}
+
+	log.Debug().
+		Int("jdks", len(jdks)).
+		Msg("discover jdks")
+
+	return jdks
+}
+
+// Select returns the JDK that fits the given feature release best. A JDK with exactly that
+// release is preferred, otherwise the oldest JDK that is newer than the release. If the release
+// is unknown (zero), or no JDK is new enough, JAVA_HOME or the newest JDK is selected.
+// Select returns nil if there are no JDKs.
+func Select(jdks []*JDK, release int) *JDK {
+	if len(jdks) == 0 {
+		return nil
+	}
+
+	var javaHome *JDK
+	for _, j := range jdks {
+		if j.Source == "JAVA_HOME" {
+			javaHome = j
+			break
+		}
+	}
+
+	if release > 0 {
+		if javaHome != nil && javaHome.FeatureVersion() == release {
+			return javaHome
+		}
+
+		var best *JDK
+		for _, j := range jdks {
+			v := j.FeatureVersion()
+			if v < release {
+				continue
+			}
+			if best == nil || v < best.FeatureVersion() {
+				best = j
+			}
+		}
+		if best != nil {
+			return best
+		}
+	}
+
+	if javaHome != nil {
+		return javaHome
+	}
+	newest := jdks[0]
+	for _, j := range jdks[1:] {
+		if j.FeatureVersion() > newest.FeatureVersion() {
+			newest = j
+		}
+	}
+	return newest
+}
+
+// Find discovers the installed JDKs and selects the one that fits the given release best.
+// See Discover and Select for details. Find returns nil if no JDK is installed.
+func Find(release int) *JDK {
+	j := Select(Discover(), release)
+	if j != nil {
+		log.Debug().
+			Int("release", release).
+			Stringer("jdk", j).
+			Msg("select jdk")
+	}
+	return j
+}
+
+// FindHome reads the JDK in the given home directory, and falls back to Find
+// if the home directory is empty or does not contain a JDK.
+func FindHome(home string, release int) *JDK {
+	if home != "" {
+		if j, err := Read(home); err == nil {
+			return j
+		}
+		log.Warn().
+			Str("home", home).
+			Msg("configured jdk not found, falling back to installed jdks")
+	}
+	return Find(release)
+}
@@ -0,0 +1,51 @@
+package jdk
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+)
+
+var (
+	gradleBuildFiles    = []string{"build.gradle.kts", "build.gradle"}
+	gradleSettingsFiles = []string{"settings.gradle.kts", "settings.gradle"}
+
+	// gradleToolchain matches the language version of a toolchain in the Groovy and Kotlin DSL,
+	// such as languageVersion = JavaLanguageVersion.of(17) or languageVersion.set(JavaLanguageVersion.of("17"))
+	gradleToolchain = regexp.MustCompile(`languageVersion\s*(?:=|\.set\s*\()\s*JavaLanguageVersion\.of\s*\(\s*["']?(\d+)["']?\s*\)`)
+)
+
+// GradleToolchain returns the java release of the toolchain that the Gradle build in the given
+// directory configures with java.toolchain.languageVersion, or zero if there is none. If the build
+// file of the directory doesn't configure a toolchain, the build files of the parent directories
+// are searched up to the root project, which contains the settings file, since toolchains are
+// often configured for all projects of a build.
+func GradleToolchain(dir string) int {
+	for {
+		found := false
+		for _, name := range gradleBuildFiles {
+			content, err := os.ReadFile(filepath.Join(dir, name))
+			if err != nil {
+				continue
+			}
+			found = true
+			if match := gradleToolchain.FindSubmatch(content); match != nil {
+				release, _ := strconv.Atoi(string(match[1]))
+				return release
+			}
+		}
+		for _, name := range gradleSettingsFiles {
+			if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+				// the root project has been searched
+				return 0
+			}
+		}
+
+		parent := filepath.Dir(dir)
+		if !found || parent == dir {
+			return 0
+		}
+		dir = parent
+	}
+}
@@ -0,0 +1,145 @@
+// Package jdk discovers installed JDKs and provides the boot classpath of a JDK.
+package jdk
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/tsatke/jt/classpath"
+	"github.com/tsatke/jt/internal/properties"
+)
+
+const (
+	ReleaseFileName = "release"
+)
+
+// JDK is an installed JDK (or JRE).
+type JDK struct {
+	// Home is the installation directory, which contains the release file.
+	Home string
+	// Version is the full version, such as "1.8.0_312" or "17.0.2".
+	Version string
+	// Vendor is the implementor of the JDK, such as "Eclipse Adoptium".
+	// It may be empty for old JDKs.
+	Vendor string
+	// Source describes where the JDK was discovered, such as "JAVA_HOME" or "sdkman".
+	Source string
+}
+
+// Read reads the JDK that is installed in the given directory.
+func Read(home string) (*JDK, error) {
+	f, err := os.Open(filepath.Join(home, ReleaseFileName))
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", ReleaseFileName, err)
+	}
+	defer func() { _ = f.Close() }()
+
+	release, err := properties.Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", ReleaseFileName, err)
+	}
+
+	version := unquote(release.Get("JAVA_VERSION"))
+	if version == "" {
+		return nil, fmt.Errorf("%s does not contain JAVA_VERSION", ReleaseFileName)
+	}
+
+	return &JDK{
+		Home:    home,
+		Version: version,
+		Vendor:  unquote(release.Get("IMPLEMENTOR")),
+	}, nil
+}
+
+func unquote(s string) string {
+	return strings.Trim(s, `"`)
+}
+
+// FeatureVersion returns the feature release number of the JDK, such as 8 for "1.8.0_312" or 17 for "17.0.2".
+func (j *JDK) FeatureVersion() int {
+	release, _ := ParseRelease(j.Version)
+	return release
+}
+
+// IsModular returns whether the JDK has a modular runtime image (Java 9 and later),
+// as opposed to an rt.jar.
+func (j *JDK) IsModular() bool {
+	return j.FeatureVersion() >= 9
+}
+
+func (j *JDK) String() string {
+	if j.Vendor == "" {
+		return fmt.Sprintf("%s (%s)", j.Version, j.Home)
+	}
+	return fmt.Sprintf("%s %s (%s)", j.Vendor, j.Version, j.Home)
+}
+
+// bootJars are the jars of a Java 8 (or earlier) JRE that the bootstrap class loader loads.
+var bootJars = []string{"resources.jar", "rt.jar", "jsse.jar", "jce.jar", "charsets.jar", "jfr.jar"}
+
+// BootClasspath returns the classpath entries that contain the standard library of the JDK.
+// For Java 8 and earlier, these are the jars of the bootstrap class loader and the extension
+// jars, but no tools, demo or sample jars. For Java 9 and later, this is the runtime image
+// in lib/modules, or the jmod files if the runtime image is missing.
+func (j *JDK) BootClasspath() []*classpath.Entry {
+	if j.IsModular() {
+		return j.modules()
+	}
+
+	// a JDK contains a JRE in the 'jre' directory, a JRE is located in the home directory
+	jreHome := filepath.Join(j.Home, "jre")
+	if _, err := os.Stat(jreHome); err != nil {
+		jreHome = j.Home
+	}
+
+	var entries []*classpath.Entry
+	for _, jar := range bootJars {
+		path := filepath.Join(jreHome, "lib", jar)
+		if _, err := os.Stat(path); err == nil {
+			entries = append(entries, &classpath.Entry{Type: classpath.EntryTypeJar, Path: path})
+		}
+	}
+	extJars, _ := filepath.Glob(filepath.Join(jreHome, "lib", "ext", "*.jar"))
+	for _, path := range extJars {
+		entries = append(entries, &classpath.Entry{Type: classpath.EntryTypeJar, Path: path})
+	}
+	return entries
+}
+
+func (j *JDK) modules() []*classpath.Entry {
+	image := filepath.Join(j.Home, "lib", "modules")
+	if _, err := os.Stat(image); err == nil {
+		return []*classpath.Entry{{Type: classpath.EntryTypeJImage, Path: image}}
+	}
+
+	var entries []*classpath.Entry
+	jmods, _ := filepath.Glob(filepath.Join(j.Home, "jmods", "*.jmod"))
+	for _, path := range jmods {
+		entries = append(entries, &classpath.Entry{Type: classpath.EntryTypeJmod, Path: path})
+	}
+	return entries
+}
+
+// ParseRelease parses a java release as it appears in build configurations, such as
+// "1.8", "8", "17", "17.0.2", "JavaSE-1.8", "JavaSE-17" or "JDK_1_8" and returns the
+// feature release number, such as 8 or 17.
+func ParseRelease(s string) (int, bool) {
+	for _, prefix := range []string{"JavaSE-", "JDK_", "jdk-", "jdk"} {
+		s = strings.TrimPrefix(s, prefix)
+	}
+	s = strings.ReplaceAll(s, "_", ".")
+	s = strings.TrimPrefix(s, "1.")
+
+	end := strings.IndexFunc(s, func(r rune) bool { return r < '0' || r > '9' })
+	if end == -1 {
+		end = len(s)
+	}
+	release, err := strconv.Atoi(s[:end])
+	if err != nil || release <= 0 {
+		return 0, false
+	}
+	return release, true
+}
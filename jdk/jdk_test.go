@@ -0,0 +1,136 @@
+package jdk
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"github.com/tsatke/jt/classpath"
+)
+
+var (
+	testdataJDKs = filepath.Join("testdata", "jdks")
+)
+
+func TestJDKSuite(t *testing.T) {
+	suite.Run(t, new(JDKSuite))
+}
+
+type JDKSuite struct {
+	suite.Suite
+}
+
+func (suite *JDKSuite) TestRead() {
+	j, err := Read(filepath.Join(testdataJDKs, "jdk17"))
+	suite.NoError(err)
+	suite.Equal("17.0.2", j.Version)
+	suite.Equal("Eclipse Adoptium", j.Vendor)
+	suite.Equal(17, j.FeatureVersion())
+	suite.True(j.IsModular())
+
+	j, err = Read(filepath.Join(testdataJDKs, "jdk8"))
+	suite.NoError(err)
+	suite.Equal("1.8.0_312", j.Version)
+	suite.Equal("", j.Vendor)
+	suite.Equal(8, j.FeatureVersion())
+	suite.False(j.IsModular())
+
+	_, err = Read(filepath.Join(testdataJDKs, "notajdk"))
+	suite.Error(err)
+}
+
+func (suite *JDKSuite) TestBootClasspathJava8() {
+	j, err := Read(filepath.Join(testdataJDKs, "jdk8"))
+	suite.Require().NoError(err)
+
+	var paths []string
+	for _, entry := range j.BootClasspath() {
+		rel, err := filepath.Rel(j.Home, entry.Path)
+		suite.NoError(err)
+		paths = append(paths, filepath.ToSlash(rel))
+	}
+	suite.Equal([]string{
+		"jre/lib/resources.jar",
+		"jre/lib/rt.jar",
+		"jre/lib/jsse.jar",
+		"jre/lib/jce.jar",
+		"jre/lib/charsets.jar",
+		"jre/lib/ext/nashorn.jar",
+	}, paths)
+}
+
+func (suite *JDKSuite) TestBootClasspathModular() {
+	j, err := Read(filepath.Join(testdataJDKs, "jdk17"))
+	suite.Require().NoError(err)
+
+	entries := j.BootClasspath()
+	suite.Require().Len(entries, 1)
+	suite.Equal(classpath.EntryTypeJImage, entries[0].Type)
+	suite.Equal(filepath.Join(j.Home, "lib", "modules"), entries[0].Path)
+
+	// without a runtime image, the jmods are used
+	home := suite.T().TempDir()
+	suite.Require().NoError(os.MkdirAll(filepath.Join(home, "jmods"), 0755))
+	suite.Require().NoError(os.WriteFile(filepath.Join(home, "jmods", "java.base.jmod"), nil, 0644))
+	j = &JDK{Home: home, Version: "11.0.14"}
+
+	entries = j.BootClasspath()
+	suite.Require().Len(entries, 1)
+	suite.Equal(classpath.EntryTypeJmod, entries[0].Type)
+	suite.Equal(filepath.Join(home, "jmods", "java.base.jmod"), entries[0].Path)
+}
+
+func (suite *JDKSuite) TestParseRelease() {
+	for input, expected := range map[string]int{
+		"1.8":        8,
+		"8":          8,
+		"1.8.0_312":  8,
+		"11":         11,
+		"17.0.2":     17,
+		"JavaSE-1.8": 8,
+		"JavaSE-17":  17,
+		"JDK_1_8":    8,
+		"JDK_17":     17,
+		"jdk-17":     17,
+	} {
+		release, ok := ParseRelease(input)
+		suite.Truef(ok, "parse %s", input)
+		suite.Equalf(expected, release, "parse %s", input)
+	}
+
+	for _, input := range []string{"", "abc", "${java.version}"} {
+		_, ok := ParseRelease(input)
+		suite.Falsef(ok, "parse %s", input)
+	}
+}
+
+func (suite *JDKSuite) TestGradleToolchain() {
+	for dir, expected := range map[string]int{
+		"groovy": 11,
+		"kotlin": 17,
+		"multi":  21,
+		// the toolchain of the root project applies to the subprojects
+		filepath.Join("multi", "app"): 21,
+		"none":                        0,
+		"missing":                     0,
+	} {
+		suite.Equalf(expected, GradleToolchain(filepath.Join("testdata", "gradle", dir)), "toolchain of %s", dir)
+	}
+}
+
+func (suite *JDKSuite) TestSelect() {
+	jdk8 := &JDK{Home: "8", Version: "1.8.0_312", Source: "system"}
+	jdk11 := &JDK{Home: "11", Version: "11.0.14", Source: "JAVA_HOME"}
+	jdk17 := &JDK{Home: "17", Version: "17.0.2", Source: "sdkman"}
+	jdks := []*JDK{jdk11, jdk8, jdk17}
+
+	suite.Nil(Select(nil, 8))
+	suite.Equal(jdk8, Select(jdks, 8))
+	suite.Equal(jdk11, Select(jdks, 11))
+	suite.Equal(jdk17, Select(jdks, 17))
+	suite.Equal(jdk11, Select(jdks, 9), "oldest newer JDK")
+	suite.Equal(jdk11, Select(jdks, 21), "JAVA_HOME if none is new enough")
+	suite.Equal(jdk11, Select(jdks, 0), "JAVA_HOME if release is unknown")
+	suite.Equal(jdk17, Select([]*JDK{jdk8, jdk17}, 0), "newest without JAVA_HOME")
+}
@@ -0,0 +1,397 @@
+// Package jimage implements a reader for the jimage format, which is used
+// for the runtime image (lib/modules) of Java 9 and later.
+package jimage
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tsatke/jt/class"
+)
+
+var _ io.Closer = (*File)(nil)
+
+const (
+	Magic = 0xCAFEDADA
+
+	headerSize = 7 * 4
+
+	compressedMagic      = 0xCAFEFAFA
+	compressedHeaderSize = 4 + 8 + 8 + 4 + 4 + 1
+)
+
+// location attribute kinds
+const (
+	attributeEnd = iota
+	attributeModule
+	attributeParent
+	attributeBase
+	attributeExtension
+	attributeOffset
+	attributeCompressed
+	attributeUncompressed
+	attributeCount
+)
+
+type readerAtCloser interface {
+	io.ReaderAt
+	io.Closer
+}
+
+// File is a jimage file. Classes are addressed by their name without
+// the module, e.g. java/lang/Object.
+type File struct {
+	rd        io.ReaderAt
+	byteOrder binary.ByteOrder
+	indexSize int64
+	strings   []byte
+
+	// resources holds the locations of all resources by their
+	// name within the module, like java/lang/Object.class
+	resources map[string]*location
+	// moduleInfos holds the location of the module-info class by module,
+	// since all modules have a module-info class with the same name
+	moduleInfos map[string]*location
+
+	io.Closer
+}
+
+type location struct {
+	module           string
+	name             string
+	offset           int64
+	compressedSize   int64
+	uncompressedSize int64
+}
+
+func Open(name string) (*File, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("open: %w", err)
+	}
+	stat, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("stat: %w", err)
+	}
+	file, err := New(f, stat.Size())
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return file, nil
+}
+
+func New(rd readerAtCloser, size int64) (*File, error) {
+	header := make([]byte, headerSize)
+	if _, err := rd.ReadAt(header, 0); err != nil {
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+
+	// the jimage is written in the native byte order of the platform
+	var byteOrder binary.ByteOrder
+	switch {
+	case binary.LittleEndian.Uint32(header) == Magic:
+		byteOrder = binary.LittleEndian
+	case binary.BigEndian.Uint32(header) == Magic:
+		byteOrder = binary.BigEndian
+	default:
+		return nil, fmt.Errorf("invalid magic value: 0x%08X", binary.BigEndian.Uint32(header))
+	}
+
+	version := byteOrder.Uint32(header[4:])
+	if major := version >> 16; major != 1 {
+		return nil, fmt.Errorf("unsupported version %d.%d", major, version&0xFFFF)
+	}
+	tableLength := int64(byteOrder.Uint32(header[16:]))
+	locationsSize := int64(byteOrder.Uint32(header[20:]))
+	stringsSize := int64(byteOrder.Uint32(header[24:]))
+
+	indexSize := headerSize + 2*4*tableLength + locationsSize + stringsSize
+	if indexSize > size {
+		return nil, fmt.Errorf("index size %d exceeds file size %d", indexSize, size)
+	}
+
+	index := make([]byte, indexSize-headerSize)
+	if _, err := rd.ReadAt(index, headerSize); err != nil {
+		return nil, fmt.Errorf("read index: %w", err)
+	}
+
+	// the redirect table is only needed for hash lookups, we index all resources instead
+	offsets := index[4*tableLength : 8*tableLength]
+	locations := index[8*tableLength : 8*tableLength+locationsSize]
+
+	f := &File{
+		rd:          rd,
+		byteOrder:   byteOrder,
+		indexSize:   indexSize,
+		strings:     index[8*tableLength+locationsSize:],
+		resources:   make(map[string]*location, tableLength),
+		moduleInfos: make(map[string]*location),
+		Closer:      rd,
+	}
+
+	for i := int64(0); i < tableLength; i++ {
+		offset := int64(byteOrder.Uint32(offsets[4*i:]))
+		if offset >= int64(len(locations)) {
+			return nil, fmt.Errorf("location offset %d out of bounds", offset)
+		}
+		loc, err := f.decodeLocation(locations[offset:])
+		if err != nil {
+			return nil, fmt.Errorf("decode location %d: %w", i, err)
+		}
+		// the image also contains the directory structure /modules and /packages, which we don't need
+		if loc.module == "" || loc.module == "modules" || loc.module == "packages" {
+			continue
+		}
+		if loc.name == "module-info.class" {
+			f.moduleInfos[loc.module] = loc
+		}
+		if _, ok := f.resources[loc.name]; !ok {
+			f.resources[loc.name] = loc
+		}
+	}
+
+	return f, nil
+}
+
+func (f *File) decodeLocation(data []byte) (*location, error) {
+	var attributes [attributeCount]uint64
+	for i := 0; i < len(data); {
+		b := data[i]
+		i++
+		kind := b >> 3
+		if kind == attributeEnd {
+			break
+		}
+		if kind >= attributeCount {
+			return nil, fmt.Errorf("invalid attribute kind %d", kind)
+		}
+		length := int(b&0x7) + 1
+		if i+length > len(data) {
+			return nil, fmt.Errorf("attribute exceeds location data")
+		}
+		var value uint64
+		for j := 0; j < length; j++ {
+			value = value<<8 | uint64(data[i+j])
+		}
+		i += length
+		attributes[kind] = value
+	}
+
+	module := f.string(attributes[attributeModule])
+	parent := f.string(attributes[attributeParent])
+	base := f.string(attributes[attributeBase])
+	extension := f.string(attributes[attributeExtension])
+
+	var name strings.Builder
+	if parent != "" {
+		name.WriteString(parent)
+		name.WriteByte('/')
+	}
+	name.WriteString(base)
+	if extension != "" {
+		name.WriteByte('.')
+		name.WriteString(extension)
+	}
+
+	return &location{
+		module:           module,
+		name:             name.String(),
+		offset:           int64(attributes[attributeOffset]),
+		compressedSize:   int64(attributes[attributeCompressed]),
+		uncompressedSize: int64(attributes[attributeUncompressed]),
+	}, nil
+}
+
+// string reads the zero terminated string at the given offset in the strings table.
+func (f *File) string(offset uint64) string {
+	if offset >= uint64(len(f.strings)) {
+		return ""
+	}
+	data := f.strings[offset:]
+	if end := bytes.IndexByte(data, 0); end >= 0 {
+		data = data[:end]
+	}
+	return string(data)
+}
+
+// Modules returns the names of all modules in the image, sorted by name.
+func (f *File) Modules() []string {
+	modules := make([]string, 0, len(f.moduleInfos))
+	for module := range f.moduleInfos {
+		modules = append(modules, module)
+	}
+	sort.Strings(modules)
+	return modules
+}
+
+// ModuleOf returns the module that contains the class with the given name.
+func (f *File) ModuleOf(name string) (string, bool) {
+	loc, ok := f.resources[name+".class"]
+	if !ok {
+		return "", false
+	}
+	return loc.module, true
+}
+
+func (f *File) OpenClass(name string) (*class.Class, error) {
+	data, err := f.ReadResource(name + ".class")
+	if err != nil {
+		return nil, fmt.Errorf("open: %w", err)
+	}
+
+	class, err := class.ParseClass(bufio.NewReader(bytes.NewReader(data)))
+	if err != nil {
+		return nil, fmt.Errorf("parse class: %w", err)
+	}
+
+	return class, nil
+}
+
+// OpenModuleInfo opens the module-info class of the module with the given name.
+func (f *File) OpenModuleInfo(module string) (*class.Class, error) {
+	loc, ok := f.moduleInfos[module]
+	if !ok {
+		return nil, fmt.Errorf("module %s: %w", module, os.ErrNotExist)
+	}
+	data, err := f.read(loc)
+	if err != nil {
+		return nil, fmt.Errorf("read module-info: %w", err)
+	}
+	class, err := class.ParseClass(bufio.NewReader(bytes.NewReader(data)))
+	if err != nil {
+		return nil, fmt.Errorf("parse class: %w", err)
+	}
+	return class, nil
+}
+
+// ListClasses returns the names of all classes in the image, without the module,
+// e.g. java/lang/Object. The module descriptors (module-info) are not included,
+// since every module has one.
+func (f *File) ListClasses() []string {
+	res := make([]string, 0)
+	for name := range f.resources {
+		if strings.HasSuffix(name, ".class") && name != "module-info.class" {
+			res = append(res, strings.TrimSuffix(name, ".class"))
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+// ReadResource reads the content of the resource with the given name within its module,
+// such as java/lang/Object.class, decompressing it if necessary.
+func (f *File) ReadResource(name string) ([]byte, error) {
+	loc, ok := f.resources[name]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", name, os.ErrNotExist)
+	}
+	data, err := f.read(loc)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", name, err)
+	}
+	return data, nil
+}
+
+// read reads the content of the resource at the given location, decompressing it if necessary.
+func (f *File) read(loc *location) ([]byte, error) {
+	size := loc.uncompressedSize
+	if loc.compressedSize != 0 {
+		size = loc.compressedSize
+	}
+	data := make([]byte, size)
+	if _, err := f.rd.ReadAt(data, f.indexSize+loc.offset); err != nil {
+		return nil, err
+	}
+
+	if loc.compressedSize == 0 {
+		return data, nil
+	}
+	return f.decompress(data)
+}
+
+// decompress decompresses a resource. A resource may be compressed multiple times,
+// each time with its own header.
+func (f *File) decompress(data []byte) ([]byte, error) {
+	for len(data) >= compressedHeaderSize && f.byteOrder.Uint32(data) == compressedMagic {
+		compressedSize := f.byteOrder.Uint64(data[4:])
+		uncompressedSize := f.byteOrder.Uint64(data[12:])
+		decompressor := f.string(uint64(f.byteOrder.Uint32(data[20:])))
+
+		content := data[compressedHeaderSize:]
+		if uint64(len(content)) < compressedSize {
+			return nil, fmt.Errorf("compressed resource is truncated")
+		}
+		content = content[:compressedSize]
+
+		switch decompressor {
+		case "zip":
+			rd, err := zlib.NewReader(bytes.NewReader(content))
+			if err != nil {
+				return nil, fmt.Errorf("zip decompressor: %w", err)
+			}
+			decompressed := make([]byte, uncompressedSize)
+			if _, err := io.ReadFull(rd, decompressed); err != nil {
+				return nil, fmt.Errorf("zip decompressor: %w", err)
+			}
+			data = decompressed
+		default:
+			return nil, fmt.Errorf("unsupported decompressor '%s'", decompressor)
+		}
+	}
+	return data, nil
+}
+
+// ListResources returns the names of all resources in the image that are not classes,
+// such as java/lang/uniName.dat, without the module.
+func (f *File) ListResources() []string {
+	res := make([]string, 0)
+	for name := range f.resources {
+		if !strings.HasSuffix(name, ".class") {
+			res = append(res, name)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+// OpenResource opens the resource with the given name within its module.
+func (f *File) OpenResource(name string) (io.ReadCloser, error) {
+	data, err := f.ReadResource(name)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// StatResource returns information about the resource with the given name within its module.
+func (f *File) StatResource(name string) (fs.FileInfo, error) {
+	loc, ok := f.resources[name]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", name, os.ErrNotExist)
+	}
+	return resourceInfo{loc}, nil
+}
+
+// resourceInfo describes a resource of an image.
+type resourceInfo struct {
+	loc *location
+}
+
+func (i resourceInfo) Name() string       { return path.Base(i.loc.name) }
+func (i resourceInfo) Size() int64        { return i.loc.uncompressedSize }
+func (i resourceInfo) Mode() fs.FileMode  { return 0444 }
+func (i resourceInfo) ModTime() time.Time { return time.Time{} }
+func (i resourceInfo) IsDir() bool        { return false }
+func (i resourceInfo) Sys() interface{}   { return nil }
@@ -0,0 +1,185 @@
+package jimage
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"github.com/tsatke/jt/internal/classbuild"
+)
+
+func TestJImageSuite(t *testing.T) {
+	suite.Run(t, new(JImageSuite))
+}
+
+type JImageSuite struct {
+	suite.Suite
+
+	app []byte
+}
+
+func (suite *JImageSuite) SetupSuite() {
+	app, err := os.ReadFile(filepath.Join("testdata", "classes", "App.class"))
+	suite.Require().NoError(err)
+	suite.app = app
+}
+
+// imageBuilder assembles a jimage for tests.
+type imageBuilder struct {
+	byteOrder binary.ByteOrder
+	strings   bytes.Buffer
+	locations bytes.Buffer
+	offsets   []uint32
+	content   bytes.Buffer
+}
+
+func newImageBuilder(byteOrder binary.ByteOrder) *imageBuilder {
+	b := &imageBuilder{byteOrder: byteOrder}
+	b.strings.WriteByte(0) // offset 0 is the empty string
+	return b
+}
+
+func (b *imageBuilder) string(s string) uint64 {
+	if s == "" {
+		return 0
+	}
+	offset := b.strings.Len()
+	b.strings.WriteString(s)
+	b.strings.WriteByte(0)
+	return uint64(offset)
+}
+
+func (b *imageBuilder) attribute(kind byte, value uint64) {
+	var data [8]byte
+	binary.BigEndian.PutUint64(data[:], value)
+	length := 1
+	for length < 8 && value>>(8*length) != 0 {
+		length++
+	}
+	b.locations.WriteByte(kind<<3 | byte(length-1))
+	b.locations.Write(data[8-length:])
+}
+
+func (b *imageBuilder) add(module, parent, base, extension string, content []byte, compress bool) {
+	b.offsets = append(b.offsets, uint32(b.locations.Len()))
+	b.attribute(attributeModule, b.string(module))
+	b.attribute(attributeParent, b.string(parent))
+	b.attribute(attributeBase, b.string(base))
+	b.attribute(attributeExtension, b.string(extension))
+	b.attribute(attributeOffset, uint64(b.content.Len()))
+	b.attribute(attributeUncompressed, uint64(len(content)))
+	if compress {
+		var compressed bytes.Buffer
+		w := zlib.NewWriter(&compressed)
+		_, _ = w.Write(content)
+		_ = w.Close()
+
+		header := make([]byte, compressedHeaderSize)
+		b.byteOrder.PutUint32(header, compressedMagic)
+		b.byteOrder.PutUint64(header[4:], uint64(compressed.Len()))
+		b.byteOrder.PutUint64(header[12:], uint64(len(content)))
+		b.byteOrder.PutUint32(header[20:], uint32(b.string("zip")))
+		content = append(header, compressed.Bytes()...)
+		b.attribute(attributeCompressed, uint64(len(content)))
+	}
+	b.locations.WriteByte(attributeEnd)
+	b.content.Write(content)
+}
+
+func (b *imageBuilder) bytes() []byte {
+	var image bytes.Buffer
+	write := func(v uint32) { _ = binary.Write(&image, b.byteOrder, v) }
+	write(Magic)
+	write(1 << 16) // version 1.0
+	write(0)       // flags
+	write(uint32(len(b.offsets)))
+	write(uint32(len(b.offsets)))
+	write(uint32(b.locations.Len()))
+	write(uint32(b.strings.Len()))
+	for range b.offsets {
+		write(0) // redirect
+	}
+	for _, offset := range b.offsets {
+		write(offset)
+	}
+	image.Write(b.locations.Bytes())
+	image.Write(b.strings.Bytes())
+	image.Write(b.content.Bytes())
+	return image.Bytes()
+}
+
+func moduleInfo(name string) []byte {
+	c := classbuild.NewModuleInfo()
+	c.Attributes = append(c.Attributes, c.ModuleAttribute(name, nil, nil, nil, nil))
+	return c.Bytes()
+}
+
+func (suite *JImageSuite) createImage(byteOrder binary.ByteOrder) string {
+	b := newImageBuilder(byteOrder)
+	b.add("app", "com/github/tsatke/jt", "App", "class", suite.app, false)
+	b.add("app", "", "module-info", "class", moduleInfo("app"), false)
+	b.add("other", "", "module-info", "class", moduleInfo("other"), true)
+	b.add("app", "META-INF", "app", "properties", []byte("a=b\n"), false)
+	b.add("other", "com/github/tsatke/jt/other", "Compressed", "class", suite.app, true)
+	b.add("packages", "com.github.tsatke.jt", "app", "", nil, false)
+	b.add("modules", "app/com/github/tsatke", "jt", "", nil, false)
+
+	path := filepath.Join(suite.T().TempDir(), "modules")
+	suite.Require().NoError(os.WriteFile(path, b.bytes(), 0644))
+	return path
+}
+
+func (suite *JImageSuite) TestOpen() {
+	for _, byteOrder := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		image, err := Open(suite.createImage(byteOrder))
+		suite.Require().NoError(err, byteOrder)
+
+		suite.Equal([]string{"app", "other"}, image.Modules())
+		for _, module := range image.Modules() {
+			info, err := image.OpenModuleInfo(module)
+			suite.Require().NoError(err)
+			suite.Equal(module, info.Module().Name)
+		}
+		suite.Equal([]string{
+			"com/github/tsatke/jt/App",
+			"com/github/tsatke/jt/other/Compressed",
+		}, image.ListClasses())
+
+		module, ok := image.ModuleOf("com/github/tsatke/jt/other/Compressed")
+		suite.True(ok)
+		suite.Equal("other", module)
+
+		class, err := image.OpenClass("com/github/tsatke/jt/App")
+		suite.NoError(err)
+		suite.Equal("com/github/tsatke/jt/App", class.Name())
+		suite.Equal("java/lang/Object", class.SuperclassName())
+
+		data, err := image.ReadResource("com/github/tsatke/jt/other/Compressed.class")
+		suite.NoError(err)
+		suite.Equal(suite.app, data)
+
+		data, err = image.ReadResource("META-INF/app.properties")
+		suite.NoError(err)
+		suite.Equal("a=b\n", string(data))
+
+		suite.Equal([]string{"META-INF/app.properties"}, image.ListResources())
+		info, err := image.StatResource("META-INF/app.properties")
+		suite.NoError(err)
+		suite.Equal("app.properties", info.Name())
+		suite.Equal(int64(4), info.Size())
+
+		_, err = image.OpenClass("java/lang/Object")
+		suite.ErrorIs(err, os.ErrNotExist)
+
+		suite.NoError(image.Close())
+	}
+}
+
+func (suite *JImageSuite) TestOpenInvalid() {
+	_, err := Open(filepath.Join("testdata", "classes", "App.class"))
+	suite.Error(err)
+}
@@ -0,0 +1,380 @@
+// Package linkage finds references in compiled classes that fail to link on a classpath, which
+// the JVM reports with errors like NoClassDefFoundError or NoSuchMethodError when the referencing
+// code runs, usually after a dependency was upgraded without recompiling the code that uses it.
+package linkage
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/tsatke/jt/class"
+	"github.com/tsatke/jt/classfile"
+	"github.com/tsatke/jt/classpath"
+)
+
+// the errors that the JVM throws when linking fails
+const (
+	NoClassDefFoundError         = "NoClassDefFoundError"
+	NoSuchFieldError             = "NoSuchFieldError"
+	NoSuchMethodError            = "NoSuchMethodError"
+	IncompatibleClassChangeError = "IncompatibleClassChangeError"
+	AbstractMethodError          = "AbstractMethodError"
+	IllegalAccessError           = "IllegalAccessError"
+)
+
+// Problem is a reference that fails to link.
+type Problem struct {
+	// Class is the class that contains the reference.
+	Class string
+	// Entry is the classpath entry that contains the class.
+	Entry *classpath.Entry
+	// Member is the name and descriptor of the method that contains the reference, or empty
+	// for problems of the class itself, such as a missing superclass.
+	Member string
+	// Line is the source line of the reference, or 0 if it is unknown.
+	Line int
+	// Error is the error that the JVM throws, such as NoSuchMethodError.
+	Error string
+	// Reference is the class, field or method that fails to link, formatted like class.Reference.
+	Reference string
+	// Message explains the problem, if the error doesn't explain it by itself.
+	Message string
+}
+
+// Check calls fn for every problem in the classes of the classpath, except for the entries that
+// skip returns true for, which may be nil. Like the JVM, the first class on the classpath with a
+// name is used to resolve references. Private members are considered accessible from classes
+// with the same outer class, like nestmates of Java 11 and later.
+func Check(cp *classpath.Classpath, skip func(*classpath.Entry) bool, fn func(*Problem)) error {
+	cache, err := classpath.NewCache(100)
+	if err != nil {
+		return fmt.Errorf("create archive cache: %w", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	ch := &checker{
+		cp:       cp,
+		cache:    cache,
+		classes:  make(map[string]*class.Class),
+		resolved: make(map[class.Reference]*resolution),
+	}
+	cp.WalkArchives(skip, func(entry *classpath.Entry, archive classpath.Archive) {
+		for _, name := range archive.ListClasses() {
+			c, err := archive.OpenClass(name)
+			if err != nil {
+				log.Error().
+					Err(err).
+					Str("class", name).
+					Str("entry", entry.Path).
+					Msg("open class")
+				continue
+			}
+			for _, p := range ch.check(c) {
+				p.Entry = entry
+				fn(p)
+			}
+		}
+	})
+	return nil
+}
+
+// checker checks the classes of a classpath, remembering the classes that it opened and the
+// references that it resolved.
+type checker struct {
+	cp       *classpath.Classpath
+	cache    *classpath.Cache
+	classes  map[string]*class.Class
+	resolved map[class.Reference]*resolution
+}
+
+// resolution is the result of resolving a reference to a field or method.
+type resolution struct {
+	declaring *class.Class
+	// missing is the class of the hierarchy that is not on the classpath, if any
+	missing string
+}
+
+// open returns the class with the given name, or nil if it is not on the classpath.
+func (ch *checker) open(name string) *class.Class {
+	c, ok := ch.classes[name]
+	if !ok {
+		var err error
+		if c, err = ch.cp.OpenClassWithCache(name, ch.cache); err != nil {
+			log.Debug().
+				Err(err).
+				Str("class", name).
+				Msg("open class")
+		}
+		ch.classes[name] = c
+	}
+	return c
+}
+
+func (ch *checker) resolve(ref class.Reference) *resolution {
+	r, ok := ch.resolved[ref]
+	if !ok {
+		r = &resolution{}
+		declaring, err := ch.cp.ResolveMember(ref, ch.cache)
+		var notFound *classpath.ClassNotFoundError
+		if errors.As(err, &notFound) {
+			r.missing = notFound.Name
+		} else if err != nil {
+			log.Debug().
+				Err(err).
+				Str("reference", ref.String()).
+				Msg("resolve member")
+		}
+		r.declaring = declaring
+		ch.resolved[ref] = r
+	}
+	return r
+}
+
+// check returns the problems of the class.
+func (ch *checker) check(c *class.Class) []*Problem {
+	var problems []*Problem
+	seen := make(map[Problem]bool)
+	report := func(member string, line int, err, reference, message string) {
+		p := Problem{Class: c.Name(), Member: member, Line: line, Error: err, Reference: reference, Message: message}
+		if !seen[p] {
+			seen[p] = true
+			problems = append(problems, &p)
+		}
+	}
+
+	if super := c.SuperclassName(); super != "" {
+		if s := ch.open(super); s == nil {
+			report("", 0, NoClassDefFoundError, super, "superclass not found")
+		} else if s.IsInterface() {
+			report("", 0, IncompatibleClassChangeError, super, "superclass is an interface")
+		}
+	}
+	for _, name := range c.Interfaces() {
+		if iface := ch.open(name); iface == nil {
+			report("", 0, NoClassDefFoundError, name, "interface not found")
+		} else if !iface.IsInterface() {
+			report("", 0, IncompatibleClassChangeError, name, "implemented interface is a class")
+		}
+	}
+	if !c.IsAbstract() && !c.IsInterface() {
+		for _, p := range ch.unimplemented(c) {
+			report("", 0, p.Error, p.Reference, p.Message)
+		}
+	}
+
+	for _, m := range c.Methods() {
+		references, err := m.CodeReferences()
+		if err != nil {
+			log.Debug().
+				Err(err).
+				Str("class", c.Name()).
+				Msg("decode method")
+			continue
+		}
+		member := m.Name() + m.Descriptor()
+		for _, ref := range references {
+			for _, p := range ch.checkReference(c, ref) {
+				report(member, ref.Line, p.Error, p.Reference, p.Message)
+			}
+		}
+	}
+	return problems
+}
+
+// checkReference returns the problems of a reference in the code of the class.
+func (ch *checker) checkReference(c *class.Class, ref class.CodeReference) []*Problem {
+	name := class.ElementClass(ref.Class)
+	if name == "" {
+		// arrays of primitives have the members of java/lang/Object
+		return nil
+	}
+	owner := c
+	if name != c.Name() {
+		owner = ch.open(name)
+	}
+	if owner == nil {
+		return []*Problem{{Error: NoClassDefFoundError, Reference: name}}
+	}
+	if !owner.IsPublic() && !samePackage(c.Name(), name) {
+		return []*Problem{{Error: IllegalAccessError, Reference: name, Message: "class is not public"}}
+	}
+	if ref.Kind == class.RefClass || strings.HasPrefix(ref.Class, "[") {
+		return nil
+	}
+
+	reference := ref.Reference.String()
+	if ref.Kind == class.RefMethod && owner.IsInterface() {
+		return []*Problem{{Error: IncompatibleClassChangeError, Reference: reference, Message: "method of an interface is referenced as method of a class"}}
+	}
+	if ref.Kind == class.RefInterfaceMethod && !owner.IsInterface() {
+		return []*Problem{{Error: IncompatibleClassChangeError, Reference: reference, Message: "method of a class is referenced as method of an interface"}}
+	}
+
+	r := ch.resolve(ref.Reference)
+	switch {
+	case r.missing != "":
+		return []*Problem{{Error: NoClassDefFoundError, Reference: r.missing}}
+	case r.declaring == nil && ref.Kind == class.RefField:
+		return []*Problem{{Error: NoSuchFieldError, Reference: reference}}
+	case r.declaring == nil:
+		return []*Problem{{Error: NoSuchMethodError, Reference: reference}}
+	}
+
+	var flags class.AccessFlags
+	if ref.Kind == class.RefField {
+		f, _ := r.declaring.Field(ref.Name, ref.Descriptor)
+		flags = f.AccessFlags()
+	} else if m, ok := r.declaring.Method(ref.Name, ref.Descriptor); ok {
+		flags = m.AccessFlags()
+	} else {
+		// signature polymorphic methods are declared with another descriptor
+		return nil
+	}
+	// the declaring class may be a superclass of the referenced class
+	reference = r.declaring.Name() + strings.TrimPrefix(reference, ref.Class)
+
+	static := flags&class.AccStatic != 0
+	switch ref.Opcode {
+	case classfile.OpGetstatic, classfile.OpPutstatic, classfile.OpInvokestatic:
+		if !static {
+			return []*Problem{{Error: IncompatibleClassChangeError, Reference: reference, Message: "expected static member, but it is not static"}}
+		}
+	case classfile.OpGetfield, classfile.OpPutfield, classfile.OpInvokevirtual, classfile.OpInvokeinterface, classfile.OpInvokespecial:
+		if static {
+			return []*Problem{{Error: IncompatibleClassChangeError, Reference: reference, Message: "expected instance member, but it is static"}}
+		}
+	}
+	if ref.Opcode == classfile.OpInvokespecial && flags&class.AccAbstract != 0 {
+		return []*Problem{{Error: AbstractMethodError, Reference: reference, Message: "called method is abstract"}}
+	}
+	if !ch.accessible(c, r.declaring, flags) {
+		return []*Problem{{Error: IllegalAccessError, Reference: reference, Message: fmt.Sprintf("member is %s", visibility(flags))}}
+	}
+	return nil
+}
+
+// accessible returns whether a member with the given flags of the declaring class can be
+// accessed from the class.
+func (ch *checker) accessible(c, declaring *class.Class, flags class.AccessFlags) bool {
+	switch {
+	case flags&class.AccPublic != 0:
+		return true
+	case flags&class.AccPrivate != 0:
+		return outermost(c.Name()) == outermost(declaring.Name())
+	case samePackage(c.Name(), declaring.Name()):
+		return true
+	case flags&class.AccProtected != 0:
+		subclass, err := ch.cp.IsSubtype(c.Name(), declaring.Name(), ch.cache)
+		// superclasses that are missing are reported by their own
+		return subclass || err != nil
+	}
+	return false
+}
+
+// unimplemented returns the abstract methods that the class inherits without an implementation,
+// which throw an AbstractMethodError when they are called, and missing superclasses and
+// superinterfaces that prevent the check.
+func (ch *checker) unimplemented(c *class.Class) []*Problem {
+	var problems []*Problem
+	// the first declaration of a method in the class and its superclasses is selected
+	selected := make(map[string]*class.Class)
+	abstract := make(map[string]bool)
+	var interfaces []string
+	for current := c; current != nil; {
+		for _, m := range current.Methods() {
+			key := m.Name() + m.Descriptor()
+			if _, ok := selected[key]; !ok && !m.IsStatic() && !m.IsPrivate() && m.Name() != "<init>" {
+				selected[key] = current
+				abstract[key] = m.IsAbstract()
+			}
+		}
+		interfaces = append(interfaces, current.Interfaces()...)
+		super := current.SuperclassName()
+		if super == "" {
+			break
+		}
+		if current = ch.open(super); current == nil {
+			problems = append(problems, &Problem{Error: NoClassDefFoundError, Reference: super, Message: "superclass not found"})
+		}
+	}
+
+	// methods that no class declares are selected from the default methods of the interfaces
+	declaredBy := make(map[string]*class.Class)
+	implemented := make(map[string]bool)
+	seen := make(map[string]bool)
+	for len(interfaces) > 0 {
+		name := interfaces[0]
+		interfaces = interfaces[1:]
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		iface := ch.open(name)
+		if iface == nil {
+			problems = append(problems, &Problem{Error: NoClassDefFoundError, Reference: name, Message: "interface not found"})
+			continue
+		}
+		for _, m := range iface.Methods() {
+			key := m.Name() + m.Descriptor()
+			if m.IsStatic() || m.IsPrivate() {
+				continue
+			}
+			if _, ok := declaredBy[key]; !ok {
+				declaredBy[key] = iface
+			}
+			implemented[key] = implemented[key] || !m.IsAbstract()
+		}
+		interfaces = append(interfaces, iface.Interfaces()...)
+	}
+
+	for key, declaring := range selected {
+		if abstract[key] {
+			problems = append(problems, &Problem{Error: AbstractMethodError, Reference: declaring.Name() + "#" + key, Message: "method is not implemented"})
+		}
+	}
+	for key, iface := range declaredBy {
+		if _, ok := selected[key]; !ok && !implemented[key] {
+			problems = append(problems, &Problem{Error: AbstractMethodError, Reference: iface.Name() + "#" + key, Message: "method is not implemented"})
+		}
+	}
+	// the methods are collected in maps
+	sort.Slice(problems, func(i, j int) bool {
+		return problems[i].Reference < problems[j].Reference
+	})
+	return problems
+}
+
+// visibility returns the visibility of a member that is not public.
+func visibility(flags class.AccessFlags) string {
+	switch {
+	case flags&class.AccPrivate != 0:
+		return "private"
+	case flags&class.AccProtected != 0:
+		return "protected"
+	}
+	return "package-private"
+}
+
+func samePackage(a, b string) bool {
+	return packageOf(a) == packageOf(b)
+}
+
+func packageOf(name string) string {
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		return name[:i]
+	}
+	return ""
+}
+
+// outermost returns the outermost class of a nested class, assuming that nested classes are named
+// like Outer$Inner, or the class itself.
+func outermost(name string) string {
+	pkg := packageOf(name)
+	if i := strings.Index(name[len(pkg):], "$"); i >= 0 {
+		return name[:len(pkg)+i]
+	}
+	return name
+}
@@ -0,0 +1,113 @@
+package linkage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"github.com/tsatke/jt/classpath"
+	"github.com/tsatke/jt/internal/classbuild"
+)
+
+func TestLinkageSuite(t *testing.T) {
+	suite.Run(t, new(LinkageSuite))
+}
+
+type LinkageSuite struct {
+	suite.Suite
+
+	cp *classpath.Classpath
+}
+
+func (suite *LinkageSuite) SetupTest() {
+	app, lib := suite.T().TempDir(), suite.T().TempDir()
+	write := func(dir string, c *classbuild.Class) { suite.Require().NoError(c.WriteFile(dir)) }
+
+	object := classbuild.New("java/lang/Object", "")
+	object.Method(classbuild.AccPublic, "<init>", "()V")
+	write(lib, object)
+
+	api := classbuild.New("com/lib/Api", "java/lang/Object")
+	api.Method(classbuild.AccPublic|classbuild.AccStatic, "create", "()Lcom/lib/Api;")
+	api.Method(classbuild.AccPublic, "send", "(Ljava/lang/String;)V")
+	api.Method(classbuild.AccPrivate, "secret", "()V")
+	api.Method(classbuild.AccProtected, "help", "()V")
+	api.Field(classbuild.AccPublic, "count", "I")
+	write(lib, api)
+
+	hidden := classbuild.New("com/lib/Hidden", "java/lang/Object")
+	hidden.AccessFlags = classbuild.AccSuper
+	write(lib, hidden)
+
+	listener := classbuild.New("com/lib/Listener", "java/lang/Object")
+	listener.AccessFlags = classbuild.AccPublic | classbuild.AccInterface | classbuild.AccAbstract
+	listener.Method(classbuild.AccPublic|classbuild.AccAbstract, "onEvent", "()V")
+	listener.Method(classbuild.AccPublic|classbuild.AccAbstract, "onClose", "()V")
+	listener.Method(classbuild.AccPublic, "onOpen", "()V")
+	write(lib, listener)
+
+	b := classbuild.New("com/example/App", "com/lib/Api")
+	b.Interfaces = []string{"com/lib/Listener"}
+	b.Method(classbuild.AccPublic, "onEvent", "()V")
+	b.Method(classbuild.AccPublic, "run", "()V", b.Code(classbuild.Data(
+		byte(0xb8), b.Methodref("com/lib/Api", "create", "()Lcom/lib/Api;"), // 0: invokestatic
+		byte(0xb6), b.Methodref("com/lib/Api", "send", "(Ljava/lang/String;)V"), // 3: invokevirtual
+		byte(0xb6), b.Methodref("com/lib/Api", "send", "(I)V"), // 6: invokevirtual
+		byte(0xb8), b.Methodref("com/lib/Api", "send", "(Ljava/lang/String;)V"), // 9: invokestatic
+		byte(0xb6), b.Methodref("com/lib/Api", "secret", "()V"), // 12: invokevirtual
+		byte(0xb6), b.Methodref("com/example/App", "help", "()V"), // 15: invokevirtual
+		byte(0xb2), b.Fieldref("com/lib/Api", "count", "I"), // 18: getstatic
+		byte(0xb4), b.Fieldref("com/example/App", "missing", "I"), // 21: getfield
+		byte(0xbb), b.Class("com/lib/Hidden"), // 24: new
+		byte(0xbb), b.Class("com/lib/Gone"), // 27: new
+		byte(0xb6), b.Methodref("com/lib/Listener", "onOpen", "()V"), // 30: invokevirtual
+		byte(0xb7), b.Methodref("java/lang/Object", "<init>", "()V"), // 33: invokespecial
+		byte(0xb1), // 36: return
+	),
+		classbuild.LineNumber{Pc: 0, Line: 1},
+		classbuild.LineNumber{Pc: 3, Line: 2},
+		classbuild.LineNumber{Pc: 6, Line: 3},
+		classbuild.LineNumber{Pc: 9, Line: 4},
+		classbuild.LineNumber{Pc: 12, Line: 5},
+		classbuild.LineNumber{Pc: 15, Line: 6},
+		classbuild.LineNumber{Pc: 18, Line: 7},
+		classbuild.LineNumber{Pc: 21, Line: 8},
+		classbuild.LineNumber{Pc: 24, Line: 9},
+		classbuild.LineNumber{Pc: 27, Line: 10},
+		classbuild.LineNumber{Pc: 30, Line: 11},
+		classbuild.LineNumber{Pc: 33, Line: 12},
+	))
+	write(app, b)
+
+	other := classbuild.New("com/example/Other", "java/lang/Object")
+	other.Interfaces = []string{"com/lib/Gone"}
+	write(app, other)
+
+	suite.cp = classpath.NewClasspath()
+	suite.cp.AddEntry(classpath.EntryTypeOutput, app)
+	suite.cp.AddEntry(classpath.EntryTypeOutput, lib)
+}
+
+func (suite *LinkageSuite) TestCheck() {
+	var problems []Problem
+	suite.Require().NoError(Check(suite.cp, func(entry *classpath.Entry) bool {
+		return entry != suite.cp.Entries[0]
+	}, func(p *Problem) {
+		suite.Equal(suite.cp.Entries[0], p.Entry)
+		p.Entry = nil
+		problems = append(problems, *p)
+	}))
+
+	run := "run()V"
+	suite.Equal([]Problem{
+		{Class: "com/example/App", Error: AbstractMethodError, Reference: "com/lib/Listener#onClose()V", Message: "method is not implemented"},
+		{Class: "com/example/App", Member: run, Line: 3, Error: NoSuchMethodError, Reference: "com/lib/Api#send(I)V"},
+		{Class: "com/example/App", Member: run, Line: 4, Error: IncompatibleClassChangeError, Reference: "com/lib/Api#send(Ljava/lang/String;)V", Message: "expected static member, but it is not static"},
+		{Class: "com/example/App", Member: run, Line: 5, Error: IllegalAccessError, Reference: "com/lib/Api#secret()V", Message: "member is private"},
+		{Class: "com/example/App", Member: run, Line: 7, Error: IncompatibleClassChangeError, Reference: "com/lib/Api#count:I", Message: "expected static member, but it is not static"},
+		{Class: "com/example/App", Member: run, Line: 8, Error: NoSuchFieldError, Reference: "com/example/App#missing:I"},
+		{Class: "com/example/App", Member: run, Line: 9, Error: IllegalAccessError, Reference: "com/lib/Hidden", Message: "class is not public"},
+		{Class: "com/example/App", Member: run, Line: 10, Error: NoClassDefFoundError, Reference: "com/lib/Gone"},
+		{Class: "com/example/App", Member: run, Line: 11, Error: IncompatibleClassChangeError, Reference: "com/lib/Listener#onOpen()V", Message: "method of an interface is referenced as method of a class"},
+		{Class: "com/example/Other", Error: NoClassDefFoundError, Reference: "com/lib/Gone", Message: "interface not found"},
+	}, problems)
+}
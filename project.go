@@ -3,12 +3,25 @@ package jt
 import (
 	"github.com/tsatke/jt/classpath"
 	"github.com/tsatke/jt/internal/eclipse"
+	"github.com/tsatke/jt/internal/intellij"
 	"github.com/tsatke/jt/internal/maven"
+	"github.com/tsatke/jt/jdk"
 )
 
 type Project interface {
 	Name() string
-	Classpath() (*classpath.Classpath, error)
+	// Classpath returns the classpath of the project in the given scope.
+	// The classpath contains the source and output folders of the project
+	// as well as all dependencies that are visible in the scope.
+	Classpath(scope classpath.Scope) (*classpath.Classpath, error)
+	// Sources returns the source folders of the project that are visible
+	// in the given scope. Computing the sources is cheap compared to
+	// computing the classpath.
+	Sources(scope classpath.Scope) ([]*classpath.Entry, error)
+	// JDK returns the JDK that the project is configured to use, or the
+	// installed JDK that fits the project's configuration best. Returns nil
+	// if no JDK is installed.
+	JDK() *jdk.JDK
 }
 
 func LoadProject(path string) (Project, error) {
@@ -17,6 +30,8 @@ func LoadProject(path string) (Project, error) {
 		return maven.LoadProject(path)
 	case eclipse.IsEclipseProject(path):
 		return eclipse.LoadProject(path)
+	case intellij.IsIntellijProject(path):
+		return intellij.LoadProject(path)
 	}
 	return nil, ErrUnknownProjectKind
 }
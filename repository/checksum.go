@@ -0,0 +1,170 @@
+// Package repository checks the integrity of artifacts in a local Maven repository.
+package repository
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tsatke/jt/internal/properties"
+)
+
+// RemoteRepositoriesFile is the file in which Maven records the repositories
+// that the artifacts in a directory of the local repository were downloaded from.
+const RemoteRepositoriesFile = "_remote.repositories"
+
+// algorithms are the checksum algorithms by the extension of their checksum files,
+// in the order of their strength.
+var algorithms = []struct {
+	ext string
+	new func() hash.Hash
+}{
+	{".sha512", sha512.New},
+	{".sha256", sha256.New},
+	{".sha1", sha1.New},
+	{".md5", md5.New},
+}
+
+// Checksum is the result of comparing a file to one of its checksum files.
+type Checksum struct {
+	// Algorithm is the name of the algorithm, such as sha1.
+	Algorithm string
+	Expected  string
+	Actual    string
+}
+
+// OK returns whether the file matches the checksum.
+func (c Checksum) OK() bool {
+	return strings.EqualFold(c.Expected, c.Actual)
+}
+
+// Result is the result of verifying an artifact in a local repository.
+type Result struct {
+	Path string
+	// Checksums holds the comparisons with all checksum files next to the artifact.
+	Checksums []Checksum
+	// Repositories holds the ids of the remote repositories that the artifact was
+	// downloaded from, such as central.
+	Repositories []string
+	// Installed is true if the artifact was installed into the local repository,
+	// for example with mvn install, instead of being downloaded.
+	Installed bool
+}
+
+// Mismatches returns the checksums that don't match the artifact.
+func (r *Result) Mismatches() []Checksum {
+	var mismatches []Checksum
+	for _, c := range r.Checksums {
+		if !c.OK() {
+			mismatches = append(mismatches, c)
+		}
+	}
+	return mismatches
+}
+
+// Verify compares the artifact with the given path to the checksum files next to it, such
+// as foo-1.0.jar.sha1, and looks up where it came from in the _remote.repositories file of
+// its directory.
+func Verify(path string) (*Result, error) {
+	result := &Result{Path: path}
+
+	var hashes []hash.Hash
+	var writers []io.Writer
+	for _, alg := range algorithms {
+		data, err := os.ReadFile(path + alg.ext)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read checksum: %w", err)
+		}
+		h := alg.new()
+		hashes = append(hashes, h)
+		writers = append(writers, h)
+		result.Checksums = append(result.Checksums, Checksum{
+			Algorithm: strings.TrimPrefix(alg.ext, "."),
+			Expected:  parseChecksum(string(data), h.Size()),
+		})
+	}
+
+	if len(hashes) > 0 {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("open: %w", err)
+		}
+		_, err = io.Copy(io.MultiWriter(writers...), f)
+		_ = f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("read: %w", err)
+		}
+		for i, h := range hashes {
+			result.Checksums[i].Actual = hex.EncodeToString(h.Sum(nil))
+		}
+	}
+
+	repositories, err := remoteRepositories(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, repository := range repositories {
+		if repository == "" {
+			result.Installed = true
+		} else {
+			result.Repositories = append(result.Repositories, repository)
+		}
+	}
+	return result, nil
+}
+
+// parseChecksum extracts the checksum from the content of a checksum file. Besides the plain
+// checksum, some tools write the name of the file as well, like "<checksum>  foo-1.0.jar" or
+// "MD5 (foo-1.0.jar) = <checksum>".
+func parseChecksum(content string, size int) string {
+	for _, field := range strings.Fields(content) {
+		if len(field) != size*2 {
+			continue
+		}
+		if _, err := hex.DecodeString(field); err == nil {
+			return strings.ToLower(field)
+		}
+	}
+	return strings.TrimSpace(content)
+}
+
+// remoteRepositories returns the ids of the repositories that the artifact with the given path
+// was obtained from according to the _remote.repositories file. An empty id means that the
+// artifact was installed locally. If the file doesn't exist, nil is returned.
+func remoteRepositories(path string) ([]string, error) {
+	f, err := os.Open(filepath.Join(filepath.Dir(path), RemoteRepositoriesFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", RemoteRepositoriesFile, err)
+	}
+	defer func() { _ = f.Close() }()
+
+	props, err := properties.Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", RemoteRepositoriesFile, err)
+	}
+	// entries look like foo-1.0.jar>central=
+	prefix := filepath.Base(path) + ">"
+	var repositories []string
+	for _, key := range props.Keys {
+		if strings.HasPrefix(key, prefix) {
+			repositories = append(repositories, key[len(prefix):])
+		}
+	}
+	return repositories, nil
+}
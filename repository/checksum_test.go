@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+func TestChecksumSuite(t *testing.T) {
+	suite.Run(t, new(ChecksumSuite))
+}
+
+type ChecksumSuite struct {
+	suite.Suite
+
+	dir string
+}
+
+func (suite *ChecksumSuite) SetupTest() {
+	suite.dir = suite.T().TempDir()
+}
+
+func (suite *ChecksumSuite) write(name, content string) string {
+	path := filepath.Join(suite.dir, name)
+	suite.Require().NoError(os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func (suite *ChecksumSuite) TestVerify() {
+	// checksums of "hello\n"
+	jar := suite.write("foo-1.0.jar", "hello\n")
+	suite.write("foo-1.0.jar.sha1", "f572d396fae9206628714fb2ce00f72e94f2258f")
+	suite.write("foo-1.0.jar.md5", "MD5 (foo-1.0.jar) = b1946ac92492d2347c6235b4d2611184\n")
+	suite.write("foo-1.0.jar.sha256", "0000000000000000000000000000000000000000000000000000000000000000  foo-1.0.jar\n")
+	suite.write(RemoteRepositoriesFile, `#NOTE: This is a Maven Resolver internal implementation file, its format can be changed without prior notice.
+#Tue Mar 01 12:00:00 CET 2022
+foo-1.0.jar>central=
+foo-1.0.pom>central=
+bar-1.0.jar>=
+`)
+
+	result, err := Verify(jar)
+	suite.Require().NoError(err)
+	suite.Equal([]Checksum{
+		{Algorithm: "sha256", Expected: "0000000000000000000000000000000000000000000000000000000000000000", Actual: "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03"},
+		{Algorithm: "sha1", Expected: "f572d396fae9206628714fb2ce00f72e94f2258f", Actual: "f572d396fae9206628714fb2ce00f72e94f2258f"},
+		{Algorithm: "md5", Expected: "b1946ac92492d2347c6235b4d2611184", Actual: "b1946ac92492d2347c6235b4d2611184"},
+	}, result.Checksums)
+	suite.Equal([]Checksum{result.Checksums[0]}, result.Mismatches())
+	suite.Equal([]string{"central"}, result.Repositories)
+	suite.False(result.Installed)
+}
+
+func (suite *ChecksumSuite) TestVerifyInstalled() {
+	jar := suite.write("bar-1.0.jar", "hello\n")
+	suite.write(RemoteRepositoriesFile, "bar-1.0.jar>=\n")
+
+	result, err := Verify(jar)
+	suite.Require().NoError(err)
+	suite.Empty(result.Checksums)
+	suite.Empty(result.Repositories)
+	suite.True(result.Installed)
+}
+
+func (suite *ChecksumSuite) TestVerifyWithoutMetadata() {
+	jar := suite.write("baz-1.0.jar", "hello\n")
+
+	result, err := Verify(jar)
+	suite.Require().NoError(err)
+	suite.Empty(result.Checksums)
+	suite.Empty(result.Repositories)
+	suite.False(result.Installed)
+}
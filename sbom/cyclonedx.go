@@ -0,0 +1,138 @@
+package sbom
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/tsatke/jt/jar"
+)
+
+// cycloneDXSpecVersion is the version of the CycloneDX specification that documents conform to,
+// see https://cyclonedx.org/docs/1.5/json/.
+const cycloneDXSpecVersion = "1.5"
+
+type cdxBOM struct {
+	BOMFormat    string         `json:"bomFormat"`
+	SpecVersion  string         `json:"specVersion"`
+	SerialNumber string         `json:"serialNumber"`
+	Version      int            `json:"version"`
+	Metadata     cdxMetadata    `json:"metadata"`
+	Components   []cdxComponent `json:"components"`
+}
+
+type cdxMetadata struct {
+	Timestamp string        `json:"timestamp"`
+	Tools     cdxTools      `json:"tools"`
+	Component *cdxComponent `json:"component,omitempty"`
+}
+
+type cdxTools struct {
+	Components []cdxComponent `json:"components"`
+}
+
+type cdxComponent struct {
+	Type       string         `json:"type"`
+	BOMRef     string         `json:"bom-ref,omitempty"`
+	Group      string         `json:"group,omitempty"`
+	Name       string         `json:"name"`
+	Version    string         `json:"version,omitempty"`
+	Hashes     []cdxHash      `json:"hashes,omitempty"`
+	Licenses   []cdxLicense   `json:"licenses,omitempty"`
+	PURL       string         `json:"purl,omitempty"`
+	Properties []cdxProperty  `json:"properties,omitempty"`
+	Components []cdxComponent `json:"components,omitempty"`
+}
+
+type cdxHash struct {
+	Alg     string `json:"alg"`
+	Content string `json:"content"`
+}
+
+type cdxLicense struct {
+	License    *cdxLicenseChoice `json:"license,omitempty"`
+	Expression string            `json:"expression,omitempty"`
+}
+
+type cdxLicenseChoice struct {
+	ID   string `json:"id,omitempty"`
+	Name string `json:"name,omitempty"`
+	URL  string `json:"url,omitempty"`
+}
+
+type cdxProperty struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// WriteCycloneDX writes the document as a CycloneDX JSON document. Nested jars are
+// written as components of the jar that they are nested in.
+func (d *Document) WriteCycloneDX(w io.Writer) error {
+	bom := cdxBOM{
+		BOMFormat:    "CycloneDX",
+		SpecVersion:  cycloneDXSpecVersion,
+		SerialNumber: "urn:uuid:" + d.Serial,
+		Version:      1,
+		Metadata: cdxMetadata{
+			Timestamp: d.Created.UTC().Format(time.RFC3339),
+			Tools: cdxTools{Components: []cdxComponent{
+				{Type: "application", Name: "jt"},
+			}},
+			Component: &cdxComponent{Type: "application", Name: d.Name},
+		},
+		Components: cdxComponents(d.Components),
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(bom)
+}
+
+func cdxComponents(components []*Component) []cdxComponent {
+	result := make([]cdxComponent, 0, len(components))
+	for _, c := range components {
+		component := cdxComponent{
+			Type:       "library",
+			BOMRef:     c.Path,
+			Name:       c.Name(),
+			Hashes:     []cdxHash{{Alg: "SHA-256", Content: c.SHA256}},
+			PURL:       c.PackageURL(),
+			Properties: []cdxProperty{{Name: "jt:path", Value: c.Path}},
+			Components: cdxComponents(c.Components),
+		}
+		if c.Artifact != nil {
+			component.Group = c.Artifact.GroupID
+			component.Version = c.Artifact.Version
+		}
+		if len(component.Components) == 0 {
+			component.Components = nil
+		}
+		component.Licenses = cdxLicenses(c.Licenses)
+		result = append(result, component)
+	}
+	return result
+}
+
+// cdxLicenses converts the licenses of a component. SPDX expressions can't be combined with
+// other licenses, so if there is one, all licenses are written as a single expression.
+func cdxLicenses(licenses []jar.License) []cdxLicense {
+	if expression, ok := licenseExpression(licenses); ok && isExpression(expression) {
+		return []cdxLicense{{Expression: expression}}
+	}
+
+	var result []cdxLicense
+	for _, l := range licenses {
+		choice := &cdxLicenseChoice{URL: l.URL}
+		if id := LicenseID(l); id != "" && !isExpression(id) {
+			choice.ID = id
+		} else if id != "" {
+			choice.Name = id
+		} else if l.Name != "" {
+			choice.Name = l.Name
+		} else {
+			choice.Name = l.URL
+		}
+		result = append(result, cdxLicense{License: choice})
+	}
+	return result
+}
@@ -0,0 +1,111 @@
+package sbom
+
+import (
+	"strings"
+
+	"github.com/tsatke/jt/jar"
+)
+
+// licenseIDs maps normalized license names and URLs, as found in pom.xml files,
+// to SPDX license identifiers.
+var licenseIDs = map[string]string{
+	"apache license, version 2.0": "Apache-2.0",
+	"apache license version 2.0":  "Apache-2.0",
+	"apache license 2.0":          "Apache-2.0",
+	"apache 2.0":                  "Apache-2.0",
+	"apache 2":                    "Apache-2.0",
+	"apache-2.0":                  "Apache-2.0",
+	"the apache software license, version 2.0": "Apache-2.0",
+	"the apache license, version 2.0":          "Apache-2.0",
+	"apache software license - version 2.0":    "Apache-2.0",
+	"www.apache.org/licenses/license-2.0":      "Apache-2.0",
+	"www.apache.org/licenses/license-2.0.txt":  "Apache-2.0",
+	"www.apache.org/licenses/license-2.0.html": "Apache-2.0",
+	"mit":                         "MIT",
+	"mit license":                 "MIT",
+	"the mit license":             "MIT",
+	"opensource.org/licenses/mit": "MIT",
+	"opensource.org/licenses/mit-license.php":        "MIT",
+	"eclipse public license 1.0":                     "EPL-1.0",
+	"eclipse public license - v 1.0":                 "EPL-1.0",
+	"www.eclipse.org/legal/epl-v10.html":             "EPL-1.0",
+	"eclipse public license 2.0":                     "EPL-2.0",
+	"eclipse public license - v 2.0":                 "EPL-2.0",
+	"epl 2.0":                                        "EPL-2.0",
+	"www.eclipse.org/legal/epl-2.0":                  "EPL-2.0",
+	"www.eclipse.org/legal/epl-v20.html":             "EPL-2.0",
+	"eclipse distribution license - v 1.0":           "BSD-3-Clause",
+	"bsd-2-clause":                                   "BSD-2-Clause",
+	"bsd-3-clause":                                   "BSD-3-Clause",
+	"new bsd license":                                "BSD-3-Clause",
+	"the bsd 3-clause license":                       "BSD-3-Clause",
+	"opensource.org/licenses/bsd-3-clause":           "BSD-3-Clause",
+	"gnu lesser general public license, version 2.1": "LGPL-2.1-only",
+	"lgpl 2.1": "LGPL-2.1-only",
+	"www.gnu.org/licenses/old-licenses/lgpl-2.1.html": "LGPL-2.1-only",
+	"gpl2 w/ cpe":                           "GPL-2.0-only WITH Classpath-exception-2.0",
+	"cddl + gplv2 with classpath exception": "CDDL-1.0 OR GPL-2.0-only WITH Classpath-exception-2.0",
+	"cc0":                                   "CC0-1.0",
+	"creativecommons.org/publicdomain/zero/1.0/": "CC0-1.0",
+	"mozilla public license, version 2.0":        "MPL-2.0",
+	"mpl 2.0":                                    "MPL-2.0",
+	"www.mozilla.org/mpl/2.0/":                   "MPL-2.0",
+}
+
+// LicenseID returns the SPDX identifier or expression of the given license,
+// or the empty string if it is not known.
+func LicenseID(l jar.License) string {
+	if l.File != "" {
+		// licenses from license files are detected by their SPDX identifier
+		return l.Name
+	}
+	if id, ok := licenseIDs[normalizeLicense(l.Name)]; ok {
+		return id
+	}
+	if id, ok := licenseIDs[normalizeLicense(l.URL)]; ok {
+		return id
+	}
+	return ""
+}
+
+func normalizeLicense(s string) string {
+	s = strings.ToLower(strings.Join(strings.Fields(s), " "))
+	s = strings.TrimPrefix(s, "https://")
+	s = strings.TrimPrefix(s, "http://")
+	return s
+}
+
+// licenseExpression combines the given licenses into a single SPDX expression. Like the
+// licenses of a Maven pom, they are alternatives, so that the expression allows any of them.
+// If a license is not known, false is returned.
+func licenseExpression(licenses []jar.License) (string, bool) {
+	if len(licenses) == 0 {
+		return "", false
+	}
+	ids := make([]string, 0, len(licenses))
+	seen := make(map[string]struct{})
+	for _, l := range licenses {
+		id := LicenseID(l)
+		if id == "" {
+			return "", false
+		}
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		ids = append(ids, id)
+	}
+	if len(ids) > 1 {
+		for i, id := range ids {
+			if isExpression(id) {
+				ids[i] = "(" + id + ")"
+			}
+		}
+	}
+	return strings.Join(ids, " OR "), true
+}
+
+// isExpression returns whether the given SPDX identifier is a compound expression.
+func isExpression(id string) bool {
+	return strings.Contains(id, " ")
+}
@@ -0,0 +1,158 @@
+// Package sbom creates software bills of materials of the jars on a classpath
+// in the CycloneDX and SPDX formats.
+package sbom
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/tsatke/jt/classpath"
+	"github.com/tsatke/jt/jar"
+)
+
+// Component is a jar on the classpath.
+type Component struct {
+	// Path is the path of the jar, which addresses nested jars like app.jar!/BOOT-INF/lib/foo.jar.
+	Path     string
+	Artifact *jar.Artifact
+	// SHA256 is the hex encoded SHA-256 checksum of the jar.
+	SHA256   string
+	Licenses []jar.License
+	// Components holds the jars that are nested in this jar, such as the libraries
+	// of a Spring Boot jar.
+	Components []*Component
+}
+
+// Name returns the artifact id of the component, or the file name of the jar
+// if it could not be identified.
+func (c *Component) Name() string {
+	if c.Artifact != nil {
+		return c.Artifact.ArtifactID
+	}
+	name := c.Path
+	if i := strings.LastIndex(name, jar.NestedSeparator); i >= 0 {
+		name = name[i+len(jar.NestedSeparator):]
+	}
+	return path.Base(filepath.ToSlash(name))
+}
+
+// PackageURL returns the package URL of the component, such as pkg:maven/junit/junit@4.13.2,
+// or the empty string if the component has no group id or could not be identified.
+func (c *Component) PackageURL() string {
+	if c.Artifact == nil || c.Artifact.GroupID == "" {
+		return ""
+	}
+	return fmt.Sprintf("pkg:maven/%s/%s@%s", c.Artifact.GroupID, c.Artifact.ArtifactID, c.Artifact.Version)
+}
+
+// Document is a bill of materials.
+type Document struct {
+	// Name is the name of the project that the document describes.
+	Name string
+	// Serial is a UUID that identifies the document.
+	Serial     string
+	Created    time.Time
+	Components []*Component
+}
+
+// New creates a document with the given components, a random serial number
+// and the current time.
+func New(name string, components []*Component) (*Document, error) {
+	serial, err := newUUID()
+	if err != nil {
+		return nil, err
+	}
+	return &Document{
+		Name:       name,
+		Serial:     serial,
+		Created:    time.Now().UTC().Truncate(time.Second),
+		Components: components,
+	}, nil
+}
+
+// Collect creates components for all jars on the classpath, including nested jars.
+// Folders, as well as jmod files and runtime images of the JDK, are not included.
+// Jars that can't be read, or whose pom or manifest is malformed, are logged and left out.
+func Collect(cp *classpath.Classpath) []*Component {
+	var components []*Component
+	seen := make(map[string]struct{})
+	for _, entry := range cp.Entries {
+		if entry.Type != classpath.EntryTypeJar {
+			continue
+		}
+		if _, ok := seen[entry.Path]; ok {
+			continue
+		}
+		seen[entry.Path] = struct{}{}
+
+		c, err := collect(entry.Path)
+		if err != nil {
+			log.Error().
+				Err(err).
+				Str("jar", entry.Path).
+				Msg("skip component")
+			continue
+		}
+		components = append(components, c)
+	}
+	return components
+}
+
+func collect(name string) (*Component, error) {
+	f, err := jar.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f.Content()); err != nil {
+		return nil, fmt.Errorf("hash: %w", err)
+	}
+	artifact, err := f.Identify(name)
+	if err != nil {
+		return nil, fmt.Errorf("identify: %w", err)
+	}
+	licenses, err := f.Licenses()
+	if err != nil {
+		return nil, fmt.Errorf("licenses: %w", err)
+	}
+
+	c := &Component{
+		Path:     name,
+		Artifact: artifact,
+		SHA256:   hex.EncodeToString(h.Sum(nil)),
+		Licenses: licenses,
+	}
+	for _, nested := range f.NestedArchives() {
+		nestedComponent, err := collect(name + jar.NestedSeparator + nested)
+		if err != nil {
+			log.Error().
+				Err(err).
+				Str("jar", name+jar.NestedSeparator+nested).
+				Msg("skip component")
+			continue
+		}
+		c.Components = append(c.Components, nestedComponent)
+	}
+	return c, nil
+}
+
+// newUUID creates a random (version 4) UUID.
+func newUUID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", fmt.Errorf("create uuid: %w", err)
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
@@ -0,0 +1,154 @@
+package sbom
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"github.com/tsatke/jt/classpath"
+	"github.com/tsatke/jt/jar"
+)
+
+func TestSBOMSuite(t *testing.T) {
+	suite.Run(t, new(SBOMSuite))
+}
+
+type SBOMSuite struct {
+	suite.Suite
+
+	document *Document
+}
+
+func (suite *SBOMSuite) SetupTest() {
+	jars := filepath.Join("..", "jar", "testdata", "jars")
+	cp := classpath.NewClasspath()
+	cp.AddEntry(classpath.EntryTypeOutput, filepath.Join("testdata", "classes"))
+	cp.AddEntry(classpath.EntryTypeJar, filepath.Join(jars, "licensed-1.0.jar"))
+	cp.AddEntry(classpath.EntryTypeJar, filepath.Join(jars, "boot.jar"))
+	cp.AddEntry(classpath.EntryTypeJar, filepath.Join(jars, "boot.jar"))
+	// jars that can't be read are left out
+	broken := filepath.Join(suite.T().TempDir(), "broken.jar")
+	suite.Require().NoError(os.WriteFile(broken, []byte("not a jar"), 0644))
+	cp.AddEntry(classpath.EntryTypeJar, broken)
+
+	components := Collect(cp)
+	suite.document = &Document{
+		Name:       "my-app",
+		Serial:     "3e671687-395b-41f5-a30f-a58921a69b79",
+		Created:    time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC),
+		Components: components,
+	}
+}
+
+func (suite *SBOMSuite) TestCollect() {
+	components := suite.document.Components
+	suite.Require().Len(components, 2)
+
+	licensed := components[0]
+	suite.Equal("licensed", licensed.Name())
+	suite.Equal("pkg:maven/com.example/licensed@1.0", licensed.PackageURL())
+	data, err := os.ReadFile(licensed.Path)
+	suite.Require().NoError(err)
+	sum := sha256.Sum256(data)
+	suite.Equal(hex.EncodeToString(sum[:]), licensed.SHA256)
+	suite.Len(licensed.Licenses, 4)
+	suite.Empty(licensed.Components)
+
+	boot := components[1]
+	suite.Equal("boot.jar", boot.Name())
+	suite.Nil(boot.Artifact)
+	suite.Equal("", boot.PackageURL())
+	suite.Require().Len(boot.Components, 1)
+	nested := boot.Components[0]
+	suite.Equal(boot.Path+"!/BOOT-INF/lib/test1.jar", nested.Path)
+	suite.Equal("pkg:maven/com.github.tsatke.jt/test1@1.0-SNAPSHOT", nested.PackageURL())
+	data, err = os.ReadFile(filepath.Join("..", "jar", "testdata", "jars", "test1.jar"))
+	suite.Require().NoError(err)
+	sum = sha256.Sum256(data)
+	suite.Equal(hex.EncodeToString(sum[:]), nested.SHA256)
+}
+
+func (suite *SBOMSuite) TestCycloneDX() {
+	var buf bytes.Buffer
+	suite.Require().NoError(suite.document.WriteCycloneDX(&buf))
+
+	var bom cdxBOM
+	suite.Require().NoError(json.Unmarshal(buf.Bytes(), &bom))
+	suite.Equal("CycloneDX", bom.BOMFormat)
+	suite.Equal("urn:uuid:3e671687-395b-41f5-a30f-a58921a69b79", bom.SerialNumber)
+	suite.Equal("2022-03-01T12:00:00Z", bom.Metadata.Timestamp)
+	suite.Equal("my-app", bom.Metadata.Component.Name)
+	suite.Require().Len(bom.Components, 2)
+
+	licensed := bom.Components[0]
+	suite.Equal("com.example", licensed.Group)
+	suite.Equal("licensed", licensed.Name)
+	suite.Equal("1.0", licensed.Version)
+	suite.Equal("SHA-256", licensed.Hashes[0].Alg)
+	suite.Equal([]cdxLicense{
+		{License: &cdxLicenseChoice{ID: "Apache-2.0", URL: "https://www.apache.org/licenses/LICENSE-2.0.txt"}},
+		{License: &cdxLicenseChoice{Name: "Example Custom License"}},
+		{License: &cdxLicenseChoice{ID: "MIT", URL: "https://opensource.org/licenses/MIT"}},
+		{License: &cdxLicenseChoice{ID: "MIT"}},
+	}, licensed.Licenses)
+
+	boot := bom.Components[1]
+	suite.Equal("boot.jar", boot.Name)
+	suite.Require().Len(boot.Components, 1)
+	suite.Equal("test1", boot.Components[0].Name)
+}
+
+func (suite *SBOMSuite) TestSPDX() {
+	var buf bytes.Buffer
+	suite.Require().NoError(suite.document.WriteSPDX(&buf))
+
+	var doc spdxDocument
+	suite.Require().NoError(json.Unmarshal(buf.Bytes(), &doc))
+	suite.Equal("SPDX-2.3", doc.SPDXVersion)
+	suite.Equal("https://spdx.org/spdxdocs/jt/my-app-3e671687-395b-41f5-a30f-a58921a69b79", doc.DocumentNamespace)
+	suite.Equal("2022-03-01T12:00:00Z", doc.CreationInfo.Created)
+
+	var names []string
+	for _, p := range doc.Packages {
+		names = append(names, p.SPDXID)
+	}
+	suite.Equal([]string{
+		"SPDXRef-Project",
+		"SPDXRef-Package-1-licensed",
+		"SPDXRef-Package-2-boot.jar",
+		"SPDXRef-Package-3-test1",
+	}, names)
+	// the custom license has no SPDX identifier
+	suite.Equal(spdxNoAssertion, doc.Packages[1].LicenseDeclared)
+	suite.Equal("pkg:maven/com.example/licensed@1.0", doc.Packages[1].ExternalRefs[0].ReferenceLocator)
+
+	suite.Equal([]spdxRelationship{
+		{SPDXElementID: "SPDXRef-DOCUMENT", RelationshipType: "DESCRIBES", RelatedSPDXElement: "SPDXRef-Project"},
+		{SPDXElementID: "SPDXRef-Project", RelationshipType: "DEPENDS_ON", RelatedSPDXElement: "SPDXRef-Package-1-licensed"},
+		{SPDXElementID: "SPDXRef-Project", RelationshipType: "DEPENDS_ON", RelatedSPDXElement: "SPDXRef-Package-2-boot.jar"},
+		{SPDXElementID: "SPDXRef-Package-2-boot.jar", RelationshipType: "CONTAINS", RelatedSPDXElement: "SPDXRef-Package-3-test1"},
+	}, doc.Relationships)
+}
+
+func (suite *SBOMSuite) TestLicenseExpression() {
+	for expected, licenses := range map[string][]string{
+		"Apache-2.0":        {"Apache License, Version 2.0", "The Apache Software License, Version 2.0"},
+		"Apache-2.0 OR MIT": {"Apache License, Version 2.0", "MIT License"},
+		"(CDDL-1.0 OR GPL-2.0-only WITH Classpath-exception-2.0) OR MIT": {"CDDL + GPLv2 with classpath exception", "MIT"},
+		"": {"Apache 2.0", "Unknown"},
+	} {
+		var ls []jar.License
+		for _, name := range licenses {
+			ls = append(ls, jar.License{Name: name})
+		}
+		expression, ok := licenseExpression(ls)
+		suite.Equal(expected != "", ok)
+		suite.Equal(expected, expression)
+	}
+}
@@ -0,0 +1,148 @@
+package sbom
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"time"
+)
+
+// spdxVersion is the version of the SPDX specification that documents conform to,
+// see https://spdx.github.io/spdx-spec/v2.3/.
+const spdxVersion = "SPDX-2.3"
+
+const (
+	spdxDocumentID  = "SPDXRef-DOCUMENT"
+	spdxProjectID   = "SPDXRef-Project"
+	spdxNoAssertion = "NOASSERTION"
+)
+
+type spdxDocument struct {
+	SPDXVersion       string             `json:"spdxVersion"`
+	DataLicense       string             `json:"dataLicense"`
+	SPDXID            string             `json:"SPDXID"`
+	Name              string             `json:"name"`
+	DocumentNamespace string             `json:"documentNamespace"`
+	CreationInfo      spdxCreationInfo   `json:"creationInfo"`
+	Packages          []spdxPackage      `json:"packages"`
+	Relationships     []spdxRelationship `json:"relationships"`
+}
+
+type spdxCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+type spdxPackage struct {
+	Name             string            `json:"name"`
+	SPDXID           string            `json:"SPDXID"`
+	VersionInfo      string            `json:"versionInfo,omitempty"`
+	PackageFileName  string            `json:"packageFileName,omitempty"`
+	DownloadLocation string            `json:"downloadLocation"`
+	FilesAnalyzed    bool              `json:"filesAnalyzed"`
+	Checksums        []spdxChecksum    `json:"checksums,omitempty"`
+	LicenseConcluded string            `json:"licenseConcluded"`
+	LicenseDeclared  string            `json:"licenseDeclared"`
+	CopyrightText    string            `json:"copyrightText"`
+	ExternalRefs     []spdxExternalRef `json:"externalRefs,omitempty"`
+}
+
+type spdxChecksum struct {
+	Algorithm     string `json:"algorithm"`
+	ChecksumValue string `json:"checksumValue"`
+}
+
+type spdxExternalRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+}
+
+type spdxRelationship struct {
+	SPDXElementID      string `json:"spdxElementId"`
+	RelationshipType   string `json:"relationshipType"`
+	RelatedSPDXElement string `json:"relatedSpdxElement"`
+}
+
+// WriteSPDX writes the document as an SPDX JSON document. The project is described by the
+// document and depends on the jars on its classpath, which contain the jars nested in them.
+// Licenses that can't be expressed with SPDX identifiers are declared as NOASSERTION.
+func (d *Document) WriteSPDX(w io.Writer) error {
+	doc := spdxDocument{
+		SPDXVersion:       spdxVersion,
+		DataLicense:       "CC0-1.0",
+		SPDXID:            spdxDocumentID,
+		Name:              d.Name,
+		DocumentNamespace: fmt.Sprintf("https://spdx.org/spdxdocs/jt/%s-%s", url.PathEscape(d.Name), d.Serial),
+		CreationInfo: spdxCreationInfo{
+			Created:  d.Created.UTC().Format(time.RFC3339),
+			Creators: []string{"Tool: jt"},
+		},
+		Packages: []spdxPackage{{
+			Name:             d.Name,
+			SPDXID:           spdxProjectID,
+			DownloadLocation: spdxNoAssertion,
+			LicenseConcluded: spdxNoAssertion,
+			LicenseDeclared:  spdxNoAssertion,
+			CopyrightText:    spdxNoAssertion,
+		}},
+		Relationships: []spdxRelationship{{
+			SPDXElementID:      spdxDocumentID,
+			RelationshipType:   "DESCRIBES",
+			RelatedSPDXElement: spdxProjectID,
+		}},
+	}
+
+	counter := 0
+	var add func(parent, relationship string, components []*Component)
+	add = func(parent, relationship string, components []*Component) {
+		for _, c := range components {
+			counter++
+			id := fmt.Sprintf("SPDXRef-Package-%d-%s", counter, spdxIDChars.ReplaceAllString(c.Name(), "-"))
+			doc.Packages = append(doc.Packages, newSPDXPackage(c, id))
+			doc.Relationships = append(doc.Relationships, spdxRelationship{
+				SPDXElementID:      parent,
+				RelationshipType:   relationship,
+				RelatedSPDXElement: id,
+			})
+			add(id, "CONTAINS", c.Components)
+		}
+	}
+	add(spdxProjectID, "DEPENDS_ON", d.Components)
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+// spdxIDChars matches the characters that are not allowed in SPDX identifiers.
+var spdxIDChars = regexp.MustCompile(`[^a-zA-Z0-9.-]+`)
+
+func newSPDXPackage(c *Component, id string) spdxPackage {
+	p := spdxPackage{
+		Name:             c.Name(),
+		SPDXID:           id,
+		PackageFileName:  c.Path,
+		DownloadLocation: spdxNoAssertion,
+		Checksums:        []spdxChecksum{{Algorithm: "SHA256", ChecksumValue: c.SHA256}},
+		LicenseConcluded: spdxNoAssertion,
+		LicenseDeclared:  spdxNoAssertion,
+		CopyrightText:    spdxNoAssertion,
+	}
+	if c.Artifact != nil {
+		p.VersionInfo = c.Artifact.Version
+	}
+	if purl := c.PackageURL(); purl != "" {
+		p.ExternalRefs = []spdxExternalRef{{
+			ReferenceCategory: "PACKAGE-MANAGER",
+			ReferenceType:     "purl",
+			ReferenceLocator:  purl,
+		}}
+	}
+	if expression, ok := licenseExpression(c.Licenses); ok {
+		p.LicenseDeclared = expression
+	}
+	return p
+}
@@ -0,0 +1,216 @@
+// Package service discovers the providers of services on a classpath, which are declared
+// for java.util.ServiceLoader, in module declarations and for Spring Boot auto-configuration.
+package service
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/tsatke/jt/classpath"
+	"github.com/tsatke/jt/internal/properties"
+)
+
+const (
+	servicesDir        = "META-INF/services/"
+	springFactories    = "META-INF/spring.factories"
+	springImportsDir   = "META-INF/spring/"
+	springImportsExt   = ".imports"
+	moduleInfoResource = "module-info.class"
+)
+
+// Kind is the mechanism with which a provider is declared.
+type Kind uint8
+
+const (
+	// KindServiceLoader is a provider in a META-INF/services file.
+	KindServiceLoader Kind = iota
+	// KindModule is a provider in a provides directive of a module declaration.
+	KindModule
+	// KindSpringFactories is a provider in a META-INF/spring.factories file.
+	KindSpringFactories
+	// KindSpringImports is a provider in a META-INF/spring/*.imports file, which lists
+	// classes for an annotation, such as auto-configurations.
+	KindSpringImports
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindServiceLoader:
+		return "service-loader"
+	case KindModule:
+		return "module"
+	case KindSpringFactories:
+		return "spring-factories"
+	case KindSpringImports:
+		return "spring-imports"
+	}
+	return "unknown"
+}
+
+// Provider is the declaration of a class that provides a service.
+type Provider struct {
+	Kind Kind
+	// Service is the name of the service, such as java/sql/Driver.
+	// For KindSpringImports, this is the annotation that the file is named after.
+	Service string
+	// Class is the name of the provider class.
+	Class string
+	// Entry is the classpath entry that contains the declaration.
+	Entry *classpath.Entry
+	// Resource is the name of the resource that contains the declaration,
+	// such as META-INF/services/java.sql.Driver or module-info.class.
+	Resource string
+	// Line is the line of the declaration in the resource, or 0 if it is unknown.
+	Line int
+}
+
+// Discover finds all provider declarations on the classpath, in classpath order. Entries that
+// can't be opened, or whose declarations can't be read, are logged and skipped.
+func Discover(cp *classpath.Classpath) []*Provider {
+	var providers []*Provider
+	for _, entry := range cp.Entries {
+		if !entry.Type.HasClasses() {
+			continue
+		}
+
+		archive, err := cp.OpenArchive(entry)
+		if err != nil {
+			log.Error().
+				Err(err).
+				Str("entry", entry.Path).
+				Msg("open archive")
+			continue
+		}
+		found, err := discoverInArchive(entry, archive)
+		_ = archive.Close()
+		if err != nil {
+			log.Error().
+				Err(err).
+				Str("entry", entry.Path).
+				Msg("discover providers")
+			continue
+		}
+		providers = append(providers, found...)
+	}
+	return providers
+}
+
+func discoverInArchive(entry *classpath.Entry, archive classpath.Archive) ([]*Provider, error) {
+	var providers []*Provider
+	for _, name := range archive.ListResources() {
+		var parse func(io.Reader) ([]*Provider, error)
+		switch {
+		case strings.HasPrefix(name, servicesDir) && !strings.Contains(name[len(servicesDir):], "/"):
+			service := binaryName(name[len(servicesDir):])
+			parse = func(rd io.Reader) ([]*Provider, error) {
+				return parseProviderList(rd, KindServiceLoader, service)
+			}
+		case name == springFactories:
+			parse = parseSpringFactories
+		case strings.HasPrefix(name, springImportsDir) && path.Ext(name) == springImportsExt:
+			service := binaryName(strings.TrimSuffix(path.Base(name), springImportsExt))
+			parse = func(rd io.Reader) ([]*Provider, error) {
+				return parseProviderList(rd, KindSpringImports, service)
+			}
+		default:
+			continue
+		}
+
+		rc, err := archive.OpenResource(name)
+		if err != nil {
+			return nil, fmt.Errorf("open %s: %w", name, err)
+		}
+		found, err := parse(rc)
+		_ = rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("parse %s: %w", name, err)
+		}
+		for _, p := range found {
+			p.Entry = entry
+			p.Resource = name
+		}
+		providers = append(providers, found...)
+	}
+
+	modules, err := classpath.Modules(archive)
+	if err != nil {
+		return nil, fmt.Errorf("module declaration: %w", err)
+	}
+	for _, m := range modules {
+		for _, provides := range m.Provides {
+			for _, provider := range provides.Providers {
+				providers = append(providers, &Provider{
+					Kind:     KindModule,
+					Service:  provides.Service,
+					Class:    provider,
+					Entry:    entry,
+					Resource: moduleInfoResource,
+				})
+			}
+		}
+	}
+
+	return providers, nil
+}
+
+// parseProviderList parses a file that contains one class name per line, such as
+// the files in META-INF/services. Comments start with '#'.
+func parseProviderList(rd io.Reader, kind Kind, service string) ([]*Provider, error) {
+	var providers []*Provider
+	scanner := bufio.NewScanner(rd)
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if i := strings.IndexByte(text, '#'); i >= 0 {
+			text = text[:i]
+		}
+		text = strings.TrimSpace(text)
+		if text == "" {
+			continue
+		}
+		providers = append(providers, &Provider{
+			Kind:    kind,
+			Service: service,
+			Class:   binaryName(text),
+			Line:    line,
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return providers, nil
+}
+
+// parseSpringFactories parses a spring.factories file, which is a properties file
+// with factory types as keys and comma separated lists of providers as values.
+func parseSpringFactories(rd io.Reader) ([]*Provider, error) {
+	props, err := properties.Parse(rd)
+	if err != nil {
+		return nil, err
+	}
+
+	var providers []*Provider
+	for _, key := range props.Keys {
+		for _, value := range strings.Split(props.Get(key), ",") {
+			value = strings.TrimSpace(value)
+			if value == "" {
+				continue
+			}
+			providers = append(providers, &Provider{
+				Kind:    KindSpringFactories,
+				Service: binaryName(strings.TrimSpace(key)),
+				Class:   binaryName(value),
+			})
+		}
+	}
+	return providers, nil
+}
+
+// binaryName converts a binary class name like java.util.Map$Entry
+// to the internal form java/util/Map$Entry.
+func binaryName(name string) string {
+	return strings.ReplaceAll(name, ".", "/")
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"github.com/tsatke/jt/classpath"
+	"github.com/tsatke/jt/internal/classbuild"
+)
+
+func TestServiceSuite(t *testing.T) {
+	suite.Run(t, new(ServiceSuite))
+}
+
+type ServiceSuite struct {
+	suite.Suite
+
+	cp *classpath.Classpath
+}
+
+func (suite *ServiceSuite) SetupTest() {
+	dir := suite.T().TempDir()
+	write := func(name string, data []byte) {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		suite.Require().NoError(os.MkdirAll(filepath.Dir(path), 0755))
+		suite.Require().NoError(os.WriteFile(path, data, 0644))
+	}
+
+	service := classbuild.New("com/example/Service", "java/lang/Object")
+	service.AccessFlags = classbuild.AccPublic | classbuild.AccInterface | classbuild.AccAbstract
+	write("com/example/Service.class", service.Bytes())
+
+	annotation := classbuild.New("com/example/Enable", "java/lang/Object")
+	annotation.AccessFlags = classbuild.AccPublic | classbuild.AccInterface | classbuild.AccAbstract | classbuild.AccAnnotation
+	write("com/example/Enable.class", annotation.Bytes())
+
+	base := classbuild.New("com/example/BaseImpl", "java/lang/Object")
+	base.Interfaces = []string{"com/example/Service"}
+	write("com/example/BaseImpl.class", base.Bytes())
+
+	write("com/example/Impl.class", classbuild.New("com/example/Impl", "com/example/BaseImpl").Bytes())
+	write("com/example/Other.class", classbuild.New("com/example/Other", "java/lang/Object").Bytes())
+	write("com/example/Unknown.class", classbuild.New("com/example/Unknown", "com/example/Gone").Bytes())
+
+	factory := classbuild.New("com/example/Factory", "java/lang/Object")
+	factory.Method(classbuild.AccPublic|classbuild.AccStatic, "provider", "()Lcom/example/Service;")
+	write("com/example/Factory.class", factory.Bytes())
+
+	moduleInfo := classbuild.NewModuleInfo()
+	moduleInfo.Attributes = append(moduleInfo.Attributes, moduleInfo.ModuleAttribute("com.example", nil, nil, nil, []classbuild.Provides{
+		{Service: "com/example/Service", With: []string{"com/example/Factory", "com/example/Other"}},
+	}))
+	write("module-info.class", moduleInfo.Bytes())
+
+	write("META-INF/services/com.example.Service", []byte(`# providers
+com.example.Impl # the default
+com.example.Missing
+
+com.example.Other
+com.example.Unknown
+`))
+	write("META-INF/spring.factories", []byte(`com.example.Service=\
+  com.example.Impl,\
+  com.example.Other
+com.example.Enable=com.example.Other
+`))
+	write("META-INF/spring/com.example.Enable.imports", []byte("com.example.Other\ncom.example.Absent\n"))
+
+	suite.cp = classpath.NewClasspath()
+	// entries that can't be opened are skipped
+	suite.cp.AddEntry(classpath.EntryTypeUnknown, filepath.Join(dir, "unknown"))
+	suite.cp.AddEntry(classpath.EntryTypeJar, filepath.Join(dir, "missing.jar"))
+	suite.cp.AddEntry(classpath.EntryTypeOutput, dir)
+}
+
+func (suite *ServiceSuite) TestDiscover() {
+	providers := Discover(suite.cp)
+
+	type declaration struct {
+		Kind     Kind
+		Service  string
+		Class    string
+		Resource string
+		Line     int
+	}
+	var declarations []declaration
+	for _, p := range providers {
+		suite.Equal(suite.cp.Entries[2], p.Entry)
+		declarations = append(declarations, declaration{p.Kind, p.Service, p.Class, p.Resource, p.Line})
+	}
+	suite.ElementsMatch([]declaration{
+		{KindServiceLoader, "com/example/Service", "com/example/Impl", "META-INF/services/com.example.Service", 2},
+		{KindServiceLoader, "com/example/Service", "com/example/Missing", "META-INF/services/com.example.Service", 3},
+		{KindServiceLoader, "com/example/Service", "com/example/Other", "META-INF/services/com.example.Service", 5},
+		{KindServiceLoader, "com/example/Service", "com/example/Unknown", "META-INF/services/com.example.Service", 6},
+		{KindSpringFactories, "com/example/Service", "com/example/Impl", "META-INF/spring.factories", 0},
+		{KindSpringFactories, "com/example/Service", "com/example/Other", "META-INF/spring.factories", 0},
+		{KindSpringFactories, "com/example/Enable", "com/example/Other", "META-INF/spring.factories", 0},
+		{KindSpringImports, "com/example/Enable", "com/example/Other", "META-INF/spring/com.example.Enable.imports", 1},
+		{KindSpringImports, "com/example/Enable", "com/example/Absent", "META-INF/spring/com.example.Enable.imports", 2},
+		{KindModule, "com/example/Service", "com/example/Factory", "module-info.class", 0},
+		{KindModule, "com/example/Service", "com/example/Other", "module-info.class", 0},
+	}, declarations)
+}
+
+func (suite *ServiceSuite) TestVerify() {
+	providers := Discover(suite.cp)
+
+	cache, err := classpath.NewCache(16)
+	suite.Require().NoError(err)
+	defer func() { _ = cache.Close() }()
+
+	statuses := make(map[string]Status)
+	for _, p := range providers {
+		status, err := Verify(suite.cp, p, cache)
+		suite.Require().NoError(err)
+		statuses[p.Kind.String()+" "+p.Service+" "+p.Class] = status
+	}
+	suite.Equal(map[string]Status{
+		"service-loader com/example/Service com/example/Impl":    StatusOK,
+		"service-loader com/example/Service com/example/Missing": StatusMissing,
+		"service-loader com/example/Service com/example/Other":   StatusNotSubtype,
+		"service-loader com/example/Service com/example/Unknown": StatusUnknown,
+		"spring-factories com/example/Service com/example/Impl":  StatusOK,
+		"spring-factories com/example/Service com/example/Other": StatusNotSubtype,
+		"spring-factories com/example/Enable com/example/Other":  StatusOK,
+		"spring-imports com/example/Enable com/example/Other":    StatusOK,
+		"spring-imports com/example/Enable com/example/Absent":   StatusMissing,
+		"module com/example/Service com/example/Factory":         StatusOK,
+		"module com/example/Service com/example/Other":           StatusNotSubtype,
+	}, statuses)
+}
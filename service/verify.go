@@ -0,0 +1,99 @@
+package service
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/tsatke/jt/class"
+	"github.com/tsatke/jt/classpath"
+)
+
+// Status is the result of verifying a provider declaration.
+type Status uint8
+
+const (
+	// StatusOK means that the provider exists and implements the service.
+	StatusOK Status = iota
+	// StatusMissing means that the provider class is not on the classpath.
+	StatusMissing
+	// StatusNotSubtype means that the provider doesn't implement or extend the service.
+	StatusNotSubtype
+	// StatusUnknown means that the provider exists, but it can't be determined whether it
+	// implements the service, because the service or a class of the hierarchy of the
+	// provider is not on the classpath.
+	StatusUnknown
+)
+
+func (s Status) String() string {
+	switch s {
+	case StatusOK:
+		return "ok"
+	case StatusMissing:
+		return "missing"
+	case StatusNotSubtype:
+		return "not a subtype"
+	case StatusUnknown:
+		return "unknown"
+	}
+	return "invalid"
+}
+
+// providerMethod is the name of the static factory method with which a provider
+// of a module may provide a service instead of implementing it.
+const providerMethod = "provider"
+
+// Verify checks whether the provider of the given declaration exists on the classpath and
+// implements the service. Providers in spring/*.imports files are named after an annotation,
+// which they don't implement, so only their existence is checked. The cache may be nil.
+func Verify(cp *classpath.Classpath, p *Provider, cache *classpath.Cache) (Status, error) {
+	provider, err := cp.OpenClassWithCache(p.Class, cache)
+	if err != nil {
+		return 0, err
+	}
+	if provider == nil {
+		return StatusMissing, nil
+	}
+	if p.Kind == KindSpringImports {
+		return StatusOK, nil
+	}
+
+	service, err := cp.OpenClassWithCache(p.Service, cache)
+	if err != nil {
+		return 0, err
+	}
+	if service == nil {
+		return StatusUnknown, nil
+	}
+	if service.IsAnnotation() {
+		// spring.factories may use annotations as keys, such as EnableAutoConfiguration
+		return StatusOK, nil
+	}
+
+	ok, err := cp.IsSubtype(p.Class, p.Service, cache)
+	var notFound *classpath.ClassNotFoundError
+	if errors.As(err, &notFound) {
+		return StatusUnknown, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	if ok {
+		return StatusOK, nil
+	}
+	if p.Kind == KindModule && hasProviderMethod(provider) {
+		return StatusOK, nil
+	}
+	return StatusNotSubtype, nil
+}
+
+// hasProviderMethod returns whether the class declares a method
+// public static provider() without parameters. Its return type is not checked.
+func hasProviderMethod(c *class.Class) bool {
+	for _, m := range c.Methods() {
+		if m.Name() == providerMethod && m.IsPublic() && m.IsStatic() &&
+			strings.HasPrefix(m.Descriptor(), "()") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,227 @@
+// Package unused finds the jars on a classpath that the classes of a project don't need, by
+// following the class references in the constant pools of the project classes through the
+// classes of the jars that they reach.
+package unused
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/tsatke/jt/class"
+	"github.com/tsatke/jt/classpath"
+)
+
+// usages of a jar, from the strongest to the weakest
+const (
+	// UsageDirect is a jar with classes that project code references.
+	UsageDirect = "direct"
+	// UsageAnnotation is a jar with classes that project code uses only in annotations, which
+	// the JVM doesn't load unless the annotations are read by reflection.
+	UsageAnnotation = "annotation"
+	// UsageReflection is a jar with classes that project code only names in string constants,
+	// like the names that are passed to Class.forName.
+	UsageReflection = "reflection"
+	// UsageTransitive is a jar with classes that only classes of other jars reference.
+	UsageTransitive = "transitive"
+	// UsageUnused is a jar without any class that is reached from project code.
+	UsageUnused = "unused"
+)
+
+var usageRank = map[string]int{UsageDirect: 4, UsageAnnotation: 3, UsageReflection: 2, UsageTransitive: 1, UsageUnused: 0}
+
+// Dependency is the usage of a jar of the classpath.
+type Dependency struct {
+	Entry *classpath.Entry
+	// Usage is how the jar is used, one of the Usage constants.
+	Usage string
+	// Via is the jar with the first class that references a class of a transitive dependency.
+	Via *classpath.Entry
+	// Classes are the classes of the jar that project code uses, sorted, for usages other
+	// than UsageTransitive and UsageUnused.
+	Classes []string
+}
+
+// Analyze returns the usage of every jar of the classpath by the classes in the entries that
+// project returns true for, in the order of the classpath. Jars that are reached in any way,
+// including from annotations and string constants, count as used, and so do the jars that
+// classes of them reference, but the JDK and the project itself are not followed. Like the
+// JVM, only the first class on the classpath with a name is used.
+func Analyze(cp *classpath.Classpath, project func(*classpath.Entry) bool) ([]*Dependency, error) {
+	cache, err := classpath.NewCache(100)
+	if err != nil {
+		return nil, fmt.Errorf("create archive cache: %w", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	a := &analysis{
+		cp:           cp,
+		cache:        cache,
+		dependencies: make(map[string]*Dependency),
+		classes:      make(map[string]map[string]bool),
+		reached:      make(map[string]bool),
+	}
+	for _, entry := range cp.Entries {
+		if entry.Type == classpath.EntryTypeJar && a.dependencies[entry.Path] == nil {
+			a.dependencies[entry.Path] = &Dependency{Entry: entry, Usage: UsageUnused}
+		}
+	}
+
+	seen := make(map[string]bool)
+	cp.WalkArchives(func(entry *classpath.Entry) bool { return !project(entry) }, func(entry *classpath.Entry, archive classpath.Archive) {
+		for _, name := range archive.ListClasses() {
+			if err != nil || seen[name] {
+				continue
+			}
+			seen[name] = true
+			var c *class.Class
+			if c, err = archive.OpenClass(name); err != nil {
+				err = fmt.Errorf("open class %s: %w", name, err)
+				continue
+			}
+			err = a.project(c)
+		}
+	})
+	if err == nil {
+		err = a.follow()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var dependencies []*Dependency
+	for _, entry := range cp.Entries {
+		if d := a.dependencies[entry.Path]; d != nil && d.Entry == entry {
+			for name := range a.classes[entry.Path] {
+				d.Classes = append(d.Classes, name)
+			}
+			sort.Strings(d.Classes)
+			dependencies = append(dependencies, d)
+		}
+	}
+	return dependencies, nil
+}
+
+type analysis struct {
+	cp    *classpath.Classpath
+	cache *classpath.Cache
+	// dependencies are the jars by path
+	dependencies map[string]*Dependency
+	// classes are the classes of the jars that project code uses, by the path of the jar
+	classes map[string]map[string]bool
+	// reached are the classes of jars that are reached, and queue those that are not followed yet
+	reached map[string]bool
+	queue   []string
+}
+
+// project adds the usages of the classes that the project class references.
+func (a *analysis) project(c *class.Class) error {
+	annotations := c.AnnotationDependencies()
+	inAnnotations := make(map[string]bool, len(annotations))
+	for _, name := range annotations {
+		inAnnotations[name] = true
+		if err := a.use(name, UsageAnnotation); err != nil {
+			return err
+		}
+	}
+	for _, name := range c.Dependencies() {
+		if inAnnotations[name] {
+			continue
+		}
+		if err := a.use(name, UsageDirect); err != nil {
+			return err
+		}
+	}
+	for _, value := range c.Strings() {
+		if !isBinaryName(value) {
+			continue
+		}
+		if err := a.use(strings.ReplaceAll(value, ".", "/"), UsageReflection); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// use records that project code uses the class, if it is in a jar.
+func (a *analysis) use(name, usage string) error {
+	entry, err := a.cp.Locate(name)
+	if err != nil {
+		return fmt.Errorf("locate %s: %w", name, err)
+	}
+	if entry == nil || a.dependencies[entry.Path] == nil {
+		return nil
+	}
+	d := a.dependencies[entry.Path]
+	if usageRank[usage] > usageRank[d.Usage] {
+		d.Usage = usage
+		a.classes[entry.Path] = make(map[string]bool)
+	}
+	if d.Usage == usage {
+		a.classes[entry.Path][name] = true
+	}
+	a.reach(name)
+	return nil
+}
+
+func (a *analysis) reach(name string) {
+	if !a.reached[name] {
+		a.reached[name] = true
+		a.queue = append(a.queue, name)
+	}
+}
+
+// follow reaches the classes of jars that the classes in the queue reference, breadth first,
+// so that a transitive dependency is reported via one of the closest jars.
+func (a *analysis) follow() error {
+	for len(a.queue) > 0 {
+		name := a.queue[0]
+		a.queue = a.queue[1:]
+		c, err := a.cp.OpenClassWithCache(name, a.cache)
+		if err != nil {
+			return fmt.Errorf("open class %s: %w", name, err)
+		}
+		if c == nil {
+			continue
+		}
+		via, err := a.cp.Locate(name)
+		if err != nil {
+			return fmt.Errorf("locate %s: %w", name, err)
+		}
+		for _, dependency := range c.Dependencies() {
+			entry, err := a.cp.Locate(dependency)
+			if err != nil {
+				return fmt.Errorf("locate %s: %w", dependency, err)
+			}
+			if entry == nil || a.dependencies[entry.Path] == nil {
+				continue
+			}
+			if d := a.dependencies[entry.Path]; d.Usage == UsageUnused {
+				d.Usage, d.Via = UsageTransitive, via
+			}
+			a.reach(dependency)
+		}
+	}
+	return nil
+}
+
+// isBinaryName returns whether the string looks like the binary name of a class in a package,
+// such as com.example.Outer$Inner.
+func isBinaryName(s string) bool {
+	parts := strings.Split(s, ".")
+	if len(parts) < 2 {
+		return false
+	}
+	for _, part := range parts {
+		if part == "" {
+			return false
+		}
+		for i, r := range part {
+			letter := r == '_' || r == '$' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || r > 0x7f
+			if !letter && (i == 0 || r < '0' || r > '9') {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,93 @@
+package unused
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"github.com/tsatke/jt/classpath"
+	"github.com/tsatke/jt/internal/classbuild"
+)
+
+func TestUnusedSuite(t *testing.T) {
+	suite.Run(t, new(UnusedSuite))
+}
+
+type UnusedSuite struct {
+	suite.Suite
+}
+
+func (suite *UnusedSuite) jar(name string, classes ...*classbuild.Class) string {
+	path := filepath.Join(suite.T().TempDir(), name)
+	f, err := os.Create(path)
+	suite.Require().NoError(err)
+	defer func() { _ = f.Close() }()
+	w := zip.NewWriter(f)
+	for _, c := range classes {
+		entry, err := w.Create(c.Name + ".class")
+		suite.Require().NoError(err)
+		_, err = entry.Write(c.Bytes())
+		suite.Require().NoError(err)
+	}
+	suite.Require().NoError(w.Close())
+	return path
+}
+
+func (suite *UnusedSuite) TestAnalyze() {
+	app := suite.T().TempDir()
+	b := classbuild.New("com/example/App", "com/lib/Base")
+	b.Attributes = append(b.Attributes, b.Annotations(true, b.Annotation("com/meta/Component")))
+	b.Method(classbuild.AccPublic, "run", "()V", b.Code(classbuild.Data(
+		byte(0x12), byte(b.String("com.plugin.Impl")), // 0: ldc
+		byte(0x12), byte(b.String("not a class")), // 2: ldc
+		byte(0xb1), // 4: return
+	)))
+	suite.Require().NoError(b.WriteFile(app))
+
+	base := classbuild.New("com/lib/Base", "com/core/Core")
+	core := classbuild.New("com/core/Core", "java/lang/Object")
+	util := classbuild.New("com/util/Util", "java/lang/Object")
+	component := classbuild.New("com/meta/Component", "java/lang/Object")
+	impl := classbuild.New("com/plugin/Impl", "java/lang/Object")
+
+	cp := classpath.NewClasspath()
+	cp.AddEntry(classpath.EntryTypeOutput, app)
+	for _, jar := range []string{
+		suite.jar("lib.jar", base),
+		suite.jar("core.jar", core),
+		suite.jar("util.jar", util),
+		suite.jar("meta.jar", component),
+		suite.jar("plugin.jar", impl),
+	} {
+		cp.AddEntry(classpath.EntryTypeJar, jar)
+	}
+
+	dependencies, err := Analyze(cp, func(entry *classpath.Entry) bool { return entry.Type == classpath.EntryTypeOutput })
+	suite.Require().NoError(err)
+	suite.Require().Len(dependencies, 5)
+
+	usages := make(map[string]*Dependency)
+	for _, d := range dependencies {
+		usages[filepath.Base(d.Entry.Path)] = d
+	}
+	suite.Equal(UsageDirect, usages["lib.jar"].Usage)
+	suite.Equal([]string{"com/lib/Base"}, usages["lib.jar"].Classes)
+	suite.Equal(UsageTransitive, usages["core.jar"].Usage)
+	suite.Equal(usages["lib.jar"].Entry, usages["core.jar"].Via)
+	suite.Equal(UsageUnused, usages["util.jar"].Usage)
+	suite.Equal(UsageAnnotation, usages["meta.jar"].Usage)
+	suite.Equal([]string{"com/meta/Component"}, usages["meta.jar"].Classes)
+	suite.Equal(UsageReflection, usages["plugin.jar"].Usage)
+	suite.Equal([]string{"com/plugin/Impl"}, usages["plugin.jar"].Classes)
+}
+
+func (suite *UnusedSuite) TestIsBinaryName() {
+	suite.True(isBinaryName("com.example.Outer$Inner"))
+	suite.True(isBinaryName("org.h2.Driver"))
+	suite.False(isBinaryName("Driver"))
+	suite.False(isBinaryName("1.0.2"))
+	suite.False(isBinaryName("com..Example"))
+	suite.False(isBinaryName("hello world.txt"))
+}
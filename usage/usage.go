@@ -0,0 +1,236 @@
+// Package usage finds the places in compiled classes that reference a class, field or method,
+// using the references in their constant pools and the instructions of their methods.
+package usage
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/tsatke/jt/class"
+	"github.com/tsatke/jt/classpath"
+)
+
+// Target is the class, field or method whose usages are searched.
+type Target struct {
+	Class string
+	// Member is the name of a field or method, or empty to search the usages of the class.
+	Member string
+	// Descriptor is the descriptor of the method, or empty for all fields and methods with the name.
+	Descriptor string
+}
+
+// ParseTarget parses a target like com/example/Foo, com.example.Foo#bar or com/example/Foo#bar(I)V.
+func ParseTarget(s string) (*Target, error) {
+	t := &Target{Class: s}
+	if i := strings.Index(s, "#"); i >= 0 {
+		t.Class, t.Member = s[:i], s[i+1:]
+		if j := strings.Index(t.Member, "("); j >= 0 {
+			t.Member, t.Descriptor = t.Member[:j], t.Member[j:]
+		}
+		if t.Member == "" {
+			return nil, fmt.Errorf("missing member name in %s", s)
+		}
+	}
+	if t.Class == "" {
+		return nil, fmt.Errorf("missing class in %s", s)
+	}
+	t.Class = strings.ReplaceAll(t.Class, ".", "/")
+	return t, nil
+}
+
+func (t Target) String() string {
+	if t.Member == "" {
+		return t.Class
+	}
+	return t.Class + "#" + t.Member + t.Descriptor
+}
+
+// kinds of usages besides the mnemonics of instructions
+const (
+	KindExtends    = "extends"
+	KindImplements = "implements"
+	// KindField is a field of the class type.
+	KindField = "field"
+	// KindSignature is the use of a class in the signature of a class or method, including
+	// the declared exceptions.
+	KindSignature = "signature"
+	// KindConstant is a reference in the constant pool that is not used by an instruction or
+	// a declaration, such as in an attribute of the class.
+	KindConstant = "constant"
+)
+
+// Usage is a place where the target is used.
+type Usage struct {
+	// Class is the class that uses the target.
+	Class string
+	// Entry is the classpath entry that contains the class.
+	Entry *classpath.Entry
+	// Member is the name of the field or the name and descriptor of the method that uses the
+	// target, such as run()V, or empty if the target is used by the class itself.
+	Member string
+	// Line is the source line of the instruction that uses the target, or 0 if it is unknown.
+	Line int
+	// Kind is the mnemonic of the instruction that uses the target, such as invokevirtual or new,
+	// or one of the other kinds.
+	Kind string
+}
+
+// Find calls fn for every usage of the target in the classes of the classpath, except for the
+// entries that skip returns true for, which may be nil. Classes are searched in classpath order,
+// and in every entry that contains them. Usages of a class within the class itself are left out,
+// as well as references of a field or method to a class that only inherits it from another class
+// than the target.
+func Find(cp *classpath.Classpath, target *Target, skip func(*classpath.Entry) bool, fn func(*Usage)) error {
+	cache, err := classpath.NewCache(100)
+	if err != nil {
+		return fmt.Errorf("create archive cache: %w", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	s := &search{
+		cp:       cp,
+		target:   target,
+		cache:    cache,
+		resolved: make(map[class.Reference]bool),
+	}
+	cp.WalkArchives(skip, func(entry *classpath.Entry, archive classpath.Archive) {
+		for _, name := range archive.ListClasses() {
+			if target.Member == "" && name == target.Class {
+				continue
+			}
+			c, err := archive.OpenClass(name)
+			if err != nil {
+				log.Error().
+					Err(err).
+					Str("class", name).
+					Str("entry", entry.Path).
+					Msg("open class")
+				continue
+			}
+			for _, u := range s.class(c) {
+				u.Entry = entry
+				fn(u)
+			}
+		}
+	})
+	return nil
+}
+
+// search finds the usages of a target in classes.
+type search struct {
+	cp     *classpath.Classpath
+	target *Target
+	cache  *classpath.Cache
+	// resolved holds whether references through other classes than the target resolve to the target
+	resolved map[class.Reference]bool
+}
+
+// class returns the usages of the target in the given class.
+func (s *search) class(c *class.Class) []*Usage {
+	var usages []*Usage
+	seen := make(map[Usage]bool)
+	add := func(member string, line int, kind string) {
+		u := Usage{Class: c.Name(), Member: member, Line: line, Kind: kind}
+		if !seen[u] {
+			seen[u] = true
+			usages = append(usages, &u)
+		}
+	}
+
+	if s.target.Member == "" {
+		if c.SuperclassName() == s.target.Class {
+			add("", 0, KindExtends)
+		}
+		for _, iface := range c.Interfaces() {
+			if iface == s.target.Class {
+				add("", 0, KindImplements)
+			}
+		}
+		if contains(class.SignatureClasses(c.Signature()), s.target.Class) {
+			add("", 0, KindSignature)
+		}
+		for _, f := range c.Fields() {
+			if contains(class.DescriptorClasses(f.Descriptor()), s.target.Class) || contains(class.SignatureClasses(f.Signature()), s.target.Class) {
+				add(f.Name(), 0, KindField)
+			}
+		}
+	}
+
+	for _, m := range c.Methods() {
+		member := m.Name() + m.Descriptor()
+		if s.target.Member == "" {
+			classes := append(class.DescriptorClasses(m.Descriptor()), class.SignatureClasses(m.Signature())...)
+			if contains(append(classes, m.Exceptions()...), s.target.Class) {
+				add(member, 0, KindSignature)
+			}
+		}
+
+		references, err := m.CodeReferences()
+		if err != nil {
+			log.Debug().
+				Err(err).
+				Str("class", c.Name()).
+				Msg("decode method")
+			continue
+		}
+		for _, ref := range references {
+			if s.matches(ref.Reference) {
+				add(member, ref.Line, ref.Opcode.String())
+			}
+		}
+	}
+
+	// references that no instruction or declaration uses, such as in annotations or inner classes
+	if len(usages) == 0 {
+		for _, ref := range c.References() {
+			if ref.Class != c.Name() && s.matches(ref) {
+				add("", 0, KindConstant)
+				break
+			}
+		}
+	}
+	return usages
+}
+
+// matches returns whether the reference uses the target.
+func (s *search) matches(ref class.Reference) bool {
+	if s.target.Member == "" {
+		return class.ElementClass(ref.Class) == s.target.Class ||
+			(ref.Kind != class.RefClass && contains(class.DescriptorClasses(ref.Descriptor), s.target.Class))
+	}
+
+	if ref.Kind == class.RefClass || ref.Name != s.target.Member ||
+		(s.target.Descriptor != "" && (ref.Kind == class.RefField || ref.Descriptor != s.target.Descriptor)) {
+		return false
+	}
+	if ref.Class == s.target.Class {
+		return true
+	}
+
+	// a member can be referenced through a subclass that inherits it
+	matches, ok := s.resolved[ref]
+	if !ok {
+		declaring, err := s.cp.ResolveMember(ref, s.cache)
+		var notFound *classpath.ClassNotFoundError
+		if err != nil && !errors.As(err, &notFound) {
+			log.Debug().
+				Err(err).
+				Str("reference", ref.String()).
+				Msg("resolve member")
+		}
+		matches = declaring != nil && declaring.Name() == s.target.Class
+		s.resolved[ref] = matches
+	}
+	return matches
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
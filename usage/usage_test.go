@@ -0,0 +1,139 @@
+package usage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"github.com/tsatke/jt/classpath"
+	"github.com/tsatke/jt/internal/classbuild"
+)
+
+func TestUsageSuite(t *testing.T) {
+	suite.Run(t, new(UsageSuite))
+}
+
+type UsageSuite struct {
+	suite.Suite
+
+	cp *classpath.Classpath
+}
+
+func (suite *UsageSuite) SetupTest() {
+	dir := suite.T().TempDir()
+	write := func(c *classbuild.Class) { suite.Require().NoError(c.WriteFile(dir)) }
+
+	write(classbuild.New("java/lang/Object", ""))
+	api := classbuild.New("com/example/Api", "java/lang/Object")
+	api.Field(classbuild.AccPublic|classbuild.AccStatic, "NAME", "Ljava/lang/String;")
+	api.Method(classbuild.AccPublic, "send", "(Ljava/lang/String;)V")
+	write(api)
+	write(classbuild.New("com/example/SubApi", "com/example/Api"))
+
+	caller := classbuild.New("com/example/Caller", "java/lang/Object")
+	caller.Field(classbuild.AccPrivate, "api", "Lcom/example/Api;")
+	caller.Method(classbuild.AccPublic, "get", "()Ljava/util/List;", caller.Signature("()Ljava/util/List<Lcom/example/Api;>;"))
+	lambda := caller.MethodHandle(5, caller.Methodref("com/example/Api", "send", "(Ljava/lang/String;)V"))
+	metafactory := caller.MethodHandle(6, caller.Methodref("java/lang/invoke/LambdaMetafactory", "metafactory",
+		"(Ljava/lang/invoke/MethodHandles$Lookup;Ljava/lang/String;Ljava/lang/invoke/MethodType;Ljava/lang/invoke/MethodType;Ljava/lang/invoke/MethodHandle;Ljava/lang/invoke/MethodType;)Ljava/lang/invoke/CallSite;"))
+	code := classbuild.Data(
+		byte(0xbb), caller.Class("com/example/Api"), // 0: new
+		byte(0x59),                                                       // 3: dup
+		byte(0xb7), caller.Methodref("com/example/Api", "<init>", "()V"), // 4: invokespecial
+		byte(0x12), byte(caller.String("hello")), // 7: ldc
+		byte(0xb6), caller.Methodref("com/example/Api", "send", "(Ljava/lang/String;)V"), // 9: invokevirtual
+		byte(0xb6), caller.Methodref("com/example/SubApi", "send", "(Ljava/lang/String;)V"), // 12: invokevirtual
+		byte(0xba), caller.InvokeDynamic(0, "accept", "(Lcom/example/Api;)Ljava/util/function/Consumer;"), byte(0), byte(0), // 15: invokedynamic
+		byte(0xb2), caller.Fieldref("com/example/Api", "NAME", "Ljava/lang/String;"), // 20: getstatic
+		byte(0xb1), // 23: return
+	)
+	caller.Method(classbuild.AccPublic, "run", "()V", caller.Code(code,
+		classbuild.LineNumber{Pc: 0, Line: 10},
+		classbuild.LineNumber{Pc: 9, Line: 11},
+		classbuild.LineNumber{Pc: 12, Line: 12},
+		classbuild.LineNumber{Pc: 15, Line: 13},
+		classbuild.LineNumber{Pc: 20, Line: 14},
+	))
+	caller.Attributes = append(caller.Attributes, caller.BootstrapMethods(classbuild.BootstrapMethod{
+		MethodRef: metafactory,
+		Arguments: []uint16{caller.MethodType("(Ljava/lang/Object;)V"), lambda, caller.MethodType("(Ljava/lang/String;)V")},
+	}))
+	write(caller)
+
+	impl := classbuild.New("com/example/Impl", "com/example/Api")
+	impl.Method(classbuild.AccPublic, "send", "(Ljava/lang/String;)V")
+	write(impl)
+
+	// references an overriding method, which is not a usage of Api#send
+	other := classbuild.New("com/example/Other", "java/lang/Object")
+	other.Method(classbuild.AccPublic, "run", "(Lcom/example/Impl;)V", other.Code(classbuild.Data(
+		byte(0x2b),                                                                       // 0: aload_1
+		byte(0x01),                                                                       // 1: aconst_null
+		byte(0xb6), other.Methodref("com/example/Impl", "send", "(Ljava/lang/String;)V"), // 2: invokevirtual
+		byte(0xb1), // 5: return
+	)))
+	write(other)
+
+	suite.cp = classpath.NewClasspath()
+	suite.cp.AddEntry(classpath.EntryTypeOutput, dir)
+}
+
+func (suite *UsageSuite) find(target string) []Usage {
+	t, err := ParseTarget(target)
+	suite.Require().NoError(err)
+	var usages []Usage
+	suite.Require().NoError(Find(suite.cp, t, nil, func(u *Usage) {
+		suite.Equal(suite.cp.Entries[0], u.Entry)
+		u.Entry = nil
+		usages = append(usages, *u)
+	}))
+	return usages
+}
+
+func (suite *UsageSuite) TestParseTarget() {
+	t, err := ParseTarget("com.example.Api#send(Ljava/lang/String;)V")
+	suite.NoError(err)
+	suite.Equal(&Target{Class: "com/example/Api", Member: "send", Descriptor: "(Ljava/lang/String;)V"}, t)
+	suite.Equal("com/example/Api#send(Ljava/lang/String;)V", t.String())
+
+	t, err = ParseTarget("com/example/Api#NAME")
+	suite.NoError(err)
+	suite.Equal(&Target{Class: "com/example/Api", Member: "NAME"}, t)
+
+	_, err = ParseTarget("com/example/Api#")
+	suite.Error(err)
+	_, err = ParseTarget("#send")
+	suite.Error(err)
+}
+
+func (suite *UsageSuite) TestClass() {
+	usages := suite.find("com/example/Api")
+	suite.ElementsMatch([]Usage{
+		{Class: "com/example/SubApi", Kind: KindExtends},
+		{Class: "com/example/Impl", Kind: KindExtends},
+		{Class: "com/example/Caller", Member: "api", Kind: KindField},
+		{Class: "com/example/Caller", Member: "get()Ljava/util/List;", Kind: KindSignature},
+		{Class: "com/example/Caller", Member: "run()V", Line: 10, Kind: "new"},
+		{Class: "com/example/Caller", Member: "run()V", Line: 10, Kind: "invokespecial"},
+		{Class: "com/example/Caller", Member: "run()V", Line: 11, Kind: "invokevirtual"},
+		{Class: "com/example/Caller", Member: "run()V", Line: 13, Kind: "invokedynamic"},
+		{Class: "com/example/Caller", Member: "run()V", Line: 14, Kind: "getstatic"},
+	}, usages)
+}
+
+func (suite *UsageSuite) TestMethod() {
+	// calls through a subclass that inherits the method count, calls of overriding methods don't
+	expected := []Usage{
+		{Class: "com/example/Caller", Member: "run()V", Line: 11, Kind: "invokevirtual"},
+		{Class: "com/example/Caller", Member: "run()V", Line: 12, Kind: "invokevirtual"},
+		{Class: "com/example/Caller", Member: "run()V", Line: 13, Kind: "invokedynamic"},
+	}
+	suite.Equal(expected, suite.find("com/example/Api#send"))
+	suite.Equal(expected, suite.find("com/example/Api#send(Ljava/lang/String;)V"))
+	suite.Empty(suite.find("com/example/Api#send(I)V"))
+}
+
+func (suite *UsageSuite) TestField() {
+	suite.Equal([]Usage{
+		{Class: "com/example/Caller", Member: "run()V", Line: 14, Kind: "getstatic"},
+	}, suite.find("com/example/Api#NAME"))
+}
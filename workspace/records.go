@@ -0,0 +1,135 @@
+package workspace
+
+import (
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/tsatke/jt/class"
+	"github.com/tsatke/jt/classpath"
+	"github.com/tsatke/jt/jar"
+)
+
+// Entry describes a classpath entry.
+type Entry struct {
+	Path string `json:"path"`
+	// Type is one of jar, source, output, jimage or jmod.
+	Type string `json:"type"`
+	// Artifact holds the Maven coordinates of jars, such as org.slf4j:slf4j-api:1.7.36.
+	Artifact string `json:"artifact,omitempty"`
+}
+
+func NewEntry(entry *classpath.Entry, artifact string) *Entry {
+	return &Entry{
+		Path:     entry.Path,
+		Type:     entry.Type.String(),
+		Artifact: artifact,
+	}
+}
+
+// Class describes a class.
+type Class struct {
+	// Name is the internal name of the class, such as java/util/Map$Entry.
+	Name string `json:"name"`
+	// BinaryName is the binary name of the class, such as java.util.Map$Entry.
+	BinaryName string `json:"binaryName"`
+	// QualifiedName is the fully qualified name of the class as written in source code, such as
+	// java.util.Map.Entry. Local and anonymous classes have none.
+	QualifiedName string `json:"qualifiedName,omitempty"`
+	// File is the source file of classes that are found in the sources of the project.
+	File  string `json:"file,omitempty"`
+	Entry *Entry `json:"entry,omitempty"`
+	// ClassVersion is the version of the class file, if the class was read.
+	ClassVersion *ClassVersion `json:"classVersion,omitempty"`
+	// Versions holds the releases of the versioned variants of classes in multi-release jars.
+	Versions []int `json:"versions,omitempty"`
+	// SelectedVersion is the release of the variant that is used, 0 for the base variant.
+	SelectedVersion *int `json:"selectedVersion,omitempty"`
+}
+
+func NewClass(name string, entry *Entry) *Class {
+	return &Class{
+		Name:          name,
+		BinaryName:    strings.ReplaceAll(name, "/", "."),
+		QualifiedName: QualifiedName(name),
+		Entry:         entry,
+	}
+}
+
+// Declarations are the declarations of a class and of its members in Java syntax, like javap
+// prints them.
+type Declarations struct {
+	Class *Class `json:"class"`
+	// Declaration is the declaration of the class, such as public class com.example.App extends com.example.Base.
+	Declaration string `json:"declaration"`
+	// Members are the declarations of the fields and methods that are not private, in the order
+	// of the class file.
+	Members []string `json:"members"`
+}
+
+// ClassVersion is the version of a class file.
+type ClassVersion struct {
+	Major int `json:"major"`
+	Minor int `json:"minor"`
+	// Release is the Java release that introduced the major version, such as 17 for 61.
+	Release int `json:"release"`
+}
+
+func NewClassVersion(c *class.Class) *ClassVersion {
+	major, minor := c.Version()
+	return &ClassVersion{
+		Major:   major,
+		Minor:   minor,
+		Release: major - 44,
+	}
+}
+
+// InternalNames returns the internal names that a class name given by a user may stand for,
+// most likely first. Binary names like java.util.Map$Entry stand for java/util/Map$Entry, but
+// qualified names like java.util.Map.Entry separate nested classes with dots as well, so that
+// they stand for java/util/Map/Entry, java/util/Map$Entry and so on. Names without dots are
+// taken as internal names.
+func InternalNames(name string) []string {
+	if !strings.Contains(name, ".") {
+		return []string{name}
+	}
+	name = strings.ReplaceAll(name, ".", "/")
+	names := []string{name}
+	for i := strings.LastIndex(name, "/"); i > 0; i = strings.LastIndex(name[:i], "/") {
+		name = name[:i] + "$" + name[i+1:]
+		names = append(names, name)
+	}
+	return names
+}
+
+// QualifiedName returns the fully qualified name of the class with the given internal name,
+// or the empty string if it is a local or anonymous class, whose names contain a '$' followed
+// by a digit.
+func QualifiedName(name string) string {
+	for i := 0; i < len(name)-1; i++ {
+		if name[i] == '$' && name[i+1] >= '0' && name[i+1] <= '9' {
+			return ""
+		}
+	}
+	return strings.NewReplacer("/", ".", "$", ".").Replace(name)
+}
+
+// Artifact returns the Maven coordinates of the given entry, such as org.slf4j:slf4j-api:1.7.36,
+// or the empty string if the entry is not a jar or can't be identified.
+func Artifact(entry *classpath.Entry, archive classpath.Archive) string {
+	f, ok := archive.(*jar.File)
+	if !ok {
+		return ""
+	}
+	artifact, err := f.Identify(entry.Path)
+	if err != nil {
+		log.Debug().
+			Err(err).
+			Str("entry", entry.Path).
+			Msg("identify artifact")
+		return ""
+	}
+	if artifact == nil {
+		return ""
+	}
+	return artifact.String()
+}
@@ -0,0 +1,376 @@
+// Package workspace answers questions about the classes of a project, such as where a class is
+// located or which classes extend it. A workspace builds the classpath of its project once and
+// reuses it for all questions, which is what keeps classpaths warm in jt serve.
+package workspace
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/tsatke/jt"
+	"github.com/tsatke/jt/api"
+	"github.com/tsatke/jt/class"
+	"github.com/tsatke/jt/classpath"
+	"github.com/tsatke/jt/jar"
+)
+
+// Workspace is a project together with its classpath in a scope. It is safe for concurrent use,
+// but answers one question at a time.
+type Workspace struct {
+	Project jt.Project
+	Scope   classpath.Scope
+	// Release is the Java release for which multi-release jars are resolved,
+	// or 0 for the release of the project JDK.
+	Release int
+
+	// mu serializes the questions, since the classpath loads its entries lazily
+	mu sync.Mutex
+
+	once      sync.Once
+	classpath *classpath.Classpath
+	err       error
+
+	// entries holds the descriptions of classpath entries by their path
+	entries map[string]*Entry
+
+	// classes indexes the classes of the classpath, see index
+	classes *classIndex
+}
+
+// classIndex maps the names of the classes on the classpath to the entries that contain them,
+// in the order of the classpath. A workspace builds it once, since jt serve loads a workspace
+// again when its files change.
+type classIndex struct {
+	names   []string
+	entries map[string][]*classpath.Entry
+}
+
+func New(project jt.Project, scope classpath.Scope, release int) *Workspace {
+	return &Workspace{
+		Project: project,
+		Scope:   scope,
+		Release: release,
+		entries: make(map[string]*Entry),
+	}
+}
+
+// Classpath returns the classpath of the project, which is built on the first call.
+func (w *Workspace) Classpath() (*classpath.Classpath, error) {
+	w.once.Do(func() {
+		w.classpath, w.err = w.Project.Classpath(w.Scope)
+		if w.err != nil {
+			w.err = fmt.Errorf("get classpath: %w", w.err)
+			return
+		}
+		if w.Release > 0 {
+			w.classpath.Release = w.Release
+		}
+	})
+	return w.classpath, w.err
+}
+
+// Preload builds the classpath and loads the classes of all entries, so that
+// the first question is answered as quickly as the following ones.
+func (w *Workspace) Preload() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	cp, err := w.Classpath()
+	if err != nil {
+		return err
+	}
+	resultsCh := make(chan string)
+	go cp.FindClasses(func(string) bool { return false }, resultsCh)
+	for range resultsCh {
+	}
+	_, err = w.index()
+	return err
+}
+
+// index returns the index of the classes on the classpath, which is built on the first call.
+// The caller must hold mu.
+func (w *Workspace) index() (*classIndex, error) {
+	if w.classes != nil {
+		return w.classes, nil
+	}
+	cp, err := w.Classpath()
+	if err != nil {
+		return nil, err
+	}
+	index := &classIndex{entries: make(map[string][]*classpath.Entry)}
+	cp.WalkArchives(nil, func(entry *classpath.Entry, archive classpath.Archive) {
+		for _, name := range archive.ListClasses() {
+			if len(index.entries[name]) == 0 {
+				index.names = append(index.names, name)
+			}
+			index.entries[name] = append(index.entries[name], entry)
+		}
+	})
+	sort.Strings(index.names)
+	w.classes = index
+	return index, nil
+}
+
+// Classes returns the names of all classes on the classpath, sorted and without duplicates.
+func (w *Workspace) Classes() ([]string, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	index, err := w.index()
+	if err != nil {
+		return nil, err
+	}
+	return append([]string(nil), index.names...), nil
+}
+
+// Find calls fn for every class whose name matches the given name, see jt.ClassNameMatches.
+// Classes in the sources of the project are found first, then the classes in the archives on the
+// classpath, unless onlyProject is set. A class is found in every archive that contains it.
+func (w *Workspace) Find(name string, onlyProject bool, fn func(*Class)) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !onlyProject {
+		// build the classpath while searching the sources, since that takes time
+		go func() { _, _ = w.Classpath() }()
+	}
+
+	sources, err := w.Project.Sources(w.Scope)
+	if err != nil {
+		return fmt.Errorf("get sources: %w", err)
+	}
+	for _, source := range sources {
+		if err := fs.WalkDir(os.DirFS(source.Path), ".", func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() || filepath.Ext(path) != ".java" {
+				return nil
+			}
+			if source.Includes(path) && jt.ClassNameMatches(path, name) {
+				c := NewClass(strings.TrimSuffix(filepath.ToSlash(path), ".java"), NewEntry(source, ""))
+				c.File = filepath.Join(source.Path, path)
+				fn(c)
+			}
+			return nil
+		}); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("walk %s: %w", source.Path, err)
+		}
+	}
+	if onlyProject {
+		return nil
+	}
+
+	cp, err := w.Classpath()
+	if err != nil {
+		return err
+	}
+	index, err := w.index()
+	if err != nil {
+		return err
+	}
+	for _, path := range index.names {
+		if !jt.ClassNameMatches(path, name) {
+			continue
+		}
+		for _, entry := range index.entries[path] {
+			// sources and outputs of the project are covered by the project search
+			if entry.Type == classpath.EntryTypeOutput {
+				continue
+			}
+			// only archives that contain matches are identified, since that takes time
+			fn(NewClass(path, w.locatedEntry(cp, entry)))
+		}
+	}
+	return nil
+}
+
+// Which returns the class with the given name as it is loaded from the classpath, that is
+// from the first entry that contains it. Multi-release jars are resolved for the release of
+// the classpath. If the class is not on the classpath, a *classpath.ClassNotFoundError is returned.
+func (w *Workspace) Which(name string) (*Class, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	c, _, err := w.which(name)
+	return c, err
+}
+
+// Javap returns the declarations of the class with the given name as it is loaded from the
+// classpath, see Which, and of its fields and methods that are not private or synthetic,
+// like javap prints them without options.
+func (w *Workspace) Javap(name string) (*Declarations, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	c, class, err := w.which(name)
+	if err != nil {
+		return nil, err
+	}
+	d := &Declarations{
+		Class:       c,
+		Declaration: api.ClassDeclaration(class),
+		Members:     []string{},
+	}
+	for _, f := range class.Fields() {
+		if !f.IsPrivate() && !f.IsSynthetic() {
+			d.Members = append(d.Members, api.FieldDeclaration(f))
+		}
+	}
+	for _, m := range class.Methods() {
+		if !m.IsPrivate() && !m.IsSynthetic() && m.Name() != "<clinit>" {
+			d.Members = append(d.Members, api.MethodDeclaration(class, m))
+		}
+	}
+	return d, nil
+}
+
+// which returns the description of the class with the given name together with the class.
+// The caller must hold mu.
+func (w *Workspace) which(name string) (*Class, *class.Class, error) {
+	cp, err := w.Classpath()
+	if err != nil {
+		return nil, nil, err
+	}
+	entry, err := cp.Locate(name)
+	if err != nil {
+		return nil, nil, fmt.Errorf("locate %s: %w", name, err)
+	}
+	if entry == nil {
+		return nil, nil, &classpath.ClassNotFoundError{Name: name}
+	}
+
+	archive, err := cp.OpenArchive(entry)
+	if err != nil {
+		return nil, nil, fmt.Errorf("open archive: %w", err)
+	}
+	defer func() { _ = archive.Close() }()
+
+	c := NewClass(name, w.entry(entry, archive))
+	class, err := archive.OpenClass(name)
+	if err != nil {
+		return nil, nil, fmt.Errorf("open class: %w", err)
+	}
+	c.ClassVersion = NewClassVersion(class)
+	if f, ok := archive.(*jar.File); ok && len(f.Versions(name)) > 0 {
+		selected := f.SelectedVersion(name)
+		c.Versions = f.Versions(name)
+		c.SelectedVersion = &selected
+	}
+	return c, class, nil
+}
+
+// Superclasses returns the class with the given name followed by its superclasses, ending at
+// java/lang/Object. If a class is not on the classpath, the classes up to that class are returned
+// together with a *classpath.ClassNotFoundError.
+func (w *Workspace) Superclasses(name string) ([]*Class, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	cp, err := w.Classpath()
+	if err != nil {
+		return nil, err
+	}
+	var result []*Class
+	for name != "" {
+		class, err := cp.OpenClass(name)
+		if err != nil {
+			return result, fmt.Errorf("open class %s: %w", name, err)
+		}
+		if class == nil {
+			return result, &classpath.ClassNotFoundError{Name: name}
+		}
+		entry, err := cp.Locate(name)
+		if err != nil {
+			return result, fmt.Errorf("locate %s: %w", name, err)
+		}
+		c := NewClass(name, w.locatedEntry(cp, entry))
+		c.ClassVersion = NewClassVersion(class)
+		result = append(result, c)
+		name = class.SuperclassName()
+	}
+	return result, nil
+}
+
+// Subclasses calls fn for every class on the classpath that directly extends the class with the
+// given name. Only classes whose names match the filter regex are considered, or if invert is set,
+// only the classes that don't match it. An empty filter matches all classes. The classes are
+// searched concurrently, so they are found in no particular order.
+func (w *Workspace) Subclasses(name, filter string, invert bool, fn func(*Class)) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	pattern, err := regexp.Compile(filter)
+	if err != nil {
+		return fmt.Errorf("compile filter: %w", err)
+	}
+	cp, err := w.Classpath()
+	if err != nil {
+		return err
+	}
+	cache, err := classpath.NewCache(100)
+	if err != nil {
+		return fmt.Errorf("create archive cache: %w", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	resultsCh := make(chan string, 5)
+	go cp.FindClasses(func(s string) bool {
+		condition := filter != "" && !pattern.MatchString(s)
+		if invert {
+			condition = !condition
+		}
+		if condition {
+			return false
+		}
+
+		c, err := cp.OpenClassWithCache(s, cache)
+		if err != nil || c == nil {
+			return false
+		}
+		return c.SuperclassName() == name
+	}, resultsCh)
+
+	for result := range resultsCh {
+		entry, err := cp.Locate(result)
+		if err != nil {
+			return fmt.Errorf("locate %s: %w", result, err)
+		}
+		fn(NewClass(result, w.locatedEntry(cp, entry)))
+	}
+	return nil
+}
+
+// entry returns the description of the given entry, whose archive is open.
+func (w *Workspace) entry(entry *classpath.Entry, archive classpath.Archive) *Entry {
+	e, ok := w.entries[entry.Path]
+	if !ok {
+		e = NewEntry(entry, Artifact(entry, archive))
+		w.entries[entry.Path] = e
+	}
+	return e
+}
+
+// locatedEntry returns the description of the given entry, opening its archive if the entry
+// has not been described yet. It returns nil if the entry is nil.
+func (w *Workspace) locatedEntry(cp *classpath.Classpath, entry *classpath.Entry) *Entry {
+	if entry == nil {
+		return nil
+	}
+	if e, ok := w.entries[entry.Path]; ok {
+		return e
+	}
+	archive, err := cp.OpenArchive(entry)
+	if err != nil {
+		return NewEntry(entry, "")
+	}
+	defer func() { _ = archive.Close() }()
+	return w.entry(entry, archive)
+}
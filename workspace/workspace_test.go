@@ -0,0 +1,168 @@
+package workspace
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"github.com/tsatke/jt"
+	"github.com/tsatke/jt/classpath"
+	"github.com/tsatke/jt/internal/classbuild"
+)
+
+func TestWorkspaceSuite(t *testing.T) {
+	suite.Run(t, new(WorkspaceSuite))
+}
+
+type WorkspaceSuite struct {
+	suite.Suite
+
+	dir       string
+	workspace *Workspace
+}
+
+func (suite *WorkspaceSuite) SetupTest() {
+	suite.dir = suite.T().TempDir()
+	write := func(name string, data []byte) {
+		path := filepath.Join(suite.dir, filepath.FromSlash(name))
+		suite.Require().NoError(os.MkdirAll(filepath.Dir(path), 0755))
+		suite.Require().NoError(os.WriteFile(path, data, 0644))
+	}
+
+	write(".project", []byte(`<projectDescription><name>example</name></projectDescription>`))
+	write(".classpath", []byte(`<classpath>
+<classpathentry kind="src" path="src"/>
+<classpathentry kind="output" path="bin"/>
+</classpath>`))
+	write("src/com/example/Impl.java", []byte("package com.example;\npublic class Impl extends Base {}\n"))
+
+	write("bin/java/lang/Object.class", classbuild.New("java/lang/Object", "").Bytes())
+	write("bin/com/example/Base.class", classbuild.New("com/example/Base", "java/lang/Object").Bytes())
+	impl := classbuild.New("com/example/Impl", "com/example/Base")
+	impl.Field(classbuild.AccPublic|classbuild.AccStatic|classbuild.AccFinal, "COUNT", "I", impl.ConstantValue(impl.Integer(3)))
+	impl.Field(classbuild.AccPrivate, "secret", "I")
+	impl.Method(0, "reset", "()V")
+	write("bin/com/example/Impl.class", impl.Bytes())
+	write("bin/com/example/Other.class", classbuild.New("com/example/Other", "com/example/Base").Bytes())
+	write("bin/com/example/Broken.class", classbuild.New("com/example/Broken", "com/example/Gone").Bytes())
+
+	project, err := jt.LoadProject(suite.dir)
+	suite.Require().NoError(err)
+	suite.workspace = New(project, classpath.ScopeTest, 0)
+}
+
+func (suite *WorkspaceSuite) TestQualifiedName() {
+	suite.Equal("java.util.Map.Entry", QualifiedName("java/util/Map$Entry"))
+	suite.Equal("Foo", QualifiedName("Foo"))
+	suite.Equal("", QualifiedName("com/example/Foo$1"))
+	suite.Equal("", QualifiedName("com/example/Foo$1Local"))
+}
+
+func (suite *WorkspaceSuite) TestInternalNames() {
+	suite.Equal([]string{"java/util/Map$Entry"}, InternalNames("java/util/Map$Entry"))
+	suite.Equal([]string{"a/b/Outer$Inner", "a/b$Outer$Inner", "a$b$Outer$Inner"}, InternalNames("a.b.Outer$Inner"))
+	suite.Equal([]string{"a/b/Outer/Inner", "a/b/Outer$Inner", "a/b$Outer$Inner", "a$b$Outer$Inner"}, InternalNames("a.b.Outer.Inner"))
+}
+
+func (suite *WorkspaceSuite) TestFind() {
+	var classes []*Class
+	suite.NoError(suite.workspace.Find("Impl", false, func(c *Class) {
+		classes = append(classes, c)
+	}))
+	// project results come first, and the output folder is covered by them
+	suite.Require().Len(classes, 1)
+	suite.Equal("com/example/Impl", classes[0].Name)
+	suite.Equal("com.example.Impl", classes[0].BinaryName)
+	suite.Equal(filepath.Join(suite.dir, "src", "com", "example", "Impl.java"), classes[0].File)
+	suite.Equal("source", classes[0].Entry.Type)
+}
+
+func (suite *WorkspaceSuite) TestClasses() {
+	classes, err := suite.workspace.Classes()
+	suite.NoError(err)
+	suite.Equal([]string{
+		"com/example/Base",
+		"com/example/Broken",
+		"com/example/Impl",
+		"com/example/Other",
+		"java/lang/Object",
+	}, classes)
+
+	// the index is built once and reused until the workspace is loaded again
+	suite.Require().NoError(os.Remove(filepath.Join(suite.dir, "bin", "com", "example", "Other.class")))
+	again, err := suite.workspace.Classes()
+	suite.NoError(err)
+	suite.Equal(classes, again)
+}
+
+func (suite *WorkspaceSuite) TestWhich() {
+	c, err := suite.workspace.Which("com/example/Base")
+	suite.Require().NoError(err)
+	suite.Equal("com.example.Base", c.QualifiedName)
+	suite.Equal(filepath.Join(suite.dir, "bin"), c.Entry.Path)
+	suite.Equal("output", c.Entry.Type)
+	suite.Equal(&ClassVersion{Major: 55, Minor: 0, Release: 11}, c.ClassVersion)
+	suite.Nil(c.SelectedVersion)
+
+	_, err = suite.workspace.Which("com/example/Missing")
+	var notFound *classpath.ClassNotFoundError
+	suite.True(errors.As(err, &notFound))
+}
+
+func (suite *WorkspaceSuite) TestJavap() {
+	d, err := suite.workspace.Javap("com/example/Impl")
+	suite.Require().NoError(err)
+	suite.Equal("com/example/Impl", d.Class.Name)
+	suite.Equal("public class com.example.Impl extends com.example.Base", d.Declaration)
+	suite.Equal([]string{"public static final int COUNT = 3", "void reset()"}, d.Members)
+
+	_, err = suite.workspace.Javap("com/example/Missing")
+	var notFound *classpath.ClassNotFoundError
+	suite.True(errors.As(err, &notFound))
+}
+
+func (suite *WorkspaceSuite) TestSuperclasses() {
+	classes, err := suite.workspace.Superclasses("com/example/Impl")
+	suite.NoError(err)
+	suite.Equal([]string{"com/example/Impl", "com/example/Base", "java/lang/Object"}, names(classes))
+
+	classes, err = suite.workspace.Superclasses("com/example/Broken")
+	var notFound *classpath.ClassNotFoundError
+	suite.Require().True(errors.As(err, &notFound))
+	suite.Equal("com/example/Gone", notFound.Name)
+	suite.Equal([]string{"com/example/Broken"}, names(classes))
+}
+
+func (suite *WorkspaceSuite) TestSubclasses() {
+	// subclasses are found in no particular order
+	var classes []*Class
+	collect := func(c *Class) {
+		classes = append(classes, c)
+		sort.Slice(classes, func(i, j int) bool {
+			return classes[i].Name < classes[j].Name
+		})
+	}
+
+	suite.NoError(suite.workspace.Subclasses("com/example/Base", "", false, collect))
+	suite.Equal([]string{"com/example/Impl", "com/example/Other"}, names(classes))
+	suite.Equal(filepath.Join(suite.dir, "bin"), classes[0].Entry.Path)
+
+	classes = nil
+	suite.NoError(suite.workspace.Subclasses("com/example/Base", "Impl$", false, collect))
+	suite.Equal([]string{"com/example/Impl"}, names(classes))
+
+	classes = nil
+	suite.NoError(suite.workspace.Subclasses("com/example/Base", "Impl$", true, collect))
+	suite.Equal([]string{"com/example/Other"}, names(classes))
+}
+
+func names(classes []*Class) []string {
+	var result []string
+	for _, c := range classes {
+		result = append(result, c.Name)
+	}
+	return result
+}